@@ -0,0 +1,178 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"golang.org/x/time/rate"
+)
+
+// A private discovery server only deals with the devices on its allowlist:
+// other devices can't announce, and lookups of them are answered as not
+// found. Lookups may additionally require one of a set of bearer tokens.
+// Both lists are read from files, one entry per line, and are reloaded on
+// SIGHUP.
+
+type accessControl struct {
+	allowlistFile string // optional
+	tokensFile    string // optional
+
+	mut     sync.RWMutex
+	devices map[protocol.DeviceID]struct{} // nil when all devices are allowed
+	tokens  []string                       // nil when no token is required
+}
+
+func newAccessControl(allowlistFile, tokensFile string) (*accessControl, error) {
+	a := &accessControl{
+		allowlistFile: allowlistFile,
+		tokensFile:    tokensFile,
+	}
+	return a, a.reload()
+}
+
+// reload reads the allowlist and the tokens from their files. The current
+// ones are retained if either can't be read.
+func (a *accessControl) reload() error {
+	var devices map[protocol.DeviceID]struct{}
+	if a.allowlistFile != "" {
+		lines, err := readListFile(a.allowlistFile)
+		if err != nil {
+			return err
+		}
+		devices = make(map[protocol.DeviceID]struct{}, len(lines))
+		for _, line := range lines {
+			id, err := protocol.DeviceIDFromString(line)
+			if err != nil {
+				return fmt.Errorf("%s: %v", a.allowlistFile, err)
+			}
+			devices[id] = struct{}{}
+		}
+	}
+
+	var tokens []string
+	if a.tokensFile != "" {
+		lines, err := readListFile(a.tokensFile)
+		if err != nil {
+			return err
+		}
+		tokens = lines
+		if tokens == nil {
+			// An empty list still requires a token, which no one has.
+			tokens = []string{}
+		}
+	}
+
+	a.mut.Lock()
+	a.devices = devices
+	a.tokens = tokens
+	a.mut.Unlock()
+	return nil
+}
+
+// deviceAllowed returns true if the device may announce and be looked up.
+func (a *accessControl) deviceAllowed(id protocol.DeviceID) bool {
+	a.mut.RLock()
+	defer a.mut.RUnlock()
+	if a.devices == nil {
+		return true
+	}
+	_, ok := a.devices[id]
+	return ok
+}
+
+// lookupAuthorized returns true if the request carries a valid bearer
+// token, or if none is required.
+func (a *accessControl) lookupAuthorized(req *http.Request) bool {
+	a.mut.RLock()
+	defer a.mut.RUnlock()
+	if a.tokens == nil {
+		return true
+	}
+
+	const prefix = "Bearer "
+	hdr := req.Header.Get("Authorization")
+	if !strings.HasPrefix(hdr, prefix) {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(hdr, prefix))
+	for _, candidate := range a.tokens {
+		if subtle.ConstantTimeCompare(token, []byte(candidate)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// readListFile returns the lines of the file, ignoring empty lines and
+// comments starting with "#".
+func readListFile(name string) ([]string, error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(fd)
+	for sc.Scan() {
+		line := sc.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// clientLimiter limits the rate of requests from each client address,
+// keeping track of the most recently seen clients.
+type clientLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mut   sync.Mutex
+	cache *lru.Cache
+}
+
+func newClientLimiter(limit rate.Limit, burst int) *clientLimiter {
+	return &clientLimiter{
+		limit: limit,
+		burst: burst,
+		cache: lru.New(clientLimiterCacheSize),
+	}
+}
+
+// allow returns true if a request from the address is allowed now.
+func (l *clientLimiter) allow(ip net.IP) bool {
+	key := ip.String()
+
+	l.mut.Lock()
+	var lim *rate.Limiter
+	if val, ok := l.cache.Get(key); ok {
+		lim = val.(*rate.Limiter)
+	} else {
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.cache.Add(key, lim)
+	}
+	l.mut.Unlock()
+
+	return lim.Allow()
+}
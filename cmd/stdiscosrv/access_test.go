@@ -0,0 +1,160 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+	"golang.org/x/time/rate"
+)
+
+func TestAccessControl(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stdiscosrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	allowedCert := []byte("allowed")
+	otherCert := []byte("other")
+	allowed := protocol.NewDeviceID(allowedCert)
+	other := protocol.NewDeviceID(otherCert)
+
+	allowlistFile := filepath.Join(dir, "allowlist")
+	tokensFile := filepath.Join(dir, "tokens")
+	writeListFile(t, allowlistFile, "# our devices\n"+allowed.String()+"\n\n")
+	writeListFile(t, tokensFile, "secret # the only token\n")
+
+	access, err := newAccessControl(allowlistFile, tokensFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := newInMemoryStore(filepath.Join(dir, inMemorySnapshotFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newAPISrv("", tls.Certificate{}, db, nil, false, access, nil)
+
+	announce := func(cert []byte) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"addresses":["tcp://192.0.2.42:22000"]}`))
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Raw: cert}}}
+		w := httptest.NewRecorder()
+		s.handler(w, req)
+		return w.Code
+	}
+	lookup := func(id protocol.DeviceID, token string) int {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/?device="+id.String(), nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		s.handler(w, req)
+		return w.Code
+	}
+
+	if code := announce(allowedCert); code != http.StatusNoContent {
+		t.Error("announcement of allowed device failed:", code)
+	}
+	if code := announce(otherCert); code != http.StatusForbidden {
+		t.Error("announcement of other device should be forbidden:", code)
+	}
+
+	if code := lookup(allowed, ""); code != http.StatusUnauthorized {
+		t.Error("lookup without token should be unauthorized:", code)
+	}
+	if code := lookup(allowed, "wrong"); code != http.StatusUnauthorized {
+		t.Error("lookup with wrong token should be unauthorized:", code)
+	}
+	if code := lookup(allowed, "secret"); code != http.StatusOK {
+		t.Error("lookup of allowed device failed:", code)
+	}
+
+	// Devices not on the allowlist are not found, even when known.
+
+	if err := s.handleAnnounce(nil, other, []string{"tcp://192.0.2.43:22000"}); err != nil {
+		t.Fatal(err)
+	}
+	if code := lookup(other, "secret"); code != http.StatusNotFound {
+		t.Error("lookup of other device should not be found:", code)
+	}
+
+	// Reload the changed lists.
+
+	writeListFile(t, allowlistFile, allowed.String()+"\n"+other.String()+"\n")
+	writeListFile(t, tokensFile, "")
+	if err := access.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if code := lookup(other, "secret"); code != http.StatusUnauthorized {
+		t.Error("lookup should be unauthorized without any tokens:", code)
+	}
+	if err := os.Remove(tokensFile); err != nil {
+		t.Fatal(err)
+	}
+	access.tokensFile = ""
+	if err := access.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if code := lookup(other, ""); code != http.StatusOK {
+		t.Error("lookup of newly allowed device failed:", code)
+	}
+
+	// Invalid lists are not loaded.
+
+	writeListFile(t, allowlistFile, "not a device ID\n")
+	if err := access.reload(); err == nil {
+		t.Error("invalid allowlist should not load")
+	}
+	if !access.deviceAllowed(other) {
+		t.Error("previous allowlist should be retained")
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	limiter := newClientLimiter(rate.Every(time.Hour), 2)
+	s := newAPISrv("", tls.Certificate{}, nil, nil, false, nil, limiter)
+
+	ping := func(remote string) int {
+		t.Helper()
+		req := httptest.NewRequest("PUT", "/", nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		s.handler(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := ping("192.0.2.1:1234"); code != http.StatusMethodNotAllowed {
+			t.Error("request within the burst should be allowed:", code)
+		}
+	}
+	if code := ping("192.0.2.1:4321"); code != http.StatusTooManyRequests {
+		t.Error("request beyond the burst should be limited:", code)
+	}
+	if code := ping("192.0.2.2:1234"); code != http.StatusMethodNotAllowed {
+		t.Error("request from other client should be allowed:", code)
+	}
+}
+
+func writeListFile(t *testing.T, name, contents string) {
+	t.Helper()
+	if err := ioutil.WriteFile(name, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// Replication updates are pushed to the peers as they happen, on a best
+// effort basis, so updates are lost while a peer is unreachable or
+// restarting. To make up for that each replica periodically pulls the
+// records seen since its previous pull from every peer and merges them
+// into its database. The first pull after startup asks for all records, so
+// that a restarted replica catches up. Merging is idempotent, so records
+// pulled more than once do no harm.
+//
+// Pulls use the replication listener, with the connection marked by the
+// negotiated TLS protocol. The puller sends a ReplicationPullRequest and
+// the peer answers with a ReplicationRecord per record, followed by an
+// empty message.
+
+const (
+	replicationPullProto = "stdiscosrv-pull/1"
+
+	// Records seen up to this long before the previous pull started are
+	// pulled again, allowing for clock differences between the replicas.
+	replicationPullSlack = 5 * time.Minute
+
+	// How long to wait before retrying a failed pull
+	replicationPullRetryInterval = time.Minute
+
+	replicationWriteTimeout = 5 * time.Second
+)
+
+var errPullInterrupted = errors.New("pull interrupted")
+
+// a replicationPuller periodically pulls records from the remote address
+// into the database.
+type replicationPuller struct {
+	dst        string
+	cert       tls.Certificate // our certificate
+	allowedIDs []protocol.DeviceID
+	db         database
+	interval   time.Duration
+	since      int64 // Unix nanos, oldest seen time of records to pull
+	stop       chan struct{}
+}
+
+func newReplicationPuller(dst string, cert tls.Certificate, allowedIDs []protocol.DeviceID, db database, interval time.Duration) *replicationPuller {
+	return &replicationPuller{
+		dst:        dst,
+		cert:       cert,
+		allowedIDs: allowedIDs,
+		db:         db,
+		interval:   interval,
+		stop:       make(chan struct{}),
+	}
+}
+
+func (p *replicationPuller) Serve() {
+	// Sleep a little at startup, for the same reasons as the
+	// replicationSender.
+	t := time.NewTimer(2 * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-p.stop:
+			return
+		}
+
+		if err := p.pull(); err != nil {
+			replicationPullsTotal.WithLabelValues("error").Inc()
+			log.Println("Replication pull:", err)
+			t.Reset(replicationPullRetryInterval)
+			continue
+		}
+		replicationPullsTotal.WithLabelValues("success").Inc()
+		t.Reset(p.interval)
+	}
+}
+
+func (p *replicationPuller) Stop() {
+	close(p.stop)
+}
+
+func (p *replicationPuller) String() string {
+	return fmt.Sprintf("replicationPuller(%q)", p.dst)
+}
+
+func (p *replicationPuller) pull() error {
+	t0 := time.Now()
+
+	tlsCfg := &tls.Config{
+		Certificates:       []tls.Certificate{p.cert},
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true,
+		NextProtos:         []string{replicationPullProto},
+	}
+
+	// Dial the TLS connection.
+	conn, err := tls.Dial("tcp", p.dst, tlsCfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		conn.SetWriteDeadline(time.Now().Add(time.Second))
+		conn.Close()
+	}()
+
+	// Get the other side device ID and verify it's in the set of allowed
+	// device IDs.
+	remoteID, err := deviceID(conn)
+	if err != nil {
+		return err
+	}
+	if !deviceIDIn(remoteID, p.allowedIDs) {
+		return fmt.Errorf("unexpected device ID: %v", remoteID)
+	}
+
+	if proto := conn.ConnectionState().NegotiatedProtocol; proto != replicationPullProto {
+		return fmt.Errorf("%s does not support pulling (protocol %q)", p.dst, proto)
+	}
+
+	n, err := p.pullRecords(conn)
+	if err != nil {
+		return err
+	}
+
+	// The next pull needs only what has been seen since this one started.
+	p.since = t0.Add(-replicationPullSlack).UnixNano()
+	if debug {
+		log.Printf("Pulled %d records from %s", n, p.dst)
+	}
+	return nil
+}
+
+// pullRecords requests the records on the connection and merges them into
+// the database, returning the number of records merged.
+func (p *replicationPuller) pullRecords(conn net.Conn) (int, error) {
+	conn.SetWriteDeadline(time.Now().Add(replicationWriteTimeout))
+	w := &messageWriter{w: conn}
+	if err := w.write(&ReplicationPullRequest{Since: p.since}); err != nil {
+		return 0, err
+	}
+
+	r := &messageReader{r: bufio.NewReader(conn)}
+	n := 0
+	for {
+		select {
+		case <-p.stop:
+			return n, errPullInterrupted
+		default:
+		}
+
+		conn.SetReadDeadline(time.Now().Add(replicationReadTimeout))
+		var rec ReplicationRecord
+		ok, err := r.read(&rec)
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			// An empty message marks the end of the records.
+			return n, nil
+		}
+
+		if err := p.db.merge(rec.Key, rec.Addresses, rec.Seen); err != nil {
+			return n, err
+		}
+		replicationPulledRecordsTotal.Inc()
+		n++
+	}
+}
+
+// handlePull sends the records asked for by a replicationPuller.
+func (l *replicationListener) handlePull(conn net.Conn) {
+	defer func() {
+		conn.SetWriteDeadline(time.Now().Add(time.Second))
+		conn.Close()
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(replicationReadTimeout))
+	var req ReplicationPullRequest
+	if _, err := (&messageReader{r: conn}).read(&req); err != nil {
+		log.Println("Replication pull request:", err)
+		return
+	}
+
+	bw := bufio.NewWriter(conn)
+	w := &messageWriter{w: bw}
+	var werr error
+	err := l.db.iterate(func(key string, rec DatabaseRecord) bool {
+		select {
+		case <-l.stop:
+			werr = errPullInterrupted
+			return false
+		default:
+		}
+
+		// Records without current addresses are of no use to the puller.
+		if rec.Seen < req.Since || len(rec.Addresses) == 0 {
+			return true
+		}
+
+		// The address slice must always be sorted for database merges to
+		// work, and expiry doesn't preserve the order.
+		sort.Sort(databaseAddressOrder(rec.Addresses))
+
+		conn.SetWriteDeadline(time.Now().Add(replicationWriteTimeout))
+		werr = w.write(&ReplicationRecord{
+			Key:       key,
+			Addresses: rec.Addresses,
+			Seen:      rec.Seen,
+		})
+		return werr == nil
+	})
+	if err == nil {
+		err = werr
+	}
+	if err == nil {
+		conn.SetWriteDeadline(time.Now().Add(replicationWriteTimeout))
+		if err = w.write(&ReplicationRecord{}); err == nil {
+			err = bw.Flush()
+		}
+	}
+	if err != nil {
+		log.Println("Replication pull:", err)
+	}
+}
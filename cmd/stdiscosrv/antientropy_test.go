@@ -0,0 +1,99 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplicationPull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stdiscosrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := newInMemoryStore(filepath.Join(dir, "src", inMemorySnapshotFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := newInMemoryStore(filepath.Join(dir, "dst", inMemorySnapshotFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	expires := now.Add(time.Hour).UnixNano()
+	records := map[string][]DatabaseAddress{
+		"abcd": {{Address: "tcp://1.2.3.4:5", Expires: expires}, {Address: "tcp://6.7.8.9:0", Expires: expires}},
+		"efgh": {{Address: "tcp://10.0.0.1:22000", Expires: expires}},
+	}
+	for key, addrs := range records {
+		if err := src.merge(key, addrs, now.UnixNano()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Without current addresses, not worth pulling.
+	if err := src.merge("ijkl", []DatabaseAddress{{Address: "tcp://1.2.3.4:5", Expires: 1}}, now.UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The destination has one address of its own already.
+	if err := dst.merge("abcd", []DatabaseAddress{{Address: "tcp://2.3.4.5:6", Expires: expires}}, now.UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newReplicationListener("", tls.Certificate{}, nil, src)
+	p := newReplicationPuller("", tls.Certificate{}, nil, dst, time.Hour)
+
+	pull := func() int {
+		t.Helper()
+		client, server := net.Pipe()
+		go l.handlePull(server)
+		n, err := p.pullRecords(client)
+		client.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if n := pull(); n != 2 {
+		t.Errorf("pulled %d records, expected 2", n)
+	}
+
+	rec, err := dst.get("abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Addresses) != 3 {
+		t.Error("incorrect addresses", rec.Addresses)
+	}
+	rec, err = dst.get("efgh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Addresses) != 1 || rec.Addresses[0].Address != "tcp://10.0.0.1:22000" {
+		t.Error("incorrect addresses", rec.Addresses)
+	}
+	if _, ok := dst.lookup("ijkl"); ok {
+		t.Error("record without addresses should not be pulled")
+	}
+
+	// Only records seen since are pulled.
+
+	p.since = now.Add(time.Second).UnixNano()
+	if n := pull(); n != 0 {
+		t.Errorf("pulled %d records, expected none", n)
+	}
+}
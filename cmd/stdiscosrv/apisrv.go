@@ -40,6 +40,8 @@ type apiSrv struct {
 	listener net.Listener
 	repl     replicator // optional
 	useHTTP  bool
+	access   *accessControl // optional
+	limiter  *clientLimiter // optional
 
 	mapsMut sync.Mutex
 	misses  map[string]int32
@@ -55,13 +57,15 @@ type contextKey int
 
 const idKey contextKey = iota
 
-func newAPISrv(addr string, cert tls.Certificate, db database, repl replicator, useHTTP bool) *apiSrv {
+func newAPISrv(addr string, cert tls.Certificate, db database, repl replicator, useHTTP bool, access *accessControl, limiter *clientLimiter) *apiSrv {
 	return &apiSrv{
 		addr:    addr,
 		cert:    cert,
 		db:      db,
 		repl:    repl,
 		useHTTP: useHTTP,
+		access:  access,
+		limiter: limiter,
 		misses:  make(map[string]int32),
 	}
 }
@@ -147,6 +151,15 @@ func (s *apiSrv) handler(w http.ResponseWriter, req *http.Request) {
 		remoteIP = addr.IP
 	}
 
+	if s.limiter != nil && !s.limiter.allow(remoteIP) {
+		if debug {
+			log.Println(reqID, "rate limited", remoteIP)
+		}
+		lw.Header().Set("Retry-After", errorRetryAfterString())
+		http.Error(lw, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+
 	switch req.Method {
 	case "GET":
 		s.handleGET(ctx, lw, req)
@@ -160,6 +173,17 @@ func (s *apiSrv) handler(w http.ResponseWriter, req *http.Request) {
 func (s *apiSrv) handleGET(ctx context.Context, w http.ResponseWriter, req *http.Request) {
 	reqID := ctx.Value(idKey).(requestID)
 
+	if s.access != nil && !s.access.lookupAuthorized(req) {
+		if debug {
+			log.Println(reqID, "unauthorized")
+		}
+		lookupRequestsTotal.WithLabelValues("unauthorized").Inc()
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.Header().Set("Retry-After", errorRetryAfterString())
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	deviceID, err := protocol.DeviceIDFromString(req.URL.Query().Get("device"))
 	if err != nil {
 		if debug {
@@ -171,6 +195,15 @@ func (s *apiSrv) handleGET(ctx context.Context, w http.ResponseWriter, req *http
 		return
 	}
 
+	if s.access != nil && !s.access.deviceAllowed(deviceID) {
+		// Devices not on the allowlist are never found, without telling
+		// the client anything more.
+		lookupRequestsTotal.WithLabelValues("not_allowed").Inc()
+		w.Header().Set("Retry-After", notFoundRetryAfterString(notFoundRetryMaxSeconds))
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
 	key := deviceID.String()
 	rec, err := s.db.get(key)
 	if err != nil {
@@ -244,6 +277,16 @@ func (s *apiSrv) handlePOST(ctx context.Context, remoteIP net.IP, w http.Respons
 
 	deviceID := protocol.NewDeviceID(rawCert)
 
+	if s.access != nil && !s.access.deviceAllowed(deviceID) {
+		if debug {
+			log.Println(reqID, "device not allowed:", deviceID)
+		}
+		announceRequestsTotal.WithLabelValues("not_allowed").Inc()
+		w.Header().Set("Retry-After", errorRetryAfterString())
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	addresses := fixupAddresses(remoteIP, ann.Addresses)
 	if len(addresses) == 0 {
 		announceRequestsTotal.WithLabelValues("bad_request").Inc()
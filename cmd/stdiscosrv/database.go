@@ -10,12 +10,15 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"path/filepath"
 	"sort"
 	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/thejerf/suture"
 )
 
 type clock interface {
@@ -32,6 +35,40 @@ type database interface {
 	put(key string, rec DatabaseRecord) error
 	merge(key string, addrs []DatabaseAddress, seen int64) error
 	get(key string) (DatabaseRecord, error)
+	// iterate calls fn for every record, with expired addresses removed,
+	// until fn returns false.
+	iterate(fn func(key string, rec DatabaseRecord) bool) error
+}
+
+// A store is a database backend, running as a service.
+type store interface {
+	database
+	suture.Service
+}
+
+const (
+	storeLevelDB  = "leveldb"
+	storeInMemory = "memory"
+)
+
+// newStore opens the database backend of the given kind in dir.
+func newStore(kind, dir string) (store, error) {
+	switch kind {
+	case storeLevelDB:
+		s, err := newLevelDBStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case storeInMemory:
+		s, err := newInMemoryStore(filepath.Join(dir, inMemorySnapshotFile))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", kind)
+	}
 }
 
 type levelDBStore struct {
@@ -155,6 +192,24 @@ func (s *levelDBStore) get(key string) (DatabaseRecord, error) {
 	return rec, nil
 }
 
+func (s *levelDBStore) iterate(fn func(key string, rec DatabaseRecord) bool) error {
+	iter := s.db.NewIterator(&util.Range{}, nil)
+	defer iter.Release()
+
+	nowNanos := s.clock.Now().UnixNano()
+	for iter.Next() {
+		var rec DatabaseRecord
+		if err := rec.Unmarshal(iter.Value()); err != nil {
+			continue
+		}
+		rec.Addresses = expire(rec.Addresses, nowNanos)
+		if !fn(string(iter.Key()), rec) {
+			break
+		}
+	}
+	return iter.Error()
+}
+
 func (s *levelDBStore) Serve() {
 	t := time.NewTimer(0)
 	defer t.Stop()
@@ -198,12 +253,7 @@ func (s *levelDBStore) statisticsServe(trigger <-chan struct{}, done chan<- stru
 	defer close(done)
 
 	for range trigger {
-		t0 := time.Now()
-		nowNanos := t0.UnixNano()
-		cutoff24h := t0.Add(-24 * time.Hour).UnixNano()
-		cutoff1w := t0.Add(-7 * 24 * time.Hour).UnixNano()
-		cutoff2Mon := t0.Add(-60 * 24 * time.Hour).UnixNano()
-		current, last24h, last1w, inactive, errors := 0, 0, 0, 0, 0
+		stats := newDatabaseStatistics(time.Now())
 
 		iter := s.db.NewIterator(&util.Range{}, nil)
 		for iter.Next() {
@@ -211,44 +261,22 @@ func (s *levelDBStore) statisticsServe(trigger <-chan struct{}, done chan<- stru
 			// failure if there's something wrong with it.
 			var rec DatabaseRecord
 			if err := rec.Unmarshal(iter.Value()); err != nil {
-				errors++
+				stats.errors++
 				continue
 			}
 
-			// If there are addresses that have not expired it's a current
-			// record, otherwise account it based on when it was last seen
-			// (last 24 hours or last week) or finally as inactice.
-			switch {
-			case len(expire(rec.Addresses, nowNanos)) > 0:
-				current++
-			case rec.Seen > cutoff24h:
-				last24h++
-			case rec.Seen > cutoff1w:
-				last1w++
-			case rec.Seen > cutoff2Mon:
-				inactive++
-			case rec.Missed < cutoff2Mon:
-				// It hasn't been seen lately and we haven't recorded
-				// someone asking for this device in a long time either;
-				// delete the record.
+			if !stats.account(rec) {
 				if err := s.db.Delete(iter.Key(), nil); err != nil {
 					databaseOperations.WithLabelValues(dbOpDelete, dbResError).Inc()
 				} else {
 					databaseOperations.WithLabelValues(dbOpDelete, dbResSuccess).Inc()
 				}
-			default:
-				inactive++
 			}
 		}
 
 		iter.Release()
 
-		databaseKeys.WithLabelValues("current").Set(float64(current))
-		databaseKeys.WithLabelValues("last24h").Set(float64(last24h))
-		databaseKeys.WithLabelValues("last1w").Set(float64(last1w))
-		databaseKeys.WithLabelValues("inactive").Set(float64(inactive))
-		databaseKeys.WithLabelValues("error").Set(float64(errors))
-		databaseStatisticsSeconds.Set(time.Since(t0).Seconds())
+		stats.publish()
 
 		// Signal that we are done and can be scheduled again.
 		done <- struct{}{}
@@ -259,6 +287,62 @@ func (s *levelDBStore) Stop() {
 	close(s.stop)
 }
 
+// databaseStatistics counts the records of a database by how recently
+// they were seen.
+type databaseStatistics struct {
+	t0         time.Time
+	nowNanos   int64
+	cutoff24h  int64
+	cutoff1w   int64
+	cutoff2Mon int64
+
+	current, last24h, last1w, inactive, errors int
+}
+
+func newDatabaseStatistics(t0 time.Time) *databaseStatistics {
+	return &databaseStatistics{
+		t0:         t0,
+		nowNanos:   t0.UnixNano(),
+		cutoff24h:  t0.Add(-24 * time.Hour).UnixNano(),
+		cutoff1w:   t0.Add(-7 * 24 * time.Hour).UnixNano(),
+		cutoff2Mon: t0.Add(-60 * 24 * time.Hour).UnixNano(),
+	}
+}
+
+// account counts the record, returning false if it should be deleted.
+func (s *databaseStatistics) account(rec DatabaseRecord) bool {
+	// If there are addresses that have not expired it's a current
+	// record, otherwise account it based on when it was last seen
+	// (last 24 hours or last week) or finally as inactice.
+	switch {
+	case hasCurrentAddress(rec.Addresses, s.nowNanos):
+		s.current++
+	case rec.Seen > s.cutoff24h:
+		s.last24h++
+	case rec.Seen > s.cutoff1w:
+		s.last1w++
+	case rec.Seen > s.cutoff2Mon:
+		s.inactive++
+	case rec.Missed < s.cutoff2Mon:
+		// It hasn't been seen lately and we haven't recorded
+		// someone asking for this device in a long time either;
+		// delete the record.
+		return false
+	default:
+		s.inactive++
+	}
+	return true
+}
+
+func (s *databaseStatistics) publish() {
+	databaseKeys.WithLabelValues("current").Set(float64(s.current))
+	databaseKeys.WithLabelValues("last24h").Set(float64(s.last24h))
+	databaseKeys.WithLabelValues("last1w").Set(float64(s.last1w))
+	databaseKeys.WithLabelValues("inactive").Set(float64(s.inactive))
+	databaseKeys.WithLabelValues("error").Set(float64(s.errors))
+	databaseStatisticsSeconds.Set(time.Since(s.t0).Seconds())
+}
+
 // merge returns the merged result of the two database records a and b. The
 // result is the union of the two address sets, with the newer expiry time
 // chosen for any duplicates.
@@ -357,6 +441,16 @@ func expire(addrs []DatabaseAddress, now int64) []DatabaseAddress {
 	return addrs
 }
 
+// hasCurrentAddress returns true if any of the addresses have not expired.
+func hasCurrentAddress(addrs []DatabaseAddress, now int64) bool {
+	for _, addr := range addrs {
+		if addr.Expires >= now {
+			return true
+		}
+	}
+	return false
+}
+
 func sortedAddressCopy(addrs []DatabaseAddress) []DatabaseAddress {
 	sorted := make([]DatabaseAddress, len(addrs))
 	copy(sorted, addrs)
@@ -102,6 +102,81 @@ func (m *ReplicationRecord) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ReplicationRecord proto.InternalMessageInfo
 
+type ReplicationPullRequest struct {
+	Since int64 `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+}
+
+func (m *ReplicationPullRequest) Reset()         { *m = ReplicationPullRequest{} }
+func (m *ReplicationPullRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicationPullRequest) ProtoMessage()    {}
+func (*ReplicationPullRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b90fe3356ea5df07, []int{2}
+}
+func (m *ReplicationPullRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReplicationPullRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicationPullRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ReplicationPullRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationPullRequest.Merge(m, src)
+}
+func (m *ReplicationPullRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicationPullRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationPullRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationPullRequest proto.InternalMessageInfo
+
+type DatabaseSnapshotRecord struct {
+	Key    string         `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Record DatabaseRecord `protobuf:"bytes,2,opt,name=record,proto3" json:"record"`
+}
+
+func (m *DatabaseSnapshotRecord) Reset()         { *m = DatabaseSnapshotRecord{} }
+func (m *DatabaseSnapshotRecord) String() string { return proto.CompactTextString(m) }
+func (*DatabaseSnapshotRecord) ProtoMessage()    {}
+func (*DatabaseSnapshotRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b90fe3356ea5df07, []int{3}
+}
+func (m *DatabaseSnapshotRecord) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DatabaseSnapshotRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DatabaseSnapshotRecord.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DatabaseSnapshotRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DatabaseSnapshotRecord.Merge(m, src)
+}
+func (m *DatabaseSnapshotRecord) XXX_Size() int {
+	return m.Size()
+}
+func (m *DatabaseSnapshotRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_DatabaseSnapshotRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DatabaseSnapshotRecord proto.InternalMessageInfo
+
 type DatabaseAddress struct {
 	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
 	Expires int64  `protobuf:"varint,2,opt,name=expires,proto3" json:"expires,omitempty"`
@@ -111,7 +186,7 @@ func (m *DatabaseAddress) Reset()         { *m = DatabaseAddress{} }
 func (m *DatabaseAddress) String() string { return proto.CompactTextString(m) }
 func (*DatabaseAddress) ProtoMessage()    {}
 func (*DatabaseAddress) Descriptor() ([]byte, []int) {
-	return fileDescriptor_b90fe3356ea5df07, []int{2}
+	return fileDescriptor_b90fe3356ea5df07, []int{4}
 }
 func (m *DatabaseAddress) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -143,30 +218,36 @@ var xxx_messageInfo_DatabaseAddress proto.InternalMessageInfo
 func init() {
 	proto.RegisterType((*DatabaseRecord)(nil), "main.DatabaseRecord")
 	proto.RegisterType((*ReplicationRecord)(nil), "main.ReplicationRecord")
+	proto.RegisterType((*ReplicationPullRequest)(nil), "main.ReplicationPullRequest")
+	proto.RegisterType((*DatabaseSnapshotRecord)(nil), "main.DatabaseSnapshotRecord")
 	proto.RegisterType((*DatabaseAddress)(nil), "main.DatabaseAddress")
 }
 
 func init() { proto.RegisterFile("database.proto", fileDescriptor_b90fe3356ea5df07) }
 
 var fileDescriptor_b90fe3356ea5df07 = []byte{
-	// 270 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x90, 0x41, 0x4a, 0xc4, 0x30,
-	0x18, 0x85, 0x9b, 0x49, 0x1d, 0x99, 0x08, 0xa3, 0x06, 0x94, 0x20, 0x12, 0x4b, 0xdd, 0x74, 0xd5,
-	0x01, 0x5d, 0xb9, 0x74, 0xd0, 0x0b, 0xe4, 0x06, 0xe9, 0xe4, 0x77, 0x08, 0x3a, 0x4d, 0x49, 0x2a,
-	0xe8, 0x29, 0xf4, 0x58, 0x5d, 0xce, 0xd2, 0x95, 0x68, 0x7b, 0x11, 0x69, 0x26, 0x55, 0x14, 0x37,
-	0xb3, 0x7b, 0xdf, 0xff, 0xbf, 0x97, 0xbc, 0x84, 0x4c, 0x95, 0xac, 0x65, 0x21, 0x1d, 0xe4, 0x95,
-	0x35, 0xb5, 0xa1, 0xf1, 0x4a, 0xea, 0xf2, 0xe4, 0xdc, 0x42, 0x65, 0xdc, 0xcc, 0x8f, 0x8a, 0xc7,
-	0xbb, 0xd9, 0xd2, 0x2c, 0x8d, 0x07, 0xaf, 0x36, 0xd6, 0xf4, 0x05, 0x91, 0xe9, 0x4d, 0x48, 0x0b,
-	0x58, 0x18, 0xab, 0xe8, 0x15, 0x99, 0x48, 0xa5, 0x2c, 0x38, 0x07, 0x8e, 0xa1, 0x04, 0x67, 0x7b,
-	0x17, 0x47, 0x79, 0x7f, 0x62, 0x3e, 0x18, 0xaf, 0x37, 0xeb, 0x79, 0xdc, 0xbc, 0x9f, 0x45, 0xe2,
-	0xc7, 0x4d, 0x8f, 0xc9, 0x78, 0xa5, 0x7d, 0x6e, 0x94, 0xa0, 0x6c, 0x47, 0x04, 0xa2, 0x94, 0xc4,
-	0x0e, 0xa0, 0x64, 0x38, 0x41, 0x19, 0x16, 0x5e, 0x7f, 0x7b, 0x15, 0x8b, 0xfd, 0x34, 0x50, 0x5a,
-	0x93, 0x43, 0x01, 0xd5, 0x83, 0x5e, 0xc8, 0x5a, 0x9b, 0x32, 0x74, 0x3a, 0x20, 0xf8, 0x1e, 0x9e,
-	0x19, 0x4a, 0x50, 0x36, 0x11, 0xbd, 0xfc, 0xdd, 0x72, 0xb4, 0x55, 0xcb, 0x7f, 0xda, 0xa4, 0xb7,
-	0x64, 0xff, 0x4f, 0x8e, 0x32, 0xb2, 0x1b, 0x32, 0xe1, 0xde, 0x01, 0xfb, 0x0d, 0x3c, 0x55, 0xda,
-	0x86, 0x77, 0x62, 0x31, 0xe0, 0xfc, 0xb4, 0xf9, 0xe4, 0x51, 0xd3, 0x72, 0xb4, 0x6e, 0x39, 0xfa,
-	0x68, 0x39, 0x7a, 0xed, 0x78, 0xb4, 0xee, 0x78, 0xf4, 0xd6, 0xf1, 0xa8, 0x18, 0xfb, 0x3f, 0xbf,
-	0xfc, 0x0a, 0x00, 0x00, 0xff, 0xff, 0x7a, 0xa2, 0xf6, 0x1e, 0xb0, 0x01, 0x00, 0x00,
+	// 328 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0xc1, 0x4e, 0xf2, 0x40,
+	0x10, 0xc7, 0xbb, 0xb4, 0x40, 0x18, 0x12, 0xbe, 0xcf, 0x0d, 0x92, 0xc6, 0x98, 0xb5, 0xa9, 0x97,
+	0x9e, 0x4a, 0x82, 0x27, 0x8f, 0x12, 0xbd, 0x9b, 0xf5, 0x6e, 0xb2, 0xd0, 0x11, 0x1b, 0xa1, 0x5b,
+	0xbb, 0x4b, 0xa2, 0x4f, 0xa1, 0x8f, 0xc5, 0x91, 0xa3, 0x27, 0xa3, 0xf0, 0x22, 0x86, 0xed, 0x56,
+	0xc4, 0xe8, 0xc1, 0xdb, 0xfc, 0x77, 0xfe, 0x33, 0xf3, 0x9b, 0xc9, 0x42, 0x27, 0x11, 0x5a, 0x8c,
+	0x84, 0xc2, 0x38, 0x2f, 0xa4, 0x96, 0xd4, 0x9b, 0x89, 0x34, 0x3b, 0x38, 0x2e, 0x30, 0x97, 0xaa,
+	0x6f, 0x9e, 0x46, 0xf3, 0x9b, 0xfe, 0x44, 0x4e, 0xa4, 0x11, 0x26, 0x2a, 0xad, 0xe1, 0x13, 0x81,
+	0xce, 0xb9, 0xad, 0xe6, 0x38, 0x96, 0x45, 0x42, 0x4f, 0xa1, 0x25, 0x92, 0xa4, 0x40, 0xa5, 0x50,
+	0xf9, 0x24, 0x70, 0xa3, 0xf6, 0x60, 0x3f, 0xde, 0x74, 0x8c, 0x2b, 0xe3, 0x59, 0x99, 0x1e, 0x7a,
+	0x8b, 0xd7, 0x23, 0x87, 0x6f, 0xdd, 0xb4, 0x07, 0x8d, 0x59, 0x6a, 0xea, 0x6a, 0x01, 0x89, 0xea,
+	0xdc, 0x2a, 0x4a, 0xc1, 0x53, 0x88, 0x99, 0xef, 0x06, 0x24, 0x72, 0xb9, 0x89, 0x3f, 0xbd, 0x89,
+	0xef, 0x99, 0x57, 0xab, 0x42, 0x0d, 0x7b, 0x1c, 0xf3, 0x69, 0x3a, 0x16, 0x3a, 0x95, 0x99, 0x65,
+	0xfa, 0x0f, 0xee, 0x1d, 0x3e, 0xfa, 0x24, 0x20, 0x51, 0x8b, 0x6f, 0xc2, 0x5d, 0xca, 0xda, 0x9f,
+	0x28, 0x7f, 0xa0, 0x09, 0x63, 0xe8, 0x7d, 0x99, 0x7a, 0x39, 0x9f, 0x4e, 0x39, 0xde, 0xcf, 0x51,
+	0x69, 0xda, 0x85, 0xba, 0x4a, 0xb3, 0x31, 0x9a, 0xe1, 0x2e, 0x2f, 0x45, 0x78, 0x0d, 0xbd, 0x6a,
+	0xce, 0x55, 0x26, 0x72, 0x75, 0x2b, 0xf5, 0xaf, 0xa8, 0x03, 0x68, 0x14, 0x26, 0x67, 0xae, 0xd2,
+	0x1e, 0x74, 0x77, 0x39, 0xcb, 0x3a, 0x8b, 0x69, 0x9d, 0xe1, 0x05, 0xfc, 0xfb, 0xb6, 0x07, 0xf5,
+	0xa1, 0x69, 0x77, 0xb0, 0xcd, 0x9b, 0x62, 0x9b, 0xc1, 0x87, 0x3c, 0x2d, 0xec, 0xdd, 0x5d, 0x5e,
+	0xc9, 0xe1, 0xe1, 0xe2, 0x9d, 0x39, 0x8b, 0x15, 0x23, 0xcb, 0x15, 0x23, 0x6f, 0x2b, 0x46, 0x9e,
+	0xd7, 0xcc, 0x59, 0xae, 0x99, 0xf3, 0xb2, 0x66, 0xce, 0xa8, 0x61, 0xfe, 0xc0, 0xc9, 0xc7, 0x00,
+	0x36, 0x24, 0x29, 0x9c, 0x40, 0x02, 0x00, 0x00,
 }
 
 func (m *DatabaseRecord) Marshal() (dAtA []byte, err error) {
@@ -270,6 +351,74 @@ func (m *ReplicationRecord) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *ReplicationPullRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicationPullRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicationPullRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Since != 0 {
+		i = encodeVarintDatabase(dAtA, i, uint64(m.Since))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DatabaseSnapshotRecord) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DatabaseSnapshotRecord) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DatabaseSnapshotRecord) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Record.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintDatabase(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintDatabase(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *DatabaseAddress) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -362,6 +511,33 @@ func (m *ReplicationRecord) Size() (n int) {
 	return n
 }
 
+func (m *ReplicationPullRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Since != 0 {
+		n += 1 + sovDatabase(uint64(m.Since))
+	}
+	return n
+}
+
+func (m *DatabaseSnapshotRecord) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovDatabase(uint64(l))
+	}
+	l = m.Record.Size()
+	n += 1 + l + sovDatabase(uint64(l))
+	return n
+}
+
 func (m *DatabaseAddress) Size() (n int) {
 	if m == nil {
 		return 0
@@ -666,6 +842,196 @@ func (m *ReplicationRecord) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ReplicationPullRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDatabase
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicationPullRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicationPullRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Since", wireType)
+			}
+			m.Since = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatabase
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Since |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDatabase(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DatabaseSnapshotRecord) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDatabase
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DatabaseSnapshotRecord: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DatabaseSnapshotRecord: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatabase
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatabase
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Record.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDatabase(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthDatabase
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *DatabaseAddress) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -20,9 +20,19 @@ func TestDatabaseGetSet(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// Set up a clock
+
+	tc := &testClock{time.Now()}
+	db.clock = tc
+
 	go db.Serve()
 	defer db.Stop()
 
+	testDatabaseGetSet(t, db, tc)
+}
+
+func testDatabaseGetSet(t *testing.T, db database, tc *testClock) {
 	// Check missing record
 
 	rec, err := db.get("abcd")
@@ -36,12 +46,6 @@ func TestDatabaseGetSet(t *testing.T) {
 		t.Error("missing should be zero")
 	}
 
-	// Set up a clock
-
-	now := time.Now()
-	tc := &testClock{now}
-	db.clock = tc
-
 	// Put a record
 
 	rec.Addresses = []DatabaseAddress{
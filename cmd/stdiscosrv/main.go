@@ -13,7 +13,9 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -22,6 +24,7 @@ import (
 	"github.com/syncthing/syncthing/lib/tlsutil"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/thejerf/suture"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -53,6 +56,13 @@ const (
 
 	// Size of the replication outbox channel
 	replicationOutboxSize = 10000
+
+	// How often to pull records from the replication peers, to catch up
+	// on replication updates that were lost.
+	defaultReplicationPullInterval = time.Hour
+
+	// Number of clients tracked by the per client rate limiter
+	clientLimiterCacheSize = 25000
 )
 
 // These options make the database a little more optimized for writes, at
@@ -70,25 +80,37 @@ var (
 func main() {
 	var listen string
 	var dir string
+	var dbBackend string
 	var metricsListen string
 	var replicationListen string
 	var replicationPeers string
 	var certFile string
 	var keyFile string
 	var useHTTP bool
+	var replicationPullInterval time.Duration
+	var allowlistFile string
+	var tokensFile string
+	var clientRateLimit float64
+	var clientRateBurst int
 
 	log.SetOutput(os.Stdout)
 	log.SetFlags(0)
 
+	flag.StringVar(&allowlistFile, "allowlist", "", "File listing the only device IDs allowed to announce and be looked up (reloaded on SIGHUP)")
 	flag.StringVar(&certFile, "cert", "./cert.pem", "Certificate file")
+	flag.StringVar(&dbBackend, "db-backend", storeLevelDB, "Database backend (leveldb or memory)")
 	flag.StringVar(&dir, "db-dir", "./discovery.db", "Database directory")
 	flag.BoolVar(&debug, "debug", false, "Print debug output")
 	flag.BoolVar(&useHTTP, "http", false, "Listen on HTTP (behind an HTTPS proxy)")
 	flag.StringVar(&listen, "listen", ":8443", "Listen address")
 	flag.StringVar(&keyFile, "key", "./key.pem", "Key file")
+	flag.StringVar(&tokensFile, "lookup-tokens", "", "File listing the bearer tokens required for lookups (reloaded on SIGHUP)")
 	flag.StringVar(&metricsListen, "metrics-listen", "", "Metrics listen address")
+	flag.Float64Var(&clientRateLimit, "rate-limit", 0, "Requests per second allowed per client address (0 for unlimited)")
+	flag.IntVar(&clientRateBurst, "rate-burst", 10, "Requests allowed per client address in a burst above the rate limit")
 	flag.StringVar(&replicationPeers, "replicate", "", "Replication peers, id@address, comma separated")
 	flag.StringVar(&replicationListen, "replication-listen", ":19200", "Replication listen address")
+	flag.DurationVar(&replicationPullInterval, "replication-pull-interval", defaultReplicationPullInterval, "Interval between pulls from replication peers (0 to disable)")
 	showVersion := flag.Bool("version", false, "Show version")
 	flag.Parse()
 
@@ -148,7 +170,7 @@ func main() {
 	})
 
 	// Start the database.
-	db, err := newLevelDBStore(dir)
+	db, err := newStore(dbBackend, dir)
 	if err != nil {
 		log.Fatalln("Open database:", err)
 	}
@@ -162,14 +184,48 @@ func main() {
 		repl = append(repl, rs)
 	}
 
+	// Start the anti-entropy pullers.
+	if replicationPullInterval > 0 {
+		for _, dst := range replicationDestinations {
+			rp := newReplicationPuller(dst, cert, allowedReplicationPeers, db, replicationPullInterval)
+			main.Add(rp)
+		}
+	}
+
 	// If we have replication configured, start the replication listener.
 	if len(allowedReplicationPeers) > 0 {
 		rl := newReplicationListener(replicationListen, cert, allowedReplicationPeers, db)
 		main.Add(rl)
 	}
 
+	// Set up access control for a private server, reloading it when we get
+	// a SIGHUP.
+	var access *accessControl
+	if allowlistFile != "" || tokensFile != "" {
+		access, err = newAccessControl(allowlistFile, tokensFile)
+		if err != nil {
+			log.Fatalln("Access control:", err)
+		}
+		go func() {
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGHUP)
+			for range sigs {
+				if err := access.reload(); err != nil {
+					log.Println("Reload access control:", err)
+					continue
+				}
+				log.Println("Reloaded access control")
+			}
+		}()
+	}
+
+	var limiter *clientLimiter
+	if clientRateLimit > 0 {
+		limiter = newClientLimiter(rate.Limit(clientRateLimit), clientRateBurst)
+	}
+
 	// Start the main API server.
-	qs := newAPISrv(listen, cert, db, repl, useHTTP)
+	qs := newAPISrv(listen, cert, db, repl, useHTTP, access, limiter)
 	main.Add(qs)
 
 	// If we have a metrics port configured, start a metrics handler.
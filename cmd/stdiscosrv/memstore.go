@@ -0,0 +1,255 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/osutil"
+)
+
+// The in-memory store keeps all records in memory and periodically writes
+// a snapshot of them to disk, which is read back at startup. Lookups and
+// announcements never touch the disk. Records changed since the last
+// snapshot are lost in a crash, but they are recovered from the other
+// replicas by the anti-entropy pull at startup, or from the devices'
+// next announcements.
+
+const inMemorySnapshotFile = "records.snapshot"
+
+type inMemoryStore struct {
+	path  string
+	clock clock
+	stop  chan struct{}
+
+	mut     sync.RWMutex
+	records map[string]DatabaseRecord
+}
+
+func newInMemoryStore(path string) (*inMemoryStore, error) {
+	s := &inMemoryStore{
+		path:    path,
+		clock:   defaultClock{},
+		stop:    make(chan struct{}),
+		records: make(map[string]DatabaseRecord),
+	}
+	if err := s.readSnapshot(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *inMemoryStore) put(key string, rec DatabaseRecord) error {
+	t0 := time.Now()
+	defer func() {
+		databaseOperationSeconds.WithLabelValues(dbOpPut).Observe(time.Since(t0).Seconds())
+	}()
+
+	rec.Addresses = append([]DatabaseAddress(nil), rec.Addresses...)
+
+	s.mut.Lock()
+	s.records[key] = rec
+	s.mut.Unlock()
+
+	databaseOperations.WithLabelValues(dbOpPut, dbResSuccess).Inc()
+	return nil
+}
+
+func (s *inMemoryStore) merge(key string, addrs []DatabaseAddress, seen int64) error {
+	t0 := time.Now()
+	defer func() {
+		databaseOperationSeconds.WithLabelValues(dbOpMerge).Observe(time.Since(t0).Seconds())
+	}()
+
+	newRec := DatabaseRecord{
+		Addresses: addrs,
+		Seen:      seen,
+	}
+
+	s.mut.Lock()
+	newRec = merge(newRec, s.records[key])
+	// The merged addresses are a new slice, so we can drop the expired
+	// ones in place instead of keeping them around until the next
+	// snapshot.
+	newRec.Addresses = expire(newRec.Addresses, s.clock.Now().UnixNano())
+	s.records[key] = newRec
+	s.mut.Unlock()
+
+	databaseOperations.WithLabelValues(dbOpMerge, dbResSuccess).Inc()
+	return nil
+}
+
+func (s *inMemoryStore) get(key string) (DatabaseRecord, error) {
+	t0 := time.Now()
+	defer func() {
+		databaseOperationSeconds.WithLabelValues(dbOpGet).Observe(time.Since(t0).Seconds())
+	}()
+
+	rec, ok := s.lookup(key)
+	if !ok {
+		databaseOperations.WithLabelValues(dbOpGet, dbResNotFound).Inc()
+		return DatabaseRecord{}, nil
+	}
+
+	databaseOperations.WithLabelValues(dbOpGet, dbResSuccess).Inc()
+	return rec, nil
+}
+
+func (s *inMemoryStore) iterate(fn func(key string, rec DatabaseRecord) bool) error {
+	// Grab the keys only, so that we don't block writes while the caller
+	// handles the records.
+	s.mut.RLock()
+	keys := make([]string, 0, len(s.records))
+	for key := range s.records {
+		keys = append(keys, key)
+	}
+	s.mut.RUnlock()
+
+	for _, key := range keys {
+		rec, ok := s.lookup(key)
+		if !ok {
+			// Deleted since we grabbed the keys.
+			continue
+		}
+		if !fn(key, rec) {
+			break
+		}
+	}
+	return nil
+}
+
+// lookup returns a copy of the record, with expired addresses removed.
+func (s *inMemoryStore) lookup(key string) (DatabaseRecord, bool) {
+	s.mut.RLock()
+	rec, ok := s.records[key]
+	s.mut.RUnlock()
+	if !ok {
+		return DatabaseRecord{}, false
+	}
+
+	// The addresses are shared with the stored record, so they must be
+	// copied before being expired in place.
+	rec.Addresses = append([]DatabaseAddress(nil), rec.Addresses...)
+	rec.Addresses = expire(rec.Addresses, s.clock.Now().UnixNano())
+	return rec, true
+}
+
+func (s *inMemoryStore) Serve() {
+	t := time.NewTimer(databaseStatisticsInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := s.snapshot(); err != nil {
+				log.Println("Write database snapshot:", err)
+			}
+			t.Reset(databaseStatisticsInterval)
+
+		case <-s.stop:
+			// Write a final snapshot so that a restart loses nothing.
+			if err := s.snapshot(); err != nil {
+				log.Println("Write database snapshot:", err)
+			}
+			return
+		}
+	}
+}
+
+func (s *inMemoryStore) Stop() {
+	close(s.stop)
+}
+
+// snapshot writes the records to the snapshot file, replacing the previous
+// one. Records that are no longer needed are deleted and the statistics
+// are updated on the way.
+func (s *inMemoryStore) snapshot() error {
+	stats := newDatabaseStatistics(s.clock.Now())
+
+	// Copy the records to keep under the lock, and write them out after
+	// releasing it, so that writes aren't blocked by the disk. Stored
+	// address slices are replaced rather than modified, so sharing them is
+	// safe.
+	var deletes []string
+	s.mut.RLock()
+	keep := make([]DatabaseSnapshotRecord, 0, len(s.records))
+	for key, rec := range s.records {
+		if !stats.account(rec) {
+			deletes = append(deletes, key)
+			continue
+		}
+		keep = append(keep, DatabaseSnapshotRecord{Key: key, Record: rec})
+	}
+	s.mut.RUnlock()
+
+	if err := s.writeSnapshot(keep); err != nil {
+		return err
+	}
+
+	if len(deletes) > 0 {
+		s.mut.Lock()
+		for _, key := range deletes {
+			// Check again, in case the record was updated meanwhile.
+			if rec, ok := s.records[key]; ok && !newDatabaseStatistics(stats.t0).account(rec) {
+				delete(s.records, key)
+				databaseOperations.WithLabelValues(dbOpDelete, dbResSuccess).Inc()
+			}
+		}
+		s.mut.Unlock()
+	}
+
+	stats.publish()
+	return nil
+}
+
+func (s *inMemoryStore) writeSnapshot(recs []DatabaseSnapshotRecord) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	fd, err := osutil.CreateAtomic(s.path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(fd)
+	w := &messageWriter{w: bw}
+	for i := range recs {
+		if err := w.write(&recs[i]); err != nil {
+			fd.Close()
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+func (s *inMemoryStore) readSnapshot() error {
+	fd, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	r := &messageReader{r: bufio.NewReader(fd)}
+	for {
+		var rec DatabaseSnapshotRecord
+		if _, err := r.read(&rec); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		s.records[rec.Key] = rec.Record
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInMemoryStoreGetSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stdiscosrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := newInMemoryStore(filepath.Join(dir, inMemorySnapshotFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := &testClock{time.Now()}
+	db.clock = tc
+
+	testDatabaseGetSet(t, db, tc)
+}
+
+func TestInMemoryStoreSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stdiscosrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "db", inMemorySnapshotFile)
+
+	db, err := newInMemoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := &testClock{time.Now()}
+	db.clock = tc
+
+	addrs := []DatabaseAddress{
+		{Address: "tcp://1.2.3.4:5", Expires: tc.Now().Add(time.Minute).UnixNano()},
+	}
+	if err := db.merge("abcd", addrs, tc.Now().UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.put("efgh", DatabaseRecord{Misses: 42, Missed: tc.Now().UnixNano()}); err != nil {
+		t.Fatal(err)
+	}
+	// Neither seen nor asked for lately, so removed by the snapshot.
+	old := tc.Now().Add(-90 * 24 * time.Hour).UnixNano()
+	if err := db.put("ijkl", DatabaseRecord{Seen: old, Missed: old}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.snapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the snapshot back
+
+	db, err = newInMemoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.clock = tc
+
+	rec, err := db.get("abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Addresses) != 1 || rec.Addresses[0].Address != "tcp://1.2.3.4:5" {
+		t.Error("incorrect addresses", rec.Addresses)
+	}
+	rec, err = db.get("efgh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Misses != 42 {
+		t.Error("incorrect misses", rec.Misses)
+	}
+	if _, ok := db.lookup("ijkl"); ok {
+		t.Error("old record should have been removed")
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 const replicationReadTimeout = time.Minute
 const replicationHeartbeatInterval = time.Second * 30
+const replicationMaxMessageSize = 1 << 20
 
 type replicator interface {
 	send(key string, addrs []DatabaseAddress, seen int64)
@@ -191,6 +192,7 @@ func (l *replicationListener) Serve() {
 		ClientAuth:         tls.RequestClientCert,
 		MinVersion:         tls.VersionTLS12,
 		InsecureSkipVerify: true,
+		NextProtos:         []string{replicationPullProto},
 	}
 
 	lst, err := tls.Listen("tcp", l.addr, tlsCfg)
@@ -231,6 +233,12 @@ func (l *replicationListener) Serve() {
 			continue
 		}
 
+		// Pullers ask for our records, everyone else sends theirs.
+		if conn.(*tls.Conn).ConnectionState().NegotiatedProtocol == replicationPullProto {
+			go l.handlePull(conn)
+			continue
+		}
+
 		go l.handle(conn)
 	}
 }
@@ -324,3 +332,67 @@ func deviceIDIn(id protocol.DeviceID, ids []protocol.DeviceID) bool {
 	}
 	return false
 }
+
+type marshaller interface {
+	Size() int
+	MarshalTo([]byte) (int, error)
+}
+
+type unmarshaller interface {
+	Unmarshal([]byte) error
+}
+
+// A messageWriter writes messages prefixed by their four byte size.
+type messageWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (w *messageWriter) write(msg marshaller) error {
+	// Buffer must hold message plus four bytes for size
+	size := msg.Size()
+	if len(w.buf) < size+4 {
+		w.buf = make([]byte, size+4)
+	}
+	n, err := msg.MarshalTo(w.buf[4:])
+	if err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(w.buf, uint32(n))
+	_, err = w.w.Write(w.buf[:4+n])
+	return err
+}
+
+// A messageReader reads messages written by a messageWriter.
+type messageReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// read reads the next message into msg, returning false if the message is
+// empty. The error is io.EOF if there are no more messages.
+func (r *messageReader) read(msg unmarshaller) (bool, error) {
+	if len(r.buf) < 4 {
+		r.buf = make([]byte, 1024)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:4]); err != nil {
+		return false, err
+	}
+	size := int(binary.BigEndian.Uint32(r.buf[:4]))
+	if size == 0 {
+		return false, nil
+	}
+	if size > replicationMaxMessageSize {
+		return false, fmt.Errorf("message too large (%d bytes)", size)
+	}
+	if len(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:size]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return false, err
+	}
+	return true, msg.Unmarshal(r.buf[:size])
+}
@@ -58,6 +58,20 @@ var (
 			Name:      "replication_recvs_total",
 			Help:      "Number of replication receives.",
 		}, []string{"result"})
+	replicationPullsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "discovery",
+			Name:      "replication_pulls_total",
+			Help:      "Number of anti-entropy replication pulls.",
+		}, []string{"result"})
+	replicationPulledRecordsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "discovery",
+			Name:      "replication_pulled_records_total",
+			Help:      "Number of records received by anti-entropy replication pulls.",
+		})
 
 	databaseKeys = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -106,6 +120,7 @@ func init() {
 	prometheus.MustRegister(apiRequestsTotal, apiRequestsSeconds,
 		lookupRequestsTotal, announceRequestsTotal,
 		replicationSendsTotal, replicationRecvsTotal,
+		replicationPullsTotal, replicationPulledRecordsTotal,
 		databaseKeys, databaseStatisticsSeconds,
 		databaseOperations, databaseOperationSeconds)
 
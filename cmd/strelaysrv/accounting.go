@@ -0,0 +1,290 @@
+// Copyright (C) 2020 Audrius Butkevicius and Contributors.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/osutil"
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+)
+
+// Each quota window is split into this many buckets, which expire one at a
+// time as the window rolls on.
+const quotaBuckets = 60
+
+const accountingSaveInterval = time.Minute
+
+// Pairs that have relayed nothing for this long are forgotten.
+const accountingPairExpiry = 30 * 24 * time.Hour
+
+// Sessions add up the bytes they relay, and account for them once they are
+// this many or this old.
+const (
+	sessionAccountingBytes    = 1 << 20
+	sessionAccountingInterval = 10 * time.Second
+)
+
+// relayAccounting is nil unless there is a policy or an accounting file,
+// in which case the bytes relayed are counted.
+var relayAccounting *accounting
+
+// accounting keeps track of the bytes relayed between each pair of devices,
+// and of the bytes relayed by each device with a quota within its window.
+type accounting struct {
+	mut   sync.Mutex
+	pairs map[devicePair]*pairStats
+	usage map[syncthingprotocol.DeviceID]*deviceUsage
+}
+
+// devicePair is a pair of devices, in order.
+type devicePair [2]syncthingprotocol.DeviceID
+
+func newDevicePair(a, b syncthingprotocol.DeviceID) devicePair {
+	if b.Compare(a) < 0 {
+		a, b = b, a
+	}
+	return devicePair{a, b}
+}
+
+type pairStats struct {
+	bytes       int64
+	lastRelayed time.Time
+}
+
+// pairEntry is the persisted and reported form of the pair statistics.
+type pairEntry struct {
+	Devices     [2]string `json:"devices"`
+	Bytes       int64     `json:"bytes"`
+	LastRelayed time.Time `json:"lastRelayed"`
+}
+
+func newAccounting() *accounting {
+	return &accounting{
+		pairs: make(map[devicePair]*pairStats),
+		usage: make(map[syncthingprotocol.DeviceID]*deviceUsage),
+	}
+}
+
+// add accounts for bytes relayed between the two devices, returning false
+// if either is now over its quota.
+func (a *accounting) add(p relayPolicy, from, to syncthingprotocol.DeviceID, bytes int64, now time.Time) bool {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	pair := newDevicePair(from, to)
+	stats, ok := a.pairs[pair]
+	if !ok {
+		stats = &pairStats{}
+		a.pairs[pair] = stats
+	}
+	stats.bytes += bytes
+	stats.lastRelayed = now
+
+	within := true
+	for _, id := range pair {
+		quota := p.quotaFor(id)
+		if quota <= 0 {
+			continue
+		}
+		usage, ok := a.usage[id]
+		if !ok {
+			usage = &deviceUsage{}
+			a.usage[id] = usage
+		}
+		usage.add(bytes, now, p.window)
+		if usage.total > quota {
+			within = false
+		}
+	}
+	return within
+}
+
+// overQuota returns true if the device has used up its quota.
+func (a *accounting) overQuota(p relayPolicy, id syncthingprotocol.DeviceID, now time.Time) bool {
+	quota := p.quotaFor(id)
+	if quota <= 0 {
+		return false
+	}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	usage, ok := a.usage[id]
+	if !ok {
+		return false
+	}
+	usage.expire(now, p.window)
+	return usage.total >= quota
+}
+
+// expire forgets the usage of devices that have relayed nothing within the
+// window, and the pairs that have relayed nothing for accountingPairExpiry.
+func (a *accounting) expire(p relayPolicy, now time.Time) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	for id, usage := range a.usage {
+		usage.expire(now, p.window)
+		if len(usage.buckets) == 0 {
+			delete(a.usage, id)
+		}
+	}
+	cutoff := now.Add(-accountingPairExpiry)
+	for pair, stats := range a.pairs {
+		if stats.lastRelayed.Before(cutoff) {
+			delete(a.pairs, pair)
+		}
+	}
+}
+
+// entries returns the pair statistics, most bytes relayed first.
+func (a *accounting) entries() []pairEntry {
+	a.mut.Lock()
+	entries := make([]pairEntry, 0, len(a.pairs))
+	for pair, stats := range a.pairs {
+		entries = append(entries, pairEntry{
+			Devices:     [2]string{pair[0].String(), pair[1].String()},
+			Bytes:       stats.bytes,
+			LastRelayed: stats.lastRelayed,
+		})
+	}
+	a.mut.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Bytes > entries[j].Bytes
+	})
+	return entries
+}
+
+func (a *accounting) save(path string) error {
+	fd, err := osutil.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(fd).Encode(a.entries()); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+func (a *accounting) load(path string) error {
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	var entries []pairEntry
+	if err := json.NewDecoder(fd).Decode(&entries); err != nil {
+		return err
+	}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	for _, entry := range entries {
+		from, err := syncthingprotocol.DeviceIDFromString(entry.Devices[0])
+		if err != nil {
+			return err
+		}
+		to, err := syncthingprotocol.DeviceIDFromString(entry.Devices[1])
+		if err != nil {
+			return err
+		}
+		a.pairs[newDevicePair(from, to)] = &pairStats{
+			bytes:       entry.Bytes,
+			lastRelayed: entry.LastRelayed,
+		}
+	}
+	return nil
+}
+
+// accountingService periodically expires the quota usage and old pairs,
+// and saves the accounting, if we have somewhere to save it.
+func accountingService(path string) {
+	for range time.NewTicker(accountingSaveInterval).C {
+		relayAccounting.expire(currentPolicy(), time.Now())
+		if path == "" {
+			continue
+		}
+		if err := relayAccounting.save(path); err != nil {
+			log.Println("Saving accounting:", err)
+		}
+	}
+}
+
+// sessionAccounting adds up the bytes relayed by a session, so that the
+// shared accounting isn't locked for every read. A nil accounting counts
+// nothing.
+type sessionAccounting struct {
+	a        *accounting
+	from, to syncthingprotocol.DeviceID
+	pending  int64
+	flushed  time.Time
+}
+
+func newSessionAccounting(a *accounting, from, to syncthingprotocol.DeviceID, now time.Time) *sessionAccounting {
+	return &sessionAccounting{a: a, from: from, to: to, flushed: now}
+}
+
+// add counts bytes relayed by the session, returning false if either device
+// was found over its quota when accounting for them.
+func (s *sessionAccounting) add(bytes int64, now time.Time) bool {
+	if s.a == nil {
+		return true
+	}
+	s.pending += bytes
+	if s.pending < sessionAccountingBytes && now.Sub(s.flushed) < sessionAccountingInterval {
+		return true
+	}
+	return s.flush(now)
+}
+
+// flush accounts for the bytes counted so far, returning false if either
+// device is now over its quota.
+func (s *sessionAccounting) flush(now time.Time) bool {
+	if s.a == nil || s.pending == 0 {
+		return true
+	}
+	within := s.a.add(currentPolicy(), s.from, s.to, s.pending, now)
+	s.pending = 0
+	s.flushed = now
+	return within
+}
+
+// deviceUsage is the number of bytes relayed by a device within a rolling
+// window, kept in buckets of a fraction of the window.
+type deviceUsage struct {
+	buckets []usageBucket
+	total   int64
+}
+
+type usageBucket struct {
+	start time.Time
+	bytes int64
+}
+
+func (u *deviceUsage) add(bytes int64, now time.Time, window time.Duration) {
+	u.expire(now, window)
+	if n := len(u.buckets); n > 0 && now.Sub(u.buckets[n-1].start) < window/quotaBuckets {
+		u.buckets[n-1].bytes += bytes
+	} else {
+		u.buckets = append(u.buckets, usageBucket{start: now, bytes: bytes})
+	}
+	u.total += bytes
+}
+
+// expire drops the buckets that started before the window.
+func (u *deviceUsage) expire(now time.Time, window time.Duration) {
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(u.buckets) && !u.buckets[i].start.After(cutoff) {
+		u.total -= u.buckets[i].bytes
+		i++
+	}
+	u.buckets = u.buckets[i:]
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2020 Audrius Butkevicius and Contributors.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestAccountingQuota(t *testing.T) {
+	p := relayPolicy{
+		quota:       1000,
+		deviceQuota: map[syncthingprotocol.DeviceID]int64{device3: 0},
+		window:      time.Hour,
+	}
+	a := newAccounting()
+	now := time.Now()
+
+	if !a.add(p, device1, device2, 600, now) {
+		t.Error("should be within quota")
+	}
+	if a.overQuota(p, device1, now) {
+		t.Error("device1 should not be over quota")
+	}
+	if a.add(p, device2, device1, 600, now.Add(30*time.Minute)) {
+		t.Error("should be over quota")
+	}
+	if !a.overQuota(p, device1, now.Add(30*time.Minute)) || !a.overQuota(p, device2, now.Add(30*time.Minute)) {
+		t.Error("both devices should be over quota")
+	}
+
+	// The first bytes fall out of the window.
+
+	if a.overQuota(p, device1, now.Add(time.Hour)) {
+		t.Error("device1 should no longer be over quota")
+	}
+
+	// device3 is unlimited, but device2 is not.
+
+	if a.add(p, device3, device2, 100000, now.Add(time.Hour)) {
+		t.Error("device2 should be over quota")
+	}
+	if a.overQuota(p, device3, now.Add(time.Hour)) {
+		t.Error("device3 should not have a quota")
+	}
+
+	// Usage is forgotten once out of the window.
+
+	a.expire(p, now.Add(3*time.Hour))
+	if len(a.usage) != 0 {
+		t.Error("usage should have expired")
+	}
+	if len(a.pairs) != 2 {
+		t.Error("pairs should be kept", a.pairs)
+	}
+
+	// Pairs are forgotten once they have relayed nothing for long enough.
+
+	a.expire(p, now.Add(2*time.Hour+accountingPairExpiry))
+	if len(a.pairs) != 0 {
+		t.Error("pairs should have expired", a.pairs)
+	}
+}
+
+func TestSessionAccounting(t *testing.T) {
+	p := relayPolicy{quota: 2 * sessionAccountingBytes, window: time.Hour}
+	setPolicy(p)
+	defer setPolicy(relayPolicy{})
+
+	a := newAccounting()
+	now := time.Now()
+	s := newSessionAccounting(a, device1, device2, now)
+
+	// Small reads are only counted by the session.
+
+	if !s.add(1000, now) {
+		t.Error("should be within quota")
+	}
+	if len(a.pairs) != 0 {
+		t.Error("should not be accounted yet")
+	}
+
+	// Until enough time has passed.
+
+	if !s.add(1000, now.Add(sessionAccountingInterval)) {
+		t.Error("should be within quota")
+	}
+	if entries := a.entries(); len(entries) != 1 || entries[0].Bytes != 2000 {
+		t.Error("unexpected accounting", entries)
+	}
+
+	// Or enough bytes have been relayed.
+
+	if !s.add(sessionAccountingBytes, now.Add(sessionAccountingInterval)) {
+		t.Error("should be within quota")
+	}
+	if entries := a.entries(); entries[0].Bytes != 2000+sessionAccountingBytes {
+		t.Error("unexpected accounting", entries)
+	}
+	if s.add(sessionAccountingBytes, now.Add(sessionAccountingInterval)) {
+		t.Error("should be over quota")
+	}
+
+	// What is left is accounted for when the session ends.
+
+	s.add(1000, now.Add(sessionAccountingInterval))
+	s.flush(now.Add(sessionAccountingInterval))
+	if entries := a.entries(); entries[0].Bytes != 3000+2*sessionAccountingBytes {
+		t.Error("unexpected accounting", entries)
+	}
+
+	// Nothing is counted without accounting.
+
+	s = newSessionAccounting(nil, device1, device2, now)
+	if !s.add(10*sessionAccountingBytes, now) || !s.flush(now) {
+		t.Error("should not be limited without accounting")
+	}
+}
+
+func TestAccountingPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "strelaysrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "accounting.json")
+
+	a := newAccounting()
+	now := time.Now().Truncate(time.Second)
+	a.add(relayPolicy{}, device1, device2, 100, now)
+	a.add(relayPolicy{}, device2, device1, 50, now)
+	a.add(relayPolicy{}, device1, device3, 500, now)
+	if err := a.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newAccounting()
+	if err := b.load(path); err != nil {
+		t.Fatal(err)
+	}
+	entries := b.entries()
+	if len(entries) != 2 {
+		t.Fatal("expected two pairs, got", entries)
+	}
+	if entries[0].Bytes != 500 || entries[1].Bytes != 150 {
+		t.Error("unexpected bytes relayed", entries)
+	}
+	pair := newDevicePair(device2, device1)
+	if entries[1].Devices != [2]string{pair[0].String(), pair[1].String()} {
+		t.Error("unexpected devices", entries[1].Devices)
+	}
+	if !entries[1].LastRelayed.Equal(now) {
+		t.Error("unexpected last relayed time", entries[1].LastRelayed)
+	}
+}
@@ -129,6 +129,15 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config) {
 					continue
 				}
 
+				if res, ok := policyResponse(id); !ok {
+					protocol.WriteMessage(conn, res)
+					if debug {
+						log.Println("Refusing join request from", id, "due to policy:", res.Message)
+					}
+					conn.Close()
+					continue
+				}
+
 				outboxesMut.RLock()
 				_, ok := outboxes[id]
 				outboxesMut.RUnlock()
@@ -149,6 +158,15 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config) {
 				protocol.WriteMessage(conn, protocol.ResponseSuccess)
 
 			case protocol.ConnectRequest:
+				if res, ok := policyResponse(id); !ok {
+					protocol.WriteMessage(conn, res)
+					if debug {
+						log.Println("Refusing connect request from", id, "due to policy:", res.Message)
+					}
+					conn.Close()
+					continue
+				}
+
 				requestedPeer := syncthingprotocol.DeviceIDFromBytes(msg.ID)
 				outboxesMut.RLock()
 				peerOutbox, ok := outboxes[requestedPeer]
@@ -161,6 +179,15 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config) {
 					conn.Close()
 					continue
 				}
+
+				if res, ok := policyResponse(requestedPeer); !ok {
+					protocol.WriteMessage(conn, res)
+					if debug {
+						log.Println("Refusing connect request from", id, "to", requestedPeer, "due to policy:", res.Message)
+					}
+					conn.Close()
+					continue
+				}
 				// requestedPeer is the server, id is the client
 				ses := newSession(requestedPeer, id, sessionLimiter, globalLimiter)
 
@@ -243,6 +270,15 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config) {
 				continue
 			}
 
+			if !currentPolicy().allows(id) {
+				if debug {
+					log.Println("Dropping", id, "as it is no longer allowed")
+				}
+				protocol.WriteMessage(conn, protocol.ResponseNotAllowed)
+				conn.Close()
+				continue
+			}
+
 			if err := protocol.WriteMessage(conn, protocol.Ping{}); err != nil {
 				if debug {
 					log.Println(id, err)
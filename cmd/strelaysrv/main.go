@@ -67,6 +67,10 @@ var (
 	natTimeout int
 
 	pprofEnabled bool
+
+	policyPath     string
+	accountingPath string
+	adminAddr      string
 )
 
 // httpClient is the HTTP client we use for outbound requests. It has a
@@ -99,6 +103,9 @@ func main() {
 	flag.IntVar(&natTimeout, "nat-timeout", 10, "NAT discovery timeout in seconds")
 	flag.BoolVar(&pprofEnabled, "pprof", false, "Enable the built in profiling on the status server")
 	flag.IntVar(&networkBufferSize, "network-buffer", 2048, "Network buffer size (two of these per proxied connection)")
+	flag.StringVar(&policyPath, "policy", "", "JSON file with the allowed and denied devices and their quotas (reloaded on SIGHUP)")
+	flag.StringVar(&accountingPath, "accounting-file", "", "File to persist the bytes relayed per device pair to (blank to not persist)")
+	flag.StringVar(&adminAddr, "admin-srv", "", "Listen address for the admin service with the bytes relayed per device pair (blank to disable, needs -policy or -accounting-file)")
 	showVersion := flag.Bool("version", false, "Show version")
 	flag.Parse()
 
@@ -221,6 +228,45 @@ func main() {
 		globalLimiter = rate.NewLimiter(rate.Limit(globalLimitBps), 2*globalLimitBps)
 	}
 
+	if policyPath != "" {
+		p, err := loadPolicy(policyPath)
+		if err != nil {
+			log.Fatalln("Failed to load policy:", err)
+		}
+		setPolicy(p)
+
+		go func() {
+			sighup := make(chan os.Signal, 1)
+			signal.Notify(sighup, syscall.SIGHUP)
+			for range sighup {
+				p, err := loadPolicy(policyPath)
+				if err != nil {
+					log.Println("Failed to reload policy:", err)
+					continue
+				}
+				setPolicy(p)
+				log.Println("Reloaded policy")
+			}
+		}()
+	}
+
+	if policyPath != "" || accountingPath != "" {
+		relayAccounting = newAccounting()
+		if accountingPath != "" {
+			if err := relayAccounting.load(accountingPath); err != nil && !os.IsNotExist(err) {
+				log.Fatalln("Failed to load accounting:", err)
+			}
+		}
+		go accountingService(accountingPath)
+	}
+
+	if adminAddr != "" {
+		if relayAccounting == nil {
+			log.Fatalln("The admin service needs -policy or -accounting-file")
+		}
+		go adminService(adminAddr)
+	}
+
 	if statusAddr != "" {
 		go statusService(statusAddr)
 	}
@@ -273,6 +319,12 @@ func main() {
 	outboxesMut.RUnlock()
 
 	time.Sleep(500 * time.Millisecond)
+
+	if accountingPath != "" {
+		if err := relayAccounting.save(accountingPath); err != nil {
+			log.Println("Saving accounting:", err)
+		}
+	}
 }
 
 func monitorLimits() {
@@ -0,0 +1,154 @@
+// Copyright (C) 2020 Audrius Butkevicius and Contributors.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/relay/protocol"
+)
+
+// The relay policy decides which devices may use the relay, and how many
+// bytes each device may have relayed within a rolling window. It is read
+// from a JSON file, reloaded on SIGHUP:
+//
+//     {
+//         "allowed": ["<device ID>", ...],
+//         "denied": ["<device ID>", ...],
+//         "quotaBytes": 10737418240,
+//         "quotaWindow": "24h",
+//         "deviceQuotaBytes": {"<device ID>": 53687091200}
+//     }
+//
+// Without an allowlist all devices not denied may use the relay. Quotas
+// apply to each device separately, counting the bytes relayed in either
+// direction of its sessions, with zero meaning unlimited.
+
+const defaultQuotaWindow = 24 * time.Hour
+
+var (
+	policyMut = sync.RWMutex{}
+	policy    relayPolicy
+)
+
+type policyFile struct {
+	Allowed          []syncthingprotocol.DeviceID         `json:"allowed"`
+	Denied           []syncthingprotocol.DeviceID         `json:"denied"`
+	QuotaBytes       int64                                `json:"quotaBytes"`
+	QuotaWindow      string                               `json:"quotaWindow"`
+	DeviceQuotaBytes map[syncthingprotocol.DeviceID]int64 `json:"deviceQuotaBytes"`
+}
+
+type relayPolicy struct {
+	allowed     map[syncthingprotocol.DeviceID]struct{} // nil allows all devices
+	denied      map[syncthingprotocol.DeviceID]struct{}
+	quota       int64
+	deviceQuota map[syncthingprotocol.DeviceID]int64
+	window      time.Duration
+}
+
+func loadPolicy(path string) (relayPolicy, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return relayPolicy{}, err
+	}
+	defer fd.Close()
+
+	var pf policyFile
+	if err := json.NewDecoder(fd).Decode(&pf); err != nil {
+		return relayPolicy{}, err
+	}
+
+	p := relayPolicy{
+		quota:       pf.QuotaBytes,
+		deviceQuota: pf.DeviceQuotaBytes,
+		window:      defaultQuotaWindow,
+	}
+	if pf.QuotaWindow != "" {
+		p.window, err = time.ParseDuration(pf.QuotaWindow)
+		if err != nil {
+			return relayPolicy{}, err
+		}
+		if p.window <= 0 {
+			return relayPolicy{}, errors.New("quota window must be positive")
+		}
+	}
+	if len(pf.Allowed) > 0 {
+		p.allowed = deviceSet(pf.Allowed)
+	}
+	p.denied = deviceSet(pf.Denied)
+	return p, nil
+}
+
+// allows returns true if the device may use the relay.
+func (p relayPolicy) allows(id syncthingprotocol.DeviceID) bool {
+	if _, ok := p.denied[id]; ok {
+		return false
+	}
+	if p.allowed == nil {
+		return true
+	}
+	_, ok := p.allowed[id]
+	return ok
+}
+
+// quotaFor returns the number of bytes the device may have relayed within
+// the window, or zero if unlimited.
+func (p relayPolicy) quotaFor(id syncthingprotocol.DeviceID) int64 {
+	if quota, ok := p.deviceQuota[id]; ok {
+		return quota
+	}
+	return p.quota
+}
+
+func currentPolicy() relayPolicy {
+	policyMut.RLock()
+	defer policyMut.RUnlock()
+	return policy
+}
+
+// setPolicy replaces the current policy, closing the sessions of devices
+// no longer allowed.
+func setPolicy(p relayPolicy) {
+	policyMut.Lock()
+	policy = p
+	policyMut.Unlock()
+
+	sessionMut.RLock()
+	for _, session := range activeSessions {
+		if !p.allows(session.serverid) || !p.allows(session.clientid) {
+			if debug {
+				log.Println("Dropping session", session, "no longer allowed")
+			}
+			session.CloseConns()
+		}
+	}
+	sessionMut.RUnlock()
+}
+
+// policyResponse returns the response refusing the device, if the current
+// policy doesn't let it use the relay right now.
+func policyResponse(id syncthingprotocol.DeviceID) (protocol.Response, bool) {
+	p := currentPolicy()
+	if !p.allows(id) {
+		return protocol.ResponseNotAllowed, false
+	}
+	if relayAccounting != nil && relayAccounting.overQuota(p, id, time.Now()) {
+		return protocol.ResponseQuotaExceeded, false
+	}
+	return protocol.ResponseSuccess, true
+}
+
+func deviceSet(ids []syncthingprotocol.DeviceID) map[syncthingprotocol.DeviceID]struct{} {
+	set := make(map[syncthingprotocol.DeviceID]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2020 Audrius Butkevicius and Contributors.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+)
+
+var (
+	device1 = syncthingprotocol.NewDeviceID([]byte("device1"))
+	device2 = syncthingprotocol.NewDeviceID([]byte("device2"))
+	device3 = syncthingprotocol.NewDeviceID([]byte("device3"))
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "strelaysrv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policy.json")
+
+	writePolicy := func(contents string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writePolicy(fmt.Sprintf(`{
+		"allowed": [%q, %q],
+		"denied": [%q],
+		"quotaBytes": 1000,
+		"quotaWindow": "1h",
+		"deviceQuotaBytes": {%q: 5000}
+	}`, device1, device2, device2, device1))
+
+	p, err := loadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.allows(device1) {
+		t.Error("device1 should be allowed")
+	}
+	if p.allows(device2) {
+		t.Error("device2 is denied, which takes precedence")
+	}
+	if p.allows(device3) {
+		t.Error("device3 is not on the allowlist")
+	}
+	if quota := p.quotaFor(device1); quota != 5000 {
+		t.Error("unexpected quota for device1:", quota)
+	}
+	if quota := p.quotaFor(device3); quota != 1000 {
+		t.Error("unexpected quota for device3:", quota)
+	}
+	if p.window != time.Hour {
+		t.Error("unexpected window:", p.window)
+	}
+
+	// Without an allowlist all devices are allowed, unless denied.
+
+	writePolicy(fmt.Sprintf(`{"denied": [%q]}`, device2))
+	p, err = loadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.allows(device1) || !p.allows(device3) || p.allows(device2) {
+		t.Error("only device2 should be denied")
+	}
+	if p.window != defaultQuotaWindow {
+		t.Error("unexpected window:", p.window)
+	}
+
+	writePolicy(`{"denied": ["not a device ID"]}`)
+	if _, err := loadPolicy(path); err == nil {
+		t.Error("invalid device ID should not load")
+	}
+	writePolicy(`{"quotaWindow": "-1h"}`)
+	if _, err := loadPolicy(path); err == nil {
+		t.Error("negative window should not load")
+	}
+}
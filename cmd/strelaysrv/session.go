@@ -5,6 +5,7 @@ package main
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -19,6 +20,8 @@ import (
 	"github.com/syncthing/syncthing/lib/relay/protocol"
 )
 
+var errQuotaExceeded = errors.New("quota exceeded")
+
 var (
 	sessionMut      = sync.RWMutex{}
 	activeSessions  = make([]*session, 0)
@@ -254,6 +257,11 @@ func (s *session) proxy(c1, c2 net.Conn) error {
 	atomic.AddInt64(&numProxies, 1)
 	defer atomic.AddInt64(&numProxies, -1)
 
+	acc := newSessionAccounting(relayAccounting, s.serverid, s.clientid, time.Now())
+	defer func() {
+		acc.flush(time.Now())
+	}()
+
 	buf := make([]byte, networkBufferSize)
 	for {
 		c1.SetReadDeadline(time.Now().Add(networkTimeout))
@@ -264,6 +272,10 @@ func (s *session) proxy(c1, c2 net.Conn) error {
 
 		atomic.AddInt64(&bytesProxied, int64(n))
 
+		if !acc.add(int64(n), time.Now()) {
+			return errQuotaExceeded
+		}
+
 		if debug {
 			log.Printf("%d bytes from %s to %s", n, c1.RemoteAddr(), c2.RemoteAddr())
 		}
@@ -36,6 +36,23 @@ func statusService(addr string) {
 	}
 }
 
+// adminService serves the pair accounting. It tells which devices talk to
+// each other, so it is kept off the status service, which is public.
+func adminService(addr string) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/accounting", getAccounting)
+
+	srv := http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		ReadTimeout: 15 * time.Second,
+	}
+	srv.SetKeepAlivesEnabled(false)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func getStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	status := make(map[string]interface{})
@@ -87,6 +104,17 @@ func getStatus(w http.ResponseWriter, r *http.Request) {
 	w.Write(bs)
 }
 
+func getAccounting(w http.ResponseWriter, r *http.Request) {
+	bs, err := json.MarshalIndent(relayAccounting.entries(), "", "    ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bs)
+}
+
 type rateCalculator struct {
 	counter   *int64 // atomic, must remain 64-bit aligned
 	rates     []int64
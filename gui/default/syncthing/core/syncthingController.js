@@ -28,6 +28,8 @@ angular.module('syncthing.core')
         $scope.model = {};
         $scope.myID = '';
         $scope.devices = [];
+        $scope.pendingDevices = {};
+        $scope.pendingFolders = {};
         $scope.discoveryCache = {};
         $scope.protocolChanged = false;
         $scope.reportData = {};
@@ -119,6 +121,7 @@ angular.module('syncthing.core')
             refreshSystem();
             refreshDiscoveryCache();
             refreshConfig();
+            refreshCluster();
             refreshConnectionStats();
             refreshDeviceStats();
             refreshFolderStats();
@@ -261,8 +264,17 @@ angular.module('syncthing.core')
             }
         });
 
+        $scope.$on(Events.DEVICE_REJECTED, function (event, arg) {
+            refreshCluster();
+        });
+
+        $scope.$on(Events.FOLDER_REJECTED, function (event, arg) {
+            refreshCluster();
+        });
+
         $scope.$on(Events.CONFIG_SAVED, function (event, arg) {
             updateLocalConfig(arg.data);
+            refreshCluster();
 
             $http.get(urlbase + '/system/config/insync').success(function (data) {
                 $scope.configInSync = data.configInSync;
@@ -580,6 +592,18 @@ angular.module('syncthing.core')
             }).error($scope.emitHTTPError);
         }
 
+        function refreshCluster() {
+            $http.get(urlbase + '/cluster/pending/devices').success(function (data) {
+                $scope.pendingDevices = data;
+                console.log("refreshCluster devices", data);
+            }).error($scope.emitHTTPError);
+
+            $http.get(urlbase + '/cluster/pending/folders').success(function (data) {
+                $scope.pendingFolders = data;
+                console.log("refreshCluster folders", data);
+            }).error($scope.emitHTTPError);
+        }
+
         function refreshNeed(folder) {
             if (!$scope.neededFolder) {
                 return;
@@ -1013,7 +1037,6 @@ angular.module('syncthing.core')
 
             // loop through all devices
             var deviceCount = $scope.devices.length;
-            var pendingFolders = 0;
             for (var i = 0; i < $scope.devices.length; i++) {
                 var status = $scope.deviceStatus({
                     deviceID: $scope.devices[i].deviceID
@@ -1029,12 +1052,11 @@ angular.module('syncthing.core')
                         deviceCount--;
                         break;
                 }
-                pendingFolders += $scope.devices[i].pendingFolders.length;
             }
 
             // enumerate notifications
             if ($scope.openNoAuth || !$scope.configInSync || $scope.errorList().length > 0 || !online || (
-                !isEmptyObject($scope.config) && ($scope.config.pendingDevices.length > 0 || pendingFolders > 0)
+                !isEmptyObject($scope.pendingDevices) || !isEmptyObject($scope.pendingFolders)
             )) {
                 notifyCount++;
             }
@@ -1466,7 +1488,6 @@ angular.module('syncthing.core')
                         compression: 'metadata',
                         introducer: false,
                         selectedFolders: {},
-                        pendingFolders: [],
                         ignoredFolders: []
                     };
                     $scope.editingExisting = false;
@@ -1545,11 +1566,12 @@ angular.module('syncthing.core')
             $scope.saveConfig();
         };
 
-        $scope.ignoreDevice = function (pendingDevice) {
-            pendingDevice = angular.copy(pendingDevice);
+        $scope.ignoreDevice = function (deviceID, pendingDevice) {
+            var ignoredDevice = angular.copy(pendingDevice);
+            ignoredDevice.deviceID = deviceID;
             // Bump time
-            pendingDevice.time = (new Date()).toISOString();
-            $scope.config.remoteIgnoredDevices.push(pendingDevice);
+            ignoredDevice.time = (new Date()).toISOString();
+            $scope.config.remoteIgnoredDevices.push(ignoredDevice);
             $scope.saveConfig();
         };
 
@@ -1910,14 +1932,15 @@ angular.module('syncthing.core')
             });
         };
 
-        $scope.ignoreFolder = function (device, pendingFolder) {
-            pendingFolder = angular.copy(pendingFolder);
+        $scope.ignoreFolder = function (device, folderID, offeringDevice) {
+            var ignoredFolder = angular.copy(offeringDevice);
+            ignoredFolder.id = folderID;
             // Bump time
-            pendingFolder.time = (new Date()).toISOString();
+            ignoredFolder.time = (new Date()).toISOString();
 
             for (var i = 0; i < $scope.devices.length; i++) {
                 if ($scope.devices[i].deviceID == device) {
-                    $scope.devices[i].ignoredFolders.push(pendingFolder);
+                    $scope.devices[i].ignoredFolders.push(ignoredFolder);
                     $scope.saveConfig();
                     return;
                 }
@@ -78,9 +78,10 @@ type service struct {
 	evLogger             events.Logger
 	discoverer           discover.CachingMux
 	connectionsService   connections.Service
+	metrics              *metricsCollector
 	fss                  model.FolderSummaryService
 	urService            *ur.Service
-	systemConfigMut      sync.Mutex // serializes posts to /rest/system/config
+	systemConfigMut      sync.Mutex // serializes posts to /rest/system/config and changes through /rest/config
 	contr                Controller
 	noUpgrade            bool
 	tlsDefaultCommonName string
@@ -120,6 +121,7 @@ func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonNam
 		evLogger:             evLogger,
 		discoverer:           discoverer,
 		connectionsService:   connectionsService,
+		metrics:              newMetricsCollector(cfg, m, locations.Get(locations.Database)),
 		fss:                  fss,
 		urService:            urService,
 		systemConfigMut:      sync.NewMutex(),
@@ -211,6 +213,16 @@ func sendJSON(w http.ResponseWriter, jsonObject interface{}) {
 	fmt.Fprintf(w, "%s\n", bs)
 }
 
+// sendJSONError is like http.Error, but the message is sent as the "error"
+// attribute of a JSON object.
+func sendJSONError(w http.ResponseWriter, msg string, code int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	bs, _ := json.MarshalIndent(map[string]string{"error": msg}, "", "  ")
+	fmt.Fprintf(w, "%s\n", bs)
+}
+
 func (s *service) serve(ctx context.Context) {
 	listener, err := s.getListener(s.cfg.GUI())
 	if err != nil {
@@ -242,16 +254,22 @@ func (s *service) serve(ctx context.Context) {
 	s.cfg.Subscribe(s)
 	defer s.cfg.Unsubscribe(s)
 
+	metricsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.metrics.serve(metricsCtx, s.evLogger)
+
 	// The GET handlers
 	getRestMux := http.NewServeMux()
 	getRestMux.HandleFunc("/rest/db/completion", s.getDBCompletion)              // device folder
 	getRestMux.HandleFunc("/rest/db/file", s.getDBFile)                          // folder file
+	getRestMux.HandleFunc("/rest/db/history", s.getDBHistory)                    // folder [file]
 	getRestMux.HandleFunc("/rest/db/ignores", s.getDBIgnores)                    // folder
 	getRestMux.HandleFunc("/rest/db/need", s.getDBNeed)                          // folder [perpage] [page]
 	getRestMux.HandleFunc("/rest/db/remoteneed", s.getDBRemoteNeed)              // device folder [perpage] [page]
 	getRestMux.HandleFunc("/rest/db/localchanged", s.getDBLocalChanged)          // folder
 	getRestMux.HandleFunc("/rest/db/status", s.getDBStatus)                      // folder
 	getRestMux.HandleFunc("/rest/db/browse", s.getDBBrowse)                      // folder [prefix] [dirsonly] [levels]
+	getRestMux.HandleFunc("/rest/folder/conflicts", s.getFolderConflicts)        // folder
 	getRestMux.HandleFunc("/rest/folder/versions", s.getFolderVersions)          // folder
 	getRestMux.HandleFunc("/rest/folder/errors", s.getFolderErrors)              // folder
 	getRestMux.HandleFunc("/rest/folder/pullerrors", s.getFolderErrors)          // folder (deprecated)
@@ -279,23 +297,26 @@ func (s *service) serve(ctx context.Context) {
 
 	// The POST handlers
 	postRestMux := http.NewServeMux()
-	postRestMux.HandleFunc("/rest/db/prio", s.postDBPrio)                          // folder file [perpage] [page]
-	postRestMux.HandleFunc("/rest/db/ignores", s.postDBIgnores)                    // folder
-	postRestMux.HandleFunc("/rest/db/override", s.postDBOverride)                  // folder
-	postRestMux.HandleFunc("/rest/db/revert", s.postDBRevert)                      // folder
-	postRestMux.HandleFunc("/rest/db/scan", s.postDBScan)                          // folder [sub...] [delay]
-	postRestMux.HandleFunc("/rest/folder/versions", s.postFolderVersionsRestore)   // folder <body>
-	postRestMux.HandleFunc("/rest/system/config", s.postSystemConfig)              // <body>
-	postRestMux.HandleFunc("/rest/system/error", s.postSystemError)                // <body>
-	postRestMux.HandleFunc("/rest/system/error/clear", s.postSystemErrorClear)     // -
-	postRestMux.HandleFunc("/rest/system/ping", s.restPing)                        // -
-	postRestMux.HandleFunc("/rest/system/reset", s.postSystemReset)                // [folder]
-	postRestMux.HandleFunc("/rest/system/restart", s.postSystemRestart)            // -
-	postRestMux.HandleFunc("/rest/system/shutdown", s.postSystemShutdown)          // -
-	postRestMux.HandleFunc("/rest/system/upgrade", s.postSystemUpgrade)            // -
-	postRestMux.HandleFunc("/rest/system/pause", s.makeDevicePauseHandler(true))   // [device]
-	postRestMux.HandleFunc("/rest/system/resume", s.makeDevicePauseHandler(false)) // [device]
-	postRestMux.HandleFunc("/rest/system/debug", s.postSystemDebug)                // [enable] [disable]
+	postRestMux.HandleFunc("/rest/db/prio", s.postDBPrio)                            // folder file [perpage] [page]
+	postRestMux.HandleFunc("/rest/db/hydrate", s.postDBHydrate)                      // folder file
+	postRestMux.HandleFunc("/rest/db/ignores", s.postDBIgnores)                      // folder
+	postRestMux.HandleFunc("/rest/db/override", s.postDBOverride)                    // folder
+	postRestMux.HandleFunc("/rest/db/revert", s.postDBRevert)                        // folder
+	postRestMux.HandleFunc("/rest/db/scan", s.postDBScan)                            // folder [sub...] [delay]
+	postRestMux.HandleFunc("/rest/folder/conflicts", s.postFolderConflicts)          // folder copy keep
+	postRestMux.HandleFunc("/rest/folder/versions", s.postFolderVersionsRestore)     // folder <body>
+	postRestMux.HandleFunc("/rest/folder/versions/clean", s.postFolderVersionsClean) // folder
+	postRestMux.HandleFunc("/rest/system/config", s.postSystemConfig)                // <body>
+	postRestMux.HandleFunc("/rest/system/error", s.postSystemError)                  // <body>
+	postRestMux.HandleFunc("/rest/system/error/clear", s.postSystemErrorClear)       // -
+	postRestMux.HandleFunc("/rest/system/ping", s.restPing)                          // -
+	postRestMux.HandleFunc("/rest/system/reset", s.postSystemReset)                  // [folder]
+	postRestMux.HandleFunc("/rest/system/restart", s.postSystemRestart)              // -
+	postRestMux.HandleFunc("/rest/system/shutdown", s.postSystemShutdown)            // -
+	postRestMux.HandleFunc("/rest/system/upgrade", s.postSystemUpgrade)              // -
+	postRestMux.HandleFunc("/rest/system/pause", s.makeDevicePauseHandler(true))     // [device]
+	postRestMux.HandleFunc("/rest/system/resume", s.makeDevicePauseHandler(false))   // [device]
+	postRestMux.HandleFunc("/rest/system/debug", s.postSystemDebug)                  // [enable] [disable]
 
 	// Debug endpoints, not for general use
 	debugMux := http.NewServeMux()
@@ -310,11 +331,32 @@ func (s *service) serve(ctx context.Context) {
 	// caching
 	restMux := noCacheMiddleware(metricsMiddleware(getPostHandler(getRestMux, postRestMux)))
 
+	// The granular config endpoints use more methods than just GET and
+	// POST, so they get their own handler
+	configHandler := noCacheMiddleware(http.HandlerFunc(s.serveConfig))
+
+	// Pending devices and folders can be listed (GET), accepted (POST) and
+	// dismissed (DELETE)
+	clusterMux := http.NewServeMux()
+	clusterMux.HandleFunc("/rest/cluster/pending/devices", s.servePendingDevices) // [device]
+	clusterMux.HandleFunc("/rest/cluster/pending/folders", s.servePendingFolders) // [device] [folder] [path]
+	clusterHandler := noCacheMiddleware(metricsMiddleware(clusterMux))
+
+	// The paths selected for selective sync can be listed (GET), added
+	// (POST) and removed (DELETE)
+	selectionHandler := noCacheMiddleware(metricsMiddleware(http.HandlerFunc(s.serveFolderSelection))) // folder [path]
+
 	// The main routing handler
 	mux := http.NewServeMux()
 	mux.Handle("/rest/", restMux)
+	mux.Handle("/rest/config/", configHandler)
+	mux.Handle("/rest/cluster/", clusterHandler)
+	mux.Handle("/rest/folder/selection", selectionHandler)
 	mux.HandleFunc("/qr/", s.getQR)
 
+	// Prometheus metrics, guarded like the REST API
+	mux.Handle("/metrics", noCacheMiddleware(s.metrics.handler()))
+
 	// Serve compiled in assets unless an asset directory was set (for development)
 	mux.Handle("/", s.statics)
 
@@ -825,6 +867,30 @@ func (s *service) getDBFile(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *service) getDBHistory(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	entries, err := s.model.History(qs.Get("folder"), qs.Get("file"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]map[string]interface{}, len(entries))
+	for i, entry := range entries {
+		res[i] = map[string]interface{}{
+			"time":     entry.Time,
+			"device":   entry.Device,
+			"oldFile":  nil,
+			"newFile":  jsonFileInfo(entry.NewFile),
+			"archived": entry.Archived,
+		}
+		if entry.OldFile != nil {
+			res[i]["oldFile"] = jsonFileInfo(*entry.OldFile)
+		}
+	}
+	sendJSON(w, res)
+}
+
 func (s *service) getSystemConfig(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, s.cfg.RawCopy())
 }
@@ -841,17 +907,10 @@ func (s *service) postSystemConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if to.GUI.Password != s.cfg.GUI().Password {
-		if to.GUI.Password != "" && !bcryptExpr.MatchString(to.GUI.Password) {
-			hash, err := bcrypt.GenerateFromPassword([]byte(to.GUI.Password), 0)
-			if err != nil {
-				l.Warnln("bcrypting password:", err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			to.GUI.Password = string(hash)
-		}
+	if err := s.hashGUIPassword(&to.GUI); err != nil {
+		l.Warnln("bcrypting password:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	// Activate and save. Wait for the configuration to become active before
@@ -872,6 +931,195 @@ func (s *service) postSystemConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// hashGUIPassword replaces a changed, plaintext GUI password with its bcrypt
+// hash.
+func (s *service) hashGUIPassword(gui *config.GUIConfiguration) error {
+	if gui.Password == s.cfg.GUI().Password || gui.Password == "" || bcryptExpr.MatchString(gui.Password) {
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(gui.Password), 0)
+	if err != nil {
+		return err
+	}
+	gui.Password = string(hash)
+	return nil
+}
+
+func (s *service) servePendingDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		devices, err := s.model.PendingDevices()
+		if err != nil {
+			sendJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, devices)
+		return
+	}
+
+	device, err := protocol.DeviceIDFromString(r.URL.Query().Get("device"))
+	if err != nil {
+		sendJSONError(w, "invalid device ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.systemConfigMut.Lock()
+		defer s.systemConfigMut.Unlock()
+
+		devices, err := s.model.PendingDevices()
+		if err != nil {
+			sendJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pending, ok := devices[device]
+		if !ok {
+			sendJSONError(w, "No such pending device", http.StatusNotFound)
+			return
+		}
+		// The pending entry is removed by the model once the device is
+		// configured.
+		waiter, err := s.cfg.SetDevice(config.NewDeviceConfiguration(device, pending.Name))
+		s.finishConfigChange(w, waiter, err)
+
+	case http.MethodDelete:
+		if err := s.model.DismissPendingDevice(device); err != nil {
+			sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *service) servePendingFolders(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var device protocol.DeviceID
+	if deviceStr := qs.Get("device"); deviceStr != "" {
+		var err error
+		device, err = protocol.DeviceIDFromString(deviceStr)
+		if err != nil {
+			sendJSONError(w, "invalid device ID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if r.Method == http.MethodGet {
+		folders, err := s.model.PendingFolders(device)
+		if err != nil {
+			sendJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, folders)
+		return
+	}
+
+	folder := qs.Get("folder")
+	if folder == "" {
+		sendJSONError(w, "folder ID is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		if device == protocol.EmptyDeviceID {
+			sendJSONError(w, "device ID is required", http.StatusBadRequest)
+			return
+		}
+
+		s.systemConfigMut.Lock()
+		defer s.systemConfigMut.Unlock()
+
+		folders, err := s.model.PendingFolders(device)
+		if err != nil {
+			sendJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pending, ok := folders[folder]
+		if !ok {
+			sendJSONError(w, "No such pending folder", http.StatusNotFound)
+			return
+		}
+
+		// Share the existing folder, or create a new one at the given
+		// path. The pending entry is removed by the model once the folder
+		// is shared.
+		fcfg, ok := s.cfg.Folder(folder)
+		if !ok {
+			path := qs.Get("path")
+			if path == "" {
+				sendJSONError(w, "path is required to add a new folder", http.StatusBadRequest)
+				return
+			}
+			fcfg = config.NewFolderConfiguration(s.id, folder, pending.OfferedBy[device].Label, fs.FilesystemTypeBasic, path)
+		}
+		if !fcfg.SharedWith(device) {
+			fcfg.Devices = append(fcfg.Devices, config.FolderDeviceConfiguration{DeviceID: device})
+		}
+		waiter, err := s.cfg.SetFolder(fcfg)
+		s.finishConfigChange(w, waiter, err)
+
+	case http.MethodDelete:
+		if err := s.model.DismissPendingFolder(device, folder); err != nil {
+			sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *service) serveFolderSelection(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	s.systemConfigMut.Lock()
+	defer s.systemConfigMut.Unlock()
+
+	fcfg, ok := s.cfg.Folder(qs.Get("folder"))
+	if !ok {
+		sendJSONError(w, "No such folder", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		sendJSON(w, fcfg.SelectiveSync)
+		return
+	}
+
+	path := strings.Trim(filepath.ToSlash(qs.Get("path")), "/")
+	if path == "" {
+		sendJSONError(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	selection := make([]string, 0, len(fcfg.SelectiveSync)+1)
+	for _, sel := range fcfg.SelectiveSync {
+		if strings.Trim(sel, "/") != path {
+			selection = append(selection, sel)
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		selection = append(selection, path)
+	case http.MethodDelete:
+		if len(selection) == len(fcfg.SelectiveSync) {
+			sendJSONError(w, "No such selected path", http.StatusNotFound)
+			return
+		}
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Changing the selection restarts the folder, and the scan at startup
+	// removes the data that is no longer selected.
+	fcfg.SelectiveSync = selection
+	waiter, err := s.cfg.SetFolder(fcfg)
+	s.finishConfigChange(w, waiter, err)
+}
+
 func (s *service) getSystemConfigInsync(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, map[string]bool{"configInSync": !s.cfg.RequiresRestart()})
 }
@@ -929,7 +1177,7 @@ func (s *service) getSystemStatus(w http.ResponseWriter, r *http.Request) {
 	res["alloc"] = m.Alloc
 	res["sys"] = m.Sys - m.HeapReleased
 	res["tilde"] = tilde
-	if s.cfg.Options().LocalAnnEnabled || s.cfg.Options().GlobalAnnEnabled {
+	if opts := s.cfg.Options(); opts.LocalAnnEnabled || opts.LocalAnnMDNSEnabled || opts.GlobalAnnEnabled {
 		res["discoveryEnabled"] = true
 		discoErrors := make(map[string]string)
 		discoMethods := 0
@@ -1167,8 +1415,15 @@ func (s *service) getDBIgnores(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The shared part is distributed to all devices sharing the folder.
+	var shared []string
+	if fcfg, ok := s.cfg.Folder(folder); ok {
+		shared = fcfg.SharedIgnores.Lines
+	}
+
 	sendJSON(w, map[string][]string{
 		"ignore":   ignores,
+		"shared":   shared,
 		"expanded": patterns,
 	})
 }
@@ -1190,10 +1445,21 @@ func (s *service) postDBIgnores(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.model.SetIgnores(qs.Get("folder"), data["ignore"])
-	if err != nil {
-		http.Error(w, err.Error(), 500)
-		return
+	// The shared part is only changed when given, and the local part is
+	// then left alone unless given as well.
+	shared, setShared := data["shared"]
+	if setShared {
+		if err := s.model.SetSharedIgnores(qs.Get("folder"), shared); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	}
+
+	if ignores, ok := data["ignore"]; ok || !setShared {
+		if err := s.model.SetIgnores(qs.Get("folder"), ignores); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
 	}
 
 	s.getDBIgnores(w, r)
@@ -1393,6 +1659,13 @@ func (s *service) postDBScan(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *service) postDBHydrate(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	if err := s.model.Hydrate(qs.Get("folder"), qs.Get("file")); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (s *service) postDBPrio(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	folder := qs.Get("folder")
@@ -1438,6 +1711,36 @@ func (s *service) getPeerCompletion(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, comp)
 }
 
+func (s *service) getFolderConflicts(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	conflicts, err := s.model.Conflicts(qs.Get("folder"))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	sendJSON(w, conflicts)
+}
+
+// postFolderConflicts resolves the conflict of the given copy by keeping
+// either the "copy" or the "original".
+func (s *service) postFolderConflicts(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	var keepCopy bool
+	switch qs.Get("keep") {
+	case "copy":
+		keepCopy = true
+	case "original":
+	default:
+		http.Error(w, `keep must be "copy" or "original"`, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.model.ResolveConflict(qs.Get("folder"), qs.Get("copy"), keepCopy); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+}
+
 func (s *service) getFolderVersions(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	versions, err := s.model.GetFolderVersions(qs.Get("folder"))
@@ -1473,6 +1776,16 @@ func (s *service) postFolderVersionsRestore(w http.ResponseWriter, r *http.Reque
 	sendJSON(w, ferr)
 }
 
+func (s *service) postFolderVersionsClean(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	res, err := s.model.CleanFolderVersions(qs.Get("folder"))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	sendJSON(w, res)
+}
+
 func (s *service) getFolderErrors(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	folder := qs.Get("folder")
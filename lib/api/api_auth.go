@@ -35,9 +35,21 @@ func emitLoginAttempt(success bool, username string, evLogger events.Logger) {
 	})
 }
 
+// apiKeyFromRequest returns the API key given in the X-API-Key header, or
+// as a bearer token for clients that cannot set custom headers.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if hdr := r.Header.Get("Authorization"); strings.HasPrefix(hdr, "Bearer ") {
+		return hdr[7:]
+	}
+	return ""
+}
+
 func basicAuthAndSessionMiddleware(cookieName string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, next http.Handler, evLogger events.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if guiCfg.IsValidAPIKey(r.Header.Get("X-API-Key")) {
+		if guiCfg.IsValidAPIKey(apiKeyFromRequest(r)) {
 			next.ServeHTTP(w, r)
 			return
 		}
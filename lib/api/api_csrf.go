@@ -58,7 +58,7 @@ func newCsrfManager(unique string, prefix string, apiKeyValidator apiKeyValidato
 
 func (m *csrfManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Allow requests carrying a valid API key
-	if m.apiKeyValidator.IsValidAPIKey(r.Header.Get("X-API-Key")) {
+	if m.apiKeyValidator.IsValidAPIKey(apiKeyFromRequest(r)) {
 		// Set the access-control-allow-origin header for CORS requests
 		// since a valid API key has been provided
 		w.Header().Add("Access-Control-Allow-Origin", "*")
@@ -73,9 +73,10 @@ func (m *csrfManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Allow requests for anything not under the protected path prefix,
-	// and set a CSRF cookie if there isn't already a valid one.
-	if !strings.HasPrefix(r.URL.Path, m.prefix) {
+	// Allow requests for anything not under the protected path prefix or
+	// the metrics endpoint, and set a CSRF cookie if there isn't already a
+	// valid one.
+	if !strings.HasPrefix(r.URL.Path, m.prefix) && r.URL.Path != "/metrics" {
 		cookie, err := r.Cookie("CSRF-Token-" + m.unique)
 		if err != nil || !m.validToken(cookie.Value) {
 			l.Debugln("new CSRF cookie in response to request for", r.URL)
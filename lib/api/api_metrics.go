@@ -0,0 +1,221 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/model"
+)
+
+var (
+	folderStateDesc = prometheus.NewDesc(
+		"syncthing_folder_state",
+		"Current state of the folder, set to one for the state the folder is in.",
+		[]string{"folder", "state"}, nil)
+	folderFilesDesc = prometheus.NewDesc(
+		"syncthing_folder_files",
+		"Number of items in the folder, for the local, global and needed (queued for pulling) scopes.",
+		[]string{"folder", "scope"}, nil)
+	folderBytesDesc = prometheus.NewDesc(
+		"syncthing_folder_bytes",
+		"Size of the folder in bytes, for the local, global and needed (queued for pulling) scopes.",
+		[]string{"folder", "scope"}, nil)
+	deviceConnectedDesc = prometheus.NewDesc(
+		"syncthing_device_connected",
+		"Whether the device is currently connected.",
+		[]string{"device"}, nil)
+	deviceInBytesDesc = prometheus.NewDesc(
+		"syncthing_device_in_bytes_total",
+		"Bytes received from the device over the current connections.",
+		[]string{"device"}, nil)
+	deviceOutBytesDesc = prometheus.NewDesc(
+		"syncthing_device_out_bytes_total",
+		"Bytes sent to the device over the current connections.",
+		[]string{"device"}, nil)
+	inBytesDesc = prometheus.NewDesc(
+		"syncthing_in_bytes_total",
+		"Bytes received from all devices.",
+		nil, nil)
+	outBytesDesc = prometheus.NewDesc(
+		"syncthing_out_bytes_total",
+		"Bytes sent to all devices.",
+		nil, nil)
+	databaseSizeDesc = prometheus.NewDesc(
+		"syncthing_database_size_bytes",
+		"Size of the database on disk.",
+		nil, nil)
+)
+
+// The metricsCollector exports the state of folders and connections in the
+// Prometheus format. Everything but the event based counters is gathered
+// from the model at collection time.
+type metricsCollector struct {
+	cfg          config.Wrapper
+	model        model.Model
+	databasePath string
+
+	eventsTotal  *prometheus.CounterVec
+	scanDuration *prometheus.HistogramVec
+
+	registry *prometheus.Registry
+}
+
+func newMetricsCollector(cfg config.Wrapper, m model.Model, databasePath string) *metricsCollector {
+	c := &metricsCollector{
+		cfg:          cfg,
+		model:        m,
+		databasePath: databasePath,
+		eventsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "syncthing",
+				Subsystem: "events",
+				Name:      "total",
+				Help:      "Number of events, by type.",
+			}, []string{"event"}),
+		scanDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "syncthing",
+				Subsystem: "folder",
+				Name:      "scan_duration_seconds",
+				Help:      "Duration of folder scans.",
+				Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+			}, []string{"folder"}),
+		registry: prometheus.NewRegistry(),
+	}
+	c.registry.MustRegister(c, c.eventsTotal, c.scanDuration,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	return c
+}
+
+func (c *metricsCollector) handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// serve updates the event based counters until the context is cancelled.
+func (c *metricsCollector) serve(ctx context.Context, evLogger events.Logger) {
+	sub := evLogger.Subscribe(events.AllEvents)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-sub.C():
+			c.handleEvent(ev)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *metricsCollector) handleEvent(ev events.Event) {
+	c.eventsTotal.WithLabelValues(ev.Type.String()).Inc()
+
+	if ev.Type != events.StateChanged {
+		return
+	}
+	data, ok := ev.Data.(map[string]interface{})
+	if !ok || data["from"] != model.FolderScanning.String() {
+		return
+	}
+	folder, _ := data["folder"].(string)
+	if duration, ok := data["duration"].(float64); ok {
+		c.scanDuration.WithLabelValues(folder).Observe(duration)
+	}
+}
+
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- folderStateDesc
+	ch <- folderFilesDesc
+	ch <- folderBytesDesc
+	ch <- deviceConnectedDesc
+	ch <- deviceInBytesDesc
+	ch <- deviceOutBytesDesc
+	ch <- inBytesDesc
+	ch <- outBytesDesc
+	ch <- databaseSizeDesc
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectFolders(ch)
+	c.collectConnections(ch)
+	if size, err := dirSize(c.databasePath); err == nil {
+		ch <- prometheus.MustNewConstMetric(databaseSizeDesc, prometheus.GaugeValue, float64(size))
+	}
+}
+
+func (c *metricsCollector) collectFolders(ch chan<- prometheus.Metric) {
+	for id, folder := range c.cfg.Folders() {
+		if folder.Paused {
+			continue
+		}
+
+		if state, _, err := c.model.State(id); err == nil && state != "" {
+			ch <- prometheus.MustNewConstMetric(folderStateDesc, prometheus.GaugeValue, 1, id, state)
+		}
+
+		snap, err := c.model.DBSnapshot(id)
+		if err != nil || snap == nil {
+			continue
+		}
+		for _, scope := range []struct {
+			name   string
+			counts db.Counts
+		}{
+			{"local", snap.LocalSize()},
+			{"global", snap.GlobalSize()},
+			{"need", snap.NeedSize()},
+		} {
+			ch <- prometheus.MustNewConstMetric(folderFilesDesc, prometheus.GaugeValue, float64(scope.counts.TotalItems()), id, scope.name)
+			ch <- prometheus.MustNewConstMetric(folderBytesDesc, prometheus.GaugeValue, float64(scope.counts.Bytes), id, scope.name)
+		}
+		snap.Release()
+	}
+}
+
+func (c *metricsCollector) collectConnections(ch chan<- prometheus.Metric) {
+	stats := c.model.ConnectionStats()
+
+	conns, _ := stats["connections"].(map[string]model.ConnectionInfo)
+	for device, ci := range conns {
+		connected := 0.0
+		if ci.Connected {
+			connected = 1
+			ch <- prometheus.MustNewConstMetric(deviceInBytesDesc, prometheus.CounterValue, float64(ci.InBytesTotal), device)
+			ch <- prometheus.MustNewConstMetric(deviceOutBytesDesc, prometheus.CounterValue, float64(ci.OutBytesTotal), device)
+		}
+		ch <- prometheus.MustNewConstMetric(deviceConnectedDesc, prometheus.GaugeValue, connected, device)
+	}
+
+	if total, ok := stats["total"].(model.ConnectionInfo); ok {
+		ch <- prometheus.MustNewConstMetric(inBytesDesc, prometheus.CounterValue, float64(total.InBytesTotal))
+		ch <- prometheus.MustNewConstMetric(outBytesDesc, prometheus.CounterValue, float64(total.OutBytesTotal))
+	}
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -341,6 +341,12 @@ func TestAPIServiceRequests(t *testing.T) {
 			Type:   "application/json",
 			Prefix: "null",
 		},
+		{
+			URL:    "/metrics",
+			Code:   200,
+			Type:   "text/plain",
+			Prefix: "# HELP",
+		},
 		{
 			URL:    "/rest/system/discovery",
 			Code:   200,
@@ -628,6 +634,30 @@ func TestCSRFRequired(t *testing.T) {
 	if resp.StatusCode != http.StatusOK {
 		t.Fatal("Getting /rest/system/config with API key should succeed, not", resp.Status)
 	}
+
+	// Calling on /metrics without a token should fail
+
+	resp, err = cli.Get(baseURL + "/metrics")
+	if err != nil {
+		t.Fatal("Unexpected error from getting /metrics:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatal("Getting /metrics without CSRF token should fail, not", resp.Status)
+	}
+
+	// Calling on /metrics with the API key as bearer token should succeed
+
+	req, _ = http.NewRequest("GET", baseURL+"/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	resp, err = cli.Do(req)
+	if err != nil {
+		t.Fatal("Unexpected error from getting /metrics:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Getting /metrics with API key should succeed, not", resp.Status)
+	}
 }
 
 func TestRandomString(t *testing.T) {
@@ -743,6 +773,95 @@ func testConfigPost(data io.Reader) (*http.Response, error) {
 	return cli.Do(req)
 }
 
+func TestConfigEndpoints(t *testing.T) {
+	t.Parallel()
+
+	cfgFile, err := ioutil.TempFile("", "syncthing-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfgFile.Close()
+	defer os.Remove(cfgFile.Name())
+
+	myID := protocol.LocalDeviceID
+	w := config.Wrap(cfgFile.Name(), config.New(myID), events.NoopLogger)
+	s := &service{id: myID, cfg: w, systemConfigMut: sync.NewMutex()}
+
+	device1, _ := protocol.DeviceIDFromString("AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.serveConfig(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{"GET", "/rest/config/folders/foo", "", http.StatusNotFound},
+		{"PUT", "/rest/config/folders/foo", `{"path": "TestConfigEndpoints"}`, http.StatusOK},
+		{"PUT", "/rest/config/folders/bar", `{}`, http.StatusBadRequest},
+		{"PUT", "/rest/config/folders/bar", `{"id": "baz", "path": "x"}`, http.StatusBadRequest},
+		{"PUT", "/rest/config/folders/bar", `{"path": `, http.StatusBadRequest},
+		{"PATCH", "/rest/config/folders/foo", `{"label": "Foo"}`, http.StatusOK},
+		{"PATCH", "/rest/config/folders/nonexistent", `{"label": "Foo"}`, http.StatusNotFound},
+		{"POST", "/rest/config/folders/foo", `{}`, http.StatusMethodNotAllowed},
+		{"PUT", "/rest/config/devices/invalid", `{}`, http.StatusBadRequest},
+		{"PUT", "/rest/config/devices/" + device1.String(), `{"name": "device1"}`, http.StatusOK},
+		{"PATCH", "/rest/config/devices/" + device1.String(), `{"introducer": true}`, http.StatusOK},
+		{"DELETE", "/rest/config/devices/" + myID.String(), "", http.StatusBadRequest},
+		{"PATCH", "/rest/config/options", `{"maxSendKbps": 42}`, http.StatusOK},
+		{"PATCH", "/rest/config/gui", `{"user": "admin", "password": "secret"}`, http.StatusOK},
+		{"GET", "/rest/config/nonexistent", "", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if rec := do(tc.method, tc.path, tc.body); rec.Code != tc.code {
+			t.Errorf("%s %s: got %d, expected %d: %s", tc.method, tc.path, rec.Code, tc.code, rec.Body.String())
+		}
+	}
+
+	fcfg, ok := w.Folder("foo")
+	if !ok {
+		t.Fatal("Folder foo should exist")
+	}
+	if fcfg.Label != "Foo" || fcfg.Path != "TestConfigEndpoints" {
+		t.Errorf("Unexpected folder config after PATCH: %+v", fcfg)
+	}
+	if !fcfg.SharedWith(myID) {
+		t.Error("Folder should be shared with the local device")
+	}
+	if dcfg, ok := w.Device(device1); !ok || dcfg.Name != "device1" || !dcfg.Introducer {
+		t.Errorf("Unexpected device config: %+v", dcfg)
+	}
+	if w.Options().MaxSendKbps != 42 {
+		t.Error("Options were not patched")
+	}
+	if gui := w.GUI(); gui.User != "admin" || !bcryptExpr.MatchString(gui.Password) {
+		t.Error("GUI password should have been hashed")
+	}
+
+	var res map[string]string
+	rec := do("GET", "/rest/config/folders/nonexistent", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || res["error"] == "" {
+		t.Error("Expected error as JSON, got", rec.Body.String())
+	}
+
+	if rec := do("DELETE", "/rest/config/folders/foo", ""); rec.Code != http.StatusOK {
+		t.Error("Deleting folder failed:", rec.Body.String())
+	}
+	if _, ok := w.Folder("foo"); ok {
+		t.Error("Folder foo should have been removed")
+	}
+	if rec := do("DELETE", "/rest/config/devices/"+device1.String(), ""); rec.Code != http.StatusOK {
+		t.Error("Deleting device failed:", rec.Body.String())
+	}
+	if _, ok := w.Device(device1); ok {
+		t.Error("Device should have been removed")
+	}
+}
+
 func TestHostCheck(t *testing.T) {
 	t.Parallel()
 
@@ -1199,3 +1318,40 @@ func runningInContainer() bool {
 	}
 	return false
 }
+
+func TestMetricsCollectorEvents(t *testing.T) {
+	c := newMetricsCollector(new(mockedConfig), new(mockedModel), "")
+
+	c.handleEvent(events.Event{Type: events.StateChanged, Data: map[string]interface{}{
+		"folder":   "default",
+		"from":     "scanning",
+		"to":       "idle",
+		"duration": 1.5,
+	}})
+	c.handleEvent(events.Event{Type: events.StateChanged, Data: map[string]interface{}{
+		"folder":   "default",
+		"from":     "idle",
+		"to":       "syncing",
+		"duration": 2.5,
+	}})
+
+	families, err := c.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scans, stateChanges uint64
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "syncthing_folder_scan_duration_seconds":
+			scans = mf.GetMetric()[0].GetHistogram().GetSampleCount()
+		case "syncthing_events_total":
+			stateChanges = uint64(mf.GetMetric()[0].GetCounter().GetValue())
+		}
+	}
+	if scans != 1 {
+		t.Errorf("Expected one scan, got %d", scans)
+	}
+	if stateChanges != 2 {
+		t.Errorf("Expected two state changes, got %d", stateChanges)
+	}
+}
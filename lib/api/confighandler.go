@@ -0,0 +1,270 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/util"
+)
+
+// serveConfig handles the /rest/config/ endpoints, giving access to
+// individual parts of the configuration:
+//
+//	/rest/config/folders              GET
+//	/rest/config/folders/{id}         GET, PUT, PATCH, DELETE
+//	/rest/config/devices              GET
+//	/rest/config/devices/{id}         GET, PUT, PATCH, DELETE
+//	/rest/config/options              GET, PUT, PATCH
+//	/rest/config/gui                  GET, PUT, PATCH
+//
+// PUT replaces (or creates) the object with the given one, unset fields
+// taking their default values. PATCH only changes the fields present in the
+// request body.
+func (s *service) serveConfig(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/rest/config/"), "/", 2)
+	var id string
+	if len(parts) == 2 {
+		id = parts[1]
+	}
+
+	// Modifications are serialized, so that changes based on the current
+	// config don't get lost.
+	if r.Method != http.MethodGet {
+		s.systemConfigMut.Lock()
+		defer s.systemConfigMut.Unlock()
+	}
+
+	switch {
+	case parts[0] == "folders" && id == "":
+		s.configFolders(w, r)
+	case parts[0] == "folders":
+		s.configFolder(w, r, id)
+	case parts[0] == "devices" && id == "":
+		s.configDevices(w, r)
+	case parts[0] == "devices":
+		s.configDevice(w, r, id)
+	case parts[0] == "options" && len(parts) == 1:
+		s.configOptions(w, r)
+	case parts[0] == "gui" && len(parts) == 1:
+		s.configGUI(w, r)
+	default:
+		sendJSONError(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (s *service) configFolders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSON(w, s.cfg.FolderList())
+}
+
+func (s *service) configFolder(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		fcfg, ok := s.cfg.Folder(id)
+		if !ok {
+			sendJSONError(w, "No such folder", http.StatusNotFound)
+			return
+		}
+		sendJSON(w, fcfg)
+
+	case http.MethodPut:
+		var fcfg config.FolderConfiguration
+		util.SetDefaults(&fcfg)
+		fcfg.ID = id
+		s.adjustFolder(w, r, fcfg)
+
+	case http.MethodPatch:
+		fcfg, ok := s.cfg.Folder(id)
+		if !ok {
+			sendJSONError(w, "No such folder", http.StatusNotFound)
+			return
+		}
+		s.adjustFolder(w, r, fcfg)
+
+	case http.MethodDelete:
+		if _, ok := s.cfg.Folder(id); !ok {
+			sendJSONError(w, "No such folder", http.StatusNotFound)
+			return
+		}
+		waiter, err := s.cfg.RemoveFolder(id)
+		s.finishConfigChange(w, waiter, err)
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *service) adjustFolder(w http.ResponseWriter, r *http.Request, fcfg config.FolderConfiguration) {
+	id := fcfg.ID
+	if err := unmarshalTo(r.Body, &fcfg); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fcfg.ID != id {
+		sendJSONError(w, "Folder ID in request body does not match URL", http.StatusBadRequest)
+		return
+	}
+	if !fcfg.SharedWith(s.id) {
+		fcfg.Devices = append(fcfg.Devices, config.FolderDeviceConfiguration{DeviceID: s.id})
+	}
+
+	waiter, err := s.cfg.SetFolder(fcfg)
+	s.finishConfigChange(w, waiter, err)
+}
+
+func (s *service) configDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSON(w, s.cfg.RawCopy().Devices)
+}
+
+func (s *service) configDevice(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := protocol.DeviceIDFromString(idStr)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		dcfg, ok := s.cfg.Device(id)
+		if !ok {
+			sendJSONError(w, "No such device", http.StatusNotFound)
+			return
+		}
+		sendJSON(w, dcfg)
+
+	case http.MethodPut:
+		s.adjustDevice(w, r, config.NewDeviceConfiguration(id, ""))
+
+	case http.MethodPatch:
+		dcfg, ok := s.cfg.Device(id)
+		if !ok {
+			sendJSONError(w, "No such device", http.StatusNotFound)
+			return
+		}
+		s.adjustDevice(w, r, dcfg)
+
+	case http.MethodDelete:
+		if id == s.id {
+			sendJSONError(w, "Cannot remove the local device", http.StatusBadRequest)
+			return
+		}
+		if _, ok := s.cfg.Device(id); !ok {
+			sendJSONError(w, "No such device", http.StatusNotFound)
+			return
+		}
+		waiter, err := s.cfg.RemoveDevice(id)
+		s.finishConfigChange(w, waiter, err)
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *service) adjustDevice(w http.ResponseWriter, r *http.Request, dcfg config.DeviceConfiguration) {
+	id := dcfg.DeviceID
+	if err := unmarshalTo(r.Body, &dcfg); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dcfg.DeviceID != id {
+		sendJSONError(w, "Device ID in request body does not match URL", http.StatusBadRequest)
+		return
+	}
+
+	waiter, err := s.cfg.SetDevice(dcfg)
+	s.finishConfigChange(w, waiter, err)
+}
+
+func (s *service) configOptions(w http.ResponseWriter, r *http.Request) {
+	var opts config.OptionsConfiguration
+	switch r.Method {
+	case http.MethodGet:
+		sendJSON(w, s.cfg.Options())
+		return
+	case http.MethodPut:
+		util.SetDefaults(&opts)
+	case http.MethodPatch:
+		opts = s.cfg.Options()
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := unmarshalTo(r.Body, &opts); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	waiter, err := s.cfg.SetOptions(opts)
+	s.finishConfigChange(w, waiter, err)
+}
+
+func (s *service) configGUI(w http.ResponseWriter, r *http.Request) {
+	var gui config.GUIConfiguration
+	switch r.Method {
+	case http.MethodGet:
+		sendJSON(w, s.cfg.GUI())
+		return
+	case http.MethodPut:
+		util.SetDefaults(&gui)
+	case http.MethodPatch:
+		gui = s.cfg.GUI()
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := unmarshalTo(r.Body, &gui); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.hashGUIPassword(&gui); err != nil {
+		l.Warnln("bcrypting password:", err)
+		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	waiter, err := s.cfg.SetGUI(gui)
+	s.finishConfigChange(w, waiter, err)
+}
+
+// finishConfigChange waits for an accepted config change to become active
+// and saves it, or reports why the change was rejected.
+func (s *service) finishConfigChange(w http.ResponseWriter, waiter config.Waiter, err error) {
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	waiter.Wait()
+	if err := s.cfg.Save(); err != nil {
+		l.Warnln("Saving config:", err)
+		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func unmarshalTo(body io.ReadCloser, to interface{}) error {
+	bs, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, to)
+}
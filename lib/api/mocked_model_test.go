@@ -75,14 +75,26 @@ func (m *mockedModel) SetIgnores(folder string, content []string) error {
 	return nil
 }
 
+func (m *mockedModel) SetSharedIgnores(folder string, lines []string) error {
+	return nil
+}
+
 func (m *mockedModel) GetFolderVersions(folder string) (map[string][]versioner.FileVersion, error) {
 	return nil, nil
 }
 
+func (m *mockedModel) History(folder, file string) ([]model.FileHistoryEntry, error) {
+	return nil, nil
+}
+
 func (m *mockedModel) RestoreFolderVersions(folder string, versions map[string]time.Time) (map[string]string, error) {
 	return nil, nil
 }
 
+func (m *mockedModel) CleanFolderVersions(folder string) (versioner.CleanResult, error) {
+	return versioner.CleanResult{}, nil
+}
+
 func (m *mockedModel) PauseDevice(device protocol.DeviceID) {
 }
 
@@ -104,6 +116,18 @@ func (m *mockedModel) ScanFolderSubdirs(folder string, subs []string) error {
 
 func (m *mockedModel) BringToFront(folder, file string) {}
 
+func (m *mockedModel) Hydrate(folder, file string) error {
+	return nil
+}
+
+func (m *mockedModel) Conflicts(folder string) ([]model.Conflict, error) {
+	return nil, nil
+}
+
+func (m *mockedModel) ResolveConflict(folder, copyName string, keepCopy bool) error {
+	return nil
+}
+
 func (m *mockedModel) Connection(deviceID protocol.DeviceID) (connections.Connection, bool) {
 	return nil, false
 }
@@ -127,6 +151,22 @@ func (m *mockedModel) WatchError(folder string) error {
 func (m *mockedModel) Serve() {}
 func (m *mockedModel) Stop()  {}
 
+func (m *mockedModel) PendingDevices() (map[protocol.DeviceID]db.ObservedDevice, error) {
+	return nil, nil
+}
+
+func (m *mockedModel) PendingFolders(device protocol.DeviceID) (map[string]db.PendingFolder, error) {
+	return nil, nil
+}
+
+func (m *mockedModel) DismissPendingDevice(device protocol.DeviceID) error {
+	return nil
+}
+
+func (m *mockedModel) DismissPendingFolder(device protocol.DeviceID, folder string) error {
+	return nil
+}
+
 func (m *mockedModel) Index(deviceID protocol.DeviceID, folder string, files []protocol.FileInfo) error {
 	return nil
 }
@@ -135,7 +175,7 @@ func (m *mockedModel) IndexUpdate(deviceID protocol.DeviceID, folder string, fil
 	return nil
 }
 
-func (m *mockedModel) Request(deviceID protocol.DeviceID, folder, name string, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (protocol.RequestResponse, error) {
+func (m *mockedModel) Request(deviceID protocol.DeviceID, folder, name string, blockNo, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (protocol.RequestResponse, error) {
 	return nil, nil
 }
 
@@ -11,6 +11,7 @@ import (
 	"io"
 
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/discover"
 )
 
 // getRedactedConfig redacting some parts of config
@@ -23,6 +24,9 @@ func getRedactedConfig(s *service) config.Configuration {
 	if rawConf.GUI.User != "" {
 		rawConf.GUI.User = "REDACTED"
 	}
+	for i, srv := range rawConf.Options.RawGlobalAnnServers {
+		rawConf.Options.RawGlobalAnnServers[i] = discover.RedactToken(srv)
+	}
 	return rawConf
 }
 
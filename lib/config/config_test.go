@@ -43,6 +43,7 @@ func TestDefaultValues(t *testing.T) {
 		LocalAnnEnabled:         true,
 		LocalAnnPort:            21027,
 		LocalAnnMCAddr:          "[ff12::8384]:21027",
+		LocalAnnAcceptUnsigned:  true,
 		MaxSendKbps:             0,
 		MaxRecvKbps:             0,
 		ReconnectIntervalS:      60,
@@ -126,6 +127,10 @@ func TestDeviceConfig(t *testing.T) {
 				},
 				WeakHashThresholdPct: 25,
 				MarkerName:           DefaultMarkerName,
+				XattrFilter: XattrFilter{
+					MaxSingleEntrySize: DefaultXattrMaxSingleEntrySize,
+					MaxTotalSize:       DefaultXattrMaxTotalSize,
+				},
 			},
 		}
 
@@ -144,7 +149,6 @@ func TestDeviceConfig(t *testing.T) {
 				Compression:     protocol.CompressMetadata,
 				AllowedNetworks: []string{},
 				IgnoredFolders:  []ObservedFolder{},
-				PendingFolders:  []ObservedFolder{},
 			},
 			{
 				DeviceID:        device4,
@@ -153,7 +157,6 @@ func TestDeviceConfig(t *testing.T) {
 				Compression:     protocol.CompressMetadata,
 				AllowedNetworks: []string{},
 				IgnoredFolders:  []ObservedFolder{},
-				PendingFolders:  []ObservedFolder{},
 			},
 		}
 		expectedDeviceIDs := []protocol.DeviceID{device1, device4}
@@ -194,6 +197,7 @@ func TestOverriddenValues(t *testing.T) {
 		LocalAnnEnabled:         false,
 		LocalAnnPort:            42123,
 		LocalAnnMCAddr:          "quux:3232",
+		LocalAnnAcceptUnsigned:  false,
 		MaxSendKbps:             1234,
 		MaxRecvKbps:             2341,
 		ReconnectIntervalS:      6000,
@@ -228,6 +232,13 @@ func TestOverriddenValues(t *testing.T) {
 		StunKeepaliveStartS:     9000,
 		StunKeepaliveMinS:       900,
 		RawStunServers:          []string{"foo"},
+		Webhooks: []WebhookConfiguration{
+			{
+				URL:     "https://localhost/hook",
+				Events:  []string{"FolderErrors", "DeviceDisconnected"},
+				Folders: []string{"default"},
+			},
+		},
 	}
 
 	os.Unsetenv("STNOUPGRADE")
@@ -249,21 +260,18 @@ func TestDeviceAddressesDynamic(t *testing.T) {
 			Addresses:       []string{"dynamic"},
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device2: {
 			DeviceID:        device2,
 			Addresses:       []string{"dynamic"},
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device3: {
 			DeviceID:        device3,
 			Addresses:       []string{"dynamic"},
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device4: {
 			DeviceID:        device4,
@@ -272,7 +280,6 @@ func TestDeviceAddressesDynamic(t *testing.T) {
 			Compression:     protocol.CompressMetadata,
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 	}
 
@@ -296,7 +303,6 @@ func TestDeviceCompression(t *testing.T) {
 			Compression:     protocol.CompressMetadata,
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device2: {
 			DeviceID:        device2,
@@ -304,7 +310,6 @@ func TestDeviceCompression(t *testing.T) {
 			Compression:     protocol.CompressMetadata,
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device3: {
 			DeviceID:        device3,
@@ -312,7 +317,6 @@ func TestDeviceCompression(t *testing.T) {
 			Compression:     protocol.CompressNever,
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device4: {
 			DeviceID:        device4,
@@ -321,7 +325,6 @@ func TestDeviceCompression(t *testing.T) {
 			Compression:     protocol.CompressMetadata,
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 	}
 
@@ -344,21 +347,18 @@ func TestDeviceAddressesStatic(t *testing.T) {
 			Addresses:       []string{"tcp://192.0.2.1", "tcp://192.0.2.2"},
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device2: {
 			DeviceID:        device2,
 			Addresses:       []string{"tcp://192.0.2.3:6070", "tcp://[2001:db8::42]:4242"},
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device3: {
 			DeviceID:        device3,
 			Addresses:       []string{"tcp://[2001:db8::44]:4444", "tcp://192.0.2.4:6090"},
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 		device4: {
 			DeviceID:        device4,
@@ -367,7 +367,6 @@ func TestDeviceAddressesStatic(t *testing.T) {
 			Compression:     protocol.CompressMetadata,
 			AllowedNetworks: []string{},
 			IgnoredFolders:  []ObservedFolder{},
-			PendingFolders:  []ObservedFolder{},
 		},
 	}
 
@@ -460,6 +459,33 @@ func TestFolderPath(t *testing.T) {
 	}
 }
 
+func TestFolderIsSelected(t *testing.T) {
+	folder := FolderConfiguration{}
+	if !folder.IsSelected("anything") {
+		t.Error("everything should be selected without selective sync")
+	}
+
+	folder.SelectiveSync = []string{"/projects/current/", "docs"}
+	cases := []struct {
+		name     string
+		selected bool
+	}{
+		{"projects", true},
+		{filepath.Join("projects", "current"), true},
+		{filepath.Join("projects", "current", "file"), true},
+		{filepath.Join("projects", "old"), false},
+		{filepath.Join("projects", "currentish"), false},
+		{"docs", true},
+		{filepath.Join("docs", "a", "b"), true},
+		{"other", false},
+	}
+	for _, tc := range cases {
+		if res := folder.IsSelected(tc.name); res != tc.selected {
+			t.Errorf("IsSelected(%q) = %v, expected %v", tc.name, res, tc.selected)
+		}
+	}
+}
+
 func TestFolderCheckPath(t *testing.T) {
 	n, err := ioutil.TempDir("", "")
 	if err != nil {
@@ -1099,10 +1125,6 @@ func TestDeviceConfigObservedNotNil(t *testing.T) {
 		if dev.IgnoredFolders == nil {
 			t.Errorf("Ignored folders nil")
 		}
-
-		if dev.PendingFolders == nil {
-			t.Errorf("Pending folders nil")
-		}
 	}
 }
 
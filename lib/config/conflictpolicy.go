@@ -0,0 +1,52 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+// ConflictPolicy decides which side of a conflict is kept when pulling.
+type ConflictPolicy int
+
+const (
+	ConflictKeepBoth ConflictPolicy = iota // default is to keep a conflict copy
+	ConflictKeepNewest
+	ConflictKeepLargest
+	ConflictPreferMaster
+)
+
+func (p ConflictPolicy) String() string {
+	switch p {
+	case ConflictKeepBoth:
+		return "keepBoth"
+	case ConflictKeepNewest:
+		return "keepNewest"
+	case ConflictKeepLargest:
+		return "keepLargest"
+	case ConflictPreferMaster:
+		return "preferMaster"
+	default:
+		return "unknown"
+	}
+}
+
+func (p ConflictPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *ConflictPolicy) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "keepBoth":
+		*p = ConflictKeepBoth
+	case "keepNewest":
+		*p = ConflictKeepNewest
+	case "keepLargest":
+		*p = ConflictKeepLargest
+	case "preferMaster":
+		*p = ConflictPreferMaster
+	default:
+		*p = ConflictKeepBoth
+	}
+	return nil
+}
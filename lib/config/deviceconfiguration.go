@@ -28,8 +28,9 @@ type DeviceConfiguration struct {
 	MaxSendKbps              int                  `xml:"maxSendKbps" json:"maxSendKbps"`
 	MaxRecvKbps              int                  `xml:"maxRecvKbps" json:"maxRecvKbps"`
 	IgnoredFolders           []ObservedFolder     `xml:"ignoredFolder" json:"ignoredFolders"`
-	PendingFolders           []ObservedFolder     `xml:"pendingFolder" json:"pendingFolders"`
 	MaxRequestKiB            int                  `xml:"maxRequestKiB" json:"maxRequestKiB"`
+	NumConnections           int                  `xml:"numConnections" json:"numConnections"` // Values below one mean one.
+	DeprecatedPendingFolders []ObservedFolder     `xml:"pendingFolder,omitempty" json:"-"`
 }
 
 func NewDeviceConfiguration(id protocol.DeviceID, name string) DeviceConfiguration {
@@ -52,8 +53,10 @@ func (cfg DeviceConfiguration) Copy() DeviceConfiguration {
 	copy(c.AllowedNetworks, cfg.AllowedNetworks)
 	c.IgnoredFolders = make([]ObservedFolder, len(cfg.IgnoredFolders))
 	copy(c.IgnoredFolders, cfg.IgnoredFolders)
-	c.PendingFolders = make([]ObservedFolder, len(cfg.PendingFolders))
-	copy(c.PendingFolders, cfg.PendingFolders)
+	if cfg.DeprecatedPendingFolders != nil {
+		c.DeprecatedPendingFolders = make([]ObservedFolder, len(cfg.DeprecatedPendingFolders))
+		copy(c.DeprecatedPendingFolders, cfg.DeprecatedPendingFolders)
+	}
 	return c
 }
 
@@ -66,19 +69,12 @@ func (cfg *DeviceConfiguration) prepare(sharedFolders []string) {
 	}
 
 	ignoredFolders := deduplicateObservedFoldersToMap(cfg.IgnoredFolders)
-	pendingFolders := deduplicateObservedFoldersToMap(cfg.PendingFolders)
-
-	for id := range ignoredFolders {
-		delete(pendingFolders, id)
-	}
 
 	for _, sharedFolder := range sharedFolders {
 		delete(ignoredFolders, sharedFolder)
-		delete(pendingFolders, sharedFolder)
 	}
 
 	cfg.IgnoredFolders = sortedObservedFolderSlice(ignoredFolders)
-	cfg.PendingFolders = sortedObservedFolderSlice(pendingFolders)
 }
 
 func (cfg *DeviceConfiguration) IgnoredFolder(folder string) bool {
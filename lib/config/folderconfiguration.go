@@ -9,6 +9,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -26,7 +27,11 @@ var (
 	ErrMarkerMissing    = errors.New("folder marker missing (this indicates potential data loss, search docs/forum to get information about how to proceed)")
 )
 
-const DefaultMarkerName = ".stfolder"
+const (
+	DefaultMarkerName              = ".stfolder"
+	DefaultXattrMaxSingleEntrySize = 1024
+	DefaultXattrMaxTotalSize       = 4096
+)
 
 type FolderConfiguration struct {
 	ID                      string                      `xml:"id,attr" json:"id"`
@@ -57,6 +62,21 @@ type FolderConfiguration struct {
 	MarkerName              string                      `xml:"markerName" json:"markerName"`
 	CopyOwnershipFromParent bool                        `xml:"copyOwnershipFromParent" json:"copyOwnershipFromParent"`
 	RawModTimeWindowS       int                         `xml:"modTimeWindowS" json:"modTimeWindowS"`
+	SyncXattrs              bool                        `xml:"syncXattrs" json:"syncXattrs"`
+	XattrFilter             XattrFilter                 `xml:"xattrFilter" json:"xattrFilter"`
+	SyncOwnership           bool                        `xml:"syncOwnership" json:"syncOwnership"`
+	CaseSensitiveFS         bool                        `xml:"caseSensitiveFS" json:"caseSensitiveFS"`           // Disables the protection against case conflicts.
+	SelectiveSync           []string                    `xml:"selectiveSync" json:"selectiveSync"`               // Paths to pull; everything when empty.
+	OnDemand                bool                        `xml:"onDemand" json:"onDemand"`                         // Pull placeholders, fetching the contents when opened.
+	OnDemandCacheSize       Size                        `xml:"onDemandCacheSize" json:"onDemandCacheSize"`       // Fetched contents to keep before evicting back to placeholders; unlimited when zero.
+	SharedIgnores           SharedIgnores               `xml:"sharedIgnores" json:"sharedIgnores"`               // Ignore patterns distributed to all devices sharing the folder.
+	KeepHistory             bool                        `xml:"keepHistory" json:"keepHistory"`                   // Record a journal of the changes to the global versions of files.
+	HistoryMaxAgeDays       int                         `xml:"historyMaxAgeDays" json:"historyMaxAgeDays"`       // Days to keep history entries; forever when zero.
+	VersionLocalChanges     bool                        `xml:"versionLocalChanges" json:"versionLocalChanges"`   // Archive the previous contents of files changed locally.
+	ConflictPolicy          ConflictPolicy              `xml:"conflictPolicy" json:"conflictPolicy"`             // Which side of a conflict to keep; the loser is versioned. Without versioning both are kept.
+	ConflictMasterDevice    protocol.DeviceID           `xml:"conflictMasterDevice" json:"conflictMasterDevice"` // The device whose changes win with the preferMaster policy.
+	MergeConflicts          bool                        `xml:"mergeConflicts" json:"mergeConflicts"`             // Try a three-way merge of conflicting changes to small text files before keeping a conflict copy. Needs keepHistory and versioning other than external to find ancestors, and versionLocalChanges for ancestors of local edits.
+	MergeCommand            string                      `xml:"mergeCommand" json:"mergeCommand"`                 // External command merging the changes in place of the built-in line-based merge.
 
 	cachedFilesystem    fs.Filesystem
 	cachedModTimeWindow time.Duration
@@ -67,8 +87,32 @@ type FolderConfiguration struct {
 }
 
 type FolderDeviceConfiguration struct {
-	DeviceID     protocol.DeviceID `xml:"id,attr" json:"deviceID"`
-	IntroducedBy protocol.DeviceID `xml:"introducedBy,attr" json:"introducedBy"`
+	DeviceID           protocol.DeviceID `xml:"id,attr" json:"deviceID"`
+	IntroducedBy       protocol.DeviceID `xml:"introducedBy,attr" json:"introducedBy"`
+	EncryptionPassword string            `xml:"encryptionPassword" json:"encryptionPassword"` // Set for untrusted devices; data sent to them is encrypted.
+}
+
+// XattrFilter limits which extended attributes are synced. Sizes are the
+// sum of the attribute name and value lengths in bytes.
+type XattrFilter struct {
+	MaxSingleEntrySize int `xml:"maxSingleEntrySize" json:"maxSingleEntrySize"`
+	MaxTotalSize       int `xml:"maxTotalSize" json:"maxTotalSize"`
+}
+
+func (f XattrFilter) GetMaxSingleEntrySize() int {
+	return f.MaxSingleEntrySize
+}
+
+func (f XattrFilter) GetMaxTotalSize() int {
+	return f.MaxTotalSize
+}
+
+// SharedIgnores are ignore patterns carried in the cluster config, applied
+// after the patterns in .stignore. The version is updated on every local
+// edit; the latest version seen from any device is adopted.
+type SharedIgnores struct {
+	Lines   []string        `xml:"line" json:"lines"`
+	Version protocol.Vector `xml:"version" json:"version"`
 }
 
 func NewFolderConfiguration(myID protocol.DeviceID, id, label string, fsType fs.FilesystemType, path string) FolderConfiguration {
@@ -91,6 +135,11 @@ func (f FolderConfiguration) Copy() FolderConfiguration {
 	c.Devices = make([]FolderDeviceConfiguration, len(f.Devices))
 	copy(c.Devices, f.Devices)
 	c.Versioning = f.Versioning.Copy()
+	c.SelectiveSync = make([]string, len(f.SelectiveSync))
+	copy(c.SelectiveSync, f.SelectiveSync)
+	c.SharedIgnores.Lines = make([]string, len(f.SharedIgnores.Lines))
+	copy(c.SharedIgnores.Lines, f.SharedIgnores.Lines)
+	c.SharedIgnores.Version = f.SharedIgnores.Version.Copy()
 	return c
 }
 
@@ -99,11 +148,19 @@ func (f FolderConfiguration) Filesystem() fs.Filesystem {
 	// cfg.Folders["default"].Filesystem() should be valid.
 	if f.cachedFilesystem == nil {
 		l.Infoln("bug: uncached filesystem call (should only happen in tests)")
-		return fs.NewFilesystem(f.FilesystemType, f.Path)
+		return f.newFilesystem()
 	}
 	return f.cachedFilesystem
 }
 
+func (f FolderConfiguration) newFilesystem() fs.Filesystem {
+	filesystem := fs.NewFilesystem(f.FilesystemType, f.Path)
+	if !f.CaseSensitiveFS {
+		filesystem = fs.NewCaseFilesystem(filesystem)
+	}
+	return filesystem
+}
+
 func (f FolderConfiguration) ModTimeWindow() time.Duration {
 	return f.cachedModTimeWindow
 }
@@ -205,8 +262,37 @@ func (f *FolderConfiguration) DeviceIDs() []protocol.DeviceID {
 	return deviceIDs
 }
 
+// IsSelected returns whether the item, given as a native path relative to
+// the folder root, is pulled under selective sync. That is everything when
+// no paths are selected, and otherwise the selected paths, everything below
+// them and the directories leading up to them.
+func (f FolderConfiguration) IsSelected(name string) bool {
+	if len(f.SelectiveSync) == 0 {
+		return true
+	}
+	name = filepath.Clean(name)
+	for _, sel := range f.SelectiveSync {
+		sel = filepath.Clean(filepath.FromSlash(strings.Trim(sel, "/")))
+		if sel == "." || name == sel || fs.IsParent(name, sel) || fs.IsParent(sel, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Device returns the folder device configuration for the given device, and
+// whether the folder is shared with it.
+func (f *FolderConfiguration) Device(device protocol.DeviceID) (FolderDeviceConfiguration, bool) {
+	for _, dev := range f.Devices {
+		if dev.DeviceID == device {
+			return dev, true
+		}
+	}
+	return FolderDeviceConfiguration{}, false
+}
+
 func (f *FolderConfiguration) prepare() {
-	f.cachedFilesystem = fs.NewFilesystem(f.FilesystemType, f.Path)
+	f.cachedFilesystem = f.newFilesystem()
 
 	if f.RescanIntervalS > MaxRescanIntervalS {
 		f.RescanIntervalS = MaxRescanIntervalS
@@ -231,6 +317,21 @@ func (f *FolderConfiguration) prepare() {
 		f.MarkerName = DefaultMarkerName
 	}
 
+	if f.XattrFilter.MaxSingleEntrySize <= 0 {
+		f.XattrFilter.MaxSingleEntrySize = DefaultXattrMaxSingleEntrySize
+	}
+	if f.XattrFilter.MaxTotalSize <= 0 {
+		f.XattrFilter.MaxTotalSize = DefaultXattrMaxTotalSize
+	}
+
+	if f.Type == FolderTypeReceiveEncrypted {
+		// We only ever store encrypted data in such a folder, and can't
+		// encrypt it any further for other devices.
+		for i := range f.Devices {
+			f.Devices[i].EncryptionPassword = ""
+		}
+	}
+
 	switch {
 	case f.RawModTimeWindowS > 0:
 		f.cachedModTimeWindow = time.Duration(f.RawModTimeWindowS) * time.Second
@@ -0,0 +1,24 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+// A WebhookConfiguration describes an HTTP endpoint that events are posted
+// to, in batches.
+type WebhookConfiguration struct {
+	URL     string   `xml:"url,attr" json:"url"`
+	Events  []string `xml:"event" json:"events"`   // Event types to send; all events when empty.
+	Folders []string `xml:"folder" json:"folders"` // Folders to send folder events for; all folders when empty.
+}
+
+func (c WebhookConfiguration) Copy() WebhookConfiguration {
+	cCopy := c
+	cCopy.Events = make([]string, len(c.Events))
+	copy(cCopy.Events, c.Events)
+	cCopy.Folders = make([]string, len(c.Folders))
+	copy(cCopy.Folders, c.Folders)
+	return cCopy
+}
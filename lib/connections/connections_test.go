@@ -205,3 +205,24 @@ func TestConnectionStatus(t *testing.T) {
 
 	check(nil, nil)
 }
+
+func TestWantedConnections(t *testing.T) {
+	cases := []struct {
+		local, remote, wanted int
+	}{
+		{0, 0, 1},
+		{1, 0, 1},
+		{0, 4, 1},
+		{4, 0, 1},
+		{4, 2, 2},
+		{2, 4, 2},
+		{3, 3, 3},
+		{-1, 3, 1},
+	}
+	for _, tc := range cases {
+		cfg := config.DeviceConfiguration{NumConnections: tc.local}
+		if res := wantedConnections(cfg, tc.remote); res != tc.wanted {
+			t.Errorf("wantedConnections(%d, %d) = %d, expected %d", tc.local, tc.remote, res, tc.wanted)
+		}
+	}
+}
@@ -129,6 +129,10 @@ type service struct {
 	listeners          map[string]genericListener
 	listenerTokens     map[string]suture.ServiceToken
 	listenerSupervisor *suture.Supervisor
+
+	// The number of connections each device asked for in its last Hello.
+	remoteNumConnsMut sync.Mutex
+	remoteNumConns    map[protocol.DeviceID]int
 }
 
 func NewService(cfg config.Wrapper, myID protocol.DeviceID, mdl Model, tlsCfg *tls.Config, discoverer discover.Finder, bepProtocolName string, tlsDefaultCommonName string, evLogger events.Logger) Service {
@@ -157,6 +161,9 @@ func NewService(cfg config.Wrapper, myID protocol.DeviceID, mdl Model, tlsCfg *t
 		listeners:      make(map[string]genericListener),
 		listenerTokens: make(map[string]suture.ServiceToken),
 
+		remoteNumConnsMut: sync.NewMutex(),
+		remoteNumConns:    make(map[protocol.DeviceID]int),
+
 		// A listener can fail twice, rapidly. Any more than that and it
 		// will be put on suspension for ten minutes. Restarts and changes
 		// due to config are done by removing and adding services, so are
@@ -263,6 +270,10 @@ func (s *service) handle(ctx context.Context) {
 		}
 		_ = c.SetDeadline(time.Time{})
 
+		s.remoteNumConnsMut.Lock()
+		s.remoteNumConns[remoteID] = hello.NumConnections
+		s.remoteNumConnsMut.Unlock()
+
 		// The Model will return an error for devices that we don't want to
 		// have a connection with for whatever reason, for example unknown devices.
 		if err := s.model.OnHello(remoteID, c.RemoteAddr(), hello); err != nil {
@@ -271,13 +282,26 @@ func (s *service) handle(ctx context.Context) {
 			continue
 		}
 
+		deviceCfg, ok := s.cfg.Device(remoteID)
+		if !ok {
+			l.Infof("Device %s removed from config during connection attempt at %s", remoteID, c)
+			c.Close()
+			continue
+		}
+
 		// If we have a relay connection, and the new incoming connection is
 		// not a relay connection, we should drop that, and prefer this one.
 		ct, connected := s.model.Connection(remoteID)
 
+		// A connection of the same kind as the existing one is added to it
+		// if both sides want more connections.
+		secondary := false
+
 		// Lower priority is better, just like nice etc.
 		if connected && ct.Priority() > c.priority {
 			l.Debugf("Switching connections %s (existing: %s new: %s)", remoteID, ct, c)
+		} else if connected && ct.Priority() == c.priority && len(ct.Connections()) < wantedConnections(deviceCfg, hello.NumConnections) {
+			secondary = true
 		} else if connected {
 			// We should not already be connected to the other party. TODO: This
 			// could use some better handling. If the old connection is dead but
@@ -290,13 +314,6 @@ func (s *service) handle(ctx context.Context) {
 			continue
 		}
 
-		deviceCfg, ok := s.cfg.Device(remoteID)
-		if !ok {
-			l.Infof("Device %s removed from config during connection attempt at %s", remoteID, c)
-			c.Close()
-			continue
-		}
-
 		// Verify the name on the certificate. By default we set it to
 		// "syncthing" when generating, but the user may have replaced
 		// the certificate and used another name.
@@ -319,7 +336,13 @@ func (s *service) handle(ctx context.Context) {
 		isLAN := s.isLAN(c.RemoteAddr())
 		rd, wr := s.limiter.getLimiters(remoteID, c, isLAN)
 
-		protoConn := protocol.NewConnection(remoteID, rd, wr, s.model, c.String(), deviceCfg.Compression)
+		if secondary {
+			l.Infof("Established secondary connection to %s at %s", remoteID, c)
+			ct.AddSecondary(rd, wr, internalConnCloser{c}, c.String())
+			continue
+		}
+
+		protoConn := protocol.NewConnectionGroup(remoteID, rd, wr, s.model, c.String(), deviceCfg.Compression)
 		modelConn := completeConn{c, protoConn}
 
 		l.Infof("Established secure connection to %s at %s", remoteID, c)
@@ -375,7 +398,11 @@ func (s *service) connect(ctx context.Context) {
 
 			ct, connected := s.model.Connection(deviceID)
 
-			if connected && ct.Priority() == bestDialerPrio {
+			// Additional connections of the same kind are dialed when
+			// wanted, see shouldDialSecondary.
+			dialSecondary := connected && s.shouldDialSecondary(ct, deviceCfg)
+
+			if connected && ct.Priority() == bestDialerPrio && !dialSecondary {
 				// Things are already as good as they can get.
 				continue
 			}
@@ -447,7 +474,7 @@ func (s *service) connect(ctx context.Context) {
 
 				priority := dialerFactory.Priority()
 
-				if connected && priority >= ct.Priority() {
+				if connected && priority >= ct.Priority() && !(dialSecondary && priority == ct.Priority()) {
 					l.Debugf("Not dialing using %s as priority is less than current connection (%d >= %d)", dialerFactory, dialerFactory.Priority(), ct.Priority())
 					continue
 				}
@@ -473,8 +500,15 @@ func (s *service) connect(ctx context.Context) {
 				})
 			}
 
-			conn, ok := s.dialParallel(ctx, deviceCfg.DeviceID, dialTargets)
-			if ok {
+			numDials := 1
+			if dialSecondary {
+				numDials = s.wantedConnections(deviceCfg) - len(ct.Connections())
+			}
+			for i := 0; i < numDials; i++ {
+				conn, ok := s.dialParallel(ctx, deviceCfg.DeviceID, dialTargets)
+				if !ok {
+					break
+				}
 				s.conns <- conn
 			}
 		}
@@ -497,6 +531,41 @@ func (s *service) connect(ctx context.Context) {
 	}
 }
 
+// shouldDialSecondary returns whether we should dial additional
+// connections to the device. To avoid both sides dialing at the same time
+// only the device with the lower device ID does so.
+func (s *service) shouldDialSecondary(ct Connection, deviceCfg config.DeviceConfiguration) bool {
+	if s.myID.Compare(deviceCfg.DeviceID) > 0 {
+		return false
+	}
+	return len(ct.Connections()) < s.wantedConnections(deviceCfg)
+}
+
+// wantedConnections returns the number of connections both we and the
+// device want, as far as we know.
+func (s *service) wantedConnections(deviceCfg config.DeviceConfiguration) int {
+	s.remoteNumConnsMut.Lock()
+	remote := s.remoteNumConns[deviceCfg.DeviceID]
+	s.remoteNumConnsMut.Unlock()
+	return wantedConnections(deviceCfg, remote)
+}
+
+// wantedConnections returns the lower of the number of connections
+// configured for the device and the number it asked for in its Hello.
+func wantedConnections(deviceCfg config.DeviceConfiguration, remote int) int {
+	local := deviceCfg.NumConnections
+	if local < 1 {
+		local = 1
+	}
+	if remote < 1 {
+		remote = 1
+	}
+	if remote < local {
+		return remote
+	}
+	return local
+}
+
 func (s *service) isLANHost(host string) bool {
 	// Probably we are called with an ip:port combo which we can resolve as
 	// a TCP address.
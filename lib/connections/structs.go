@@ -20,10 +20,10 @@ import (
 	"github.com/syncthing/syncthing/lib/protocol"
 )
 
-// Connection is what we expose to the outside. It is a protocol.Connection
-// that can be closed and has some metadata.
+// Connection is what we expose to the outside. It is a
+// protocol.ConnectionGroup that can be closed and has some metadata.
 type Connection interface {
-	protocol.Connection
+	protocol.ConnectionGroup
 	Type() string
 	Transport() string
 	RemoteAddr() net.Addr
@@ -33,18 +33,29 @@ type Connection interface {
 }
 
 // completeConn is the aggregation of an internalConn and the
-// protocol.Connection running on top of it. It implements the Connection
-// interface.
+// protocol.ConnectionGroup running on top of it. It implements the
+// Connection interface.
 type completeConn struct {
 	internalConn
-	protocol.Connection
+	protocol.ConnectionGroup
 }
 
 func (c completeConn) Close(err error) {
-	c.Connection.Close(err)
+	c.ConnectionGroup.Close(err)
 	c.internalConn.Close()
 }
 
+// internalConnCloser makes an internalConn an io.Closer, for use as a
+// secondary connection.
+type internalConnCloser struct {
+	internalConn
+}
+
+func (c internalConnCloser) Close() error {
+	c.internalConn.Close()
+	return nil
+}
+
 type tlsConn interface {
 	io.ReadWriteCloser
 	ConnectionState() tls.ConnectionState
@@ -0,0 +1,128 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// The history journal records every change of the global version of a file
+// in folders that keep one, along with the device whose index update caused
+// it. Entries are keyed by file name and time, so the changes of a file are
+// listed in order.
+
+// SetKeepHistory enables or disables recording the history journal.
+func (s *FileSet) SetKeepHistory(keep bool) {
+	s.updateMutex.Lock()
+	s.keepHistory = keep
+	s.updateMutex.Unlock()
+}
+
+// History returns the recorded changes of the given file, oldest first, or
+// those of all files by name when the name is empty.
+func (s *FileSet) History(name string) ([]HistoryEntry, error) {
+	t, err := s.db.newReadOnlyTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer t.close()
+
+	key, err := t.keyer.GenerateHistoryKey(nil, []byte(s.folder), []byte(osutil.NormalizedFilename(name)), 0)
+	if err != nil {
+		return nil, err
+	}
+	prefix := key.WithoutTime()
+	if name == "" {
+		prefix = key.WithoutNameAndTime()
+	}
+
+	it, err := t.NewPrefixIterator(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+	var entries []HistoryEntry
+	for it.Next() {
+		var entry HistoryEntry
+		if err := entry.Unmarshal(it.Value()); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, it.Error()
+}
+
+// PruneHistory removes the entries recorded before the given time.
+func (s *FileSet) PruneHistory(before time.Time) error {
+	s.db.gcMut.RLock()
+	defer s.db.gcMut.RUnlock()
+
+	t, err := s.db.newReadWriteTransaction()
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	key, err := t.keyer.GenerateHistoryKey(nil, []byte(s.folder), nil, 0)
+	if err != nil {
+		return err
+	}
+	it, err := t.NewPrefixIterator(key.WithoutNameAndTime())
+	if err != nil {
+		return err
+	}
+	limit := before.UnixNano()
+	var expired [][]byte
+	for it.Next() {
+		key := it.Key()
+		if int64(binary.BigEndian.Uint64(key[len(key)-keyTimeLen:])) < limit {
+			expired = append(expired, append([]byte(nil), key...))
+		}
+	}
+	err = it.Error()
+	it.Release()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range expired {
+		if err := t.Delete(key); err != nil {
+			return err
+		}
+		if err := t.Checkpoint(); err != nil {
+			return err
+		}
+	}
+	return t.Commit()
+}
+
+// recordHistory adds an entry for a changed global version to the history
+// journal. The files are recorded without their blocks.
+func (t readWriteTransaction) recordHistory(keyBuf, folder, device, name []byte, old *protocol.FileInfo, global protocol.FileInfo) ([]byte, error) {
+	entry := HistoryEntry{
+		Time:    time.Now(),
+		Device:  protocol.DeviceIDFromBytes(device),
+		NewFile: global,
+	}
+	entry.NewFile.Blocks = nil
+	if old != nil {
+		oldFile := *old
+		oldFile.Blocks = nil
+		entry.OldFile = &oldFile
+	}
+	l.Debugf("history; folder=%q device=%v %v", folder, entry.Device, entry.NewFile)
+
+	keyBuf, err := t.keyer.GenerateHistoryKey(keyBuf, folder, name, entry.Time.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	return keyBuf, t.Put(keyBuf, mustMarshal(&entry))
+}
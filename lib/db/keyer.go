@@ -15,6 +15,7 @@ const (
 	keyFolderLen   = 4 // indexed
 	keyDeviceLen   = 4 // indexed
 	keySequenceLen = 8
+	keyTimeLen     = 8
 	keyHashLen     = 32
 
 	maxInt64 int64 = 1<<63 - 1
@@ -62,6 +63,15 @@ const (
 
 	// KeyTypeBlockList <block list hash> = BlockList
 	KeyTypeBlockList = 13
+
+	// KeyTypePendingFolder <device ID> <folder ID as string> = ObservedFolder
+	KeyTypePendingFolder = 14
+
+	// KeyTypePendingDevice <device ID> = ObservedDevice
+	KeyTypePendingDevice = 15
+
+	// KeyTypeHistory <int32 folder ID> <file name> <0x00> <int64 time> = HistoryEntry
+	KeyTypeHistory = 16
 )
 
 type keyer interface {
@@ -99,6 +109,9 @@ type keyer interface {
 
 	// Block lists
 	GenerateBlockListKey(key []byte, hash []byte) blockListKey
+
+	// History journal
+	GenerateHistoryKey(key, folder, name []byte, t int64) (historyKey, error)
 }
 
 // defaultKeyer implements our key scheme. It needs folder and device
@@ -300,6 +313,31 @@ func (k blockListKey) BlocksHash() []byte {
 	return k[keyPrefixLen:]
 }
 
+type historyKey []byte
+
+func (k defaultKeyer) GenerateHistoryKey(key, folder, name []byte, t int64) (historyKey, error) {
+	folderID, err := k.folderIdx.ID(folder)
+	if err != nil {
+		return nil, err
+	}
+	key = resize(key, keyPrefixLen+keyFolderLen+len(name)+1+keyTimeLen)
+	key[0] = KeyTypeHistory
+	binary.BigEndian.PutUint32(key[keyPrefixLen:], folderID)
+	copy(key[keyPrefixLen+keyFolderLen:], name)
+	key[keyPrefixLen+keyFolderLen+len(name)] = 0
+	binary.BigEndian.PutUint64(key[keyPrefixLen+keyFolderLen+len(name)+1:], uint64(t))
+	return key, nil
+}
+
+func (k historyKey) WithoutNameAndTime() []byte {
+	return k[:keyPrefixLen+keyFolderLen]
+}
+
+// WithoutTime returns the prefix of all entries of the file.
+func (k historyKey) WithoutTime() []byte {
+	return k[:len(k)-keyTimeLen]
+}
+
 // resize returns a byte slice of the specified size, reusing bs if possible
 func resize(bs []byte, size int) []byte {
 	if cap(bs) < size {
@@ -81,7 +81,7 @@ func (db *Lowlevel) ListFolders() []string {
 
 // updateRemoteFiles adds a list of fileinfos to the database and updates the
 // global versionlist and metadata.
-func (db *Lowlevel) updateRemoteFiles(folder, device []byte, fs []protocol.FileInfo, meta *metadataTracker) error {
+func (db *Lowlevel) updateRemoteFiles(folder, device []byte, fs []protocol.FileInfo, meta *metadataTracker, keepHistory bool) error {
 	db.gcMut.RLock()
 	defer db.gcMut.RUnlock()
 
@@ -122,7 +122,7 @@ func (db *Lowlevel) updateRemoteFiles(folder, device []byte, fs []protocol.FileI
 		if err != nil {
 			return err
 		}
-		keyBuf, _, err = t.updateGlobal(gk, keyBuf, folder, device, f, meta)
+		keyBuf, _, err = t.updateGlobal(gk, keyBuf, folder, device, f, meta, keepHistory)
 		if err != nil {
 			return err
 		}
@@ -143,7 +143,7 @@ func (db *Lowlevel) updateRemoteFiles(folder, device []byte, fs []protocol.FileI
 
 // updateLocalFiles adds fileinfos to the db, and updates the global versionlist,
 // metadata, sequence and blockmap buckets.
-func (db *Lowlevel) updateLocalFiles(folder []byte, fs []protocol.FileInfo, meta *metadataTracker) error {
+func (db *Lowlevel) updateLocalFiles(folder []byte, fs []protocol.FileInfo, meta *metadataTracker, keepHistory bool) error {
 	db.gcMut.RLock()
 	defer db.gcMut.RUnlock()
 
@@ -209,7 +209,7 @@ func (db *Lowlevel) updateLocalFiles(folder []byte, fs []protocol.FileInfo, meta
 		if err != nil {
 			return err
 		}
-		keyBuf, _, err = t.updateGlobal(gk, keyBuf, folder, protocol.LocalDeviceID[:], f, meta)
+		keyBuf, _, err = t.updateGlobal(gk, keyBuf, folder, protocol.LocalDeviceID[:], f, meta, keepHistory)
 		if err != nil {
 			return err
 		}
@@ -305,6 +305,15 @@ func (db *Lowlevel) dropFolder(folder []byte) error {
 		return err
 	}
 
+	// Remove the history journal of the folder
+	k5, err := db.keyer.GenerateHistoryKey(nil, folder, nil, 0)
+	if err != nil {
+		return err
+	}
+	if err := t.deleteKeyPrefix(k5.WithoutNameAndTime()); err != nil {
+		return err
+	}
+
 	return t.Commit()
 }
 
@@ -0,0 +1,183 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// PendingFolder lists the devices offering a folder we don't share with
+// them, along with what each of them told us about it.
+type PendingFolder struct {
+	OfferedBy map[protocol.DeviceID]ObservedFolder `json:"offeredBy"`
+}
+
+func pendingDeviceKey(device protocol.DeviceID) []byte {
+	key := make([]byte, keyPrefixLen+protocol.DeviceIDLength)
+	key[0] = KeyTypePendingDevice
+	copy(key[keyPrefixLen:], device[:])
+	return key
+}
+
+func pendingFolderKey(device protocol.DeviceID, folder string) []byte {
+	key := make([]byte, keyPrefixLen+protocol.DeviceIDLength+len(folder))
+	key[0] = KeyTypePendingFolder
+	copy(key[keyPrefixLen:], device[:])
+	copy(key[keyPrefixLen+protocol.DeviceIDLength:], folder)
+	return key
+}
+
+func deviceFromPendingKey(key []byte) (protocol.DeviceID, bool) {
+	if len(key) < keyPrefixLen+protocol.DeviceIDLength {
+		return protocol.EmptyDeviceID, false
+	}
+	return protocol.DeviceIDFromBytes(key[keyPrefixLen : keyPrefixLen+protocol.DeviceIDLength]), true
+}
+
+func folderFromPendingFolderKey(key []byte) string {
+	return string(key[keyPrefixLen+protocol.DeviceIDLength:])
+}
+
+// AddOrUpdatePendingDevice records a connection attempt by an unknown
+// device, or updates the existing record.
+func (db *Lowlevel) AddOrUpdatePendingDevice(device protocol.DeviceID, name, address string) error {
+	od := ObservedDevice{
+		Time:    time.Now().Round(time.Second),
+		Name:    name,
+		Address: address,
+	}
+	bs, err := od.Marshal()
+	if err != nil {
+		return err
+	}
+	return db.Put(pendingDeviceKey(device), bs)
+}
+
+// RemovePendingDevice forgets about a pending device. It is not an error if
+// there is no such device.
+func (db *Lowlevel) RemovePendingDevice(device protocol.DeviceID) error {
+	return db.Delete(pendingDeviceKey(device))
+}
+
+// PendingDevices returns all devices that tried to connect to us, but
+// aren't configured, keyed by device ID.
+func (db *Lowlevel) PendingDevices() (map[protocol.DeviceID]ObservedDevice, error) {
+	iter, err := db.NewPrefixIterator([]byte{KeyTypePendingDevice})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Release()
+	res := make(map[protocol.DeviceID]ObservedDevice)
+	for iter.Next() {
+		deviceID, ok := deviceFromPendingKey(iter.Key())
+		if !ok {
+			l.Infof("Invalid pending device entry, deleting from database: %x", iter.Key())
+			_ = db.Delete(iter.Key())
+			continue
+		}
+		var od ObservedDevice
+		if err := od.Unmarshal(iter.Value()); err != nil {
+			l.Infof("Invalid pending device entry, deleting from database: %x", iter.Key())
+			_ = db.Delete(iter.Key())
+			continue
+		}
+		res[deviceID] = od
+	}
+	return res, iter.Error()
+}
+
+// AddOrUpdatePendingFolder records a folder offered by the given device,
+// or updates the existing record.
+func (db *Lowlevel) AddOrUpdatePendingFolder(id, label string, device protocol.DeviceID) error {
+	of := ObservedFolder{
+		Time:  time.Now().Round(time.Second),
+		Label: label,
+	}
+	bs, err := of.Marshal()
+	if err != nil {
+		return err
+	}
+	return db.Put(pendingFolderKey(device, id), bs)
+}
+
+// RemovePendingFolderForDevice forgets that the given device offered the
+// folder. It is not an error if there is no such record.
+func (db *Lowlevel) RemovePendingFolderForDevice(id string, device protocol.DeviceID) error {
+	return db.Delete(pendingFolderKey(device, id))
+}
+
+// RemovePendingFolder forgets the folder as offered by any device.
+func (db *Lowlevel) RemovePendingFolder(id string) error {
+	t, err := db.newReadWriteTransaction()
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	iter, err := t.NewPrefixIterator([]byte{KeyTypePendingFolder})
+	if err != nil {
+		return err
+	}
+	defer iter.Release()
+	for iter.Next() {
+		if folderFromPendingFolderKey(iter.Key()) != id {
+			continue
+		}
+		if err := t.Delete(iter.Key()); err != nil {
+			return err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+// PendingFolders returns all folders offered to us, but not shared with the
+// offering devices, keyed by folder ID.
+func (db *Lowlevel) PendingFolders() (map[string]PendingFolder, error) {
+	return db.PendingFoldersForDevice(protocol.EmptyDeviceID)
+}
+
+// PendingFoldersForDevice is like PendingFolders, but only considers the
+// given device. The empty device ID means any device.
+func (db *Lowlevel) PendingFoldersForDevice(device protocol.DeviceID) (map[string]PendingFolder, error) {
+	prefix := []byte{KeyTypePendingFolder}
+	if device != protocol.EmptyDeviceID {
+		prefix = pendingFolderKey(device, "")
+	}
+	iter, err := db.NewPrefixIterator(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Release()
+	res := make(map[string]PendingFolder)
+	for iter.Next() {
+		deviceID, ok := deviceFromPendingKey(iter.Key())
+		if !ok {
+			l.Infof("Invalid pending folder entry, deleting from database: %x", iter.Key())
+			_ = db.Delete(iter.Key())
+			continue
+		}
+		var of ObservedFolder
+		if err := of.Unmarshal(iter.Value()); err != nil {
+			l.Infof("Invalid pending folder entry, deleting from database: %x", iter.Key())
+			_ = db.Delete(iter.Key())
+			continue
+		}
+		folderID := folderFromPendingFolderKey(iter.Key())
+		if _, ok := res[folderID]; !ok {
+			res[folderID] = PendingFolder{
+				OfferedBy: make(map[protocol.DeviceID]ObservedFolder),
+			}
+		}
+		res[folderID].OfferedBy[deviceID] = of
+	}
+	return res, iter.Error()
+}
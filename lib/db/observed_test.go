@@ -0,0 +1,119 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/db/backend"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestPendingDevices(t *testing.T) {
+	db := NewLowlevel(backend.OpenMemory())
+	defer db.Close()
+
+	dev1 := protocol.DeviceID{1}
+	dev2 := protocol.DeviceID{2}
+
+	if err := db.AddOrUpdatePendingDevice(dev1, "one", "tcp://192.0.2.1:22000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddOrUpdatePendingDevice(dev2, "two", "tcp://192.0.2.2:22000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddOrUpdatePendingDevice(dev1, "renamed", "tcp://192.0.2.1:22000"); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := db.PendingDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected two pending devices, got %v", devices)
+	}
+	if od := devices[dev1]; od.Name != "renamed" || od.Address != "tcp://192.0.2.1:22000" || od.Time.IsZero() {
+		t.Errorf("unexpected entry for device 1: %+v", od)
+	}
+
+	if err := db.RemovePendingDevice(dev1); err != nil {
+		t.Fatal(err)
+	}
+	devices, err = db.PendingDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := devices[dev1]; ok || len(devices) != 1 {
+		t.Errorf("expected only device 2 to remain, got %v", devices)
+	}
+}
+
+func TestPendingFolders(t *testing.T) {
+	db := NewLowlevel(backend.OpenMemory())
+	defer db.Close()
+
+	dev1 := protocol.DeviceID{1}
+	dev2 := protocol.DeviceID{2}
+
+	for _, e := range []struct {
+		folder string
+		device protocol.DeviceID
+	}{
+		{"a", dev1},
+		{"a", dev2},
+		{"b", dev1},
+	} {
+		if err := db.AddOrUpdatePendingFolder(e.folder, "label "+e.folder, e.device); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	folders, err := db.PendingFolders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 2 || len(folders["a"].OfferedBy) != 2 || len(folders["b"].OfferedBy) != 1 {
+		t.Fatalf("unexpected pending folders: %v", folders)
+	}
+	if of := folders["b"].OfferedBy[dev1]; of.Label != "label b" || of.Time.IsZero() {
+		t.Errorf("unexpected entry for folder b: %+v", of)
+	}
+
+	folders, err = db.PendingFoldersForDevice(dev2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 1 || len(folders["a"].OfferedBy) != 1 {
+		t.Errorf("unexpected pending folders for device 2: %v", folders)
+	}
+
+	if err := db.RemovePendingFolderForDevice("a", dev1); err != nil {
+		t.Fatal(err)
+	}
+	folders, err = db.PendingFolders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := folders["a"].OfferedBy[dev1]; ok || len(folders["a"].OfferedBy) != 1 {
+		t.Errorf("folder a should only be offered by device 2: %v", folders)
+	}
+
+	if err := db.AddOrUpdatePendingFolder("a", "label a", dev1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RemovePendingFolder("a"); err != nil {
+		t.Fatal(err)
+	}
+	folders, err = db.PendingFolders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := folders["a"]; ok || len(folders) != 1 {
+		t.Errorf("only folder b should remain: %v", folders)
+	}
+}
@@ -202,7 +202,7 @@ func (db *schemaUpdater) updateSchema0to1(_ int) error {
 			if err != nil {
 				return err
 			}
-			if buf, ok, err = t.updateGlobal(gk, buf, folder, device, f, meta); err != nil {
+			if buf, ok, err = t.updateGlobal(gk, buf, folder, device, f, meta, false); err != nil {
 				return err
 			} else if ok {
 				if _, ok = changedFolders[string(folder)]; !ok {
@@ -29,6 +29,7 @@ type FileSet struct {
 	db     *Lowlevel
 	meta   *metadataTracker
 
+	keepHistory bool       // record the history journal; protected by updateMutex
 	updateMutex sync.Mutex // protects database updates and the corresponding metadata changes
 }
 
@@ -232,13 +233,13 @@ func (s *FileSet) Update(device protocol.DeviceID, fs []protocol.FileInfo) {
 
 	if device == protocol.LocalDeviceID {
 		// For the local device we have a bunch of metadata to track.
-		if err := s.db.updateLocalFiles([]byte(s.folder), fs, s.meta); err != nil && !backend.IsClosed(err) {
+		if err := s.db.updateLocalFiles([]byte(s.folder), fs, s.meta, s.keepHistory); err != nil && !backend.IsClosed(err) {
 			panic(err)
 		}
 		return
 	}
 	// Easy case, just update the files and we're done.
-	if err := s.db.updateRemoteFiles([]byte(s.folder), device[:], fs, s.meta); err != nil && !backend.IsClosed(err) {
+	if err := s.db.updateRemoteFiles([]byte(s.folder), device[:], fs, s.meta, s.keepHistory); err != nil && !backend.IsClosed(err) {
 		panic(err)
 	}
 }
@@ -517,6 +518,12 @@ func (s *FileSet) SetIndexID(device protocol.DeviceID, id protocol.IndexID) {
 }
 
 func (s *FileSet) MtimeFS() *fs.MtimeFS {
+	return s.MtimeFSOn(s.fs)
+}
+
+// MtimeFSOn returns an MtimeFS using the mtimes of the folder on the given
+// filesystem, rather than on the one of the file set.
+func (s *FileSet) MtimeFSOn(ffs fs.Filesystem) *fs.MtimeFS {
 	prefix, err := s.db.keyer.GenerateMtimesKey(nil, []byte(s.folder))
 	if backend.IsClosed(err) {
 		return nil
@@ -524,7 +531,7 @@ func (s *FileSet) MtimeFS() *fs.MtimeFS {
 		panic(err)
 	}
 	kv := NewNamespacedKV(s.db, string(prefix))
-	return fs.NewMtimeFS(s.fs, kv)
+	return fs.NewMtimeFS(ffs, kv)
 }
 
 func (s *FileSet) ListDevices() []protocol.DeviceID {
@@ -1613,3 +1613,66 @@ func receiveOnlyChangedSize(fs *db.FileSet) db.Counts {
 	defer snap.Release()
 	return snap.ReceiveOnlyChangedSize()
 }
+
+func TestHistory(t *testing.T) {
+	ldb := db.NewLowlevel(backend.OpenMemory())
+	defer ldb.Close()
+
+	s := db.NewFileSet("test", fs.NewFilesystem(fs.FilesystemTypeFake, ""), ldb)
+	s.SetKeepHistory(true)
+
+	v1 := protocol.Vector{}.Update(remoteDevice0.Short())
+	v2 := v1.Copy().Update(myID)
+	s.Update(remoteDevice0, fileList{
+		protocol.FileInfo{Name: "a", Version: v1, Blocks: genBlocks(1)},
+		protocol.FileInfo{Name: "ab", Version: v1, Blocks: genBlocks(1)},
+	})
+	s.Update(protocol.LocalDeviceID, fileList{
+		// Same version as the global, no change
+		protocol.FileInfo{Name: "ab", Version: v1, Blocks: genBlocks(1)},
+	})
+	s.Update(protocol.LocalDeviceID, fileList{
+		protocol.FileInfo{Name: "a", Version: v2, Deleted: true, ModifiedBy: myID},
+	})
+
+	entries, err := s.History("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected two entries, got %v", entries)
+	}
+	if e := entries[0]; e.Device != remoteDevice0 || e.OldFile != nil || !e.NewFile.Version.Equal(v1) {
+		t.Error("Unexpected first entry", e)
+	}
+	if e := entries[1]; e.Device != protocol.LocalDeviceID || e.OldFile == nil || !e.OldFile.Version.Equal(v1) || !e.NewFile.IsDeleted() || e.NewFile.ModifiedBy != myID {
+		t.Error("Unexpected second entry", e)
+	}
+	if entries[1].Time.Before(entries[0].Time) {
+		t.Error("Entries should be in order")
+	}
+
+	if entries, err := s.History(""); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 3 {
+		t.Errorf("Expected three entries for the folder, got %v", entries)
+	}
+
+	if err := s.PruneHistory(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := s.History(""); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 0 {
+		t.Errorf("Expected no entries after pruning, got %v", entries)
+	}
+
+	// Nothing is recorded when not keeping history.
+	s.SetKeepHistory(false)
+	s.Update(remoteDevice0, fileList{protocol.FileInfo{Name: "b", Version: v1, Blocks: genBlocks(1)}})
+	if entries, err := s.History("b"); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 0 {
+		t.Errorf("Expected no entries, got %v", entries)
+	}
+}
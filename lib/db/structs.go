@@ -5,7 +5,7 @@
 // You can obtain one at https://mozilla.org/MPL/2.0/.
 
 //go:generate go run ../../script/protofmt.go structs.proto
-//go:generate protoc -I ../../ -I . --gogofast_out=Mlib/protocol/bep.proto=github.com/syncthing/syncthing/lib/protocol:. structs.proto
+//go:generate protoc -I ../../ -I . -I ../../repos/protobuf/protobuf --gogofast_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,Mlib/protocol/bep.proto=github.com/syncthing/syncthing/lib/protocol:. structs.proto
 
 package db
 
@@ -58,6 +58,14 @@ func (f FileInfoTruncated) IsReceiveOnlyChanged() bool {
 	return f.LocalFlags&protocol.FlagLocalReceiveOnly != 0
 }
 
+func (f FileInfoTruncated) IsDeselected() bool {
+	return f.LocalFlags&protocol.FlagLocalDeselected != 0
+}
+
+func (f FileInfoTruncated) IsPlaceholder() bool {
+	return f.LocalFlags&protocol.FlagLocalPlaceholder != 0
+}
+
 func (f FileInfoTruncated) IsDirectory() bool {
 	return f.Type == protocol.FileInfoTypeDirectory
 }
@@ -134,6 +142,12 @@ func (f FileInfoTruncated) ConvertToIgnoredFileInfo(by protocol.ShortID) protoco
 	return file
 }
 
+func (f FileInfoTruncated) ConvertToDeselectedFileInfo(by protocol.ShortID) protocol.FileInfo {
+	file := f.copyToFileInfo()
+	file.SetDeselected(by)
+	return file
+}
+
 func (f FileInfoTruncated) ConvertToDeletedFileInfo(by protocol.ShortID) protocol.FileInfo {
 	file := f.copyToFileInfo()
 	file.SetDeleted(by)
@@ -151,6 +165,7 @@ func (f FileInfoTruncated) copyToFileInfo() protocol.FileInfo {
 		Sequence:      f.Sequence,
 		SymlinkTarget: f.SymlinkTarget,
 		BlocksHash:    f.BlocksHash,
+		Platform:      f.Platform,
 		Type:          f.Type,
 		Permissions:   f.Permissions,
 		ModifiedNs:    f.ModifiedNs,
@@ -489,7 +489,9 @@ func (t readWriteTransaction) putFile(fkey []byte, fi protocol.FileInfo) error {
 // updateGlobal adds this device+version to the version list for the given
 // file. If the device is already present in the list, the version is updated.
 // If the file does not have an entry in the global list, it is created.
-func (t readWriteTransaction) updateGlobal(gk, keyBuf, folder, device []byte, file protocol.FileInfo, meta *metadataTracker) ([]byte, bool, error) {
+// Changes of the global version are recorded in the history journal when
+// keepHistory is set.
+func (t readWriteTransaction) updateGlobal(gk, keyBuf, folder, device []byte, file protocol.FileInfo, meta *metadataTracker, keepHistory bool) ([]byte, bool, error) {
 	l.Debugf("update global; folder=%q device=%v file=%q version=%v invalid=%v", folder, protocol.DeviceIDFromBytes(device), file.Name, file.Version, file.IsInvalid())
 
 	var fl VersionList
@@ -566,6 +568,17 @@ func (t readWriteTransaction) updateGlobal(gk, keyBuf, folder, device []byte, fi
 	// Add the new global to the global size counter
 	meta.addFile(protocol.GlobalDeviceID, global)
 
+	if keepHistory && !(ok && oldFile.Version.Equal(global.Version)) {
+		var old *protocol.FileInfo
+		if ok {
+			old = &oldFile
+		}
+		keyBuf, err = t.recordHistory(keyBuf, folder, device, name, old, global)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
 	l.Debugf(`new global for "%v" after update: %v`, file.Name, fl)
 	if err := t.Put(gk, mustMarshal(&fl)); err != nil {
 		return nil, false, err
@@ -167,6 +167,8 @@ func (m *cachingMux) Cache() map[protocol.DeviceID]CacheEntry {
 					cur.when = v.when
 				}
 				cur.Addresses = append(cur.Addresses, v.Addresses...)
+				cur.Unsigned = cur.Unsigned || v.Unsigned
+				cur.Unauthenticated = cur.Unauthenticated || v.Unauthenticated
 				res[k] = cur
 			}
 		}
@@ -181,6 +183,8 @@ func (m *cachingMux) Cache() map[protocol.DeviceID]CacheEntry {
 					cur.when = v.when
 				}
 				cur.Addresses = append(cur.Addresses, v.Addresses...)
+				cur.Unsigned = cur.Unsigned || v.Unsigned
+				cur.Unauthenticated = cur.Unauthenticated || v.Unauthenticated
 				res[k] = cur
 			}
 		}
@@ -23,11 +23,14 @@ type Finder interface {
 }
 
 type CacheEntry struct {
-	Addresses  []string  `json:"addresses"`
-	when       time.Time // When did we get the result
-	found      bool      // Is it a success (cacheTime applies) or a failure (negCacheTime applies)?
-	validUntil time.Time // Validity time, overrides normal calculation
-	instanceID int64     // for local discovery, the instance ID (random on each restart)
+	Addresses       []string          `json:"addresses"`
+	Unsigned        bool              `json:"unsigned,omitempty"`        // announced without a signature, accepted for compatibility
+	Unauthenticated bool              `json:"unauthenticated,omitempty"` // some addresses not covered by a signature
+	when            time.Time         // When did we get the result
+	found           bool              // Is it a success (cacheTime applies) or a failure (negCacheTime applies)?
+	validUntil      time.Time         // Validity time, overrides normal calculation
+	instanceID      int64             // for local discovery, the instance ID (random on each restart)
+	seen            seenAnnouncements // for local discovery, the signed announcements accepted
 }
 
 // A FinderService is a Finder that has background activity and must be run as
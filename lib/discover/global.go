@@ -17,6 +17,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	stdsync "sync"
 	"time"
 
@@ -60,6 +61,7 @@ type serverOptions struct {
 	noAnnounce bool   // don't announce
 	noLookup   bool   // don't use for lookups
 	id         string // expected server device ID
+	token      string // bearer token for lookups
 }
 
 // A lookupError is any other error but with a cache validity time attached.
@@ -89,7 +91,7 @@ func NewGlobal(server string, cert tls.Certificate, addrList AddressLister, evLo
 	// The http.Client used for announcements. It needs to have our
 	// certificate to prove our identity, and may or may not verify the server
 	// certificate depending on the insecure setting.
-	var announceClient httpClient = &contextClient{&http.Client{
+	var announceClient httpClient = &contextClient{Client: &http.Client{
 		Timeout: requestTimeout,
 		Transport: &http.Transport{
 			DialContext: dialer.DialContext,
@@ -106,7 +108,8 @@ func NewGlobal(server string, cert tls.Certificate, addrList AddressLister, evLo
 
 	// The http.Client used for queries. We don't need to present our
 	// certificate here, so lets not include it. May be insecure if requested.
-	var queryClient httpClient = &contextClient{&http.Client{
+	// Private servers may require a token instead.
+	var queryClient httpClient = &contextClient{Client: &http.Client{
 		Timeout: requestTimeout,
 		Transport: &http.Transport{
 			DialContext: dialer.DialContext,
@@ -115,7 +118,7 @@ func NewGlobal(server string, cert tls.Certificate, addrList AddressLister, evLo
 				InsecureSkipVerify: opts.insecure,
 			},
 		},
-	}}
+	}, token: opts.token}
 	if opts.id != "" {
 		queryClient = newIDCheckingHTTPClient(queryClient, devID)
 	}
@@ -293,6 +296,9 @@ func (c *globalClient) Cache() map[protocol.DeviceID]CacheEntry {
 func parseOptions(dsn string) (server string, opts serverOptions, err error) {
 	p, err := url.Parse(dsn)
 	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			uerr.URL = RedactToken(dsn)
+		}
 		return "", serverOptions{}, err
 	}
 
@@ -302,6 +308,7 @@ func parseOptions(dsn string) (server string, opts serverOptions, err error) {
 	opts.insecure = opts.id != "" || queryBool(q, "insecure")
 	opts.noAnnounce = queryBool(q, "noannounce")
 	opts.noLookup = queryBool(q, "nolookup")
+	opts.token = q.Get("token")
 
 	// Check for disallowed combinations
 	if p.Scheme == "http" {
@@ -322,6 +329,26 @@ func parseOptions(dsn string) (server string, opts serverOptions, err error) {
 	return
 }
 
+// RedactToken returns the discovery server URL with the value of any token
+// option replaced, suitable for logging and display.
+func RedactToken(dsn string) string {
+	p, err := url.Parse(dsn)
+	if err != nil {
+		// Can't tell where the token is, so drop all options.
+		if i := strings.IndexByte(dsn, '?'); i >= 0 {
+			return dsn[:i]
+		}
+		return dsn
+	}
+	q := p.Query()
+	if _, ok := q["token"]; !ok {
+		return dsn
+	}
+	q.Set("token", "REDACTED")
+	p.RawQuery = q.Encode()
+	return p.String()
+}
+
 // queryBool returns the query parameter parsed as a boolean. An empty value
 // ("?foo") is considered true, as is any value string except false
 // ("?foo=false").
@@ -406,6 +433,7 @@ func (e *errorHolder) Error() error {
 
 type contextClient struct {
 	*http.Client
+	token string // sent as bearer token, if set
 }
 
 func (c *contextClient) Get(ctx context.Context, url string) (*http.Response, error) {
@@ -417,6 +445,7 @@ func (c *contextClient) Get(ctx context.Context, url string) (*http.Response, er
 		return nil, err
 	}
 	req.Cancel = ctx.Done()
+	c.setAuthorization(req)
 	return c.Client.Do(req)
 }
 
@@ -430,5 +459,12 @@ func (c *contextClient) Post(ctx context.Context, url, ctype string, data io.Rea
 	}
 	req.Cancel = ctx.Done()
 	req.Header.Set("Content-Type", ctype)
+	c.setAuthorization(req)
 	return c.Client.Do(req)
 }
+
+func (c *contextClient) setAuthorization(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
@@ -33,6 +33,7 @@ func TestParseOptions(t *testing.T) {
 		{"https://example.com/?insecure=yes", "https://example.com/", serverOptions{insecure: true}},
 		{"https://example.com/?insecure=false&noannounce", "https://example.com/", serverOptions{noAnnounce: true}},
 		{"https://example.com/?id=abc", "https://example.com/", serverOptions{id: "abc", insecure: true}},
+		{"https://example.com/?token=abc", "https://example.com/", serverOptions{token: "abc"}},
 	}
 
 	for _, tc := range testcases {
@@ -50,6 +51,30 @@ func TestParseOptions(t *testing.T) {
 	}
 }
 
+func TestRedactToken(t *testing.T) {
+	testcases := []struct {
+		in  string
+		out string
+	}{
+		{"https://example.com/", "https://example.com/"},
+		{"https://example.com/?insecure", "https://example.com/?insecure"},
+		{"https://example.com/?token=abc", "https://example.com/?token=REDACTED"},
+		{"https://example.com/?id=abc&token=def", "https://example.com/?id=abc&token=REDACTED"},
+		{"https://exa mple.com/?token=abc", "https://exa mple.com/"},
+	}
+
+	for _, tc := range testcases {
+		if res := RedactToken(tc.in); res != tc.out {
+			t.Errorf("Incorrect redaction, %v != %v for %v", res, tc.out, tc.in)
+		}
+	}
+
+	_, _, err := parseOptions("https://exa mple.com/?token=abc")
+	if err == nil || strings.Contains(err.Error(), "abc") {
+		t.Errorf("Expected an error without the token, got %v", err)
+	}
+}
+
 func TestGlobalOverHTTP(t *testing.T) {
 	// HTTP works for queries, but is obviously insecure and we can't do
 	// announces over it (as we don't present a certificate). As such, http://
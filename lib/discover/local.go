@@ -11,6 +11,7 @@ package discover
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -30,10 +31,12 @@ import (
 
 type localClient struct {
 	*suture.Supervisor
-	myID     protocol.DeviceID
-	addrList AddressLister
-	name     string
-	evLogger events.Logger
+	myID           protocol.DeviceID
+	cert           tls.Certificate
+	acceptUnsigned bool
+	addrList       AddressLister
+	name           string
+	evLogger       events.Logger
 
 	beacon          beacon.Interface
 	localBcastStart time.Time
@@ -50,12 +53,18 @@ const (
 	v13Magic          = uint32(0x7D79BC40) // previous version
 )
 
-func NewLocal(id protocol.DeviceID, addr string, addrList AddressLister, evLogger events.Logger) (FinderService, error) {
+// NewLocal returns a local discovery client announcing and listening on the
+// given address, which is a broadcast port or a multicast address. Its
+// announcements are signed with the certificate key. Unsigned announcements
+// from older devices are only accepted when acceptUnsigned is set.
+func NewLocal(id protocol.DeviceID, addr string, cert tls.Certificate, acceptUnsigned bool, addrList AddressLister, evLogger events.Logger) (FinderService, error) {
 	c := &localClient{
 		Supervisor: suture.New("local", suture.Spec{
 			PassThroughPanics: true,
 		}),
 		myID:            id,
+		cert:            cert,
+		acceptUnsigned:  acceptUnsigned,
 		addrList:        addrList,
 		evLogger:        evLogger,
 		localBcastTick:  time.NewTicker(BroadcastInterval).C,
@@ -131,6 +140,9 @@ func (c *localClient) announcementPkt(instanceID int64, msg []byte) ([]byte, boo
 		Addresses:  addrs,
 		InstanceID: instanceID,
 	}
+	if err := signAnnouncement(&pkt, c.cert); err != nil {
+		l.Debugln("discover: Sending unsigned announcement:", err)
+	}
 	bs, _ := pkt.Marshal()
 	msg = append(msg, bs...)
 
@@ -215,18 +227,48 @@ func (c *localClient) recvAnnouncements(ctx context.Context) {
 }
 
 func (c *localClient) registerDevice(src net.Addr, device Announce) bool {
+	return registerDevice(c.cache, c.evLogger, src, device, c.acceptUnsigned)
+}
+
+// registerDevice verifies the announcement and caches the addresses of the
+// announcing device, as seen from the source of the announcement. It
+// returns whether the device is new.
+func registerDevice(c *cache, evLogger events.Logger, src net.Addr, device Announce, acceptUnsigned bool) bool {
+	ce, existsAlready := c.Get(device.ID)
+	valid := existsAlready && time.Since(ce.when) <= CacheLifeTime
+
+	unsigned := false
+	seen := ce.seen
+	if err := verifyAnnouncement(device); err == errUnsigned && acceptUnsigned {
+		// An unsigned announcement doesn't replace a signed one that's
+		// still valid.
+		if valid && !ce.Unsigned {
+			l.Debugf("discover: Ignoring unsigned announcement from %s for %s", src, device.ID)
+			return false
+		}
+		unsigned = true
+	} else if err != nil {
+		l.Debugf("discover: Rejecting announcement from %s for %s: %v", src, device.ID, err)
+		return false
+	} else if !seen.accept(device) {
+		l.Debugf("discover: Rejecting replayed announcement from %s for %s", src, device.ID)
+		return false
+	}
+
 	// Remember whether we already had a valid cache entry for this device.
 	// If the instance ID has changed the remote device has restarted since
 	// we last heard from it, so we should treat it as a new device.
 
-	ce, existsAlready := c.Get(device.ID)
-	isNewDevice := !existsAlready || time.Since(ce.when) > CacheLifeTime || ce.instanceID != device.InstanceID
+	isNewDevice := !valid || ce.instanceID != device.InstanceID
 
 	// Any empty or unspecified addresses should be set to the source address
-	// of the announcement. We also skip any addresses we can't parse.
+	// of the announcement. We also skip any addresses we can't parse. The
+	// source address is not covered by the signature, so addresses built
+	// from it are not authenticated.
 
 	l.Debugln("discover: Registering addresses for", device.ID)
 	var validAddresses []string
+	unauthenticated := unsigned
 	for _, addr := range device.Addresses {
 		u, err := url.Parse(addr)
 		if err != nil {
@@ -259,6 +301,7 @@ func (c *localClient) registerDevice(src net.Addr, device Announce) bool {
 				continue
 			}
 			u.Host = net.JoinHostPort(host, strconv.Itoa(tcpAddr.Port))
+			unauthenticated = true
 			l.Debugf("discover: Reconstructed URL is %#v", u)
 			validAddresses = append(validAddresses, u.String())
 			l.Debugf("discover: Replaced address %v in %s to get %s", tcpAddr.IP, addr, u.String())
@@ -269,16 +312,21 @@ func (c *localClient) registerDevice(src net.Addr, device Announce) bool {
 	}
 
 	c.Set(device.ID, CacheEntry{
-		Addresses:  validAddresses,
-		when:       time.Now(),
-		found:      true,
-		instanceID: device.InstanceID,
+		Addresses:       validAddresses,
+		when:            time.Now(),
+		found:           true,
+		instanceID:      device.InstanceID,
+		seen:            seen,
+		Unsigned:        unsigned,
+		Unauthenticated: unauthenticated,
 	})
 
 	if isNewDevice {
-		c.evLogger.Log(events.DeviceDiscovered, map[string]interface{}{
-			"device": device.ID.String(),
-			"addrs":  validAddresses,
+		evLogger.Log(events.DeviceDiscovered, map[string]interface{}{
+			"device":          device.ID.String(),
+			"addrs":           validAddresses,
+			"unsigned":        unsigned,
+			"unauthenticated": unauthenticated,
 		})
 	}
 
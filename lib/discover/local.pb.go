@@ -25,9 +25,12 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type Announce struct {
-	ID         github_com_syncthing_syncthing_lib_protocol.DeviceID `protobuf:"bytes,1,opt,name=id,proto3,customtype=github.com/syncthing/syncthing/lib/protocol.DeviceID" json:"id"`
-	Addresses  []string                                             `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
-	InstanceID int64                                                `protobuf:"varint,3,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	ID          github_com_syncthing_syncthing_lib_protocol.DeviceID `protobuf:"bytes,1,opt,name=id,proto3,customtype=github.com/syncthing/syncthing/lib/protocol.DeviceID" json:"id"`
+	Addresses   []string                                             `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	InstanceID  int64                                                `protobuf:"varint,3,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Certificate []byte                                               `protobuf:"bytes,4,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	Signature   []byte                                               `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	Timestamp   int64                                                `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 }
 
 func (m *Announce) Reset()         { *m = Announce{} }
@@ -70,23 +73,26 @@ func init() {
 func init() { proto.RegisterFile("local.proto", fileDescriptor_aaf1a48d01603033) }
 
 var fileDescriptor_aaf1a48d01603033 = []byte{
-	// 252 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x8e, 0x31, 0x4e, 0x84, 0x40,
-	0x14, 0x86, 0x67, 0x20, 0x31, 0xbb, 0xb3, 0xc6, 0x82, 0x8a, 0x18, 0x33, 0x10, 0x6d, 0xa8, 0xa0,
-	0xd0, 0x0b, 0x48, 0x68, 0xa6, 0xe5, 0x02, 0x06, 0x66, 0x46, 0xf6, 0x25, 0x38, 0x6f, 0xc3, 0xc0,
-	0x26, 0xde, 0xc2, 0x23, 0x78, 0x01, 0xef, 0x41, 0xb9, 0xa5, 0xb1, 0x20, 0x3a, 0x5c, 0xc4, 0x04,
-	0x34, 0xda, 0x7d, 0xef, 0xcb, 0x97, 0xbc, 0x9f, 0xed, 0x5a, 0x94, 0x55, 0x9b, 0x1e, 0x3a, 0xec,
-	0x31, 0xd8, 0x28, 0xb0, 0x12, 0x8f, 0xba, 0xbb, 0xbc, 0xe9, 0xf4, 0x01, 0x6d, 0xb6, 0xe8, 0x7a,
-	0x78, 0xcc, 0x1a, 0x6c, 0x70, 0x39, 0x16, 0x5a, 0xf3, 0xeb, 0x37, 0xca, 0x36, 0xf7, 0xc6, 0xe0,
-	0x60, 0xa4, 0x0e, 0x4a, 0xe6, 0x81, 0x0a, 0x69, 0x4c, 0x93, 0xf3, 0x3c, 0x1f, 0xa7, 0x88, 0x7c,
-	0x4c, 0xd1, 0x5d, 0x03, 0xfd, 0x7e, 0xa8, 0x53, 0x89, 0x4f, 0x99, 0x7d, 0x36, 0xb2, 0xdf, 0x83,
-	0x69, 0xfe, 0x51, 0x0b, 0xf5, 0xfa, 0x42, 0x62, 0x9b, 0x16, 0xfa, 0x08, 0x52, 0x8b, 0xc2, 0x4d,
-	0x91, 0x27, 0x8a, 0xd2, 0x03, 0x15, 0x5c, 0xb1, 0x6d, 0xa5, 0x54, 0xa7, 0xad, 0xd5, 0x36, 0xf4,
-	0x62, 0x3f, 0xd9, 0x96, 0x7f, 0x22, 0xc8, 0xd8, 0x0e, 0x8c, 0xed, 0x2b, 0x23, 0xf5, 0x03, 0xa8,
-	0xd0, 0x8f, 0x69, 0xe2, 0xe7, 0x17, 0x6e, 0x8a, 0x98, 0xf8, 0xd1, 0xa2, 0x28, 0xd9, 0x6f, 0x22,
-	0x54, 0x1e, 0x8f, 0x5f, 0x9c, 0x8c, 0x8e, 0xd3, 0x93, 0xe3, 0xf4, 0xd3, 0x71, 0xf2, 0x32, 0x73,
-	0xf2, 0x3a, 0x73, 0x7a, 0x9a, 0x39, 0x79, 0x9f, 0x39, 0xa9, 0xcf, 0x96, 0x35, 0xb7, 0xdf, 0x01,
-	0x00, 0x00, 0xff, 0xff, 0xbc, 0x46, 0xaf, 0x1d, 0x16, 0x01, 0x00, 0x00,
+	// 295 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x8f, 0x31, 0x4e, 0xf3, 0x30,
+	0x18, 0x86, 0x93, 0xf4, 0xff, 0xab, 0xd6, 0x45, 0x0c, 0x99, 0x22, 0x84, 0x9c, 0x08, 0x96, 0x4e,
+	0xcd, 0x00, 0x17, 0x20, 0xca, 0x92, 0xd5, 0x17, 0x40, 0x8e, 0xfd, 0x35, 0xfd, 0xa4, 0xd4, 0xae,
+	0x6c, 0xa7, 0x12, 0x67, 0x60, 0xe1, 0x08, 0x1c, 0xa7, 0x63, 0x47, 0xc4, 0x10, 0x41, 0x72, 0x11,
+	0x94, 0x14, 0xd4, 0x6e, 0xaf, 0x9f, 0xf7, 0x95, 0x1e, 0x7f, 0x64, 0x51, 0x6b, 0xc1, 0xeb, 0xd5,
+	0xce, 0x68, 0xa7, 0xc3, 0x99, 0x44, 0x2b, 0xf4, 0x1e, 0xcc, 0xcd, 0xbd, 0x81, 0x9d, 0xb6, 0xe9,
+	0x88, 0xcb, 0x66, 0x9d, 0x56, 0xba, 0xd2, 0xe3, 0x63, 0x4c, 0xa7, 0xf9, 0xdd, 0x6b, 0x40, 0x66,
+	0x4f, 0x4a, 0xe9, 0x46, 0x09, 0x08, 0x19, 0x09, 0x50, 0x46, 0x7e, 0xe2, 0x2f, 0xaf, 0xb2, 0xec,
+	0xd0, 0xc6, 0xde, 0x67, 0x1b, 0x3f, 0x56, 0xe8, 0x36, 0x4d, 0xb9, 0x12, 0x7a, 0x9b, 0xda, 0x17,
+	0x25, 0xdc, 0x06, 0x55, 0x75, 0x91, 0x6a, 0x2c, 0x4f, 0x0a, 0xa1, 0xeb, 0x55, 0x0e, 0x7b, 0x14,
+	0x50, 0xe4, 0x5d, 0x1b, 0x07, 0x45, 0xce, 0x02, 0x94, 0xe1, 0x2d, 0x99, 0x73, 0x29, 0x0d, 0x58,
+	0x0b, 0x36, 0x0a, 0x92, 0xc9, 0x72, 0xce, 0xce, 0x20, 0x4c, 0xc9, 0x02, 0x95, 0x75, 0x5c, 0x09,
+	0x78, 0x46, 0x19, 0x4d, 0x12, 0x7f, 0x39, 0xc9, 0xae, 0xbb, 0x36, 0x26, 0xc5, 0x2f, 0x2e, 0x72,
+	0x46, 0xfe, 0x26, 0x85, 0x0c, 0x13, 0xb2, 0x10, 0x60, 0x1c, 0xae, 0x51, 0x70, 0x07, 0xd1, 0xbf,
+	0xe1, 0xaf, 0xec, 0x12, 0x0d, 0x42, 0x8b, 0x95, 0xe2, 0xae, 0x31, 0x10, 0xfd, 0x1f, 0xfb, 0x33,
+	0x18, 0x5a, 0x87, 0x5b, 0xb0, 0x8e, 0x6f, 0x77, 0xd1, 0x74, 0xd0, 0xb1, 0x33, 0xc8, 0x92, 0xc3,
+	0x37, 0xf5, 0x0e, 0x1d, 0xf5, 0x8f, 0x1d, 0xf5, 0xbf, 0x3a, 0xea, 0xbd, 0xf5, 0xd4, 0x7b, 0xef,
+	0xa9, 0x7f, 0xec, 0xa9, 0xf7, 0xd1, 0x53, 0xaf, 0x9c, 0x8e, 0xb7, 0x3e, 0xfc, 0x0c, 0x00, 0x3c,
+	0x73, 0xbe, 0xfc, 0x74, 0x01, 0x00, 0x00,
 }
 
 func (m *Announce) Marshal() (dAtA []byte, err error) {
@@ -109,6 +115,25 @@ func (m *Announce) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Timestamp != 0 {
+		i = encodeVarintLocal(dAtA, i, uint64(m.Timestamp))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Signature) > 0 {
+		i -= len(m.Signature)
+		copy(dAtA[i:], m.Signature)
+		i = encodeVarintLocal(dAtA, i, uint64(len(m.Signature)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.Certificate) > 0 {
+		i -= len(m.Certificate)
+		copy(dAtA[i:], m.Certificate)
+		i = encodeVarintLocal(dAtA, i, uint64(len(m.Certificate)))
+		i--
+		dAtA[i] = 0x22
+	}
 	if m.InstanceID != 0 {
 		i = encodeVarintLocal(dAtA, i, uint64(m.InstanceID))
 		i--
@@ -164,6 +189,17 @@ func (m *Announce) ProtoSize() (n int) {
 	if m.InstanceID != 0 {
 		n += 1 + sovLocal(uint64(m.InstanceID))
 	}
+	l = len(m.Certificate)
+	if l > 0 {
+		n += 1 + l + sovLocal(uint64(l))
+	}
+	l = len(m.Signature)
+	if l > 0 {
+		n += 1 + l + sovLocal(uint64(l))
+	}
+	if m.Timestamp != 0 {
+		n += 1 + sovLocal(uint64(m.Timestamp))
+	}
 	return n
 }
 
@@ -286,6 +322,93 @@ func (m *Announce) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Certificate", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLocal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthLocal
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLocal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Certificate = append(m.Certificate[:0], dAtA[iNdEx:postIndex]...)
+			if m.Certificate == nil {
+				m.Certificate = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Signature", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLocal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthLocal
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLocal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Signature = append(m.Signature[:0], dAtA[iNdEx:postIndex]...)
+			if m.Signature == nil {
+				m.Signature = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			m.Timestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLocal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Timestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLocal(dAtA[iNdEx:])
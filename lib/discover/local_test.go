@@ -8,15 +8,23 @@ package discover
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"io/ioutil"
 	"net"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/tlsutil"
 )
 
 func TestLocalInstanceID(t *testing.T) {
-	c, err := NewLocal(protocol.LocalDeviceID, ":0", &fakeAddressLister{}, events.NoopLogger)
+	c, err := NewLocal(protocol.LocalDeviceID, ":0", tls.Certificate{}, true, &fakeAddressLister{}, events.NoopLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -39,7 +47,7 @@ func TestLocalInstanceID(t *testing.T) {
 }
 
 func TestLocalInstanceIDShouldTriggerNew(t *testing.T) {
-	c, err := NewLocal(protocol.LocalDeviceID, ":0", &fakeAddressLister{}, events.NoopLogger)
+	c, err := NewLocal(protocol.LocalDeviceID, ":0", tls.Certificate{}, true, &fakeAddressLister{}, events.NoopLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -87,3 +95,158 @@ func TestLocalInstanceIDShouldTriggerNew(t *testing.T) {
 		t.Fatal("new instance ID should be new")
 	}
 }
+
+func TestLocalSignedAnnouncements(t *testing.T) {
+	cert := newTestCertificate(t)
+	id := protocol.NewDeviceID(cert.Certificate[0])
+
+	c, err := NewLocal(id, ":0", cert, false, &fakeAddressLister{}, events.NoopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, ok := c.(*localClient).announcementPkt(1, nil)
+	if !ok {
+		t.Fatal("unexpectedly not ok")
+	}
+	var signed Announce
+	if err := signed.Unmarshal(msg[4:]); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := Announce{ID: id, Addresses: signed.Addresses, InstanceID: 1}
+	tampered := signed
+	tampered.Addresses = []string{"tcp://192.0.2.42:22000"}
+
+	src := &net.UDPAddr{IP: []byte{10, 20, 30, 40}, Port: 50}
+
+	c, err = NewLocal(protocol.LocalDeviceID, ":0", tls.Certificate{}, false, &fakeAddressLister{}, events.NoopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strict := c.(*localClient)
+	if strict.registerDevice(src, tampered) {
+		t.Error("tampered announcement should be rejected")
+	}
+	if strict.registerDevice(src, unsigned) {
+		t.Error("unsigned announcement should be rejected")
+	}
+	if !strict.registerDevice(src, signed) {
+		t.Error("signed announcement should be accepted")
+	}
+	// The unspecified address was completed from the source address.
+	if ce, _ := strict.Get(id); ce.Unsigned || !ce.Unauthenticated {
+		t.Error("addresses from the source address should be marked unauthenticated, but not unsigned")
+	}
+
+	c, err = NewLocal(protocol.LocalDeviceID, ":0", tls.Certificate{}, true, &fakeAddressLister{}, events.NoopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compat := c.(*localClient)
+	if compat.registerDevice(src, tampered) {
+		t.Error("tampered announcement should be rejected in compatibility mode")
+	}
+	if !compat.registerDevice(src, unsigned) {
+		t.Error("unsigned announcement should be accepted in compatibility mode")
+	}
+	if ce, _ := compat.Get(id); !ce.Unsigned || !ce.Unauthenticated {
+		t.Error("unsigned announcement should be marked")
+	}
+
+	// An unsigned announcement doesn't replace a signed one, even when
+	// some of its addresses were completed from the source address.
+	compat.registerDevice(src, signed)
+	if ce, _ := compat.Get(id); ce.Unsigned {
+		t.Error("signed announcement should not be marked unsigned")
+	}
+	unsigned.InstanceID = 2
+	unsigned.Addresses = []string{"tcp://192.0.2.42:22000"}
+	if compat.registerDevice(src, unsigned) {
+		t.Error("unsigned announcement should not replace a signed one")
+	}
+	if ce, _ := compat.Get(id); ce.Unsigned || len(ce.Addresses) != len(signed.Addresses) {
+		t.Errorf("unexpected cache entry %+v", ce)
+	}
+}
+
+func TestLocalReplayedAnnouncements(t *testing.T) {
+	cert := newTestCertificate(t)
+	id := protocol.NewDeviceID(cert.Certificate[0])
+
+	c, err := NewLocal(protocol.LocalDeviceID, ":0", tls.Certificate{}, false, &fakeAddressLister{}, events.NoopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := c.(*localClient)
+	src := &net.UDPAddr{IP: []byte{10, 20, 30, 40}, Port: 50}
+
+	sign := func(instanceID int64) Announce {
+		t.Helper()
+		pkt := Announce{ID: id, Addresses: []string{"tcp://192.168.0.1:22000"}, InstanceID: instanceID}
+		if err := signAnnouncement(&pkt, cert); err != nil {
+			t.Fatal(err)
+		}
+		return pkt
+	}
+	first := sign(1)
+	second := sign(1)
+	restarted := sign(2)
+
+	// Returns whether the announcement updated the cache entry.
+	register := func(pkt Announce) bool {
+		if ce, ok := lc.Get(id); ok {
+			ce.when = time.Time{}
+			lc.Set(id, ce)
+		}
+		lc.registerDevice(src, pkt)
+		ce, _ := lc.Get(id)
+		return !ce.when.IsZero()
+	}
+	if !register(first) {
+		t.Fatal("first announcement should be accepted")
+	}
+	if register(first) {
+		t.Error("replayed announcement should be rejected")
+	}
+	if !register(second) {
+		t.Error("later announcement should be accepted")
+	}
+	if register(first) {
+		t.Error("earlier announcement should be rejected")
+	}
+	if !register(restarted) {
+		t.Error("announcement of a new instance should be accepted")
+	}
+	if register(second) {
+		t.Error("announcement of a previous instance should be rejected")
+	}
+
+	// Timestamps are only compared with those of the same instance, so a
+	// clock far off doesn't matter.
+	skewed := Announce{ID: id, Addresses: []string{"tcp://192.168.0.1:22000"}, InstanceID: 3, Timestamp: time.Now().Add(-24 * time.Hour).UnixNano(), Certificate: cert.Certificate[0]}
+	bs, err := skewed.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(bs)
+	skewed.Signature, err = cert.PrivateKey.(crypto.Signer).Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !register(skewed) {
+		t.Error("announcement with a skewed clock should be accepted")
+	}
+}
+
+func newTestCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "syncthing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert, err := tlsutil.NewCertificate(dir+"/cert.pem", dir+"/key.pem", "syncthing", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
@@ -0,0 +1,520 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thejerf/suture"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/syncthing/syncthing/lib/util"
+)
+
+// The mDNS client announces the device as an instance of the DNS-SD service
+// _syncthing._tcp, named by its device ID, with its addresses in the TXT
+// record. It browses for the service to find the other devices, answers
+// their queries and picks up their announcements. Networks that block the
+// broadcasts and multicasts of the other local discovery often let mDNS
+// through.
+
+const (
+	mdnsService     = "_syncthing._tcp.local."
+	mdnsIPv4Addr    = "224.0.0.251:5353"
+	mdnsIPv6Addr    = "[ff02::fb]:5353"
+	mdnsMaxPacket   = 9000
+	mdnsMaxTXTData  = 240         // bytes of data in a TXT string, leaving room for the key
+	mdnsMinInterval = time.Second // between announcements in response to queries
+	mdnsCacheFlush  = 1 << 15     // set in the class of unique records
+)
+
+var errNoMulticastInterfaces = errors.New("no multicast interfaces available")
+
+type mdnsClient struct {
+	*suture.Supervisor
+	myID           protocol.DeviceID
+	cert           tls.Certificate
+	acceptUnsigned bool
+	addrList       AddressLister
+	evLogger       events.Logger
+	instanceID     int64
+	services       []util.ServiceWithError
+
+	*cache
+}
+
+// NewMDNS returns an mDNS/DNS-SD discovery client. Like the other local
+// discovery, its announcements are signed with the certificate key, and
+// unsigned ones are only accepted when acceptUnsigned is set.
+func NewMDNS(id protocol.DeviceID, cert tls.Certificate, acceptUnsigned bool, addrList AddressLister, evLogger events.Logger) FinderService {
+	c := &mdnsClient{
+		Supervisor: suture.New("mdns", suture.Spec{
+			// As for the beacons, an error to open a socket is usually
+			// permanent or takes a while to get solved.
+			FailureThreshold: 2,
+			FailureBackoff:   60 * time.Second,
+			Log: func(line string) {
+				l.Debugln(line)
+			},
+			PassThroughPanics: true,
+		}),
+		myID:           id,
+		cert:           cert,
+		acceptUnsigned: acceptUnsigned,
+		addrList:       addrList,
+		evLogger:       evLogger,
+		instanceID:     rand.Int63(),
+		cache:          newCache(),
+	}
+
+	for _, addr := range []string{mdnsIPv4Addr, mdnsIPv6Addr} {
+		addr := addr
+		svc := util.AsServiceWithError(func(ctx context.Context) error {
+			return c.serve(ctx, addr)
+		}, fmt.Sprintf("%s/%s", c, addr))
+		c.services = append(c.services, svc)
+		c.Add(svc)
+	}
+
+	return c
+}
+
+// Lookup returns a list of addresses the device is available at.
+func (c *mdnsClient) Lookup(_ context.Context, device protocol.DeviceID) (addresses []string, err error) {
+	if cache, ok := c.Get(device); ok {
+		if time.Since(cache.when) < CacheLifeTime {
+			addresses = cache.Addresses
+		}
+	}
+
+	return
+}
+
+func (c *mdnsClient) String() string {
+	return "mDNS local"
+}
+
+// Error returns an error when mDNS works on neither IPv4 nor IPv6.
+func (c *mdnsClient) Error() error {
+	var err error
+	for _, svc := range c.services {
+		if err = svc.Error(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *mdnsClient) serve(ctx context.Context, addr string) error {
+	conn, err := listenMulticast(addr)
+	if err != nil {
+		l.Debugln(c, err)
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	announce := make(chan struct{}, 1)
+	go c.sendAnnouncements(ctx, conn, announce)
+
+	buf := make([]byte, mdnsMaxPacket)
+	for {
+		n, src, err := conn.ReadFrom(buf)
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		if err != nil {
+			l.Debugln(c, err)
+			return err
+		}
+
+		query, newDevice := c.handlePacket(buf[:n], src)
+		if query || newDevice {
+			// Answer the query, or announce ourselves right away to the
+			// new device.
+			select {
+			case announce <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// sendAnnouncements browses for the service, both at startup and
+// periodically, which makes the other devices announce themselves. Our
+// announcement is sent at startup and whenever asked for.
+func (c *mdnsClient) sendAnnouncements(ctx context.Context, conn *multicastConn, announce <-chan struct{}) {
+	ticker := time.NewTicker(BroadcastInterval)
+	defer ticker.Stop()
+
+	var lastAnnounce time.Time
+	c.send(conn, c.announcementMsg)
+	c.send(conn, queryMsg)
+	for {
+		select {
+		case <-ticker.C:
+			c.send(conn, queryMsg)
+		case <-announce:
+			if time.Since(lastAnnounce) < mdnsMinInterval {
+				continue
+			}
+			lastAnnounce = time.Now()
+			c.send(conn, c.announcementMsg)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *mdnsClient) send(conn *multicastConn, msgFn func() ([]byte, error)) {
+	msg, err := msgFn()
+	if err != nil {
+		l.Debugln(c, "creating message:", err)
+		return
+	}
+	if msg == nil {
+		// Nothing to send
+		return
+	}
+	if err := conn.send(msg); err != nil {
+		l.Debugln(c, err)
+	}
+}
+
+// announcementMsg returns the mDNS response announcing our service
+// instance, or nil if there are no addresses to announce.
+func (c *mdnsClient) announcementMsg() ([]byte, error) {
+	addrs := c.addrList.AllAddresses()
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	service, err := dnsmessage.NewName(mdnsService)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := dnsmessage.NewName(c.myID.String() + "." + mdnsService)
+	if err != nil {
+		return nil, err
+	}
+	target, err := dnsmessage.NewName(mdnsTarget(c.myID))
+	if err != nil {
+		return nil, err
+	}
+
+	pkt := Announce{ID: c.myID, InstanceID: c.instanceID}
+	var port uint16
+	for _, addr := range addrs {
+		if len(addr) > mdnsMaxTXTData {
+			// Doesn't fit in a TXT string
+			continue
+		}
+		pkt.Addresses = append(pkt.Addresses, addr)
+		if port != 0 {
+			continue
+		}
+		if u, err := url.Parse(addr); err == nil {
+			if p, err := strconv.Atoi(u.Port()); err == nil {
+				port = uint16(p)
+			}
+		}
+	}
+	if err := signAnnouncement(&pkt, c.cert); err != nil {
+		l.Debugln("discover: Sending unsigned mDNS announcement:", err)
+	}
+
+	txt := []string{"txtvers=1", "instance=" + strconv.FormatInt(pkt.InstanceID, 10), "time=" + strconv.FormatInt(pkt.Timestamp, 10)}
+	for _, addr := range pkt.Addresses {
+		txt = append(txt, "addr="+addr)
+	}
+	txt = append(txt, txtChunks("cert=", pkt.Certificate)...)
+	txt = append(txt, txtChunks("sig=", pkt.Signature)...)
+
+	ttl := uint32(CacheLifeTime / time.Second)
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	b.EnableCompression()
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	if err := b.PTRResource(dnsmessage.ResourceHeader{Name: service, Class: dnsmessage.ClassINET, TTL: ttl}, dnsmessage.PTRResource{PTR: instance}); err != nil {
+		return nil, err
+	}
+	uniqueClass := dnsmessage.ClassINET | mdnsCacheFlush
+	if err := b.SRVResource(dnsmessage.ResourceHeader{Name: instance, Class: uniqueClass, TTL: ttl}, dnsmessage.SRVResource{Port: port, Target: target}); err != nil {
+		return nil, err
+	}
+	if err := b.TXTResource(dnsmessage.ResourceHeader{Name: instance, Class: uniqueClass, TTL: ttl}, dnsmessage.TXTResource{TXT: txt}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// queryMsg returns the mDNS query browsing for the service.
+func queryMsg() ([]byte, error) {
+	service, err := dnsmessage.NewName(mdnsService)
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: service, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// mdnsTarget returns the host name of the SRV record, which the host's own
+// mDNS responder is expected to resolve.
+func mdnsTarget(id protocol.DeviceID) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = id.Short().String()
+	} else if i := strings.IndexByte(host, '.'); i >= 0 {
+		host = host[:i]
+	}
+	return host + ".local."
+}
+
+// handlePacket registers the devices announced in an mDNS response, and
+// returns whether the packet was a query for the service and whether a
+// new device was found.
+func (c *mdnsClient) handlePacket(buf []byte, src net.Addr) (query, newDevice bool) {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(buf)
+	if err != nil {
+		l.Debugf("discover: Failed to parse mDNS packet from %s: %v", src, err)
+		return false, false
+	}
+
+	if !hdr.Response {
+		questions, err := p.AllQuestions()
+		if err != nil {
+			return false, false
+		}
+		for _, q := range questions {
+			if (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) && strings.EqualFold(q.Name.String(), mdnsService) {
+				l.Debugf("discover: Received mDNS query from %s", src)
+				return true, false
+			}
+		}
+		return false, false
+	}
+
+	if err := p.SkipAllQuestions(); err != nil {
+		return false, false
+	}
+	for _, pkt := range parseAnnouncements(&p) {
+		l.Debugf("discover: Received mDNS announcement from %s for %s", src, pkt.ID)
+		if pkt.ID != c.myID && registerDevice(c.cache, c.evLogger, src, pkt, c.acceptUnsigned) {
+			newDevice = true
+		}
+	}
+	return false, newDevice
+}
+
+// parseAnnouncements returns the service instances in the answer and
+// additional sections of a response.
+func parseAnnouncements(p *dnsmessage.Parser) []Announce {
+	var announcements []Announce
+	parseSection := func(header func() (dnsmessage.ResourceHeader, error), skip func() error) error {
+		for {
+			hdr, err := header()
+			if err == dnsmessage.ErrSectionDone {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if hdr.Type != dnsmessage.TypeTXT {
+				if err := skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			txt, err := p.TXTResource()
+			if err != nil {
+				return err
+			}
+			if pkt, ok := parseInstance(hdr.Name.String(), txt.TXT); ok {
+				announcements = append(announcements, pkt)
+			}
+		}
+	}
+
+	if parseSection(p.AnswerHeader, p.SkipAnswer) != nil || p.SkipAllAuthorities() != nil {
+		return announcements
+	}
+	_ = parseSection(p.AdditionalHeader, p.SkipAdditional)
+	return announcements
+}
+
+// parseInstance returns the announcement for the service instance with the
+// given name and TXT record.
+func parseInstance(name string, txt []string) (Announce, bool) {
+	if len(name) <= len(mdnsService)+1 || !strings.EqualFold(name[len(name)-len(mdnsService)-1:], "."+mdnsService) {
+		return Announce{}, false
+	}
+	id, err := protocol.DeviceIDFromString(name[:len(name)-len(mdnsService)-1])
+	if err != nil {
+		return Announce{}, false
+	}
+
+	pkt := Announce{ID: id}
+	var cert, sig string
+	for _, s := range txt {
+		switch {
+		case strings.HasPrefix(s, "addr="):
+			pkt.Addresses = append(pkt.Addresses, strings.TrimPrefix(s, "addr="))
+		case strings.HasPrefix(s, "instance="):
+			pkt.InstanceID, _ = strconv.ParseInt(strings.TrimPrefix(s, "instance="), 10, 64)
+		case strings.HasPrefix(s, "time="):
+			pkt.Timestamp, _ = strconv.ParseInt(strings.TrimPrefix(s, "time="), 10, 64)
+		case strings.HasPrefix(s, "cert="):
+			cert += strings.TrimPrefix(s, "cert=")
+		case strings.HasPrefix(s, "sig="):
+			sig += strings.TrimPrefix(s, "sig=")
+		}
+	}
+	// Undecodable ones leave the announcement unsigned.
+	pkt.Certificate, _ = base64.StdEncoding.DecodeString(cert)
+	pkt.Signature, _ = base64.StdEncoding.DecodeString(sig)
+	return pkt, len(pkt.Addresses) > 0
+}
+
+// txtChunks returns the base64 encoded data split over TXT strings with
+// the given prefix, to be concatenated in order.
+func txtChunks(prefix string, data []byte) []string {
+	enc := base64.StdEncoding.EncodeToString(data)
+	var chunks []string
+	for len(enc) > 0 {
+		n := mdnsMaxTXTData
+		if n > len(enc) {
+			n = len(enc)
+		}
+		chunks = append(chunks, prefix+enc[:n])
+		enc = enc[n:]
+	}
+	return chunks
+}
+
+// A multicastConn sends to and receives from a multicast group on all
+// interfaces.
+type multicastConn struct {
+	net.PacketConn
+	group *net.UDPAddr
+	v4    *ipv4.PacketConn
+	v6    *ipv6.PacketConn
+}
+
+// listenMulticast joins the multicast group on all interfaces. Packets are
+// sent from the group's port, which as a multicast listener may be shared
+// with other mDNS responders on the host.
+func listenMulticast(addr string) (*multicastConn, error) {
+	group, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	network := "udp6"
+	if group.IP.To4() != nil {
+		network = "udp4"
+	}
+	conn, err := net.ListenPacket(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &multicastConn{PacketConn: conn, group: group}
+	if network == "udp4" {
+		c.v4 = ipv4.NewPacketConn(conn)
+	} else {
+		c.v6 = ipv6.NewPacketConn(conn)
+	}
+
+	joined := 0
+	for _, intf := range multicastInterfaces() {
+		intf := intf
+		if c.v4 != nil {
+			err = c.v4.JoinGroup(&intf, &net.UDPAddr{IP: group.IP})
+		} else {
+			err = c.v6.JoinGroup(&intf, &net.UDPAddr{IP: group.IP})
+		}
+		if err != nil {
+			l.Debugln(network, "join", intf.Name, "failed:", err)
+			continue
+		}
+		joined++
+	}
+	if joined == 0 {
+		conn.Close()
+		return nil, errNoMulticastInterfaces
+	}
+	return c, nil
+}
+
+func (c *multicastConn) send(msg []byte) error {
+	var err error
+	sent := 0
+	for _, intf := range multicastInterfaces() {
+		intf := intf
+		if c.v4 != nil {
+			if err = c.v4.SetMulticastInterface(&intf); err == nil {
+				_, err = c.v4.WriteTo(msg, nil, c.group)
+			}
+		} else {
+			_, err = c.v6.WriteTo(msg, &ipv6.ControlMessage{IfIndex: intf.Index}, c.group)
+		}
+		if err != nil {
+			l.Debugln(err, "on write to", c.group, intf.Name)
+			continue
+		}
+		l.Debugf("sent %d bytes to %v on %s", len(msg), c.group, intf.Name)
+		sent++
+	}
+	if sent == 0 && err == nil {
+		err = errNoMulticastInterfaces
+	}
+	if sent == 0 {
+		return err
+	}
+	return nil
+}
+
+func multicastInterfaces() []net.Interface {
+	intfs, err := net.Interfaces()
+	if err != nil {
+		l.Debugln(err)
+		return nil
+	}
+	var res []net.Interface
+	for _, intf := range intfs {
+		if intf.Flags&net.FlagUp != 0 && intf.Flags&net.FlagMulticast != 0 {
+			res = append(res, intf)
+		}
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestMDNSAnnouncement(t *testing.T) {
+	cert := newTestCertificate(t)
+	id := protocol.NewDeviceID(cert.Certificate[0])
+	announcer := NewMDNS(id, cert, false, &fakeAddressLister{}, events.NoopLogger).(*mdnsClient)
+	c := NewMDNS(protocol.LocalDeviceID, tls.Certificate{}, false, &fakeAddressLister{}, events.NoopLogger).(*mdnsClient)
+	src := &net.UDPAddr{IP: []byte{10, 20, 30, 40}, Port: 5353}
+
+	msg, err := announcer.announcementMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, newDevice := c.handlePacket(msg, src)
+	if query || !newDevice {
+		t.Fatalf("Expected an announcement of a new device, got query %v, new %v", query, newDevice)
+	}
+	if _, newDevice := c.handlePacket(msg, src); newDevice {
+		t.Error("Expected the repeated announcement not to be of a new device")
+	}
+
+	addrs, _ := c.Lookup(context.Background(), id)
+	expected := []string{"tcp://10.20.30.40:22000", "tcp://192.168.0.1:22000"}
+	if len(addrs) != len(expected) {
+		t.Fatalf("Expected addresses %v, got %v", expected, addrs)
+	}
+	for i := range addrs {
+		if addrs[i] != expected[i] {
+			t.Errorf("Expected addresses %v, got %v", expected, addrs)
+		}
+	}
+
+	// Our own announcements are ignored.
+	if _, newDevice := announcer.handlePacket(msg, src); newDevice {
+		t.Error("Expected our own announcement to be ignored")
+	}
+
+	msg, err = queryMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query, _ := c.handlePacket(msg, src); !query {
+		t.Error("Expected a query for the service")
+	}
+}
+
+func TestMDNSParseInstance(t *testing.T) {
+	id := protocol.DeviceID{10, 20, 30, 40, 50, 60, 70, 80, 90}
+
+	cases := []struct {
+		name string
+		ok   bool
+	}{
+		{id.String() + "." + mdnsService, true},
+		{id.String() + "._SYNCTHING._TCP.LOCAL.", true},
+		{id.String() + "._other._tcp.local.", false},
+		{"printer." + mdnsService, false},
+		{mdnsService, false},
+	}
+
+	for _, tc := range cases {
+		pkt, ok := parseInstance(tc.name, []string{"txtvers=1", "addr=tcp://0.0.0.0:22000"})
+		if ok != tc.ok {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.ok, ok)
+		}
+		if ok && pkt.ID != id {
+			t.Errorf("%s: expected device %v, got %v", tc.name, id, pkt.ID)
+		}
+	}
+
+	if _, ok := parseInstance(id.String()+"."+mdnsService, []string{"txtvers=1"}); ok {
+		t.Error("Expected an instance without addresses to be ignored")
+	}
+}
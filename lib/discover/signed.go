@@ -0,0 +1,162 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// Local announcements carry the device certificate and a signature of the
+// announcement by its key. As the device ID is the hash of the
+// certificate, a device can't be impersonated without its key. The
+// signature covers the instance ID, which is random for each run of the
+// device, and a timestamp which increases within the run. A receiver only
+// accepts a signed announcement with a later timestamp than the previous
+// one of the same instance, or from a new instance, so that captured
+// announcements can't be replayed. Clocks are only compared with
+// themselves, so devices with a wrong clock still find each other. Older
+// devices send unsigned announcements, which are only accepted in
+// compatibility mode and are marked as such in the cache.
+//
+// A signature only vouches for the addresses as announced. Unspecified
+// addresses are completed from the source of the packet, which anyone
+// relaying it controls, so those are marked as not authenticated.
+
+// The number of earlier instances of a device remembered, whose
+// announcements are no longer accepted.
+const maxPreviousInstances = 8
+
+var (
+	errUnsigned       = errors.New("announcement is not signed")
+	errCertMismatch   = errors.New("certificate does not match the device ID")
+	errUnsupportedKey = errors.New("unsupported key type")
+)
+
+var (
+	lastTimestamp    int64
+	lastTimestampMut = sync.NewMutex()
+)
+
+// announcementTimestamp returns the current time in nanoseconds, or if the
+// clock has gone back, a timestamp just after the previous one.
+func announcementTimestamp() int64 {
+	lastTimestampMut.Lock()
+	defer lastTimestampMut.Unlock()
+	ts := time.Now().UnixNano()
+	if ts <= lastTimestamp {
+		ts = lastTimestamp + 1
+	}
+	lastTimestamp = ts
+	return ts
+}
+
+// signAnnouncement adds a timestamp, the certificate and the signature to
+// the announcement.
+func signAnnouncement(pkt *Announce, cert tls.Certificate) error {
+	pkt.Timestamp = announcementTimestamp()
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok || len(cert.Certificate) == 0 {
+		return errUnsupportedKey
+	}
+	pkt.Certificate = cert.Certificate[0]
+	pkt.Signature = nil
+	bs, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(bs)
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	pkt.Signature = sig
+	return nil
+}
+
+// verifyAnnouncement checks that the announcement is signed by the key of
+// the certificate of the announced device. It returns errUnsigned for
+// announcements without a signature.
+func verifyAnnouncement(pkt Announce) error {
+	if len(pkt.Certificate) == 0 || len(pkt.Signature) == 0 {
+		return errUnsigned
+	}
+	if protocol.NewDeviceID(pkt.Certificate) != pkt.ID {
+		return errCertMismatch
+	}
+	cert, err := x509.ParseCertificate(pkt.Certificate)
+	if err != nil {
+		return err
+	}
+
+	var algo x509.SignatureAlgorithm
+	switch cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		algo = x509.ECDSAWithSHA256
+	case *rsa.PublicKey:
+		algo = x509.SHA256WithRSA
+	default:
+		return errUnsupportedKey
+	}
+
+	sig := pkt.Signature
+	pkt.Signature = nil
+	bs, err := pkt.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := cert.CheckSignature(algo, bs, sig); err != nil {
+		return fmt.Errorf("bad signature: %v", err)
+	}
+	return nil
+}
+
+// seenAnnouncements is what we know of the signed announcements accepted
+// from a device.
+type seenAnnouncements struct {
+	instanceID int64   // of the last one
+	timestamp  int64   // of the last one, zero if none
+	previous   []int64 // earlier instance IDs, most recent first
+}
+
+// accept records the verified announcement and returns true, or returns
+// false if it is a replay.
+func (s *seenAnnouncements) accept(pkt Announce) bool {
+	switch {
+	case s.timestamp == 0:
+	case pkt.InstanceID == s.instanceID:
+		if pkt.Timestamp <= s.timestamp {
+			return false
+		}
+	default:
+		for _, id := range s.previous {
+			if id == pkt.InstanceID {
+				return false
+			}
+		}
+		// A new slice, as the old one may be shared with a cache entry.
+		previous := append([]int64{s.instanceID}, s.previous...)
+		if len(previous) > maxPreviousInstances {
+			previous = previous[:maxPreviousInstances]
+		}
+		s.previous = previous
+	}
+	s.instanceID = pkt.InstanceID
+	s.timestamp = pkt.Timestamp
+	return true
+}
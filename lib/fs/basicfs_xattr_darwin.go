@@ -0,0 +1,12 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import "golang.org/x/sys/unix"
+
+// errNoXattr is returned for attributes that don't exist.
+var errNoXattr = unix.ENOATTR
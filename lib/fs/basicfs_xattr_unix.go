@@ -0,0 +1,167 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// +build linux darwin
+
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+	"golang.org/x/sys/unix"
+)
+
+func (f *BasicFilesystem) GetXattr(name string, xattrFilter XattrFilter) ([]protocol.Xattr, error) {
+	path, err := f.rooted(name)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := listXattr(path)
+	if err == unix.ENOTSUP {
+		return nil, ErrXattrsNotSupported
+	} else if err != nil {
+		return nil, fmt.Errorf("listing xattrs of %s: %w", name, err)
+	}
+
+	maxSingle := xattrFilter.GetMaxSingleEntrySize()
+	maxTotal := xattrFilter.GetMaxTotalSize()
+	res := make([]protocol.Xattr, 0, len(attrs))
+	var buf []byte
+	total := 0
+	for _, attr := range attrs {
+		var val []byte
+		val, buf, err = getXattr(path, attr, buf)
+		if err == errNoXattr {
+			// Removed since we listed it
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("reading xattr %q of %s: %w", attr, name, err)
+		}
+		size := len(attr) + len(val)
+		if maxSingle > 0 && size > maxSingle {
+			l.Debugf("%s: xattr %q is too large (%d > %d bytes), skipping", name, attr, size, maxSingle)
+			continue
+		}
+		if maxTotal > 0 && total+size > maxTotal {
+			l.Debugf("%s: xattrs exceed the total size limit of %d bytes, skipping the rest", name, maxTotal)
+			break
+		}
+		total += size
+		res = append(res, protocol.Xattr{Name: attr, Value: val})
+	}
+	return res, nil
+}
+
+// SetXattr makes the extended attributes of the file match the given ones.
+// Existing attributes that are not in the list are removed, unless they
+// exceed the single entry size limit, i.e. would not have been synced
+// anyway.
+func (f *BasicFilesystem) SetXattr(name string, xattrs []protocol.Xattr, xattrFilter XattrFilter) error {
+	path, err := f.rooted(name)
+	if err != nil {
+		return err
+	}
+	current, err := listXattr(path)
+	if err == unix.ENOTSUP {
+		return ErrXattrsNotSupported
+	} else if err != nil {
+		return fmt.Errorf("listing xattrs of %s: %w", name, err)
+	}
+
+	currentVals := make(map[string][]byte, len(current))
+	var buf []byte
+	for _, attr := range current {
+		var val []byte
+		val, buf, err = getXattr(path, attr, buf)
+		if err == errNoXattr {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("reading xattr %q of %s: %w", attr, name, err)
+		}
+		currentVals[attr] = val
+	}
+
+	wanted := make(map[string]struct{}, len(xattrs))
+	for _, x := range xattrs {
+		wanted[x.Name] = struct{}{}
+		if val, ok := currentVals[x.Name]; ok && bytes.Equal(val, x.Value) {
+			continue
+		}
+		if err := unix.Lsetxattr(path, x.Name, x.Value, 0); err != nil {
+			return fmt.Errorf("setting xattr %q on %s: %w", x.Name, name, err)
+		}
+	}
+
+	maxSingle := xattrFilter.GetMaxSingleEntrySize()
+	for attr, val := range currentVals {
+		if _, ok := wanted[attr]; ok {
+			continue
+		}
+		if maxSingle > 0 && len(attr)+len(val) > maxSingle {
+			continue
+		}
+		if err := unix.Lremovexattr(path, attr); err != nil && err != errNoXattr {
+			return fmt.Errorf("removing xattr %q from %s: %w", attr, name, err)
+		}
+	}
+	return nil
+}
+
+// listXattr returns the sorted names of the extended attributes of the
+// file at path.
+func listXattr(path string) ([]string, error) {
+	var buf []byte
+	for {
+		size, err := unix.Llistxattr(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		buf = make([]byte, size)
+		size, err = unix.Llistxattr(path, buf)
+		if err == unix.ERANGE {
+			// Attributes were added since we asked for the size
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		buf = buf[:size]
+		break
+	}
+
+	names := strings.Split(strings.TrimRight(string(buf), "\x00"), "\x00")
+	sort.Strings(names)
+	return names, nil
+}
+
+// getXattr returns the value of the given extended attribute, using buf as
+// scratch space. The returned value is a copy, while the possibly grown
+// buffer is returned for reuse.
+func getXattr(path, attr string, buf []byte) ([]byte, []byte, error) {
+	for {
+		size, err := unix.Lgetxattr(path, attr, buf)
+		if err == unix.ERANGE || (err == nil && len(buf) == 0 && size > 0) {
+			// The buffer is too small, or we only got the size back
+			size, err = unix.Lgetxattr(path, attr, nil)
+			if err != nil {
+				return nil, buf, err
+			}
+			buf = make([]byte, size)
+			continue
+		} else if err != nil {
+			return nil, buf, err
+		}
+		val := make([]byte, size)
+		copy(val, buf[:size])
+		return val, buf, nil
+	}
+}
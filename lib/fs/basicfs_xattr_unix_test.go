@@ -0,0 +1,107 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// +build linux darwin
+
+package fs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+	"golang.org/x/sys/unix"
+)
+
+type testXattrFilter struct {
+	maxSingle, maxTotal int
+}
+
+func (f testXattrFilter) GetMaxSingleEntrySize() int { return f.maxSingle }
+func (f testXattrFilter) GetMaxTotalSize() int       { return f.maxTotal }
+
+func TestXattrs(t *testing.T) {
+	fs, dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	fd, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Close()
+
+	if err := unix.Lsetxattr(path, "user.test", []byte("probe"), 0); err == unix.ENOTSUP || err == unix.EPERM {
+		t.Skip("extended attributes not supported:", err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	filter := testXattrFilter{maxSingle: 64, maxTotal: 256}
+	want := []protocol.Xattr{
+		{Name: "user.a", Value: []byte("first")},
+		{Name: "user.b", Value: []byte{}},
+		{Name: "user.c", Value: []byte("third")},
+	}
+	if err := fs.SetXattr("file", want, filter); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fs.GetXattr("file", filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !xattrsEqual(got, want) {
+		t.Errorf("got %v, expected %v (user.test should have been removed)", got, want)
+	}
+
+	// Attributes exceeding the single entry limit are neither returned nor
+	// removed.
+	large := bytes.Repeat([]byte("x"), 100)
+	if err := unix.Lsetxattr(path, "user.large", large, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err = fs.GetXattr("file", filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !xattrsEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+	if err := fs.SetXattr("file", want[:1], filter); err != nil {
+		t.Fatal(err)
+	}
+	got, err = fs.GetXattr("file", testXattrFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "user.a" || got[1].Name != "user.large" {
+		t.Errorf("unexpected xattrs after removal: %v", got)
+	}
+
+	// The total limit cuts off the remaining attributes.
+	got, err = fs.GetXattr("file", testXattrFilter{maxTotal: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "user.a" {
+		t.Errorf("unexpected xattrs with total limit: %v", got)
+	}
+}
+
+func xattrsEqual(a, b []protocol.Xattr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || !bytes.Equal(a[i].Value, b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,355 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	caseCacheTimeout    = time.Second
+	caseCacheMaxEntries = 1000
+)
+
+// ErrCaseConflict is returned by the case filesystem when the given name
+// refers to an existing file that has a name differing only in case, e.g.
+// "Readme.md" when "README.md" exists on a case insensitive filesystem.
+type ErrCaseConflict struct {
+	Given, Real string
+}
+
+func (e *ErrCaseConflict) Error() string {
+	return fmt.Sprintf(`given name "%v" differs from name in filesystem "%v"`, e.Given, e.Real)
+}
+
+func IsErrCaseConflict(err error) bool {
+	e := &ErrCaseConflict{}
+	return errors.As(err, &e)
+}
+
+// The caseFilesystem makes sure that the given names match the case of the
+// names in the underlying filesystem. On case insensitive filesystems an
+// operation on a name that differs only in case from an existing file
+// would otherwise silently operate on that file instead. Such operations
+// fail with an ErrCaseConflict. On case sensitive filesystems there are no
+// such conflicts and all operations pass through.
+type caseFilesystem struct {
+	Filesystem
+	cache *caseCache
+}
+
+// NewCaseFilesystem returns a filesystem that refuses operations on names
+// that differ only in case from existing files.
+func NewCaseFilesystem(fs Filesystem) Filesystem {
+	return &caseFilesystem{
+		Filesystem: fs,
+		cache:      newCaseCache(),
+	}
+}
+
+func (f *caseFilesystem) Chmod(name string, mode FileMode) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	return f.Filesystem.Chmod(name, mode)
+}
+
+func (f *caseFilesystem) Lchown(name string, uid, gid int) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	return f.Filesystem.Lchown(name, uid, gid)
+}
+
+func (f *caseFilesystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	return f.Filesystem.Chtimes(name, atime, mtime)
+}
+
+func (f *caseFilesystem) Create(name string) (File, error) {
+	if err := f.checkCase(name); err != nil {
+		return nil, err
+	}
+	defer f.cache.drop()
+	return f.Filesystem.Create(name)
+}
+
+func (f *caseFilesystem) CreateSymlink(target, name string) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	defer f.cache.drop()
+	return f.Filesystem.CreateSymlink(target, name)
+}
+
+func (f *caseFilesystem) DirNames(name string) ([]string, error) {
+	if err := f.checkCase(name); err != nil {
+		return nil, err
+	}
+	return f.Filesystem.DirNames(name)
+}
+
+func (f *caseFilesystem) Lstat(name string) (FileInfo, error) {
+	info, err := f.Filesystem.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkRealCase(name); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (f *caseFilesystem) Mkdir(name string, perm FileMode) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	defer f.cache.drop()
+	return f.Filesystem.Mkdir(name, perm)
+}
+
+func (f *caseFilesystem) MkdirAll(name string, perm FileMode) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	defer f.cache.drop()
+	return f.Filesystem.MkdirAll(name, perm)
+}
+
+func (f *caseFilesystem) Open(name string) (File, error) {
+	if err := f.checkCase(name); err != nil {
+		return nil, err
+	}
+	return f.Filesystem.Open(name)
+}
+
+func (f *caseFilesystem) OpenFile(name string, flags int, mode FileMode) (File, error) {
+	if err := f.checkCase(name); err != nil {
+		return nil, err
+	}
+	if flags&os.O_CREATE != 0 {
+		defer f.cache.drop()
+	}
+	return f.Filesystem.OpenFile(name, flags, mode)
+}
+
+func (f *caseFilesystem) ReadSymlink(name string) (string, error) {
+	if err := f.checkCase(name); err != nil {
+		return "", err
+	}
+	return f.Filesystem.ReadSymlink(name)
+}
+
+func (f *caseFilesystem) Remove(name string) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	defer f.cache.drop()
+	return f.Filesystem.Remove(name)
+}
+
+func (f *caseFilesystem) RemoveAll(name string) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	defer f.cache.drop()
+	return f.Filesystem.RemoveAll(name)
+}
+
+// Rename refuses to overwrite a file whose name differs only in case from
+// the new name, unless that file is the one being renamed, i.e. a case
+// only rename.
+func (f *caseFilesystem) Rename(oldname, newname string) error {
+	if err := f.checkCase(oldname); err != nil {
+		return err
+	}
+	if err := f.checkCase(newname); err != nil {
+		e := &ErrCaseConflict{}
+		if !errors.As(err, &e) || e.Real != filepath.Clean(oldname) {
+			return err
+		}
+	}
+	defer f.cache.drop()
+	return f.Filesystem.Rename(oldname, newname)
+}
+
+func (f *caseFilesystem) Stat(name string) (FileInfo, error) {
+	if err := f.checkCase(name); err != nil {
+		return nil, err
+	}
+	return f.Filesystem.Stat(name)
+}
+
+func (f *caseFilesystem) Walk(root string, walkFn WalkFunc) error {
+	if err := f.checkCase(root); err != nil {
+		return err
+	}
+	return f.Filesystem.Walk(root, walkFn)
+}
+
+func (f *caseFilesystem) Hide(name string) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	return f.Filesystem.Hide(name)
+}
+
+func (f *caseFilesystem) Unhide(name string) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	return f.Filesystem.Unhide(name)
+}
+
+func (f *caseFilesystem) GetXattr(name string, xattrFilter XattrFilter) ([]protocol.Xattr, error) {
+	if err := f.checkCase(name); err != nil {
+		return nil, err
+	}
+	return f.Filesystem.GetXattr(name, xattrFilter)
+}
+
+func (f *caseFilesystem) SetXattr(name string, xattrs []protocol.Xattr, xattrFilter XattrFilter) error {
+	if err := f.checkCase(name); err != nil {
+		return err
+	}
+	return f.Filesystem.SetXattr(name, xattrs, xattrFilter)
+}
+
+// checkCase returns an ErrCaseConflict if the given name, or the closest
+// of its parents that exists, exists with a different case.
+func (f *caseFilesystem) checkCase(name string) error {
+	name = filepath.Clean(name)
+	for name != "." && name != string(PathSeparator) {
+		if _, err := f.Filesystem.Lstat(name); err == nil {
+			return f.checkRealCase(name)
+		} else if !IsNotExist(err) {
+			return err
+		}
+		name = filepath.Dir(name)
+	}
+	return nil
+}
+
+// checkRealCase returns an ErrCaseConflict if the given existing name
+// differs from the name in the filesystem.
+func (f *caseFilesystem) checkRealCase(name string) error {
+	name = filepath.Clean(name)
+	if name == "." || name == string(PathSeparator) {
+		return nil
+	}
+	realName, err := f.realCase(name)
+	if IsNotExist(err) {
+		// Removed in the meantime.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if realName != name {
+		return &ErrCaseConflict{Given: name, Real: realName}
+	}
+	return nil
+}
+
+// realCase returns the name as it is in the filesystem, looking up each
+// path component in the listing of its parent directory.
+func (f *caseFilesystem) realCase(name string) (string, error) {
+	comps := strings.Split(strings.Trim(name, string(PathSeparator)), string(PathSeparator))
+	realComps := make([]string, 0, len(comps))
+	dir := "."
+	for _, comp := range comps {
+		realComp, err := f.cache.lookup(f.Filesystem, dir, comp)
+		if err != nil {
+			return "", err
+		}
+		realComps = append(realComps, realComp)
+		dir = filepath.Join(realComps...)
+	}
+	return filepath.Join(realComps...), nil
+}
+
+// The caseCache holds recent directory listings. Anything but an exact match
+// against a cached listing is confirmed against a fresh listing, so a stale
+// cache never causes a spurious conflict.
+type caseCache struct {
+	mut     sync.Mutex
+	entries map[string]*caseCacheEntry
+}
+
+type caseCacheEntry struct {
+	when  time.Time
+	names map[string]struct{}
+	lower map[string]string // lower case name -> name in the filesystem
+}
+
+func newCaseCache() *caseCache {
+	return &caseCache{
+		entries: make(map[string]*caseCacheEntry),
+	}
+}
+
+func (c *caseCache) lookup(fs Filesystem, dir, name string) (string, error) {
+	c.mut.Lock()
+	entry, ok := c.entries[dir]
+	c.mut.Unlock()
+	if ok && time.Since(entry.when) < caseCacheTimeout {
+		if _, ok := entry.names[name]; ok {
+			return name, nil
+		}
+	}
+
+	entry, err := c.load(fs, dir)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := entry.names[name]; ok {
+		return name, nil
+	}
+	if realName, ok := entry.lower[UnicodeLowercase(name)]; ok {
+		return realName, nil
+	}
+	return "", os.ErrNotExist
+}
+
+func (c *caseCache) load(fs Filesystem, dir string) (*caseCacheEntry, error) {
+	names, err := fs.DirNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	entry := &caseCacheEntry{
+		when:  time.Now(),
+		names: make(map[string]struct{}, len(names)),
+		lower: make(map[string]string, len(names)),
+	}
+	for _, name := range names {
+		entry.names[name] = struct{}{}
+		entry.lower[UnicodeLowercase(name)] = name
+	}
+
+	c.mut.Lock()
+	if len(c.entries) >= caseCacheMaxEntries {
+		c.entries = make(map[string]*caseCacheEntry)
+	}
+	c.entries[dir] = entry
+	c.mut.Unlock()
+	return entry, nil
+}
+
+func (c *caseCache) drop() {
+	c.mut.Lock()
+	c.entries = make(map[string]*caseCacheEntry)
+	c.mut.Unlock()
+}
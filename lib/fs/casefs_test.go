@@ -0,0 +1,102 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCaseFSInsensitive(t *testing.T) {
+	fs := NewCaseFilesystem(newFakeFilesystem("/TestCaseFSInsensitive?insens=true"))
+
+	if err := fs.MkdirAll("Dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := fs.Create(filepath.Join("Dir", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Close()
+
+	// Exact names work as usual.
+	if _, err := fs.Lstat(filepath.Join("Dir", "README.md")); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	// Names differing in case, also in a parent, conflict.
+	for _, name := range []string{
+		filepath.Join("Dir", "Readme.md"),
+		filepath.Join("dir", "README.md"),
+		"dir",
+	} {
+		if _, err := fs.Lstat(name); !IsErrCaseConflict(err) {
+			t.Errorf("Lstat(%q): expected case conflict, got %v", name, err)
+		}
+	}
+	for _, name := range []string{
+		filepath.Join("Dir", "Readme.md"),
+		filepath.Join("dir", "new"),
+	} {
+		if _, err := fs.Create(name); !IsErrCaseConflict(err) {
+			t.Errorf("Create(%q): expected case conflict, got %v", name, err)
+		}
+	}
+
+	// Non-existent names are fine.
+	if _, err := fs.Lstat(filepath.Join("Dir", "other")); !IsNotExist(err) {
+		t.Error("Expected not exist error, got", err)
+	}
+	if fd, err := fs.Create(filepath.Join("Dir", "other")); err != nil {
+		t.Error("Unexpected error:", err)
+	} else {
+		fd.Close()
+	}
+
+	// A rename must not overwrite a differently cased sibling.
+	err = fs.Rename(filepath.Join("Dir", "other"), filepath.Join("Dir", "Readme.md"))
+	if !IsErrCaseConflict(err) {
+		t.Error("Expected case conflict on rename, got", err)
+	}
+	if _, err := fs.Lstat(filepath.Join("Dir", "README.md")); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	// Case only renames are allowed.
+	if err := fs.Rename(filepath.Join("Dir", "README.md"), filepath.Join("Dir", "Readme.md")); err != nil {
+		t.Fatal("Unexpected error on case only rename:", err)
+	}
+	if _, err := fs.Lstat(filepath.Join("Dir", "Readme.md")); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if _, err := fs.Lstat(filepath.Join("Dir", "README.md")); !IsErrCaseConflict(err) {
+		t.Error("Expected case conflict after rename, got", err)
+	}
+}
+
+func TestCaseFSSensitive(t *testing.T) {
+	fs := NewCaseFilesystem(newFakeFilesystem("/TestCaseFSSensitive"))
+
+	for _, name := range []string{"README.md", "Readme.md"} {
+		fd, err := fs.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fd.Close()
+	}
+	for _, name := range []string{"README.md", "Readme.md"} {
+		if _, err := fs.Lstat(name); err != nil {
+			t.Errorf("Lstat(%q): unexpected error %v", name, err)
+		}
+	}
+	if _, err := fs.Lstat("readme.md"); !IsNotExist(err) {
+		t.Error("Expected not exist error, got", err)
+	}
+	if err := fs.Rename("README.md", "Readme.md"); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+}
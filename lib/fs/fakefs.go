@@ -21,6 +21,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
 )
 
 // see readShortAt()
@@ -573,6 +575,14 @@ func (fs *fakefs) Usage(name string) (Usage, error) {
 	return Usage{}, errors.New("not implemented")
 }
 
+func (fs *fakefs) GetXattr(name string, xattrFilter XattrFilter) ([]protocol.Xattr, error) {
+	return nil, ErrXattrsNotSupported
+}
+
+func (fs *fakefs) SetXattr(name string, xattrs []protocol.Xattr, xattrFilter XattrFilter) error {
+	return ErrXattrsNotSupported
+}
+
 func (fs *fakefs) Type() FilesystemType {
 	return FilesystemTypeFake
 }
@@ -794,6 +804,10 @@ func (f *fakeFile) Truncate(size int64) error {
 	defer f.mut.Unlock()
 
 	if f.content != nil {
+		if int64(len(f.content)) < size {
+			// Grow with zeroes, like a sparse file
+			f.content = append(f.content, make([]byte, int(size)-len(f.content))...)
+		}
 		f.content = f.content[:int(size)]
 	}
 	f.rng = nil
@@ -14,6 +14,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
 )
 
 // The Filesystem interface abstracts access to the file system.
@@ -45,6 +47,8 @@ type Filesystem interface {
 	Glob(pattern string) ([]string, error)
 	Roots() ([]string, error)
 	Usage(name string) (Usage, error)
+	GetXattr(name string, xattrFilter XattrFilter) ([]protocol.Xattr, error)
+	SetXattr(name string, xattrs []protocol.Xattr, xattrFilter XattrFilter) error
 	Type() FilesystemType
 	URI() string
 	SameFile(fi1, fi2 FileInfo) bool
@@ -95,6 +99,14 @@ type Usage struct {
 	Total int64
 }
 
+// XattrFilter limits the extended attributes that are read and written.
+// Attributes larger than the single entry size are skipped, and so are any
+// attributes beyond the total size. Zero means no limit.
+type XattrFilter interface {
+	GetMaxSingleEntrySize() int
+	GetMaxTotalSize() int
+}
+
 type Matcher interface {
 	ShouldIgnore(name string) bool
 	SkipIgnoredDirs() bool
@@ -137,6 +149,8 @@ func (evType EventType) String() string {
 
 var ErrWatchNotSupported = errors.New("watching is not supported")
 
+var ErrXattrsNotSupported = errors.New("extended attributes are not supported")
+
 // Equivalents from os package.
 
 const ModePerm = FileMode(os.ModePerm)
@@ -179,6 +193,16 @@ func NewFilesystem(fsType FilesystemType, uri string) Filesystem {
 		fs = newBasicFilesystem(uri)
 	case FilesystemTypeFake:
 		fs = newFakeFilesystem(uri)
+	case FilesystemTypeS3:
+		var err error
+		if fs, err = newS3Filesystem(uri); err != nil {
+			l.Debugln("Invalid S3 filesystem", uri, err)
+			fs = &errorFilesystem{
+				fsType: fsType,
+				uri:    uri,
+				err:    err,
+			}
+		}
 	default:
 		l.Debugln("Unknown filesystem", fsType, uri)
 		fs = &errorFilesystem{
@@ -0,0 +1,46 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import "os"
+
+// A HydrateFunc makes sure the named file has its contents on disk, e.g. by
+// fetching them from elsewhere when the file is a placeholder.
+type HydrateFunc func(name string) error
+
+// The hydratingFilesystem calls the hydrate function before files are
+// opened, so that placeholders are filled in on access.
+type hydratingFilesystem struct {
+	Filesystem
+	hydrate HydrateFunc
+}
+
+// NewHydratingFilesystem returns a filesystem that calls the given function
+// for a file before opening it.
+func NewHydratingFilesystem(fs Filesystem, hydrate HydrateFunc) Filesystem {
+	return &hydratingFilesystem{
+		Filesystem: fs,
+		hydrate:    hydrate,
+	}
+}
+
+func (f *hydratingFilesystem) Open(name string) (File, error) {
+	if err := f.hydrate(name); err != nil {
+		return nil, err
+	}
+	return f.Filesystem.Open(name)
+}
+
+// OpenFile does not hydrate when the file is truncated anyway.
+func (f *hydratingFilesystem) OpenFile(name string, flags int, mode FileMode) (File, error) {
+	if flags&os.O_TRUNC == 0 {
+		if err := f.hydrate(name); err != nil {
+			return nil, err
+		}
+	}
+	return f.Filesystem.OpenFile(name, flags, mode)
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestHydratingFS(t *testing.T) {
+	ffs := newFakeFilesystem("/TestHydratingFS?content=true")
+	fd, err := ffs.Create("placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Truncate(4)
+	fd.Close()
+
+	var hydrated []string
+	errFailed := errors.New("failed")
+	hfs := NewHydratingFilesystem(ffs, func(name string) error {
+		hydrated = append(hydrated, name)
+		if name == "failing" {
+			return errFailed
+		}
+		fd, err := ffs.Create(name)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		_, err = fd.Write([]byte("data"))
+		return err
+	})
+
+	fd, err = hfs.Open("placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := ioutil.ReadAll(fd)
+	fd.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != "data" {
+		t.Errorf("Expected hydrated contents, got %q", bs)
+	}
+
+	if _, err := hfs.OpenFile("failing", os.O_RDONLY, 0644); err != errFailed {
+		t.Error("Expected hydration error, got", err)
+	}
+
+	// Truncating needs no contents.
+	fd, err = hfs.OpenFile("placeholder", os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Close()
+
+	if len(hydrated) != 2 || hydrated[0] != "placeholder" || hydrated[1] != "failing" {
+		t.Error("Unexpected hydrations", hydrated)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
 )
 
 type logFilesystem struct {
@@ -56,6 +58,18 @@ func (fs *logFilesystem) DirNames(name string) ([]string, error) {
 	return names, err
 }
 
+func (fs *logFilesystem) GetXattr(name string, xattrFilter XattrFilter) ([]protocol.Xattr, error) {
+	xattrs, err := fs.Filesystem.GetXattr(name, xattrFilter)
+	l.Debugln(getCaller(), fs.Type(), fs.URI(), "GetXattr", name, len(xattrs), err)
+	return xattrs, err
+}
+
+func (fs *logFilesystem) SetXattr(name string, xattrs []protocol.Xattr, xattrFilter XattrFilter) error {
+	err := fs.Filesystem.SetXattr(name, xattrs, xattrFilter)
+	l.Debugln(getCaller(), fs.Type(), fs.URI(), "SetXattr", name, len(xattrs), err)
+	return err
+}
+
 func (fs *logFilesystem) Lstat(name string) (FileInfo, error) {
 	info, err := fs.Filesystem.Lstat(name)
 	l.Debugln(getCaller(), fs.Type(), fs.URI(), "Lstat", name, info, err)
@@ -0,0 +1,372 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3MetaMtime       = "X-Amz-Meta-Mtime"
+	s3MetaMode        = "X-Amz-Meta-Mode"
+)
+
+// s3Client is a minimal client for the S3 REST API, supporting just the
+// object operations we need. Buckets are addressed path style
+// (endpoint/bucket/key), which works with AWS as well as the common self
+// hosted implementations.
+type s3Client struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// s3Object is what we know about an object from a HEAD or list request.
+type s3Object struct {
+	key   string
+	size  int64
+	mtime time.Time
+	mode  FileMode
+	// hasMode is false if the object wasn't created by us and thus carries
+	// no mode metadata.
+	hasMode bool
+}
+
+type s3ListResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		LastModified time.Time
+		Size         int64
+	}
+	CommonPrefixes []struct {
+		Prefix string
+	}
+}
+
+type s3Error struct {
+	Code    string
+	Message string
+}
+
+// head returns the metadata of the given object.
+func (c *s3Client) head(key string) (s3Object, error) {
+	resp, err := c.do(http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return s3Object{}, err
+	}
+	resp.Body.Close()
+	return objectFromHeader(key, resp.ContentLength, resp.Header), nil
+}
+
+// get returns length bytes of the object starting at offset. A negative
+// length means until the end of the object.
+func (c *s3Client) get(key string, offset, length int64) (io.ReadCloser, error) {
+	header := make(http.Header)
+	if length >= 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.do(http.MethodGet, key, nil, header, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// put uploads the object, replacing any existing one.
+func (c *s3Client) put(key string, body io.ReadSeeker, size int64, mtime time.Time, mode FileMode) error {
+	header := make(http.Header)
+	setObjectMeta(header, mtime, mode)
+	resp, err := c.do(http.MethodPut, key, nil, header, body, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// copy copies the object from src to dst, keeping its metadata.
+func (c *s3Client) copy(src, dst string) error {
+	return c.copyWithMeta(src, dst, nil)
+}
+
+// setMeta replaces the metadata of the object by copying it onto itself;
+// S3 has no way to modify an object in place.
+func (c *s3Client) setMeta(key string, mtime time.Time, mode FileMode) error {
+	header := make(http.Header)
+	setObjectMeta(header, mtime, mode)
+	header.Set("X-Amz-Metadata-Directive", "REPLACE")
+	return c.copyWithMeta(key, key, header)
+}
+
+func (c *s3Client) copyWithMeta(src, dst string, header http.Header) error {
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("X-Amz-Copy-Source", s3EscapePath("/"+c.bucket+"/"+src))
+	resp, err := c.do(http.MethodPut, dst, nil, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// A copy may fail after the 200 OK status has been sent, in which case
+	// the body contains an error instead of the result.
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var e s3Error
+	if xml.Unmarshal(bs, &e) == nil && e.Code != "" {
+		return fmt.Errorf("s3: copy %s to %s: %s: %s", src, dst, e.Code, e.Message)
+	}
+	return nil
+}
+
+// delete removes the object. It is not an error if it doesn't exist.
+func (c *s3Client) delete(key string) error {
+	resp, err := c.do(http.MethodDelete, key, nil, nil, nil, 0)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// list calls fn for every object and, if delimiter is set, every common
+// prefix below the given prefix, until fn returns false.
+func (c *s3Client) list(prefix, delimiter string, fn func(obj s3Object, isPrefix bool) bool) error {
+	query := url.Values{
+		"list-type": []string{"2"},
+		"prefix":    []string{prefix},
+	}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	for {
+		resp, err := c.do(http.MethodGet, "", query, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		var res s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		for _, obj := range res.Contents {
+			if !fn(s3Object{key: obj.Key, size: obj.Size, mtime: obj.LastModified}, false) {
+				return nil
+			}
+		}
+		for _, p := range res.CommonPrefixes {
+			if !fn(s3Object{key: p.Prefix}, true) {
+				return nil
+			}
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", res.NextContinuationToken)
+	}
+}
+
+// do performs a signed request against the given key in our bucket. Error
+// responses are turned into errors, with missing objects resulting in an
+// error satisfying os.IsNotExist.
+func (c *s3Client) do(method, key string, query url.Values, header http.Header, body io.ReadSeeker, size int64) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = "/" + c.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	var reqBody io.Reader
+	if body != nil {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		reqBody = body
+	}
+	req, err := http.NewRequest(method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	} else if method == http.MethodPut {
+		req.ContentLength = 0
+		req.Body = http.NoBody
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.accessKey != "" {
+		s3SignV4(req, c.accessKey, c.secretKey, c.region, s3UnsignedPayload, time.Now())
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &os.PathError{Op: strings.ToLower(method), Path: key, Err: os.ErrNotExist}
+	}
+	var e s3Error
+	if bs, err := ioutil.ReadAll(resp.Body); err == nil {
+		_ = xml.Unmarshal(bs, &e)
+	}
+	if e.Code == "" {
+		e.Code = resp.Status
+	}
+	return nil, fmt.Errorf("s3: %s %s: %s: %s", method, key, e.Code, e.Message)
+}
+
+func objectFromHeader(key string, size int64, header http.Header) s3Object {
+	obj := s3Object{key: key, size: size}
+	if ns, err := strconv.ParseInt(header.Get(s3MetaMtime), 10, 64); err == nil {
+		obj.mtime = time.Unix(0, ns)
+	} else if t, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+		obj.mtime = t
+	}
+	if mode, err := strconv.ParseUint(header.Get(s3MetaMode), 8, 32); err == nil {
+		obj.mode = FileMode(mode)
+		obj.hasMode = true
+	}
+	return obj
+}
+
+func setObjectMeta(header http.Header, mtime time.Time, mode FileMode) {
+	header.Set(s3MetaMtime, strconv.FormatInt(mtime.UnixNano(), 10))
+	header.Set(s3MetaMode, strconv.FormatUint(uint64(mode&ModePerm), 8))
+}
+
+// s3SignV4 adds an AWS signature version 4 to the request, covering the
+// host and all headers set on the request.
+func s3SignV4(req *http.Request, accessKey, secretKey, region, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := s3HMAC([]byte("AWS4"+secretKey), date)
+	key = s3HMAC(key, region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalQuery returns the query string in the canonical form required
+// for signing: sorted by key, with keys and values URI encoded.
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), query[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent encodes everything but the unreserved characters of RFC
+// 3986, and the slash unless encodeSlash is set.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,809 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	s3DefaultRegion     = "us-east-1"
+	s3DefaultFileMode   = FileMode(0644)
+	s3DefaultFolderMode = FileMode(0755)
+)
+
+var (
+	errS3NotDir        = errors.New("not a directory")
+	errS3IsDir         = errors.New("is a directory")
+	errS3ReadOnly      = errors.New("file not opened for writing")
+	errS3NotSupported  = errors.New("not supported on S3 filesystems")
+	errS3InvalidURI    = errors.New("S3 URI must be of the form [http[s]://]host[:port]/bucket[/prefix]")
+	errS3OutsideOfRoot = errors.New("path is outside of the root")
+)
+
+// s3fs stores files as objects in an S3 compatible bucket. It has the
+// following properties:
+//
+// - The URI is of the form [http[s]://]host[:port]/bucket[/prefix], https
+//   being the default. It survives being passed through filepath.Join, so
+//   subdirectories (e.g. for versioning) can be addressed like on a basic
+//   filesystem.
+//
+// - Credentials are taken from the AWS_ACCESS_KEY_ID and
+//   AWS_SECRET_ACCESS_KEY environment variables, the region from AWS_REGION
+//   (default us-east-1). Without credentials requests are sent unsigned.
+//
+// - Each file is an object, with the modification time and permissions
+//   kept as object metadata. Directories are empty objects with a trailing
+//   slash in their name, but any common prefix of existing objects is also
+//   considered a directory.
+//
+// - Files opened for writing are buffered in a local temporary file and
+//   uploaded when closed or synced. They don't show up in the bucket
+//   before that. Objects are uploaded in a single request, limiting their
+//   size to 5 GiB.
+//
+// - Renames are copies followed by deletes and thus not atomic; renaming a
+//   directory copies every object below it.
+//
+// - Symlinks, ownership, watching and usage reporting are not supported.
+//
+type s3fs struct {
+	client *s3Client
+	uri    string
+	prefix string
+}
+
+func newS3Filesystem(uri string) (*s3fs, error) {
+	scheme := "https"
+	rest := filepath.ToSlash(uri)
+	for _, s := range []string{"https:", "http:", "s3:"} {
+		if strings.HasPrefix(strings.ToLower(rest), s) {
+			if s == "http:" {
+				scheme = "http"
+			}
+			rest = rest[len(s):]
+			break
+		}
+	}
+	rest = strings.TrimLeft(rest, "/")
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errS3InvalidURI
+	}
+	host, bucket := parts[0], parts[1]
+	var prefix string
+	if len(parts) == 3 {
+		prefix = strings.Trim(path.Clean("/"+parts[2]), "/")
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	fs := &s3fs{
+		client: &s3Client{
+			endpoint:  &url.URL{Scheme: scheme, Host: host},
+			bucket:    bucket,
+			region:    region,
+			accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			client:    &http.Client{},
+		},
+		uri:    scheme + "://" + host + "/" + bucket,
+		prefix: prefix,
+	}
+	if prefix != "" {
+		fs.uri += "/" + prefix
+	}
+	return fs, nil
+}
+
+// key returns the object key for the given name, the empty string being
+// the root of the bucket.
+func (f *s3fs) key(name string) (string, error) {
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", errS3OutsideOfRoot
+	}
+	if name == "." || name == "" {
+		return f.prefix, nil
+	}
+	if f.prefix == "" {
+		return name, nil
+	}
+	return f.prefix + "/" + name, nil
+}
+
+// dirKey returns the prefix of all objects within the directory with the
+// given key.
+func dirKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+func (f *s3fs) Chmod(name string, mode FileMode) error {
+	info, key, err := f.lstat(name)
+	if err != nil {
+		return err
+	}
+	if info.dir {
+		return f.putDirMarker(key, info.mtime, mode)
+	}
+	return f.client.setMeta(key, info.mtime, mode)
+}
+
+func (f *s3fs) Lchown(name string, uid, gid int) error {
+	return nil
+}
+
+func (f *s3fs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	info, key, err := f.lstat(name)
+	if err != nil {
+		return err
+	}
+	if info.dir {
+		return f.putDirMarker(key, mtime, info.mode)
+	}
+	return f.client.setMeta(key, mtime, info.mode)
+}
+
+func (f *s3fs) Create(name string) (File, error) {
+	return f.OpenFile(name, OptReadWrite|OptCreate|OptTruncate, 0666)
+}
+
+func (f *s3fs) CreateSymlink(target, name string) error {
+	return &os.PathError{Op: "symlink", Path: name, Err: errS3NotSupported}
+}
+
+func (f *s3fs) DirNames(name string) ([]string, error) {
+	key, err := f.key(name)
+	if err != nil {
+		return nil, err
+	}
+	prefix := dirKey(key)
+	var names []string
+	err = f.client.list(prefix, "/", func(obj s3Object, isPrefix bool) bool {
+		rel := strings.TrimSuffix(strings.TrimPrefix(obj.key, prefix), "/")
+		if rel != "" {
+			names = append(names, rel)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		// Either an empty directory, or none at all.
+		info, _, err := f.lstat(name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.dir {
+			return nil, &os.PathError{Op: "readdirent", Path: name, Err: errS3NotDir}
+		}
+	}
+	return names, nil
+}
+
+func (f *s3fs) Lstat(name string) (FileInfo, error) {
+	info, _, err := f.lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (f *s3fs) lstat(name string) (*s3FileInfo, string, error) {
+	key, err := f.key(name)
+	if err != nil {
+		return nil, "", err
+	}
+	info := &s3FileInfo{name: path.Base(filepath.ToSlash(name))}
+	if key == "" {
+		// The bucket itself
+		info.dir = true
+		info.mode = s3DefaultFolderMode
+		return info, key, nil
+	}
+
+	if obj, err := f.client.head(key); err == nil {
+		info.size = obj.size
+		info.mtime = obj.mtime
+		info.mode = s3DefaultFileMode
+		if obj.hasMode {
+			info.mode = obj.mode
+		}
+		return info, key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, "", err
+	}
+
+	info.dir = true
+	info.mode = s3DefaultFolderMode
+	if obj, err := f.client.head(dirKey(key)); err == nil {
+		info.mtime = obj.mtime
+		if obj.hasMode {
+			info.mode = obj.mode
+		}
+		return info, key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, "", err
+	}
+
+	// No directory marker, but there might still be objects within.
+	found := false
+	err = f.client.list(dirKey(key), "/", func(s3Object, bool) bool {
+		found = true
+		return false
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if !found {
+		return nil, "", &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	return info, key, nil
+}
+
+func (f *s3fs) putDirMarker(key string, mtime time.Time, mode FileMode) error {
+	if key == "" {
+		// The bucket root can't have any metadata.
+		return nil
+	}
+	return f.client.put(dirKey(key), nil, 0, mtime, mode)
+}
+
+func (f *s3fs) Mkdir(name string, perm FileMode) error {
+	key, err := f.key(name)
+	if err != nil {
+		return err
+	}
+	if _, _, err := f.lstat(name); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if parent, _, err := f.lstat(filepath.Dir(name)); err != nil {
+		return err
+	} else if !parent.dir {
+		return &os.PathError{Op: "mkdir", Path: name, Err: errS3NotDir}
+	}
+	return f.putDirMarker(key, time.Now(), perm&ModePerm)
+}
+
+func (f *s3fs) MkdirAll(name string, perm FileMode) error {
+	name = filepath.Clean(name)
+	var parts []string
+	if name != "." {
+		parts = strings.Split(filepath.ToSlash(name), "/")
+	}
+	// Starting at the root, which may not exist yet if we have a prefix.
+	current := "."
+	for i := 0; i <= len(parts); i++ {
+		if i > 0 {
+			current = filepath.Join(current, parts[i-1])
+		}
+		info, key, err := f.lstat(current)
+		if err == nil {
+			if !info.dir {
+				return &os.PathError{Op: "mkdir", Path: current, Err: errS3NotDir}
+			}
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if key, err = f.key(current); err != nil {
+			return err
+		}
+		if err := f.putDirMarker(key, time.Now(), perm&ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *s3fs) Open(name string) (File, error) {
+	return f.OpenFile(name, OptReadOnly, 0)
+}
+
+func (f *s3fs) OpenFile(name string, flags int, mode FileMode) (File, error) {
+	info, key, err := f.lstat(name)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if flags&(OptWriteOnly|OptReadWrite) == 0 {
+		if !exists {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return &s3File{fs: f, name: name, key: key, info: *info}, nil
+	}
+
+	switch {
+	case exists && info.dir:
+		return nil, &os.PathError{Op: "open", Path: name, Err: errS3IsDir}
+	case exists && flags&OptCreate != 0 && flags&OptExclusive != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case !exists && flags&OptCreate == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case !exists:
+		if parent, _, err := f.lstat(filepath.Dir(name)); err != nil {
+			return nil, err
+		} else if !parent.dir {
+			return nil, &os.PathError{Op: "open", Path: name, Err: errS3NotDir}
+		}
+		if key, err = f.key(name); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := ioutil.TempFile("", "syncthing-s3-")
+	if err != nil {
+		return nil, err
+	}
+	fd := &s3File{fs: f, name: name, key: key, tmp: tmp}
+	if exists && flags&OptTruncate == 0 {
+		fd.info = *info
+		if err := fd.download(); err != nil {
+			fd.discard()
+			return nil, err
+		}
+	} else {
+		// Uploading even if nothing gets written, so that the file exists
+		// after closing it.
+		fd.info = s3FileInfo{
+			name:  path.Base(filepath.ToSlash(name)),
+			mode:  mode & ModePerm,
+			mtime: time.Now(),
+		}
+		if exists {
+			fd.info.mode = info.mode
+		}
+		fd.dirty = true
+	}
+	if flags&OptAppend != 0 {
+		fd.offset = fd.info.size
+	}
+	return fd, nil
+}
+
+func (f *s3fs) ReadSymlink(name string) (string, error) {
+	return "", &os.PathError{Op: "readlink", Path: name, Err: errS3NotSupported}
+}
+
+func (f *s3fs) Remove(name string) error {
+	info, key, err := f.lstat(name)
+	if err != nil {
+		return err
+	}
+	if !info.dir {
+		return f.client.delete(key)
+	}
+
+	prefix := dirKey(key)
+	empty := true
+	err = f.client.list(prefix, "/", func(obj s3Object, _ bool) bool {
+		if obj.key != prefix {
+			empty = false
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return &os.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+	}
+	return f.client.delete(prefix)
+}
+
+func (f *s3fs) RemoveAll(name string) error {
+	key, err := f.key(name)
+	if err != nil {
+		return err
+	}
+	keys, err := f.keysBelow(key)
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		keys = append(keys, key)
+	}
+	for _, k := range keys {
+		if err := f.client.delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keysBelow returns the keys of all objects within the given directory,
+// recursively, including its marker.
+func (f *s3fs) keysBelow(key string) ([]string, error) {
+	var keys []string
+	err := f.client.list(dirKey(key), "", func(obj s3Object, _ bool) bool {
+		keys = append(keys, obj.key)
+		return true
+	})
+	return keys, err
+}
+
+func (f *s3fs) Rename(oldname, newname string) error {
+	info, oldKey, err := f.lstat(oldname)
+	if err != nil {
+		return err
+	}
+	newKey, err := f.key(newname)
+	if err != nil {
+		return err
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	if !info.dir {
+		if err := f.client.copy(oldKey, newKey); err != nil {
+			return err
+		}
+		return f.client.delete(oldKey)
+	}
+
+	if oldKey == "" || strings.HasPrefix(newKey, dirKey(oldKey)) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EINVAL}
+	}
+	keys, err := f.keysBelow(oldKey)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := f.client.copy(k, newKey+strings.TrimPrefix(k, oldKey)); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if err := f.client.delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *s3fs) Stat(name string) (FileInfo, error) {
+	return f.Lstat(name)
+}
+
+func (f *s3fs) SymlinksSupported() bool {
+	return false
+}
+
+func (f *s3fs) Walk(name string, walkFn WalkFunc) error {
+	return errors.New("not implemented")
+}
+
+func (f *s3fs) Watch(path string, ignore Matcher, ctx context.Context, ignorePerms bool) (<-chan Event, <-chan error, error) {
+	return nil, nil, ErrWatchNotSupported
+}
+
+func (f *s3fs) Hide(name string) error {
+	return nil
+}
+
+func (f *s3fs) Unhide(name string) error {
+	return nil
+}
+
+// Glob supports patterns in the last path component only.
+func (f *s3fs) Glob(pattern string) ([]string, error) {
+	dir, file := filepath.Split(pattern)
+	if strings.ContainsAny(dir, `*?[\`) {
+		return nil, errS3NotSupported
+	}
+	names, err := f.DirNames(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, name := range names {
+		if ok, err := filepath.Match(file, name); err != nil {
+			return nil, err
+		} else if ok {
+			matches = append(matches, filepath.Join(dir, name))
+		}
+	}
+	return matches, nil
+}
+
+func (f *s3fs) Roots() ([]string, error) {
+	return nil, errS3NotSupported
+}
+
+func (f *s3fs) Usage(name string) (Usage, error) {
+	return Usage{}, errS3NotSupported
+}
+
+func (f *s3fs) GetXattr(name string, xattrFilter XattrFilter) ([]protocol.Xattr, error) {
+	return nil, ErrXattrsNotSupported
+}
+
+func (f *s3fs) SetXattr(name string, xattrs []protocol.Xattr, xattrFilter XattrFilter) error {
+	return ErrXattrsNotSupported
+}
+
+func (f *s3fs) Type() FilesystemType {
+	return FilesystemTypeS3
+}
+
+func (f *s3fs) URI() string {
+	return f.uri
+}
+
+func (f *s3fs) SameFile(fi1, fi2 FileInfo) bool {
+	return fi1.Name() == fi2.Name() && fi1.Size() == fi2.Size() && fi1.ModTime().Equal(fi2.ModTime()) && fi1.IsDir() == fi2.IsDir()
+}
+
+// s3File is an open file. Files opened read only are read from the bucket
+// on demand, while files opened for writing are kept in a local temporary
+// file until closed.
+type s3File struct {
+	fs   *s3fs
+	name string
+	key  string
+
+	mut    sync.Mutex
+	info   s3FileInfo
+	offset int64
+	tmp    *os.File
+	dirty  bool
+}
+
+func (f *s3File) download() error {
+	body, err := f.fs.client.get(f.key, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	n, err := io.Copy(f.tmp, body)
+	f.info.size = n
+	return err
+}
+
+func (f *s3File) discard() {
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+	f.tmp = nil
+}
+
+func (f *s3File) Close() error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if f.tmp == nil {
+		return nil
+	}
+	err := f.upload()
+	f.discard()
+	return err
+}
+
+func (f *s3File) Read(p []byte) (int, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	n, err := f.readAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *s3File) ReadAt(p []byte, off int64) (int, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.readAt(p, off)
+}
+
+func (f *s3File) readAt(p []byte, off int64) (int, error) {
+	if f.info.dir {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: errS3IsDir}
+	}
+	if f.tmp != nil {
+		return f.tmp.ReadAt(p, off)
+	}
+	if off >= f.info.size {
+		return 0, io.EOF
+	}
+	length := int64(len(p))
+	if rem := f.info.size - off; rem < length {
+		length = rem
+	}
+	body, err := f.fs.client.get(f.key, off, length)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, p[:length])
+	if err == nil && int(length) < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *s3File) Seek(offset int64, whence int) (int64, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		size, err := f.size()
+		if err != nil {
+			return f.offset, err
+		}
+		offset += size
+	default:
+		return f.offset, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return f.offset, errors.New("negative offset")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *s3File) size() (int64, error) {
+	if f.tmp == nil {
+		return f.info.size, nil
+	}
+	info, err := f.tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (f *s3File) Write(p []byte) (int, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	n, err := f.writeAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *s3File) WriteAt(p []byte, off int64) (int, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.writeAt(p, off)
+}
+
+func (f *s3File) writeAt(p []byte, off int64) (int, error) {
+	if f.tmp == nil {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: errS3ReadOnly}
+	}
+	f.dirty = true
+	return f.tmp.WriteAt(p, off)
+}
+
+func (f *s3File) Name() string {
+	return f.name
+}
+
+func (f *s3File) Truncate(size int64) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if f.tmp == nil {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: errS3ReadOnly}
+	}
+	f.dirty = true
+	return f.tmp.Truncate(size)
+}
+
+func (f *s3File) Stat() (FileInfo, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	info := f.info
+	size, err := f.size()
+	if err != nil {
+		return nil, err
+	}
+	info.size = size
+	return &info, nil
+}
+
+func (f *s3File) Sync() error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.upload()
+}
+
+func (f *s3File) upload() error {
+	if f.tmp == nil || !f.dirty {
+		return nil
+	}
+	size, err := f.size()
+	if err != nil {
+		return err
+	}
+	mtime := time.Now()
+	if err := f.fs.client.put(f.key, f.tmp, size, mtime, f.info.mode); err != nil {
+		return fmt.Errorf("uploading %s: %w", f.name, err)
+	}
+	f.info.size = size
+	f.info.mtime = mtime
+	f.dirty = false
+	return nil
+}
+
+// s3FileInfo is the stat result.
+type s3FileInfo struct {
+	name  string
+	size  int64
+	mode  FileMode
+	mtime time.Time
+	dir   bool
+}
+
+func (f *s3FileInfo) Name() string {
+	return f.name
+}
+
+func (f *s3FileInfo) Mode() FileMode {
+	if f.dir {
+		return f.mode | FileMode(os.ModeDir)
+	}
+	return f.mode
+}
+
+func (f *s3FileInfo) Size() int64 {
+	return f.size
+}
+
+func (f *s3FileInfo) ModTime() time.Time {
+	return f.mtime
+}
+
+func (f *s3FileInfo) IsDir() bool {
+	return f.dir
+}
+
+func (f *s3FileInfo) IsRegular() bool {
+	return !f.dir
+}
+
+func (f *s3FileInfo) IsSymlink() bool {
+	return false
+}
+
+func (f *s3FileInfo) Owner() int {
+	return -1
+}
+
+func (f *s3FileInfo) Group() int {
+	return -1
+}
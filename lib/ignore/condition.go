@@ -0,0 +1,198 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package ignore
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/syncthing/syncthing/lib/fs"
+)
+
+// A condition restricts a pattern to files with certain attributes, given
+// as a prefix such as (?size>100M), (?older-than=30d) or (?type=dir|file).
+// Patterns with conditions only match when the file info is known, i.e. in
+// MatchInfo.
+type condition struct {
+	expr  string // as given between "(?" and ")"
+	match func(info fs.FileInfo) bool
+}
+
+func (c condition) String() string {
+	return "(?" + c.expr + ")"
+}
+
+// parseCondition parses a condition prefix at the start of the line. It
+// returns the number of bytes consumed, which is zero when the line doesn't
+// start with a condition.
+func parseCondition(line string) (condition, int, error) {
+	if !strings.HasPrefix(line, "(?") {
+		return condition{}, 0, nil
+	}
+	end := strings.IndexByte(line, ')')
+	if end < 0 {
+		return condition{}, 0, nil
+	}
+	expr := line[2:end]
+
+	var match func(info fs.FileInfo) bool
+	var err error
+	switch {
+	case strings.HasPrefix(expr, "size"):
+		match, err = parseSizeCondition(expr[len("size"):])
+	case strings.HasPrefix(expr, "older-than="):
+		var d time.Duration
+		d, err = parseAge(expr[len("older-than="):])
+		match = func(info fs.FileInfo) bool {
+			return clock.Now().Sub(info.ModTime()) > d
+		}
+	case strings.HasPrefix(expr, "newer-than="):
+		var d time.Duration
+		d, err = parseAge(expr[len("newer-than="):])
+		match = func(info fs.FileInfo) bool {
+			return clock.Now().Sub(info.ModTime()) < d
+		}
+	case strings.HasPrefix(expr, "type="):
+		match, err = parseTypeCondition(expr[len("type="):])
+	default:
+		// Not a condition, e.g. another prefix or part of the pattern
+		return condition{}, 0, nil
+	}
+	if err != nil {
+		return condition{}, 0, errors.Wrapf(err, "condition %q", expr)
+	}
+
+	return condition{expr: expr, match: match}, end + 1, nil
+}
+
+// parseSizeCondition parses the comparison of a size condition, e.g. ">100M".
+// Only regular files match size conditions.
+func parseSizeCondition(s string) (func(fs.FileInfo) bool, error) {
+	var cmp func(a, b int64) bool
+	switch {
+	case strings.HasPrefix(s, ">="):
+		cmp = func(a, b int64) bool { return a >= b }
+		s = s[2:]
+	case strings.HasPrefix(s, "<="):
+		cmp = func(a, b int64) bool { return a <= b }
+		s = s[2:]
+	case strings.HasPrefix(s, ">"):
+		cmp = func(a, b int64) bool { return a > b }
+		s = s[1:]
+	case strings.HasPrefix(s, "<"):
+		cmp = func(a, b int64) bool { return a < b }
+		s = s[1:]
+	case strings.HasPrefix(s, "="):
+		cmp = func(a, b int64) bool { return a == b }
+		s = s[1:]
+	default:
+		return nil, errors.New("missing comparison operator")
+	}
+
+	size, err := parseSize(s)
+	if err != nil {
+		return nil, err
+	}
+	return func(info fs.FileInfo) bool {
+		return info.IsRegular() && cmp(info.Size(), size)
+	}, nil
+}
+
+// parseSize parses a size with an optional unit: k, M, G or T for powers of
+// 1000, Ki, Mi, Gi or Ti for powers of 1024, optionally followed by "B".
+func parseSize(s string) (int64, error) {
+	num, unit := splitNumber(s)
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid size %q", s)
+	}
+
+	unit = strings.TrimSuffix(unit, "B")
+	base := 1000.0
+	if strings.HasSuffix(unit, "i") {
+		base = 1024
+		unit = strings.TrimSuffix(unit, "i")
+	}
+	mult := 1.0
+	switch unit {
+	case "":
+		if base == 1024 {
+			return 0, errors.Errorf("invalid size unit in %q", s)
+		}
+	case "k", "K":
+		mult = base
+	case "M":
+		mult = base * base
+	case "G":
+		mult = base * base * base
+	case "T":
+		mult = base * base * base * base
+	default:
+		return 0, errors.Errorf("invalid size unit in %q", s)
+	}
+
+	return int64(val * mult), nil
+}
+
+// parseAge parses a duration with a unit of s, m, h, d (days) or w (weeks).
+func parseAge(s string) (time.Duration, error) {
+	num, unit := splitNumber(s)
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid age %q", s)
+	}
+
+	var mult time.Duration
+	switch unit {
+	case "s":
+		mult = time.Second
+	case "m":
+		mult = time.Minute
+	case "h":
+		mult = time.Hour
+	case "d":
+		mult = 24 * time.Hour
+	case "w":
+		mult = 7 * 24 * time.Hour
+	default:
+		return 0, errors.Errorf("invalid age unit in %q", s)
+	}
+
+	return time.Duration(val * float64(mult)), nil
+}
+
+// parseTypeCondition parses the alternatives of a type condition, e.g.
+// "file|symlink".
+func parseTypeCondition(s string) (func(fs.FileInfo) bool, error) {
+	var dir, file, symlink bool
+	for _, typ := range strings.Split(s, "|") {
+		switch typ {
+		case "dir":
+			dir = true
+		case "file":
+			file = true
+		case "symlink":
+			symlink = true
+		default:
+			return nil, errors.Errorf("invalid type %q", typ)
+		}
+	}
+	return func(info fs.FileInfo) bool {
+		return dir && info.IsDir() || file && info.IsRegular() || symlink && info.IsSymlink()
+	}, nil
+}
+
+func splitNumber(s string) (string, string) {
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -44,10 +44,14 @@ type Pattern struct {
 	pattern string
 	match   glob.Glob
 	result  Result
+	conds   []condition
 }
 
 func (p Pattern) String() string {
 	ret := p.pattern
+	for i := len(p.conds) - 1; i >= 0; i-- {
+		ret = p.conds[i].String() + ret
+	}
 	if p.result&resultInclude != resultInclude {
 		ret = "!" + ret
 	}
@@ -60,6 +64,16 @@ func (p Pattern) String() string {
 	return ret
 }
 
+// matchesInfo returns whether the file info meets all of the conditions.
+func (p Pattern) matchesInfo(info fs.FileInfo) bool {
+	for _, cond := range p.conds {
+		if !cond.match(info) {
+			return false
+		}
+	}
+	return true
+}
+
 func (p Pattern) allowsSkippingIgnoredDirs() bool {
 	if p.result.IsIgnored() {
 		return true
@@ -108,6 +122,7 @@ type ChangeDetector interface {
 type Matcher struct {
 	fs              fs.Filesystem
 	lines           []string  // exact lines read from .stignore
+	sharedLines     []string  // shared lines, applied after those from .stignore
 	patterns        []Pattern // patterns including those from included files
 	withCache       bool
 	matches         *cache
@@ -115,6 +130,7 @@ type Matcher struct {
 	stop            chan struct{}
 	changeDetector  ChangeDetector
 	skipIgnoredDirs bool
+	hasConditions   bool
 	mut             sync.Mutex
 }
 
@@ -136,6 +152,15 @@ func WithChangeDetector(cd ChangeDetector) Option {
 	}
 }
 
+// WithShared sets shared ignore lines, distributed to all devices sharing
+// the folder. They are parsed like a file loaded after .stignore, i.e. the
+// local patterns take precedence.
+func WithShared(lines []string) Option {
+	return func(m *Matcher) {
+		m.sharedLines = lines
+	}
+}
+
 func New(fs fs.Filesystem, opts ...Option) *Matcher {
 	m := &Matcher{
 		fs:              fs,
@@ -192,6 +217,14 @@ func (m *Matcher) parseLocked(r io.Reader, file string) error {
 	// Error is saved and returned at the end. We process the patterns
 	// (possibly blank) anyway.
 
+	if len(m.sharedLines) > 0 {
+		_, shared, sharedErr := parseIgnoreFile(m.fs, strings.NewReader(strings.Join(m.sharedLines, "\n")), file, m.changeDetector, make(map[string]struct{}))
+		patterns = append(patterns, shared...)
+		if err == nil && sharedErr != nil {
+			err = errors.Wrap(sharedErr, "shared ignores")
+		}
+	}
+
 	m.lines = lines
 
 	newHash := hashPatterns(patterns)
@@ -217,6 +250,14 @@ func (m *Matcher) parseLocked(r io.Reader, file string) error {
 		previous = p.pattern
 	}
 
+	m.hasConditions = false
+	for _, p := range patterns {
+		if len(p.conds) > 0 {
+			m.hasConditions = true
+			break
+		}
+	}
+
 	m.curHash = newHash
 	m.patterns = patterns
 	if m.withCache {
@@ -226,7 +267,20 @@ func (m *Matcher) parseLocked(r io.Reader, file string) error {
 	return err
 }
 
+// Match returns the result of the first pattern matching the file. Patterns
+// with conditions on the file's attributes are not considered, see
+// MatchInfo.
 func (m *Matcher) Match(file string) (result Result) {
+	return m.match(file, nil)
+}
+
+// MatchInfo is like Match, but also considers patterns with conditions,
+// evaluated against the given file info.
+func (m *Matcher) MatchInfo(file string, info fs.FileInfo) Result {
+	return m.match(file, info)
+}
+
+func (m *Matcher) match(file string, info fs.FileInfo) (result Result) {
 	if file == "." {
 		return resultNotMatched
 	}
@@ -238,7 +292,9 @@ func (m *Matcher) Match(file string) (result Result) {
 		return resultNotMatched
 	}
 
-	if m.matches != nil {
+	// The result of conditional patterns depends on the file info and
+	// cannot be cached by name.
+	if m.matches != nil && (info == nil || !m.hasConditions) {
 		// Check the cache for a known result.
 		res, ok := m.matches.get(file)
 		if ok {
@@ -255,15 +311,18 @@ func (m *Matcher) Match(file string) (result Result) {
 	file = filepath.ToSlash(file)
 	var lowercaseFile string
 	for _, pattern := range m.patterns {
+		if len(pattern.conds) > 0 && info == nil {
+			continue
+		}
 		if pattern.result.IsCaseFolded() {
 			if lowercaseFile == "" {
 				lowercaseFile = strings.ToLower(file)
 			}
-			if pattern.match.Match(lowercaseFile) {
+			if pattern.match.Match(lowercaseFile) && pattern.matchesInfo(info) {
 				return pattern.result
 			}
 		} else {
-			if pattern.match.Match(file) {
+			if pattern.match.Match(file) && pattern.matchesInfo(info) {
 				return pattern.result
 			}
 		}
@@ -280,6 +339,13 @@ func (m *Matcher) Lines() []string {
 	return m.lines
 }
 
+// SharedLines returns the shared lines the matcher was created with
+func (m *Matcher) SharedLines() []string {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.sharedLines
+}
+
 // Patterns return a list of the loaded patterns, as they've been parsed
 func (m *Matcher) Patterns() []string {
 	m.mut.Lock()
@@ -345,6 +411,14 @@ func (m *Matcher) SkipIgnoredDirs() bool {
 	return m.skipIgnoredDirs
 }
 
+// HasConditions returns whether any pattern has conditions on the file's
+// attributes, i.e. whether MatchInfo may differ from Match.
+func (m *Matcher) HasConditions() bool {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.hasConditions
+}
+
 func hashPatterns(patterns []Pattern) string {
 	h := md5.New()
 	for _, pat := range patterns {
@@ -417,13 +491,22 @@ func parseLine(line string) ([]Pattern, error) {
 			seenPrefix[2] = true
 			pattern.result |= resultDeletable
 			line = line[4:]
+		} else if cond, n, err := parseCondition(line); err != nil {
+			return nil, err
+		} else if n > 0 {
+			pattern.conds = append(pattern.conds, cond)
+			line = line[n:]
 		} else {
 			break
 		}
 	}
 
 	if line == "" {
-		return nil, errors.New("missing pattern")
+		if len(pattern.conds) == 0 {
+			return nil, errors.New("missing pattern")
+		}
+		// A condition on its own applies to everything
+		line = "**"
 	}
 
 	if pattern.result.IsCaseFolded() {
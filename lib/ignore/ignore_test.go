@@ -1168,3 +1168,140 @@ func TestEmptyPatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestConditions(t *testing.T) {
+	ffs := fs.NewFilesystem(fs.FilesystemTypeFake, "TestConditions")
+	createFile := func(name string, size int64, age time.Duration) {
+		t.Helper()
+		fd, err := ffs.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fd.Truncate(size); err != nil {
+			t.Fatal(err)
+		}
+		fd.Close()
+		mtime := time.Now().Add(-age)
+		if err := ffs.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createFile("small.iso", 1000, 0)
+	createFile("large.iso", 2<<20, 0)
+	createFile("old.log", 10, 40*24*time.Hour)
+	createFile("new.log", 10, time.Hour)
+	if err := ffs.Mkdir("cache", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ffs.CreateSymlink("small.iso", "link"); err != nil {
+		t.Fatal(err)
+	}
+
+	stignore := `
+	(?size>1MiB)*.iso
+	(?older-than=30d)*.log
+	(?type=dir|symlink)
+	`
+	pats := New(ffs, WithCache(true))
+	if err := pats.Parse(bytes.NewBufferString(stignore), ".stignore"); err != nil {
+		t.Fatal(err)
+	}
+	if !pats.HasConditions() {
+		t.Error("HasConditions should be true")
+	}
+
+	tcs := []struct {
+		file    string
+		ignored bool
+	}{
+		{"small.iso", false},
+		{"large.iso", true},
+		{"old.log", true},
+		{"new.log", false},
+		{"cache", true},
+		{"link", true},
+	}
+	for _, tc := range tcs {
+		info, err := ffs.Lstat(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := pats.MatchInfo(tc.file, info).IsIgnored(); res != tc.ignored {
+			t.Errorf("MatchInfo(%q) = %v, expected %v", tc.file, res, tc.ignored)
+		}
+		// Without file info the conditional patterns don't apply.
+		if pats.Match(tc.file).IsIgnored() {
+			t.Errorf("Match(%q) should not be ignored", tc.file)
+		}
+	}
+
+	expected := []string{"(?size>1MiB)*.iso", "(?size>1MiB)**/*.iso"}
+	if patterns := pats.Patterns(); patterns[0] != expected[0] || patterns[1] != expected[1] {
+		t.Errorf("Unexpected patterns %v", patterns[:2])
+	}
+}
+
+func TestParseConditions(t *testing.T) {
+	tcs := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"(?size>100M)", true},
+		{"(?size<=1.5GiB)*.iso", true},
+		{"(?size=0)", true},
+		{"(?size>=10kB)(?size<1MB)foo", true},
+		{"(?older-than=30d)", true},
+		{"(?newer-than=2w)*.tmp", true},
+		{"(?type=dir|file|symlink)", true},
+		{"!(?i)(?type=file)FOO", true},
+		{"(?size100M)", false},
+		{"(?size>100X)", false},
+		{"(?size>M)", false},
+		{"(?older-than=30)", false},
+		{"(?older-than=d)", false},
+		{"(?type=socket)", false},
+	}
+
+	for _, tc := range tcs {
+		_, err := parseLine(tc.pattern)
+		if valid := err == nil; valid != tc.valid {
+			t.Errorf("Pattern %q: got error %v, expected valid %v", tc.pattern, err, tc.valid)
+		}
+	}
+}
+
+func TestSharedLines(t *testing.T) {
+	shared := []string{"shared", "!local", "(?d)"}
+	pats := New(fs.NewFilesystem(fs.FilesystemTypeFake, ""), WithShared(shared[:2]))
+	if err := pats.Parse(bytes.NewBufferString("local\n!shared/keep"), ".stignore"); err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		file    string
+		ignored bool
+	}{
+		{"shared", true},
+		{"shared/foo", true},
+		{"shared/keep", false},
+		{"local", true},
+		{"other", false},
+	}
+	for _, tc := range tcs {
+		if res := pats.Match(tc.file).IsIgnored(); res != tc.ignored {
+			t.Errorf("Match(%q) = %v, expected %v", tc.file, res, tc.ignored)
+		}
+	}
+	if len(pats.Lines()) != 2 || len(pats.SharedLines()) != 2 {
+		t.Errorf("Unexpected lines %v, shared %v", pats.Lines(), pats.SharedLines())
+	}
+
+	// Errors in the shared lines don't affect the local patterns.
+	pats = New(fs.NewFilesystem(fs.FilesystemTypeFake, ""), WithShared(shared))
+	if err := pats.Parse(bytes.NewBufferString("local"), ".stignore"); err == nil {
+		t.Error("Expected error from invalid shared pattern")
+	}
+	if !pats.Match("local").IsIgnored() {
+		t.Error("local should be ignored")
+	}
+}
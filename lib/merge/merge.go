@@ -0,0 +1,174 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package merge implements a line-based three-way merge of text files, in
+// the manner of diff3.
+package merge
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	ErrConflict = errors.New("conflicting changes")
+	ErrBinary   = errors.New("not a text file")
+	ErrTooLarge = errors.New("too many changed lines to merge")
+)
+
+// maxCells limits the size of the table used to match the changed lines of
+// a file against its ancestor.
+const maxCells = 1 << 22
+
+// Merge combines the changes made to base in a and in b. Changes to
+// different lines are both kept, as are identical changes to the same
+// lines; anything else is a conflict.
+func Merge(base, a, b []byte) ([]byte, error) {
+	for _, data := range [][]byte{base, a, b} {
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil, ErrBinary
+		}
+	}
+
+	o, la, lb := splitLines(base), splitLines(a), splitLines(b)
+	matchA, err := match(o, la)
+	if err != nil {
+		return nil, err
+	}
+	matchB, err := match(o, lb)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	var io, ia, ib int
+	for {
+		// Lines unchanged on both sides
+		for io < len(o) && matchA[io] == ia && matchB[io] == ib {
+			out.WriteString(o[io])
+			io++
+			ia++
+			ib++
+		}
+
+		// The changed chunk extends to the next line of base kept on both
+		// sides, or to the end.
+		next := io
+		for next < len(o) && (matchA[next] < 0 || matchB[next] < 0) {
+			next++
+		}
+		endA, endB := len(la), len(lb)
+		if next < len(o) {
+			endA, endB = matchA[next], matchB[next]
+		}
+		if next == io && endA == ia && endB == ib {
+			return out.Bytes(), nil
+		}
+
+		chunkO, chunkA, chunkB := o[io:next], la[ia:endA], lb[ib:endB]
+		switch {
+		case equal(chunkA, chunkO), equal(chunkA, chunkB):
+			writeLines(&out, chunkB)
+		case equal(chunkB, chunkO):
+			writeLines(&out, chunkA)
+		default:
+			return nil, ErrConflict
+		}
+		io, ia, ib = next, endA, endB
+	}
+}
+
+// match returns, for each line of base, the index of the matching line in
+// the longest common subsequence of base and other, or -1.
+func match(base, other []string) ([]int, error) {
+	res := make([]int, len(base))
+	for i := range res {
+		res[i] = -1
+	}
+
+	// The common prefix and suffix match trivially, which leaves only the
+	// lines in between to compare.
+	pre := 0
+	for pre < len(base) && pre < len(other) && base[pre] == other[pre] {
+		res[pre] = pre
+		pre++
+	}
+	suf := 0
+	for suf < len(base)-pre && suf < len(other)-pre && base[len(base)-1-suf] == other[len(other)-1-suf] {
+		res[len(base)-1-suf] = len(other) - 1 - suf
+		suf++
+	}
+
+	x, y := base[pre:len(base)-suf], other[pre:len(other)-suf]
+	if len(x) == 0 || len(y) == 0 {
+		return res, nil
+	}
+	if (len(x)+1)*(len(y)+1) > maxCells {
+		return nil, ErrTooLarge
+	}
+
+	// lcs[i*w+j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	w := len(y) + 1
+	lcs := make([]int32, (len(x)+1)*w)
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+				lcs[i*w+j] = lcs[(i+1)*w+j]
+			default:
+				lcs[i*w+j] = lcs[i*w+j+1]
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(x) && j < len(y); {
+		switch {
+		case x[i] == y[j]:
+			res[pre+i] = pre + j
+			i++
+			j++
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return res, nil
+}
+
+// splitLines splits the data after each newline, keeping the newlines.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		n := bytes.IndexByte(data, '\n') + 1
+		if n == 0 {
+			n = len(data)
+		}
+		lines = append(lines, string(data[:n]))
+		data = data[n:]
+	}
+	return lines
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(buf *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+}
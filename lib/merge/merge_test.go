@@ -0,0 +1,51 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package merge
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	cases := []struct {
+		a, b, result string
+		err          error
+	}{
+		// Changes to different lines
+		{"A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", nil},
+		// Insertions and deletions
+		{"a\nb\nc\nd\nx\ne\n", "a\nc\nd\ne\n", "a\nc\nd\nx\ne\n", nil},
+		// Adjacent changes conflict, like in diff3
+		{"a\nb\nx\nc\nd\ne\n", "a\nc\nd\ne\n", "", ErrConflict},
+		{"a\nb\nc\nd\ne\nf\n", "z\na\nb\nc\nd\ne\n", "z\na\nb\nc\nd\ne\nf\n", nil},
+		// Only one side changed
+		{base, "a\nB\nc\n", "a\nB\nc\n", nil},
+		// The same change on both sides
+		{"a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n", nil},
+		// A missing final newline is kept
+		{"a\nb\nc\nd\ne", base, "a\nb\nc\nd\ne", nil},
+		// Different changes to the same line
+		{"a\nB\nc\nd\ne\n", "a\nX\nc\nd\ne\n", "", ErrConflict},
+		// Different insertions at the same place
+		{"a\nb\nx\nc\nd\ne\n", "a\nb\ny\nc\nd\ne\n", "", ErrConflict},
+		// Binary contents
+		{"a\x00\n", base, "", ErrBinary},
+	}
+
+	for i, tc := range cases {
+		res, err := Merge([]byte(base), []byte(tc.a), []byte(tc.b))
+		if err != tc.err {
+			t.Errorf("%d: expected error %v, got %v", i, tc.err, err)
+			continue
+		}
+		if string(res) != tc.result {
+			t.Errorf("%d: expected %q, got %q", i, tc.result, res)
+		}
+	}
+}
@@ -9,6 +9,7 @@ package model
 import (
 	"bytes"
 	"context"
+	"io"
 	"net"
 	"sync"
 	"time"
@@ -82,7 +83,7 @@ func (f *fakeConnection) IndexUpdate(ctx context.Context, folder string, fs []pr
 	return nil
 }
 
-func (f *fakeConnection) Request(ctx context.Context, folder, name string, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
+func (f *fakeConnection) Request(ctx context.Context, folder, name string, blockNo int, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
 	f.mut.Lock()
 	defer f.mut.Unlock()
 	if f.requestFn != nil {
@@ -93,6 +94,8 @@ func (f *fakeConnection) Request(ctx context.Context, folder, name string, offse
 
 func (f *fakeConnection) ClusterConfig(protocol.ClusterConfig) {}
 
+func (f *fakeConnection) SetFolderPasswords(map[string]string) {}
+
 func (f *fakeConnection) Ping() bool {
 	f.mut.Lock()
 	defer f.mut.Unlock()
@@ -250,6 +253,12 @@ func (f *fakeUnderlyingConn) String() string {
 	return ""
 }
 
+func (f *fakeUnderlyingConn) AddSecondary(io.Reader, io.Writer, io.Closer, string) {}
+
+func (f *fakeUnderlyingConn) Connections() []protocol.ConnectionStatistics {
+	return nil
+}
+
 type fakeAddr struct{}
 
 func (fakeAddr) Network() string {
@@ -10,6 +10,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"sort"
 	"sync/atomic"
@@ -28,6 +29,7 @@ import (
 	"github.com/syncthing/syncthing/lib/scanner"
 	"github.com/syncthing/syncthing/lib/stats"
 	"github.com/syncthing/syncthing/lib/sync"
+	"github.com/syncthing/syncthing/lib/versioner"
 	"github.com/syncthing/syncthing/lib/watchaggregator"
 
 	"github.com/thejerf/suture"
@@ -42,11 +44,12 @@ type folder struct {
 
 	localFlags uint32
 
-	model   *model
-	shortID protocol.ShortID
-	fset    *db.FileSet
-	ignores *ignore.Matcher
-	ctx     context.Context
+	model     *model
+	shortID   protocol.ShortID
+	fset      *db.FileSet
+	ignores   *ignore.Matcher
+	versioner versioner.Versioner // may be nil
+	ctx       context.Context
 
 	scanInterval        time.Duration
 	scanTimer           *time.Timer
@@ -58,6 +61,10 @@ type folder struct {
 
 	pullScheduled chan struct{}
 
+	hydrateNow  chan hydrateRequest
+	accessed    map[string]time.Time // for evicting the least recently used on-demand files
+	accessedMut sync.Mutex
+
 	watchCancel      context.CancelFunc
 	watchChan        chan []string
 	restartWatchChan chan struct{}
@@ -76,17 +83,25 @@ type puller interface {
 	pull() bool // true when successfull and should not be retried
 }
 
-func newFolder(model *model, fset *db.FileSet, ignores *ignore.Matcher, cfg config.FolderConfiguration, evLogger events.Logger, ioLimiter *byteSemaphore) folder {
+// mtimeFS returns the MtimeFS of the folder. Unlike the one of the file set
+// it doesn't hydrate placeholders, as hydrating is done on the folder's
+// goroutine and so must not be triggered from it.
+func (f *folder) mtimeFS() *fs.MtimeFS {
+	return f.fset.MtimeFSOn(f.Filesystem())
+}
+
+func newFolder(model *model, fset *db.FileSet, ignores *ignore.Matcher, cfg config.FolderConfiguration, ver versioner.Versioner, evLogger events.Logger, ioLimiter *byteSemaphore) folder {
 	return folder{
 		stateTracker:              newStateTracker(cfg.ID, evLogger),
 		FolderConfiguration:       cfg,
 		FolderStatisticsReference: stats.NewFolderStatisticsReference(model.db, cfg.ID),
 		ioLimiter:                 ioLimiter,
 
-		model:   model,
-		shortID: model.shortID,
-		fset:    fset,
-		ignores: ignores,
+		model:     model,
+		shortID:   model.shortID,
+		fset:      fset,
+		ignores:   ignores,
+		versioner: ver,
 
 		scanInterval:        time.Duration(cfg.RescanIntervalS) * time.Second,
 		scanTimer:           time.NewTimer(time.Millisecond), // The first scan should be done immediately.
@@ -97,6 +112,10 @@ func newFolder(model *model, fset *db.FileSet, ignores *ignore.Matcher, cfg conf
 
 		pullScheduled: make(chan struct{}, 1), // This needs to be 1-buffered so that we queue a pull if we're busy when it comes.
 
+		hydrateNow:  make(chan hydrateRequest),
+		accessed:    make(map[string]time.Time),
+		accessedMut: sync.NewMutex(),
+
 		watchCancel:      func() {},
 		restartWatchChan: make(chan struct{}, 1),
 		watchMut:         sync.NewMutex(),
@@ -176,6 +195,10 @@ func (f *folder) serve(ctx context.Context) {
 			l.Debugln(f, "Scanning due to request")
 			req.err <- f.scanSubdirs(req.subdirs)
 
+		case req := <-f.hydrateNow:
+			l.Debugln(f, "Hydrating", req.name)
+			req.err <- f.hydrate(req.name)
+
 		case next := <-f.scanDelay:
 			l.Debugln(f, "Delaying scan")
 			f.scanTimer.Reset(next)
@@ -375,7 +398,7 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 
 	f.setState(FolderScanning)
 
-	mtimefs := f.fset.MtimeFS()
+	mtimefs := f.mtimeFS()
 	fchan := scanner.Walk(f.ctx, scanner.Config{
 		Folder:                f.ID,
 		Subs:                  subDirs,
@@ -391,6 +414,9 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 		LocalFlags:            f.localFlags,
 		ModTimeWindow:         f.ModTimeWindow(),
 		EventLogger:           f.evLogger,
+		ScanXattrs:            f.SyncXattrs,
+		XattrFilter:           f.XattrFilter,
+		ScanOwnership:         f.SyncOwnership,
 	})
 
 	batchFn := func(fs []protocol.FileInfo) error {
@@ -409,7 +435,12 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 				switch gf, ok := snap.GetGlobal(fs[i].Name); {
 				case !ok:
 					continue
-				case gf.IsEquivalentOptional(fs[i], f.ModTimeWindow(), false, false, protocol.FlagLocalReceiveOnly):
+				case gf.IsEquivalentOptional(fs[i], protocol.FileInfoComparison{
+					ModTimeWindow:   f.ModTimeWindow(),
+					IgnoreFlags:     protocol.FlagLocalReceiveOnly,
+					IgnoreXattrs:    !f.SyncXattrs,
+					IgnoreOwnership: !f.SyncOwnership,
+				}):
 					// What we have locally is equivalent to the global file.
 					fs[i].Version = fs[i].Version.Merge(gf.Version)
 					fallthrough
@@ -445,6 +476,9 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 			return err
 		}
 
+		if f.VersionLocalChanges {
+			f.archiveLocalChange(snap, res.File)
+		}
 		batch.append(res.File)
 		changes++
 	}
@@ -463,6 +497,8 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 	// ignored files.
 	var toIgnore []db.FileInfoTruncated
 	ignoredParent := ""
+	// Items we have that selective sync doesn't select
+	var toDeselect []db.FileInfoTruncated
 
 	snap.Release()
 	snap = f.fset.Snapshot()
@@ -500,7 +536,7 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 				ignoredParent = ""
 			}
 
-			switch ignored := f.ignores.Match(file.Name).IsIgnored(); {
+			switch ignored := f.isIgnored(mtimefs, file.Name); {
 			case !file.IsIgnored() && ignored:
 				// File was not ignored at last pass but has been ignored.
 				if file.IsDirectory() {
@@ -519,6 +555,10 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 				batch.append(nf)
 				changes++
 
+			case file.IsDeselected() && !f.IsSelected(file.Name):
+				// The item isn't pulled due to selective sync, so it
+				// missing on disk is not a deletion.
+
 			case file.IsIgnored() && !ignored:
 				// Successfully scanned items are already un-ignored during
 				// the scan, so check whether it is deleted.
@@ -534,6 +574,9 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 						toIgnore = toIgnore[:0]
 						ignoredParent = ""
 					}
+					if (!file.IsInvalid() || file.IsPlaceholder()) && !f.IsSelected(file.Name) {
+						toDeselect = append(toDeselect, file)
+					}
 					return true
 				}
 				nf := file.ConvertToDeletedFileInfo(f.shortID)
@@ -575,6 +618,21 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 		}
 	}
 
+	// Remove deselected items in reverse order, i.e. the contents of
+	// directories before the directories themselves.
+	for i := len(toDeselect) - 1; i >= 0; i-- {
+		file := toDeselect[i]
+		if !f.removeDeselected(snap, mtimefs, file) {
+			continue
+		}
+		l.Debugln("marking file as deselected", file)
+		batch.append(file.ConvertToDeselectedFileInfo(f.shortID))
+		changes++
+		if err := batch.flushIfFull(); err != nil {
+			return err
+		}
+	}
+
 	if err := batch.flush(); err != nil {
 		return err
 	}
@@ -584,8 +642,120 @@ func (f *folder) scanSubdirs(subDirs []string) error {
 	return nil
 }
 
+// removeDeselected removes an item that is no longer selected for selective
+// sync from disk, and returns whether it did. Only items that are in sync
+// with the global version, and available from another device, are removed.
+// Directories are only removed when empty.
+func (f *folder) removeDeselected(snap *db.Snapshot, ffs fs.Filesystem, file db.FileInfoTruncated) bool {
+	if !availableElsewhere(snap, file) {
+		return false
+	}
+	if err := ffs.Remove(file.Name); err != nil && !fs.IsNotExist(err) {
+		l.Debugf("%v: removing deselected %v: %v", f, file.Name, err)
+		return false
+	}
+	return true
+}
+
+// isIgnored returns whether the item is ignored, also considering ignore
+// patterns with conditions on the attributes of the item on disk.
+func (f *folder) isIgnored(ffs fs.Filesystem, name string) bool {
+	if f.ignores.HasConditions() {
+		if info, err := ffs.Lstat(name); err == nil {
+			return f.ignores.MatchInfo(name, info).IsIgnored()
+		}
+	}
+	return f.ignores.Match(name).IsIgnored()
+}
+
+// isIgnoredAnnounced returns whether the needed item is ignored, also
+// considering ignore patterns with conditions on the attributes it was
+// announced with, so that it isn't pulled just to be ignored on the next
+// scan.
+func (f *folder) isIgnoredAnnounced(file protocol.FileInfo) bool {
+	if f.ignores.ShouldIgnore(file.Name) {
+		return true
+	}
+	if file.IsDeleted() || !f.ignores.HasConditions() {
+		return false
+	}
+	return f.ignores.MatchInfo(file.Name, announcedInfo{file}).IsIgnored()
+}
+
+// announcedInfo presents the attributes of an announced item as an
+// fs.FileInfo.
+type announcedInfo struct {
+	file protocol.FileInfo
+}
+
+func (i announcedInfo) Name() string {
+	return filepath.Base(i.file.Name)
+}
+
+func (i announcedInfo) Mode() fs.FileMode {
+	mode := fs.FileMode(i.file.Permissions & 0777)
+	switch {
+	case i.file.IsDirectory():
+		mode |= fs.FileMode(os.ModeDir)
+	case i.file.IsSymlink():
+		mode |= fs.ModeSymlink
+	}
+	return mode
+}
+
+func (i announcedInfo) Size() int64 {
+	return i.file.Size
+}
+
+func (i announcedInfo) ModTime() time.Time {
+	return i.file.ModTime()
+}
+
+func (i announcedInfo) IsDir() bool {
+	return i.file.IsDirectory()
+}
+
+func (i announcedInfo) IsRegular() bool {
+	return i.file.Type == protocol.FileInfoTypeFile
+}
+
+func (i announcedInfo) IsSymlink() bool {
+	return i.file.IsSymlink()
+}
+
+func (i announcedInfo) Owner() int {
+	if i.file.Platform.Unix != nil {
+		return i.file.Platform.Unix.UID
+	}
+	return -1
+}
+
+func (i announcedInfo) Group() int {
+	if i.file.Platform.Unix != nil {
+		return i.file.Platform.Unix.GID
+	}
+	return -1
+}
+
+// availableElsewhere returns whether the item is in sync with the global
+// version and available from another device, i.e. whether our copy can go
+// without losing data.
+func availableElsewhere(snap *db.Snapshot, file db.FileInfoTruncated) bool {
+	gf, ok := snap.GetGlobalTruncated(file.Name)
+	if !ok || !gf.Version.Equal(file.Version) {
+		return false
+	}
+	for _, dev := range snap.Availability(file.Name) {
+		if dev != protocol.LocalDeviceID {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *folder) scanTimerFired() {
 	err := f.scanSubdirs(nil)
+	f.pruneHistory()
 
 	select {
 	case <-f.initialScanFinished:
@@ -601,6 +771,18 @@ func (f *folder) scanTimerFired() {
 	f.Reschedule()
 }
 
+// pruneHistory removes the history entries older than the configured
+// maximum age.
+func (f *folder) pruneHistory() {
+	if !f.KeepHistory || f.HistoryMaxAgeDays <= 0 {
+		return
+	}
+	before := time.Now().Add(-time.Duration(f.HistoryMaxAgeDays) * 24 * time.Hour)
+	if err := f.fset.PruneHistory(before); err != nil {
+		l.Infof("Failed to prune history of folder %s: %v", f.Description(), err)
+	}
+}
+
 func (f *folder) WatchError() error {
 	f.watchMut.Lock()
 	defer f.watchMut.Unlock()
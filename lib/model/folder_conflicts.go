@@ -0,0 +1,245 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+var errNotConflictCopy = errors.New("not a conflict copy")
+
+type conflictSide int
+
+const (
+	conflictKeepBoth conflictSide = iota
+	conflictKeepLocal
+	conflictKeepRemote
+)
+
+// conflictWinner decides which side of a conflict between files to keep,
+// according to the folder's conflict policy. Both are kept when the
+// policy doesn't decide, and when there is no versioner to keep the loser.
+func (f *sendReceiveFolder) conflictWinner(local, remote protocol.FileInfo) conflictSide {
+	if f.versioner == nil {
+		return conflictKeepBoth
+	}
+
+	switch f.ConflictPolicy {
+	case config.ConflictKeepNewest:
+		switch {
+		case local.ModTime().After(remote.ModTime()):
+			return conflictKeepLocal
+		case remote.ModTime().After(local.ModTime()):
+			return conflictKeepRemote
+		}
+		return conflictTieBreak(local, remote)
+
+	case config.ConflictKeepLargest:
+		switch {
+		case local.Size > remote.Size:
+			return conflictKeepLocal
+		case remote.Size > local.Size:
+			return conflictKeepRemote
+		}
+		return conflictTieBreak(local, remote)
+
+	case config.ConflictPreferMaster:
+		if f.ConflictMasterDevice == protocol.EmptyDeviceID {
+			break
+		}
+		master := f.ConflictMasterDevice.Short()
+		if remote.ModifiedBy == master {
+			return conflictKeepRemote
+		}
+		if local.ModifiedBy == master {
+			return conflictKeepLocal
+		}
+	}
+
+	return conflictKeepBoth
+}
+
+// conflictTieBreak picks the side of a conflict that the policy can't
+// decide on. Each device sees the other side as remote, so the choice must
+// come out the same either way, or both would take the other's contents:
+// the file last modified by the greater device short ID wins, then the one
+// with the greater version vector.
+func conflictTieBreak(local, remote protocol.FileInfo) conflictSide {
+	switch {
+	case local.ModifiedBy > remote.ModifiedBy:
+		return conflictKeepLocal
+	case remote.ModifiedBy > local.ModifiedBy:
+		return conflictKeepRemote
+	}
+	switch c := compareCounters(local.Version.Counters, remote.Version.Counters); {
+	case c > 0:
+		return conflictKeepLocal
+	case c < 0:
+		return conflictKeepRemote
+	}
+	return conflictKeepBoth
+}
+
+// compareCounters orders the counters of version vectors, which are sorted
+// by device, element by element and then by length.
+func compareCounters(a, b []protocol.Counter) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i].ID != b[i].ID:
+			if a[i].ID > b[i].ID {
+				return 1
+			}
+			return -1
+		case a[i].Value != b[i].Value:
+			if a[i].Value > b[i].Value {
+				return 1
+			}
+			return -1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// keepLocalInConflict keeps the local file instead of the pulled one in
+// the temporary file, which is versioned in its place. The local file gets
+// a version superseding both, so that the other devices pick it up.
+func (f *sendReceiveFolder) keepLocalInConflict(cur, file protocol.FileInfo, tempName string, dbUpdateChan chan<- dbUpdateJob) error {
+	f.fs.Chtimes(tempName, file.ModTime(), file.ModTime()) // never fails
+	err := f.inWritableDir(func(tempName string) error {
+		return f.archiveLoser(f.fs, tempName, file.Name)
+	}, tempName)
+	if err != nil {
+		return err
+	}
+
+	cur.Version = file.Version.Merge(cur.Version).Update(f.shortID)
+	cur.ModifiedBy = f.shortID
+	dbUpdateChan <- dbUpdateJob{cur, dbUpdateHandleFile}
+	return nil
+}
+
+// archiveLoser versions the file at src, the losing side of a conflict, as
+// a version of the named file. Without a versioner it's removed.
+func (f *folder) archiveLoser(ffs fs.Filesystem, src, name string) error {
+	switch ver := f.versioner.(type) {
+	case nil:
+		return ffs.Remove(src)
+	case versioner.ContentArchiver:
+		return ver.ArchiveFrom(src, name)
+	default:
+		return ver.Archive(src)
+	}
+}
+
+// A Conflict is a conflict copy along with the file it's a copy of.
+type Conflict struct {
+	Name     string        `json:"name"`
+	Copy     string        `json:"copy"`
+	File     *ConflictFile `json:"file"` // nil when the file doesn't exist
+	CopyFile ConflictFile  `json:"copyFile"`
+}
+
+type ConflictFile struct {
+	ModTime    time.Time `json:"modTime"`
+	Size       int64     `json:"size"`
+	ModifiedBy string    `json:"modifiedBy"`
+}
+
+func newConflictFile(file db.FileIntf) ConflictFile {
+	return ConflictFile{
+		ModTime:    file.ModTime(),
+		Size:       file.FileSize(),
+		ModifiedBy: file.FileModifiedBy().String(),
+	}
+}
+
+// Conflicts returns the conflict copies in the folder, sorted by name.
+func (f *folder) Conflicts() []Conflict {
+	snap := f.fset.Snapshot()
+	defer snap.Release()
+
+	var conflicts []Conflict
+	snap.WithHaveTruncated(protocol.LocalDeviceID, func(intf db.FileIntf) bool {
+		if intf.IsDeleted() || intf.IsInvalid() || intf.IsDirectory() {
+			return true
+		}
+		name, ok := conflictOriginal(intf.FileName())
+		if !ok {
+			return true
+		}
+		conflict := Conflict{
+			Name:     name,
+			Copy:     intf.FileName(),
+			CopyFile: newConflictFile(intf),
+		}
+		if file, ok := snap.Get(protocol.LocalDeviceID, name); ok && !file.IsDeleted() && !file.IsInvalid() {
+			cf := newConflictFile(file)
+			conflict.File = &cf
+		}
+		conflicts = append(conflicts, conflict)
+		return true
+	})
+
+	sort.Slice(conflicts, func(a, b int) bool {
+		return conflicts[a].Copy < conflicts[b].Copy
+	})
+	return conflicts
+}
+
+// ResolveConflict keeps either the conflict copy, in place of the file it's
+// a copy of, or the file. The other one is versioned, or removed without a
+// versioner.
+func (f *folder) ResolveConflict(copyName string, keepCopy bool) error {
+	copyName = osutil.NativeFilename(copyName)
+	name, ok := conflictOriginal(copyName)
+	if !ok {
+		return errNotConflictCopy
+	}
+
+	ffs := f.mtimeFS()
+	if _, err := ffs.Lstat(copyName); err != nil {
+		return err
+	}
+	loser := copyName
+	if keepCopy {
+		loser = name
+	}
+	if err := f.archiveLoser(ffs, loser, name); err != nil && !fs.IsNotExist(err) {
+		return err
+	}
+	if keepCopy {
+		if err := osutil.RenameOrCopy(ffs, ffs, copyName, name); err != nil {
+			return err
+		}
+	}
+
+	return f.Scan([]string{name, copyName})
+}
+
+var conflictExp = regexp.MustCompile(`\.sync-conflict-\d{8}-\d{6}(?:-[A-Z2-7]{7})?`)
+
+// conflictOriginal returns the name of the file the conflict copy is a copy
+// of.
+func conflictOriginal(name string) (string, bool) {
+	dir, base := filepath.Split(name)
+	loc := conflictExp.FindStringIndex(base)
+	if loc == nil {
+		return "", false
+	}
+	return dir + base[:loc[0]] + base[loc[1]:], true
+}
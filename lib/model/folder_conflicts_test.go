@@ -0,0 +1,172 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+func TestConflictOriginal(t *testing.T) {
+	cases := []struct {
+		name, original string
+	}{
+		{"foo.sync-conflict-20200101-120000-ABCDEFG.txt", "foo.txt"},
+		{filepath.Join("dir", "foo.sync-conflict-20200101-120000-ABCDEFG"), filepath.Join("dir", "foo")},
+		{"foo.sync-conflict-20200101-120000.txt", "foo.txt"},
+		{"foo.txt", ""},
+		{"foo.sync-conflict-2020.txt", ""},
+	}
+
+	for _, tc := range cases {
+		original, ok := conflictOriginal(tc.name)
+		if ok != (tc.original != "") || original != tc.original {
+			t.Errorf("conflictOriginal(%q) = %q, %v; expected %q", tc.name, original, ok, tc.original)
+		}
+	}
+}
+
+func TestConflictWinner(t *testing.T) {
+	m, f := setupSendReceiveFolder()
+	defer cleanupModelAndRemoveDir(m, f.Filesystem().URI())
+
+	now := time.Now()
+	local := protocol.FileInfo{Name: "file", Size: 100, ModifiedS: now.Unix(), ModifiedBy: myID.Short()}
+	remote := protocol.FileInfo{Name: "file", Size: 10, ModifiedS: now.Add(-time.Hour).Unix(), ModifiedBy: device1.Short()}
+
+	cases := []struct {
+		policy config.ConflictPolicy
+		master protocol.DeviceID
+		winner conflictSide
+	}{
+		{config.ConflictKeepBoth, protocol.EmptyDeviceID, conflictKeepBoth},
+		{config.ConflictKeepNewest, protocol.EmptyDeviceID, conflictKeepLocal},
+		{config.ConflictKeepLargest, protocol.EmptyDeviceID, conflictKeepLocal},
+		{config.ConflictPreferMaster, device1, conflictKeepRemote},
+		{config.ConflictPreferMaster, myID, conflictKeepLocal},
+		{config.ConflictPreferMaster, device2, conflictKeepBoth},
+		{config.ConflictPreferMaster, protocol.EmptyDeviceID, conflictKeepBoth},
+	}
+
+	// Without a versioner the loser would be lost, so both are kept.
+	f.ConflictPolicy = config.ConflictKeepNewest
+	if winner := f.conflictWinner(local, remote); winner != conflictKeepBoth {
+		t.Errorf("expected both sides to be kept without a versioner, got %v", winner)
+	}
+
+	ver, err := versioner.New(f.Filesystem(), config.VersioningConfiguration{Type: "simple"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.versioner = ver
+
+	for _, tc := range cases {
+		f.ConflictPolicy = tc.policy
+		f.ConflictMasterDevice = tc.master
+		if winner := f.conflictWinner(local, remote); winner != tc.winner {
+			t.Errorf("%v (master %v): expected %v, got %v", tc.policy, tc.master, tc.winner, winner)
+		}
+		if tc.winner == conflictKeepLocal || tc.winner == conflictKeepRemote {
+			if winner := f.conflictWinner(remote, local); winner == tc.winner {
+				t.Errorf("%v (master %v): expected the other side to win when swapped", tc.policy, tc.master)
+			}
+		}
+	}
+}
+
+func TestConflictWinnerTie(t *testing.T) {
+	m, f := setupSendReceiveFolder()
+	defer cleanupModelAndRemoveDir(m, f.Filesystem().URI())
+
+	ver, err := versioner.New(f.Filesystem(), config.VersioningConfiguration{Type: "simple"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.versioner = ver
+
+	// Same modification time and size on both sides.
+	now := time.Now()
+	local := protocol.FileInfo{Name: "file", Size: 100, ModifiedS: now.Unix(), ModifiedBy: myID.Short()}
+	local.Version = local.Version.Update(myID.Short())
+	remote := protocol.FileInfo{Name: "file", Size: 100, ModifiedS: now.Unix(), ModifiedBy: device1.Short()}
+	remote.Version = remote.Version.Update(device1.Short())
+	// Same modifying device, different versions.
+	other := remote
+	other.Version = other.Version.Copy().Update(device1.Short()).Update(device2.Short())
+
+	for _, policy := range []config.ConflictPolicy{config.ConflictKeepNewest, config.ConflictKeepLargest} {
+		f.ConflictPolicy = policy
+		for _, pair := range [][2]protocol.FileInfo{{local, remote}, {remote, other}} {
+			// Both devices must agree on the file to keep.
+			winner := f.conflictWinner(pair[0], pair[1])
+			swapped := f.conflictWinner(pair[1], pair[0])
+			if winner == conflictKeepBoth || swapped == conflictKeepBoth || winner == swapped {
+				t.Errorf("%v: expected the same file to win on both sides of a tie, got %v and %v when swapped", policy, winner, swapped)
+			}
+		}
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	fcfg.Versioning = config.VersioningConfiguration{Type: "trashcan"}
+	w.SetFolder(fcfg)
+	m := setupModel(w)
+	tfs := fcfg.Filesystem()
+	defer cleanupModelAndRemoveDir(m, tfs.URI())
+
+	copyName := "file.sync-conflict-20200101-120000-ABCDEFG.txt"
+	must(t, writeFile(tfs, "file.txt", []byte("original"), 0644))
+	must(t, writeFile(tfs, copyName, []byte("conflict copy"), 0644))
+	if err := m.ScanFolder("default"); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := m.Conflicts("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Name != "file.txt" || conflicts[0].Copy != copyName || conflicts[0].File == nil {
+		t.Fatalf("Unexpected conflicts %v", conflicts)
+	}
+
+	if err := m.ResolveConflict("default", copyName, true); err != nil {
+		t.Fatal(err)
+	}
+	if bs := readFile(t, tfs, "file.txt"); string(bs) != "conflict copy" {
+		t.Errorf("Expected the conflict copy to be kept, got %q", bs)
+	}
+	if bs := readFile(t, tfs, filepath.Join(".stversions", "file.txt")); string(bs) != "original" {
+		t.Errorf("Expected the original to be versioned, got %q", bs)
+	}
+	if conflicts, err := m.Conflicts("default"); err != nil {
+		t.Fatal(err)
+	} else if len(conflicts) != 0 {
+		t.Error("Expected no conflicts after resolving, got", conflicts)
+	}
+}
+
+func readFile(t *testing.T, ffs fs.Filesystem, name string) []byte {
+	t.Helper()
+	fd, err := ffs.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	bs, err := ioutil.ReadAll(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
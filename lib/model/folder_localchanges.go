@@ -0,0 +1,72 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+// When versioning local changes, the scanner archives the previous contents
+// of files modified locally. As the file on disk already has the new
+// contents, the previous ones are put back together in a temporary file,
+// from blocks still present in the folder (e.g. the unchanged parts of the
+// file itself) or else requested from other devices. The versioner then
+// archives the temporary file as a version of the modified one.
+
+// archiveLocalChange archives the previous contents of the scanned file, if
+// it was a modified regular file. Failing to do so doesn't fail the scan.
+func (f *folder) archiveLocalChange(snap *db.Snapshot, file protocol.FileInfo) {
+	archiver, ok := f.versioner.(versioner.ContentArchiver)
+	if !ok {
+		return
+	}
+	if file.IsDeleted() || file.IsInvalid() {
+		return
+	}
+	cur, ok := snap.Get(protocol.LocalDeviceID, file.Name)
+	if !ok || cur.Type != protocol.FileInfoTypeFile || cur.IsDeleted() || cur.IsInvalid() || cur.IsPlaceholder() || cur.Size == 0 {
+		return
+	}
+	if file.Type == protocol.FileInfoTypeFile && cur.BlocksEqual(file) {
+		// Only the metadata changed
+		return
+	}
+
+	ffs := f.mtimeFS()
+	tempName := fs.TempName(cur.Name)
+	if err := f.fetchFile(ffs, cur, tempName); err != nil {
+		ffs.Remove(tempName)
+		l.Infof("Failed to reconstruct previous version of %s in folder %s: %v", cur.Name, f.Description(), err)
+		return
+	}
+	ffs.Chtimes(tempName, cur.ModTime(), cur.ModTime()) // never fails
+	if err := archiver.ArchiveFrom(tempName, cur.Name); err != nil {
+		ffs.Remove(tempName)
+		l.Infof("Failed to archive previous version of %s in folder %s: %v", cur.Name, f.Description(), err)
+		return
+	}
+	l.Debugln(f, "archived previous version of locally changed", cur.Name)
+}
+
+// localBlock reads the block from a file in the folder that has it.
+func (f *folder) localBlock(file protocol.FileInfo, block protocol.BlockInfo) ([]byte, bool) {
+	ffs := f.mtimeFS()
+	buf := make([]byte, block.Size)
+	found := f.model.finder.Iterate([]string{f.ID}, block.Hash, func(_, path string, index int32) bool {
+		fd, err := ffs.Open(path)
+		if err != nil {
+			return false
+		}
+		_, err = fd.ReadAt(buf, int64(file.BlockSize())*int64(index))
+		fd.Close()
+		return err == nil && verifyBuffer(buf, block) == nil
+	})
+	return buf, found
+}
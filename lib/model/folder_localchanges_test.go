@@ -0,0 +1,56 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+func TestVersionLocalChanges(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	fcfg.Versioning = config.VersioningConfiguration{Type: "simple"}
+	fcfg.VersionLocalChanges = true
+	w.SetFolder(fcfg)
+	m := setupModel(w)
+	tfs := fcfg.Filesystem()
+	defer cleanupModelAndRemoveDir(m, tfs.URI())
+
+	// The previous contents of a are reconstructed from the identical
+	// file b.
+	original := []byte("original contents\n")
+	must(t, writeFile(tfs, "a", original, 0644))
+	must(t, writeFile(tfs, "b", original, 0644))
+	if err := m.ScanFolder("default"); err != nil {
+		t.Fatal(err)
+	}
+	must(t, writeFile(tfs, "a", []byte("changed contents\n"), 0644))
+	if err := m.ScanFolder("default"); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := m.GetFolderVersions("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions["a"]) != 1 || len(versions["b"]) != 0 {
+		t.Fatalf("Expected a single version of a, got %v", versions)
+	}
+	tag := versions["a"][0].VersionTime.Format(versioner.TimeFormat)
+	bs, err := ioutil.ReadFile(filepath.Join(tfs.URI(), ".stversions", versioner.TagFilename("a", tag)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bs, original) {
+		t.Errorf("Expected the original contents to be archived, got %q", bs)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/pkg/errors"
+
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/merge"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+// When merging conflicts, a conflict between the local file and a pulled
+// one is first resolved by a three-way merge of both changes, given their
+// common ancestor. That's the newest version in the history journal which
+// both versions supersede, as long as the versioner still has its
+// contents. The merged contents replace the local file, which is
+// versioned. Without an ancestor, or when the changes conflict, a conflict
+// copy is kept as usual. Ancestors are thus only found with keepHistory and
+// a versioner that can read versions, and those of local edits only with
+// versionLocalChanges.
+
+// maxMergeSize is the largest file merged; conflicts in larger ones are
+// unlikely to be in text files.
+const maxMergeSize = 1 << 20
+
+var (
+	errNoAncestor      = errors.New("no common ancestor available")
+	errTooLargeToMerge = errors.New("file too large to merge")
+)
+
+// warnMergeRequirements warns when conflicts are to be merged, but the
+// settings of the folder keep common ancestors from being found.
+func (f *sendReceiveFolder) warnMergeRequirements() {
+	if !f.MergeConflicts {
+		return
+	}
+	if _, ok := f.versioner.(versioner.VersionReader); !ok || !f.KeepHistory {
+		l.Warnf("Folder %s is set to merge conflicts, which needs both keepHistory and versioning other than external; conflict copies are kept instead", f.Description())
+		return
+	}
+	if !f.VersionLocalChanges {
+		l.Warnf("Folder %s is set to merge conflicts, but without versionLocalChanges the ancestors of local edits are mostly not kept; conflict copies are kept instead", f.Description())
+	}
+}
+
+// mergeConflict merges the changes of the local file and of the pulled one
+// in the temporary file. It returns the name of the temporary file holding
+// the merged contents, if successful.
+func (f *sendReceiveFolder) mergeConflict(cur, file protocol.FileInfo, tempName string) (string, bool) {
+	if !f.MergeConflicts || cur.IsPlaceholder() {
+		// A placeholder has no contents to merge.
+		return "", false
+	}
+	mergedName := fs.TempName(file.Name + ".merged")
+	if err := f.merge(cur, file, tempName, mergedName); err != nil {
+		f.fs.Remove(mergedName)
+		l.Debugf("%v: not merging conflict in %v: %v", f, file.Name, err)
+		return "", false
+	}
+	return mergedName, true
+}
+
+func (f *sendReceiveFolder) merge(cur, file protocol.FileInfo, tempName, mergedName string) error {
+	if cur.Size > maxMergeSize || file.Size > maxMergeSize {
+		return errTooLargeToMerge
+	}
+	base, err := f.conflictAncestor(cur, file)
+	if err != nil {
+		return err
+	}
+	local, err := readAll(f.fs, cur.Name)
+	if err != nil {
+		return err
+	}
+
+	if f.MergeCommand != "" {
+		// The command merges into the copy of the local file.
+		baseName := fs.TempName(file.Name + ".base")
+		defer f.fs.Remove(baseName)
+		if err := writeAll(f.fs, baseName, base); err != nil {
+			return err
+		}
+		if err := writeAll(f.fs, mergedName, local); err != nil {
+			return err
+		}
+		return f.runMergeCommand(file.Name, baseName, mergedName, tempName)
+	}
+
+	remote, err := readAll(f.fs, tempName)
+	if err != nil {
+		return err
+	}
+	merged, err := merge.Merge(base, local, remote)
+	if err != nil {
+		return err
+	}
+	if err := writeAll(f.fs, mergedName, merged); err != nil {
+		return err
+	}
+	if !f.IgnorePerms && !file.NoPermissions {
+		return f.fs.Chmod(mergedName, fs.FileMode(file.Permissions&0777))
+	}
+	return nil
+}
+
+// finishMerged replaces the local file with the merged contents and
+// records the pulled file with the merged version vector. The scan after
+// pulling then picks up the merged contents as a version superseding both.
+func (f *sendReceiveFolder) finishMerged(cur, file protocol.FileInfo, tempName, mergedName string, snap *db.Snapshot, dbUpdateChan chan<- dbUpdateJob, scanChan chan<- string) error {
+	if err := f.deleteItemOnDisk(cur, snap, scanChan); err != nil {
+		return err
+	}
+	if err := osutil.RenameOrCopy(f.fs, f.fs, mergedName, file.Name); err != nil {
+		return err
+	}
+	f.fs.Remove(tempName)
+
+	l.Infof("Merged conflicting changes to %s in folder %s", file.Name, f.Description())
+	dbUpdateChan <- dbUpdateJob{file, dbUpdateHandleFile}
+	scanChan <- file.Name
+	return nil
+}
+
+// conflictAncestor returns the contents of the common ancestor of the
+// conflicting files.
+func (f *folder) conflictAncestor(local, remote protocol.FileInfo) ([]byte, error) {
+	reader, ok := f.versioner.(versioner.VersionReader)
+	if !ok {
+		return nil, errNoAncestor
+	}
+
+	entries, err := f.fset.History(local.Name)
+	if err != nil {
+		return nil, err
+	}
+	var ancestor *protocol.FileInfo
+	for i := range entries {
+		for _, file := range []*protocol.FileInfo{entries[i].OldFile, &entries[i].NewFile} {
+			if file == nil || file.Type != protocol.FileInfoTypeFile || file.IsDeleted() || file.IsInvalid() {
+				continue
+			}
+			if !local.Version.GreaterEqual(file.Version) || !remote.Version.GreaterEqual(file.Version) {
+				continue
+			}
+			if ancestor == nil || file.Version.GreaterEqual(ancestor.Version) {
+				ancestor = file
+			}
+		}
+	}
+	if ancestor == nil {
+		return nil, errNoAncestor
+	}
+
+	versions, err := f.versioner.GetVersions()
+	if err != nil {
+		return nil, err
+	}
+	modTime := ancestor.ModTime().Truncate(time.Second)
+	for _, version := range versions[osutil.NormalizedFilename(local.Name)] {
+		if version.ModTime.Equal(modTime) && version.Size == ancestor.Size {
+			return reader.ReadVersion(local.Name, version.VersionTime)
+		}
+	}
+	return nil, errNoAncestor
+}
+
+// runMergeCommand runs the external merge command, which is expected to
+// merge the changes into the local file and exit successfully, or else
+// fail. The paths passed to it are relative to the folder path.
+func (f *sendReceiveFolder) runMergeCommand(name, baseName, localName, remoteName string) error {
+	words, err := shellquote.Split(f.MergeCommand)
+	if err != nil {
+		return errors.Wrap(err, "merge command is invalid")
+	}
+	if len(words) == 0 {
+		return errors.New("merge command is empty")
+	}
+
+	context := map[string]string{
+		"%FOLDER_FILESYSTEM%": f.fs.Type().String(),
+		"%FOLDER_PATH%":       f.fs.URI(),
+		"%FILE_PATH%":         name,
+		"%BASE%":              baseName,
+		"%LOCAL%":             localName,
+		"%REMOTE%":            remoteName,
+	}
+	for i, word := range words {
+		for key, val := range context {
+			word = strings.Replace(word, key, val, -1)
+		}
+		words[i] = word
+	}
+
+	cmd := exec.CommandContext(f.ctx, words[0], words[1:]...)
+	cmd.Dir = f.fs.URI()
+	// filter STGUIAUTH and STGUIAPIKEY from environment variables
+	for _, x := range os.Environ() {
+		if !strings.HasPrefix(x, "STGUIAUTH=") && !strings.HasPrefix(x, "STGUIAPIKEY=") {
+			cmd.Env = append(cmd.Env, x)
+		}
+	}
+	out, err := cmd.CombinedOutput()
+	l.Debugln("merge command output:", string(out))
+	return err
+}
+
+func readAll(ffs fs.Filesystem, name string) ([]byte, error) {
+	fd, err := ffs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return ioutil.ReadAll(fd)
+}
+
+func writeAll(ffs fs.Filesystem, name string, data []byte) error {
+	fd, err := ffs.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+func TestMergeConflict(t *testing.T) {
+	m, f := setupSendReceiveFolder()
+	defer cleanupSRFolder(f, m)
+
+	ver, err := versioner.New(f.Filesystem(), config.VersioningConfiguration{Type: "simple"})
+	must(t, err)
+	f.versioner = ver
+	f.fset.SetKeepHistory(true)
+	f.MergeConflicts = true
+
+	// The ancestor is changed locally, in conflict with a remote change to
+	// another line. Its contents were archived by the versioner.
+
+	base := []byte("one\ntwo\nthree\n")
+	local := []byte("ONE\ntwo\nthree\n")
+	remote := []byte("one\ntwo\nTHREE\n")
+	tempName := fs.TempName("file")
+
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	baseFile := protocol.FileInfo{
+		Name:      "file",
+		Type:      protocol.FileInfoTypeFile,
+		Size:      int64(len(base)),
+		ModifiedS: modTime.Unix(),
+		Version:   protocol.Vector{}.Update(myID.Short()),
+	}
+	must(t, writeFile(f.fs, "file", base, 0644))
+	must(t, f.fs.Chtimes("file", modTime, modTime))
+	must(t, ver.Archive("file"))
+
+	localFile := baseFile
+	localFile.Version = baseFile.Version.Copy().Update(myID.Short())
+	localFile.ModifiedS = time.Now().Unix()
+	f.fset.Update(protocol.LocalDeviceID, []protocol.FileInfo{baseFile})
+	f.fset.Update(protocol.LocalDeviceID, []protocol.FileInfo{localFile})
+	must(t, writeFile(f.fs, "file", local, 0644))
+
+	remoteFile := baseFile
+	remoteFile.Version = baseFile.Version.Copy().Update(device1.Short())
+	remoteFile.Size = int64(len(remote))
+
+	must(t, writeFile(f.fs, tempName, remote, 0644))
+	mergedName, ok := f.mergeConflict(localFile, remoteFile, tempName)
+	if !ok {
+		t.Fatal("Expected the changes to be merged")
+	}
+	if bs := readFile(t, f.fs, mergedName); string(bs) != "ONE\ntwo\nTHREE\n" {
+		t.Errorf("Unexpected merged contents %q", bs)
+	}
+	must(t, f.fs.Remove(mergedName))
+
+	// Conflicting changes to the same line aren't merged.
+
+	must(t, writeFile(f.fs, tempName, []byte("uno\ntwo\nthree\n"), 0644))
+	if _, ok := f.mergeConflict(localFile, remoteFile, tempName); ok {
+		t.Error("Expected conflicting changes not to be merged")
+	}
+
+	// Neither are files without a known ancestor.
+
+	unrelated := remoteFile
+	unrelated.Version = protocol.Vector{}.Update(device1.Short())
+	must(t, writeFile(f.fs, tempName, remote, 0644))
+	if _, ok := f.mergeConflict(localFile, unrelated, tempName); ok {
+		t.Error("Expected files without a common ancestor not to be merged")
+	}
+
+	// The external command merges into the local file.
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	f.MergeCommand = "cp %REMOTE% %LOCAL%"
+	mergedName, ok = f.mergeConflict(localFile, remoteFile, tempName)
+	if !ok {
+		t.Fatal("Expected the merge command to succeed")
+	}
+	if bs := readFile(t, f.fs, mergedName); string(bs) != string(remote) {
+		t.Errorf("Unexpected merged contents %q", bs)
+	}
+}
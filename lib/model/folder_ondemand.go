@@ -0,0 +1,314 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/scanner"
+)
+
+// In on-demand folders the puller only puts placeholders in place: sparse
+// files with the size and metadata of the real file. The contents are
+// fetched from other devices when a file is hydrated, i.e. opened through
+// the file set's MtimeFS (see model.folderFilesystem) or hydrated
+// explicitly. Hydration runs on the folder's goroutine, so the folder
+// itself reads through a filesystem that doesn't hydrate, and leaves
+// placeholders out of versioning and merging. When the fetched contents
+// take up more than the configured cache size, the least recently used
+// files are turned back into placeholders. Placeholders written to without
+// being hydrated first are reported as scan errors rather than announced,
+// as their contents are mostly zeroes.
+
+type hydrateRequest struct {
+	name string
+	err  chan error
+}
+
+// Hydrate makes sure the file has its contents on disk, fetching them when
+// it is a placeholder.
+func (f *folder) Hydrate(name string) error {
+	if !f.OnDemand {
+		return nil
+	}
+
+	f.accessedMut.Lock()
+	f.accessed[name] = time.Now()
+	f.accessedMut.Unlock()
+
+	snap := f.fset.Snapshot()
+	file, ok := snap.Get(protocol.LocalDeviceID, name)
+	snap.Release()
+	if !ok || !file.IsPlaceholder() {
+		return nil
+	}
+
+	<-f.initialScanFinished
+	req := hydrateRequest{
+		name: name,
+		err:  make(chan error),
+	}
+
+	select {
+	case f.hydrateNow <- req:
+		return <-req.err
+	case <-f.ctx.Done():
+		return f.ctx.Err()
+	}
+}
+
+// hydrate replaces the placeholder with the contents of the global version
+// of the file, and then evicts other files as necessary.
+func (f *folder) hydrate(name string) error {
+	snap := f.fset.Snapshot()
+	defer snap.Release()
+
+	cur, ok := snap.Get(protocol.LocalDeviceID, name)
+	if !ok || !cur.IsPlaceholder() {
+		// Hydrated or changed in the meantime
+		return nil
+	}
+	file, ok := snap.GetGlobal(name)
+	if !ok || file.IsDeleted() || file.IsInvalid() || file.Type != protocol.FileInfoTypeFile {
+		return errNotAvailable
+	}
+
+	ffs := f.mtimeFS()
+	if err := f.checkUnchanged(ffs, cur); err != nil {
+		return err
+	}
+
+	tempName := fs.TempName(name)
+	err := inWritableDir(func(tempName string) error {
+		return f.fetchFile(ffs, file, tempName)
+	}, ffs, tempName, f.IgnorePerms)
+	if err != nil {
+		ffs.Remove(tempName)
+		return errors.Wrap(err, "fetching contents")
+	}
+
+	if err := osutil.RenameOrCopy(ffs, ffs, tempName, name); err != nil {
+		return err
+	}
+	ffs.Chtimes(name, file.ModTime(), file.ModTime()) // never fails
+
+	file.LocalFlags = 0
+	file.Sequence = 0
+	f.updateLocalsFromPulling([]protocol.FileInfo{file})
+
+	f.evict(name)
+	return nil
+}
+
+// fetchFile writes the contents of the file, requested block by block from
+// the devices that have them, to the named file.
+func (f *folder) fetchFile(ffs fs.Filesystem, file protocol.FileInfo, name string) error {
+	fd, err := ffs.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := f.fetchBlocks(fd, file); err != nil {
+		fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	if !f.IgnorePerms && !file.NoPermissions {
+		return ffs.Chmod(name, fs.FileMode(file.Permissions&0777))
+	}
+	return nil
+}
+
+func (f *folder) fetchBlocks(fd fs.File, file protocol.FileInfo) error {
+	if err := fd.Truncate(file.Size); err != nil {
+		return err
+	}
+	blocks := append([]protocol.BlockInfo{}, file.Blocks...)
+	populateOffsets(blocks)
+	for i, block := range blocks {
+		if block.IsEmpty() {
+			// Already zeroes after the truncate
+			continue
+		}
+		buf, err := f.fetchBlock(file, i, block)
+		if err != nil {
+			return err
+		}
+		if _, err := fd.WriteAt(buf, block.Offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchBlock reads the block from a local file that has it, or else
+// requests it from the devices that have it.
+func (f *folder) fetchBlock(file protocol.FileInfo, blockNo int, block protocol.BlockInfo) ([]byte, error) {
+	if buf, ok := f.localBlock(file, block); ok {
+		return buf, nil
+	}
+
+	lastErr := errNoDevice
+	for _, av := range f.model.Availability(f.ID, file, block) {
+		buf, err := f.model.requestGlobal(f.ctx, av.ID, f.ID, file.Name, blockNo, block.Offset, int(block.Size), block.Hash, block.WeakHash, av.FromTemporary)
+		if err == nil {
+			err = verifyBuffer(buf, block)
+		}
+		if err == nil {
+			return buf, nil
+		}
+		l.Debugf("%v: fetching block %d of %v from %v: %v", f, blockNo, file.Name, av.ID, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// evict turns the least recently used files, except the given one, back
+// into placeholders until the contents on disk fit the cache size. Only
+// files that are in sync with the global version and available from
+// another device are evicted.
+func (f *folder) evict(keep string) {
+	limit := int64(f.OnDemandCacheSize.BaseValue())
+	if limit <= 0 {
+		return
+	}
+
+	snap := f.fset.Snapshot()
+	defer snap.Release()
+
+	var total int64
+	var files []db.FileInfoTruncated
+	snap.WithHaveTruncated(protocol.LocalDeviceID, func(intf db.FileIntf) bool {
+		file := intf.(db.FileInfoTruncated)
+		if file.Type == protocol.FileInfoTypeFile && !file.IsDeleted() && !file.IsInvalid() {
+			total += file.Size
+			files = append(files, file)
+		}
+		return true
+	})
+	if total <= limit {
+		return
+	}
+
+	// Files that haven't been opened count as accessed when modified.
+	f.accessedMut.Lock()
+	lastAccess := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		if t, ok := f.accessed[file.Name]; ok {
+			lastAccess[file.Name] = t
+		} else {
+			lastAccess[file.Name] = file.ModTime()
+		}
+	}
+	f.accessed = make(map[string]time.Time, len(files))
+	for name, t := range lastAccess {
+		f.accessed[name] = t
+	}
+	f.accessedMut.Unlock()
+	sort.Slice(files, func(a, b int) bool {
+		return lastAccess[files[a].Name].Before(lastAccess[files[b].Name])
+	})
+
+	ffs := f.mtimeFS()
+	var evicted []protocol.FileInfo
+	for _, file := range files {
+		if total <= limit {
+			break
+		}
+		if file.Name == keep || !availableElsewhere(snap, file) {
+			continue
+		}
+		cur, ok := snap.Get(protocol.LocalDeviceID, file.Name)
+		if !ok {
+			continue
+		}
+		if err := f.evictFile(ffs, cur); err != nil {
+			l.Debugf("%v: evicting %v: %v", f, file.Name, err)
+			continue
+		}
+		l.Debugln(f, "evicted", file.Name)
+		cur.SetPlaceholder()
+		cur.Sequence = 0
+		evicted = append(evicted, cur)
+		total -= file.Size
+	}
+
+	if len(evicted) > 0 {
+		f.updateLocals(evicted)
+	}
+}
+
+// evictFile replaces the file on disk with a placeholder.
+func (f *folder) evictFile(ffs fs.Filesystem, file protocol.FileInfo) error {
+	if err := f.checkUnchanged(ffs, file); err != nil {
+		return err
+	}
+	tempName := fs.TempName(file.Name)
+	err := inWritableDir(func(tempName string) error {
+		if err := writePlaceholder(ffs, tempName, file.Size); err != nil {
+			return err
+		}
+		if !f.IgnorePerms && !file.NoPermissions {
+			return ffs.Chmod(tempName, fs.FileMode(file.Permissions&0777))
+		}
+		return nil
+	}, ffs, tempName, f.IgnorePerms)
+	if err != nil {
+		ffs.Remove(tempName)
+		return err
+	}
+	if err := osutil.RenameOrCopy(ffs, ffs, tempName, file.Name); err != nil {
+		return err
+	}
+	ffs.Chtimes(file.Name, file.ModTime(), file.ModTime()) // never fails
+	return nil
+}
+
+// checkUnchanged returns errModified when the file on disk doesn't match
+// the database, i.e. there are local changes not yet scanned.
+func (f *folder) checkUnchanged(ffs fs.Filesystem, file protocol.FileInfo) error {
+	stat, err := ffs.Lstat(file.Name)
+	if err != nil {
+		return err
+	}
+	statItem, err := scanner.CreateFileInfo(stat, file.Name, ffs)
+	if err != nil {
+		return err
+	}
+	if !statItem.IsEquivalentOptional(file, protocol.FileInfoComparison{
+		ModTimeWindow:   f.ModTimeWindow(),
+		IgnorePerms:     f.IgnorePerms,
+		IgnoreBlocks:    true,
+		IgnoreFlags:     protocol.LocalAllFlags,
+		IgnoreXattrs:    true,
+		IgnoreOwnership: true,
+	}) {
+		return errModified
+	}
+	return nil
+}
+
+// writePlaceholder creates a sparse file of the given size.
+func writePlaceholder(ffs fs.Filesystem, name string, size int64) error {
+	fd, err := ffs.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := fd.Truncate(size); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestOnDemandPlaceholders(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	fcfg.OnDemand = true
+	fcfg.OnDemandCacheSize = config.Size{Value: 30}
+	w.SetFolder(fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(w)
+	tfs := fcfg.Filesystem()
+	defer cleanupModelAndRemoveDir(m, tfs.URI())
+
+	contents := map[string][]byte{
+		"a": []byte("contents of file a\n"),
+		"b": []byte("contents of file b\n"),
+	}
+	for name, data := range contents {
+		fc.addFile(name, 0644, protocol.FileInfoTypeFile, data)
+	}
+	fc.sendIndexUpdate()
+
+	isPlaceholder := func(name string) bool {
+		t.Helper()
+		file, ok := m.CurrentFolderFile("default", name)
+		return ok && file.IsPlaceholder()
+	}
+	readFile := func(ffs fs.Filesystem, name string) []byte {
+		t.Helper()
+		fd, err := ffs.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		bs, err := ioutil.ReadAll(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bs
+	}
+
+	for start := time.Now(); !isPlaceholder("a") || !isPlaceholder("b"); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("Timed out waiting for placeholders")
+		}
+	}
+	for name, data := range contents {
+		if bs := readFile(tfs, name); !bytes.Equal(bs, make([]byte, len(data))) {
+			t.Errorf("Expected zeroed placeholder for %v, got %q", name, bs)
+		}
+	}
+	if size := needSize(t, m, "default"); size.TotalItems() != 0 {
+		t.Error("Expected nothing to be needed, got", size)
+	}
+
+	// Opening through the folder's filesystem fetches the contents.
+
+	m.fmut.RLock()
+	hfs := m.folderFiles["default"].MtimeFS()
+	m.fmut.RUnlock()
+	if bs := readFile(hfs, "a"); !bytes.Equal(bs, contents["a"]) {
+		t.Errorf("Expected contents of a, got %q", bs)
+	}
+	if isPlaceholder("a") {
+		t.Error("a should not be a placeholder after hydrating")
+	}
+
+	// Both files don't fit the cache, so the least recently used one is
+	// evicted back to a placeholder.
+
+	if bs := readFile(hfs, "b"); !bytes.Equal(bs, contents["b"]) {
+		t.Errorf("Expected contents of b, got %q", bs)
+	}
+	if isPlaceholder("b") {
+		t.Error("b should not be a placeholder after hydrating")
+	}
+	if !isPlaceholder("a") {
+		t.Error("a should have been evicted")
+	}
+	if bs := readFile(tfs, "a"); !bytes.Equal(bs, make([]byte, len(contents["a"]))) {
+		t.Errorf("Expected zeroed placeholder for a, got %q", bs)
+	}
+
+	// Placeholders are unchanged on scanning.
+
+	if err := m.ScanFolder("default"); err != nil {
+		t.Fatal(err)
+	}
+	if !isPlaceholder("a") {
+		t.Error("a should still be a placeholder after scanning")
+	}
+	if file, _ := m.CurrentFolderFile("default", "b"); file.IsInvalid() {
+		t.Error("b should be valid after scanning")
+	}
+
+	// A placeholder written to without hydrating is not a new version.
+
+	before, _ := m.CurrentFolderFile("default", "a")
+	fd, err := tfs.OpenFile("a", fs.OptReadWrite, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fd.WriteAt([]byte("local edit"), 0); err != nil {
+		t.Fatal(err)
+	}
+	fd.Close()
+	tfs.Chtimes("a", time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+	if err := m.ScanFolder("default"); err != nil {
+		t.Fatal(err)
+	}
+	if file, _ := m.CurrentFolderFile("default", "a"); !file.IsPlaceholder() || !file.Version.Equal(before.Version) {
+		t.Error("modified placeholder should not have been announced, got", file)
+	}
+	if errs, err := m.FolderErrors("default"); err != nil {
+		t.Fatal(err)
+	} else if len(errs) != 1 || errs[0].Path != "a" {
+		t.Error("expected a scan error for the modified placeholder, got", errs)
+	}
+}
+
+func TestOnDemandDeletePlaceholder(t *testing.T) {
+	for _, versioning := range []string{"simple", "dedup"} {
+		t.Run(versioning, func(t *testing.T) {
+			w, fcfg := tmpDefaultWrapper()
+			fcfg.OnDemand = true
+			fcfg.Versioning = config.VersioningConfiguration{Type: versioning}
+			w.SetFolder(fcfg)
+			m, fc := setupModelWithConnectionFromWrapper(w)
+			tfs := fcfg.Filesystem()
+			defer cleanupModelAndRemoveDir(m, tfs.URI())
+
+			fc.addFile("a", 0644, protocol.FileInfoTypeFile, []byte("contents of file a\n"))
+			fc.sendIndexUpdate()
+
+			waitFor := func(what string, cond func(file protocol.FileInfo) bool) {
+				t.Helper()
+				for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+					if file, ok := m.CurrentFolderFile("default", "a"); ok && cond(file) {
+						return
+					}
+					if time.Since(start) > 10*time.Second {
+						t.Fatal("Timed out waiting for", what)
+					}
+				}
+			}
+			waitFor("placeholder", protocol.FileInfo.IsPlaceholder)
+
+			// Deleting the placeholder neither hydrates it nor archives
+			// its zeroes as a version.
+
+			fc.deleteFile("a")
+			fc.sendIndexUpdate()
+			waitFor("deletion", protocol.FileInfo.IsDeleted)
+
+			if _, err := tfs.Lstat("a"); !fs.IsNotExist(err) {
+				t.Error("Expected the placeholder to be removed, got", err)
+			}
+			versions, err := m.GetFolderVersions("default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != 0 {
+				t.Error("Expected no versions, got", versions)
+			}
+		})
+	}
+}
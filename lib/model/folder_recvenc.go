@@ -0,0 +1,67 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/db"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/ignore"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/versioner"
+)
+
+// The encryption password token of a receive-encrypted folder is stored
+// next to the folder marker, so that we notice if a device starts sending
+// data encrypted with a different password.
+const encryptionTokenName = "syncthing-encryption_password_token"
+
+func init() {
+	folderFactories[config.FolderTypeReceiveEncrypted] = newReceiveEncryptedFolder
+}
+
+/*
+receiveEncryptedFolder is the folder type used on untrusted devices. The data
+it receives is encrypted by the sending devices and stored as is; the
+device never has access to the plaintext names or contents.
+
+Implementation wise it is a receiveOnlyFolder: local changes are never sent
+to the cluster and can be reverted. It does not validate the received data
+against the block hashes, as those are encrypted as well.
+*/
+type receiveEncryptedFolder struct {
+	*receiveOnlyFolder
+}
+
+func newReceiveEncryptedFolder(model *model, fset *db.FileSet, ignores *ignore.Matcher, cfg config.FolderConfiguration, ver versioner.Versioner, fs fs.Filesystem, evLogger events.Logger, ioLimiter *byteSemaphore) service {
+	return &receiveEncryptedFolder{newReceiveOnlyFolder(model, fset, ignores, cfg, ver, fs, evLogger, ioLimiter).(*receiveOnlyFolder)}
+}
+
+func readEncryptionToken(cfg config.FolderConfiguration) ([]byte, error) {
+	fd, err := cfg.Filesystem().Open(filepath.Join(cfg.MarkerName, encryptionTokenName))
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return ioutil.ReadAll(fd)
+}
+
+func writeEncryptionToken(token []byte, cfg config.FolderConfiguration) error {
+	fd, err := osutil.CreateAtomicFilesystem(cfg.Filesystem(), filepath.Join(cfg.MarkerName, encryptionTokenName))
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(token); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
@@ -7,6 +7,7 @@
 package model
 
 import (
+	"path/filepath"
 	"sort"
 	"time"
 
@@ -88,6 +89,11 @@ func (f *receiveOnlyFolder) Revert() {
 			// receive only mode.
 			return true
 		}
+		if f.Type == config.FolderTypeReceiveEncrypted && protocol.IsEncryptedParent(filepath.ToSlash(fi.Name)) {
+			// The intermediate directories of encrypted names are created
+			// locally and don't exist in the global index, keep them.
+			return true
+		}
 
 		if len(fi.Version.Counters) == 1 && fi.Version.Counters[0].ID == f.shortID {
 			// We are the only device mentioned in the version vector so the
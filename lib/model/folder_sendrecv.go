@@ -99,8 +99,7 @@ type dbUpdateJob struct {
 type sendReceiveFolder struct {
 	folder
 
-	fs        fs.Filesystem
-	versioner versioner.Versioner
+	fs fs.Filesystem
 
 	queue *jobQueue
 
@@ -111,9 +110,8 @@ type sendReceiveFolder struct {
 
 func newSendReceiveFolder(model *model, fset *db.FileSet, ignores *ignore.Matcher, cfg config.FolderConfiguration, ver versioner.Versioner, fs fs.Filesystem, evLogger events.Logger, ioLimiter *byteSemaphore) service {
 	f := &sendReceiveFolder{
-		folder:        newFolder(model, fset, ignores, cfg, evLogger, ioLimiter),
+		folder:        newFolder(model, fset, ignores, cfg, ver, evLogger, ioLimiter),
 		fs:            fs,
-		versioner:     ver,
 		queue:         newJobQueue(),
 		pullErrorsMut: sync.NewMutex(),
 	}
@@ -134,6 +132,8 @@ func newSendReceiveFolder(model *model, fset *db.FileSet, ignores *ignore.Matche
 		f.PullerMaxPendingKiB = blockSizeKiB
 	}
 
+	f.warnMergeRequirements()
+
 	return f
 }
 
@@ -320,11 +320,16 @@ func (f *sendReceiveFolder) processNeeded(snap *db.Snapshot, dbUpdateChan chan<-
 		file := intf.(protocol.FileInfo)
 
 		switch {
-		case f.ignores.ShouldIgnore(file.Name):
+		case f.isIgnoredAnnounced(file):
 			file.SetIgnored(f.shortID)
 			l.Debugln(f, "Handling ignored file", file)
 			dbUpdateChan <- dbUpdateJob{file, dbUpdateInvalidate}
 
+		case !f.IsSelected(file.Name):
+			file.SetDeselected(f.shortID)
+			l.Debugln(f, "Handling deselected file", file)
+			dbUpdateChan <- dbUpdateJob{file, dbUpdateInvalidate}
+
 		case runtime.GOOS == "windows" && fs.WindowsInvalidFilename(file.Name):
 			if file.IsDeleted() {
 				// Just pretend we deleted it, no reason to create an error
@@ -484,6 +489,13 @@ nextFile:
 			}
 		}
 
+		if f.OnDemand {
+			// Only put a placeholder in place, the contents are fetched
+			// when the file is opened.
+			f.placeholderFile(fi, snap, dbUpdateChan, scanChan)
+			continue
+		}
+
 		devices := snap.Availability(fileName)
 		for _, dev := range devices {
 			if _, ok := f.model.Connection(dev); ok {
@@ -599,24 +611,30 @@ func (f *sendReceiveFolder) handleDir(file protocol.FileInfo, snap *db.Snapshot,
 		// not MkdirAll because the parent should already exist.
 		mkdir := func(path string) error {
 			err = f.fs.Mkdir(path, mode)
-			if err != nil || f.IgnorePerms || file.NoPermissions {
+			if err != nil {
 				return err
 			}
 
-			// Copy the parent owner and group, if we are supposed to do that.
-			if err := f.maybeCopyOwner(path); err != nil {
-				return err
-			}
+			if !f.IgnorePerms && !file.NoPermissions {
+				// Copy the parent owner and group, if we are supposed to do that.
+				if err := f.maybeCopyOwner(path); err != nil {
+					return err
+				}
 
-			// Stat the directory so we can check its permissions.
-			info, err := f.fs.Lstat(path)
-			if err != nil {
-				return err
+				// Stat the directory so we can check its permissions.
+				info, err := f.fs.Lstat(path)
+				if err != nil {
+					return err
+				}
+
+				// Mask for the bits we want to preserve and add them in to the
+				// directories permissions.
+				if err := f.fs.Chmod(path, mode|(info.Mode()&retainBits)); err != nil {
+					return err
+				}
 			}
 
-			// Mask for the bits we want to preserve and add them in to the
-			// directories permissions.
-			return f.fs.Chmod(path, mode|(info.Mode()&retainBits))
+			return f.setPlatformData(&file, path)
 		}
 
 		if err = f.inWritableDir(mkdir, file.Name); err == nil {
@@ -641,6 +659,10 @@ func (f *sendReceiveFolder) handleDir(file protocol.FileInfo, snap *db.Snapshot,
 			return
 		}
 	}
+	if err := f.setPlatformData(&file, file.Name); err != nil {
+		f.newPullError(file.Name, err)
+		return
+	}
 	dbUpdateChan <- dbUpdateJob{file, dbUpdateHandleDir}
 }
 
@@ -750,7 +772,10 @@ func (f *sendReceiveFolder) handleSymlink(file protocol.FileInfo, snap *db.Snaps
 		if err := f.fs.CreateSymlink(file.SymlinkTarget, path); err != nil {
 			return err
 		}
-		return f.maybeCopyOwner(path)
+		if err := f.maybeCopyOwner(path); err != nil {
+			return err
+		}
+		return f.setPlatformData(&file, path)
 	}
 
 	if err = f.inWritableDir(createLink, file.Name); err == nil {
@@ -857,7 +882,7 @@ func (f *sendReceiveFolder) deleteFileWithCurrent(file, cur protocol.FileInfo, h
 		return
 	}
 
-	if f.versioner != nil && !cur.IsSymlink() {
+	if f.versioner != nil && !cur.IsSymlink() && !cur.IsPlaceholder() {
 		err = f.inWritableDir(f.versioner.Archive, file.Name)
 	} else {
 		err = f.inWritableDir(f.fs.Remove, file.Name)
@@ -942,7 +967,14 @@ func (f *sendReceiveFolder) renameFile(cur, source, target protocol.FileInfo, sn
 	default:
 		var fi protocol.FileInfo
 		if fi, err = scanner.CreateFileInfo(stat, target.Name, f.fs); err == nil {
-			if !fi.IsEquivalentOptional(curTarget, f.ModTimeWindow(), f.IgnorePerms, true, protocol.LocalAllFlags) {
+			if !fi.IsEquivalentOptional(curTarget, protocol.FileInfoComparison{
+				ModTimeWindow:   f.ModTimeWindow(),
+				IgnorePerms:     f.IgnorePerms,
+				IgnoreBlocks:    true,
+				IgnoreFlags:     protocol.LocalAllFlags,
+				IgnoreXattrs:    true,
+				IgnoreOwnership: true,
+			}) {
 				// Target changed
 				scanChan <- target.Name
 				err = errModified
@@ -1182,15 +1214,71 @@ func (f *sendReceiveFolder) shortcutFile(file, curFile protocol.FileInfo, dbUpda
 		}
 	}
 
+	if err = f.setPlatformData(&file, file.Name); err != nil {
+		f.newPullError(file.Name, err)
+		return
+	}
+
 	f.fs.Chtimes(file.Name, file.ModTime(), file.ModTime()) // never fails
 
 	// This may have been a conflict. We should merge the version vectors so
 	// that our clock doesn't move backwards.
 	file.Version = file.Version.Merge(curFile.Version)
 
+	if curFile.IsPlaceholder() {
+		// Still no contents
+		file.SetPlaceholder()
+	}
+
 	dbUpdateChan <- dbUpdateJob{file, dbUpdateShortcutFile}
 }
 
+// placeholderFile puts a placeholder for the file in place, a sparse file
+// with the size and metadata but not the contents of the file. The
+// contents are fetched when the file is hydrated.
+func (f *sendReceiveFolder) placeholderFile(file protocol.FileInfo, snap *db.Snapshot, dbUpdateChan chan<- dbUpdateJob, scanChan chan<- string) {
+	// Used in the defer closure below, updated by the function body. Take
+	// care not declare another err.
+	var err error
+
+	f.evLogger.Log(events.ItemStarted, map[string]string{
+		"folder": f.folderID,
+		"item":   file.Name,
+		"type":   "file",
+		"action": "update",
+	})
+
+	defer func() {
+		f.evLogger.Log(events.ItemFinished, map[string]interface{}{
+			"folder": f.folderID,
+			"item":   file.Name,
+			"error":  events.Error(err),
+			"type":   "file",
+			"action": "update",
+		})
+	}()
+
+	defer f.queue.Done(file.Name)
+
+	l.Debugln(f, "placeholder for", file.Name)
+
+	curFile, hasCurFile := snap.Get(protocol.LocalDeviceID, file.Name)
+	tempName := fs.TempName(file.Name)
+
+	if err = f.inWritableDir(func(name string) error {
+		return writePlaceholder(f.fs, name, file.Size)
+	}, tempName); err != nil {
+		err = errors.Wrap(err, "placeholder")
+		f.newPullError(file.Name, err)
+		return
+	}
+
+	file.SetPlaceholder()
+	if err = f.performFinish(file, curFile, hasCurFile, tempName, snap, dbUpdateChan, scanChan); err != nil {
+		f.newPullError(file.Name, err)
+	}
+}
+
 // copierRoutine reads copierStates until the in channel closes and performs
 // the relevant copies when possible, or passes it to the puller routine.
 func (f *sendReceiveFolder) copierRoutine(in <-chan copyBlocksState, pullChan chan<- pullBlockState, out chan<- *sharedPullerState) {
@@ -1314,9 +1402,13 @@ func (f *sendReceiveFolder) copierRoutine(in <-chan copyBlocksState, pullChan ch
 						return false
 					}
 
-					if err := verifyBuffer(buf, block); err != nil {
-						l.Debugln("Finder failed to verify buffer", err)
-						return false
+					// The block hashes of encrypted files aren't hashes
+					// of the data we have on disk.
+					if f.Type != config.FolderTypeReceiveEncrypted {
+						if err := verifyBuffer(buf, block); err != nil {
+							l.Debugln("Finder failed to verify buffer", err)
+							return false
+						}
 					}
 
 					_, err = dstFd.WriteAt(buf, block.Offset)
@@ -1455,7 +1547,8 @@ func (f *sendReceiveFolder) pullBlock(state pullBlockState, out chan<- *sharedPu
 		// leastBusy can select another device when someone else asks.
 		activity.using(selected)
 		var buf []byte
-		buf, lastError = f.model.requestGlobal(f.ctx, selected.ID, f.folderID, state.file.Name, state.block.Offset, int(state.block.Size), state.block.Hash, state.block.WeakHash, selected.FromTemporary)
+		blockNo := int(state.block.Offset / int64(state.file.BlockSize()))
+		buf, lastError = f.model.requestGlobal(f.ctx, selected.ID, f.folderID, state.file.Name, blockNo, state.block.Offset, int(state.block.Size), state.block.Hash, state.block.WeakHash, selected.FromTemporary)
 		activity.done(selected)
 		if lastError != nil {
 			l.Debugln("request:", f.folderID, state.file.Name, state.block.Offset, state.block.Size, "returned error:", lastError)
@@ -1463,11 +1556,14 @@ func (f *sendReceiveFolder) pullBlock(state pullBlockState, out chan<- *sharedPu
 		}
 
 		// Verify that the received block matches the desired hash, if not
-		// try pulling it from another device.
-		lastError = verifyBuffer(buf, state.block)
-		if lastError != nil {
-			l.Debugln("request:", f.folderID, state.file.Name, state.block.Offset, state.block.Size, "hash mismatch")
-			continue
+		// try pulling it from another device. Encrypted data can't be
+		// verified, as the hash is that of the plaintext.
+		if f.Type != config.FolderTypeReceiveEncrypted {
+			lastError = verifyBuffer(buf, state.block)
+			if lastError != nil {
+				l.Debugln("request:", f.folderID, state.file.Name, state.block.Offset, state.block.Size, "hash mismatch")
+				continue
+			}
 		}
 
 		// Save the block data we got from the cluster
@@ -1495,6 +1591,10 @@ func (f *sendReceiveFolder) performFinish(file, curFile protocol.FileInfo, hasCu
 		return err
 	}
 
+	if err := f.setPlatformData(&file, tempName); err != nil {
+		return err
+	}
+
 	if stat, err := f.fs.Lstat(file.Name); err == nil {
 		// There is an old file or directory already in place. We need to
 		// handle that.
@@ -1512,10 +1612,24 @@ func (f *sendReceiveFolder) performFinish(file, curFile protocol.FileInfo, hasCu
 			// we have resolved the conflict.
 			// Directories and symlinks aren't checked for conflicts.
 
+			// The pulled version vector is kept for finding the common
+			// ancestor when merging.
+			pulled := file
+			pulled.Version = file.Version.Copy()
 			file.Version = file.Version.Merge(curFile.Version)
-			err = f.inWritableDir(func(name string) error {
-				return f.moveForConflict(name, file.ModifiedBy.String(), scanChan)
-			}, curFile.Name)
+			switch f.conflictWinner(curFile, file) {
+			case conflictKeepLocal:
+				return f.keepLocalInConflict(curFile, file, tempName, dbUpdateChan)
+			case conflictKeepRemote:
+				err = f.deleteItemOnDisk(curFile, snap, scanChan)
+			default:
+				if mergedName, ok := f.mergeConflict(curFile, pulled, tempName); ok {
+					return f.finishMerged(curFile, file, tempName, mergedName, snap, dbUpdateChan, scanChan)
+				}
+				err = f.inWritableDir(func(name string) error {
+					return f.moveForConflict(name, file.ModifiedBy.String(), scanChan)
+				}, curFile.Name)
+			}
 		} else {
 			err = f.deleteItemOnDisk(curFile, snap, scanChan)
 		}
@@ -1809,11 +1923,11 @@ func (f *sendReceiveFolder) deleteItemOnDisk(item protocol.FileInfo, snap *db.Sn
 		// to potential children.
 		return f.deleteDirOnDisk(item.Name, snap, scanChan)
 
-	case !item.IsSymlink() && f.versioner != nil:
+	case !item.IsSymlink() && !item.IsPlaceholder() && f.versioner != nil:
 		// If we should use versioning, let the versioner archive the
 		// file before we replace it. Archiving a non-existent file is not
 		// an error.
-		// Symlinks aren't archived.
+		// Symlinks and placeholders, having no contents, aren't archived.
 
 		return f.inWritableDir(f.versioner.Archive, item.Name)
 	}
@@ -1913,7 +2027,14 @@ func (f *sendReceiveFolder) scanIfItemChanged(stat fs.FileInfo, item protocol.Fi
 		return errors.Wrap(err, "comparing item on disk to db")
 	}
 
-	if !statItem.IsEquivalentOptional(item, f.ModTimeWindow(), f.IgnorePerms, true, protocol.LocalAllFlags) {
+	if !statItem.IsEquivalentOptional(item, protocol.FileInfoComparison{
+		ModTimeWindow:   f.ModTimeWindow(),
+		IgnorePerms:     f.IgnorePerms,
+		IgnoreBlocks:    true,
+		IgnoreFlags:     protocol.LocalAllFlags,
+		IgnoreXattrs:    true,
+		IgnoreOwnership: true,
+	}) {
 		return errModified
 	}
 
@@ -1957,6 +2078,63 @@ func (f *sendReceiveFolder) maybeCopyOwner(path string) error {
 	return nil
 }
 
+// setPlatformData applies the ownership and extended attributes of the
+// file to the item at name, if we are supposed to do that.
+func (f *sendReceiveFolder) setPlatformData(file *protocol.FileInfo, name string) error {
+	if f.SyncOwnership {
+		if err := f.setOwnership(file, name); err != nil {
+			return errors.Wrap(err, "setting ownership")
+		}
+	}
+
+	if !f.SyncXattrs || file.IsSymlink() {
+		return nil
+	}
+	xd := file.Platform.XattrData()
+	if xd == nil {
+		// Nothing recorded for our platform, leave the attributes alone.
+		return nil
+	}
+	if err := f.fs.SetXattr(name, xd.Xattrs, f.XattrFilter); err == fs.ErrXattrsNotSupported {
+		l.Debugf("%v: cannot set xattrs on %q: %v", f, name, err)
+	} else if err != nil {
+		return errors.Wrap(err, "setting xattrs")
+	}
+	return nil
+}
+
+// setOwnership changes the owner and group of the item at name to those
+// recorded in the file. Users and groups are mapped by name when they
+// exist here, and by numeric id otherwise.
+func (f *sendReceiveFolder) setOwnership(file *protocol.FileInfo, name string) error {
+	if runtime.GOOS == "windows" || file.Platform.Unix == nil {
+		return nil
+	}
+
+	uid, gid := file.Platform.Unix.UID, file.Platform.Unix.GID
+	if file.Platform.Unix.OwnerName != "" {
+		if id, ok := osutil.LookupUID(file.Platform.Unix.OwnerName); ok {
+			uid = id
+		}
+	}
+	if file.Platform.Unix.GroupName != "" {
+		if id, ok := osutil.LookupGID(file.Platform.Unix.GroupName); ok {
+			gid = id
+		}
+	}
+
+	info, err := f.fs.Lstat(name)
+	if err != nil {
+		return err
+	}
+	if info.Owner() == uid && info.Group() == gid {
+		// Nothing to do, and changing it could require privileges we
+		// don't have.
+		return nil
+	}
+	return f.fs.Lchown(name, uid, gid)
+}
+
 func (f *sendReceiveFolder) inWritableDir(fn func(string) error, path string) error {
 	return inWritableDir(fn, f.fs, path, f.IgnorePerms)
 }
@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -897,6 +898,78 @@ func TestCopyOwner(t *testing.T) {
 	}
 }
 
+func TestSyncOwnership(t *testing.T) {
+	// Verifies that the recorded owner and group are applied, mapped by
+	// name when the name exists locally.
+
+	if runtime.GOOS == "windows" {
+		t.Skip("syncing ownership not supported on Windows")
+	}
+
+	m, f := setupSendReceiveFolder()
+	defer cleanupSRFolder(f, m)
+	f.folder.FolderConfiguration = config.NewFolderConfiguration(m.id, f.ID, f.Label, fs.FilesystemTypeFake, "/TestSyncOwnership")
+	f.folder.FolderConfiguration.SyncOwnership = true
+
+	f.fs = f.Filesystem()
+
+	dbUpdateChan := make(chan dbUpdateJob, 1)
+	defer close(dbUpdateChan)
+	snap := f.fset.Snapshot()
+
+	// Unknown names, so the numeric ids are used.
+
+	dir := protocol.FileInfo{
+		Name:        "foo",
+		Type:        protocol.FileInfoTypeDirectory,
+		Permissions: 0755,
+		Platform: protocol.PlatformData{Unix: &protocol.UnixData{
+			OwnerName: "syncthing-test-nonexistent",
+			GroupName: "syncthing-test-nonexistent",
+			UID:       1234,
+			GID:       5678,
+		}},
+	}
+	f.handleDir(dir, snap, dbUpdateChan, nil)
+	<-dbUpdateChan
+
+	info, err := f.fs.Lstat("foo")
+	if err != nil {
+		t.Fatal("Unexpected error (dir):", err)
+	}
+	if info.Owner() != 1234 || info.Group() != 5678 {
+		t.Fatalf("Expected dir owner/group to be 1234/5678, not %d/%d", info.Owner(), info.Group())
+	}
+
+	// A symlink, owned by a user that exists here under a different uid.
+
+	rootUID, ok := osutil.LookupUID("root")
+	if !ok {
+		t.Skip("no root user to map to")
+	}
+	symlink := protocol.FileInfo{
+		Name:          "foo/sym",
+		Type:          protocol.FileInfoTypeSymlink,
+		Permissions:   0644,
+		SymlinkTarget: "over the rainbow",
+		Platform: protocol.PlatformData{Unix: &protocol.UnixData{
+			OwnerName: "root",
+			UID:       rootUID + 1000,
+			GID:       5678,
+		}},
+	}
+	f.handleSymlink(symlink, snap, dbUpdateChan, nil)
+	<-dbUpdateChan
+
+	info, err = f.fs.Lstat("foo/sym")
+	if err != nil {
+		t.Fatal("Unexpected error (symlink):", err)
+	}
+	if info.Owner() != rootUID || info.Group() != 5678 {
+		t.Fatalf("Expected symlink owner/group to be %d/5678, not %d/%d", rootUID, info.Owner(), info.Group())
+	}
+}
+
 // TestSRConflictReplaceFileByDir checks that a conflict is created when an existing file
 // is replaced with a directory and versions are conflicting
 func TestSRConflictReplaceFileByDir(t *testing.T) {
@@ -1035,3 +1108,34 @@ func startCopier(f *sendReceiveFolder, pullChan chan<- pullBlockState, finisherC
 	}()
 	return copyChan, wg
 }
+
+func TestPullCaseOnlyConflict(t *testing.T) {
+	// Verifies that an item differing only in case from an existing one is
+	// not synced on a case insensitive filesystem, but reported as an error.
+
+	m, f := setupSendReceiveFolder()
+	defer cleanupSRFolder(f, m)
+	f.folder.FolderConfiguration = config.NewFolderConfiguration(m.id, f.ID, f.Label, fs.FilesystemTypeFake, "/TestPullCaseOnlyConflict?insens=true")
+	f.fs = f.Filesystem()
+
+	dbUpdateChan := make(chan dbUpdateJob, 1)
+	defer close(dbUpdateChan)
+	snap := f.fset.Snapshot()
+	defer snap.Release()
+
+	f.handleDir(protocol.FileInfo{Name: "Foo", Type: protocol.FileInfoTypeDirectory, Permissions: 0755}, snap, dbUpdateChan, nil)
+	<-dbUpdateChan
+
+	f.handleDir(protocol.FileInfo{Name: "foo", Type: protocol.FileInfoTypeDirectory, Permissions: 0755}, snap, dbUpdateChan, nil)
+	select {
+	case <-dbUpdateChan:
+		t.Fatal("Expected no db update for the conflicting dir")
+	default:
+	}
+
+	if err, ok := f.pullErrors["foo"]; !ok {
+		t.Fatal("Expected an error for foo, got", f.pullErrors)
+	} else if !strings.Contains(err, "differs from name in filesystem") {
+		t.Error("Expected a case conflict error, got", err)
+	}
+}
@@ -123,7 +123,7 @@ func (c *folderSummaryService) Summary(folder string) (map[string]interface{}, e
 		res["needDeletes"] = 0
 	}
 
-	if ok && fcfg.Type == config.FolderTypeReceiveOnly {
+	if ok && (fcfg.Type == config.FolderTypeReceiveOnly || fcfg.Type == config.FolderTypeReceiveEncrypted) {
 		// Add statistics for things that have changed locally in a receive
 		// only folder.
 		res["receiveOnlyChangedFiles"] = ro.Files
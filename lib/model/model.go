@@ -59,6 +59,9 @@ type service interface {
 	WatchError() error
 	ForceRescan(file protocol.FileInfo) error
 	GetStatistics() (stats.FolderStatistics, error)
+	Hydrate(name string) error
+	Conflicts() []Conflict
+	ResolveConflict(copyName string, keepCopy bool) error
 
 	getState() (folderState, time.Time, error)
 }
@@ -84,11 +87,17 @@ type Model interface {
 	Override(folder string)
 	Revert(folder string)
 	BringToFront(folder, file string)
+	Hydrate(folder, file string) error
+	Conflicts(folder string) ([]Conflict, error)
+	ResolveConflict(folder, copyName string, keepCopy bool) error
 	GetIgnores(folder string) ([]string, []string, error)
 	SetIgnores(folder string, content []string) error
+	SetSharedIgnores(folder string, lines []string) error
 
 	GetFolderVersions(folder string) (map[string][]versioner.FileVersion, error)
+	History(folder, file string) ([]FileHistoryEntry, error)
 	RestoreFolderVersions(folder string, versions map[string]time.Time) (map[string]string, error)
+	CleanFolderVersions(folder string) (versioner.CleanResult, error)
 
 	DBSnapshot(folder string) (*db.Snapshot, error)
 	NeedFolderFiles(folder string, page, perpage int) ([]db.FileInfoTruncated, []db.FileInfoTruncated, []db.FileInfoTruncated)
@@ -104,6 +113,11 @@ type Model interface {
 	FolderStatistics() (map[string]stats.FolderStatistics, error)
 	UsageReportingStats(version int, preview bool) map[string]interface{}
 
+	PendingDevices() (map[protocol.DeviceID]db.ObservedDevice, error)
+	PendingFolders(device protocol.DeviceID) (map[string]db.PendingFolder, error)
+	DismissPendingDevice(device protocol.DeviceID) error
+	DismissPendingFolder(device protocol.DeviceID, folder string) error
+
 	StartDeadlockDetector(timeout time.Duration)
 	GlobalDirectoryTree(folder, prefix string, levels int, dirsonly bool) map[string]interface{}
 }
@@ -170,6 +184,11 @@ var (
 	errFolderMissing     = errors.New("no such folder")
 	errNetworkNotAllowed = errors.New("network not allowed")
 	errNoVersioner       = errors.New("folder has no versioner")
+	// errors about encrypted folders
+	errEncryptionNotReceiveEncrypted = errors.New("remote device sends encrypted data, but the folder type is not receive-encrypted")
+	errEncryptionPlaintext           = errors.New("remote device sends plaintext data to a receive-encrypted folder")
+	errEncryptionBothEncrypted       = errors.New("remote device is configured as untrusted, but the folder is receive-encrypted")
+	errEncryptionTokenMismatch       = errors.New("remote device uses a different encryption password than previously seen for this folder")
 	// errors about why a connection is closed
 	errIgnoredFolderRemoved = errors.New("folder no longer ignored")
 	errReplacingConnection  = errors.New("replacing connection")
@@ -243,6 +262,9 @@ func (m *model) ServeBackground() {
 }
 
 func (m *model) onServe() {
+	m.migratePending()
+	m.cleanPending(m.cfg.RawCopy())
+
 	// Add and start folders
 	for _, folderCfg := range m.cfg.Folders() {
 		if folderCfg.Paused {
@@ -333,7 +355,7 @@ func (m *model) startFolderLocked(cfg config.FolderConfiguration) {
 		}
 	}
 
-	ffs := fset.MtimeFS()
+	ffs := fset.MtimeFSOn(cfg.Filesystem())
 
 	// These are our metadata files, and they should always be hidden.
 	_ = ffs.Hide(config.DefaultMarkerName)
@@ -354,6 +376,10 @@ func (m *model) startFolderLocked(cfg config.FolderConfiguration) {
 			token := m.Add(service)
 			m.folderRunnerTokens[folder] = append(m.folderRunnerTokens[folder], token)
 		}
+		if cleaner := versioner.NewRetentionCleaner(ver, versioner.NewRetentionPolicy(cfg.Versioning.Params)); cleaner != nil {
+			token := m.Add(cleaner)
+			m.folderRunnerTokens[folder] = append(m.folderRunnerTokens[folder], token)
+		}
 	}
 	m.folderVersioners[folder] = ver
 
@@ -415,7 +441,7 @@ func (m *model) addFolder(cfg config.FolderConfiguration) {
 
 	// Creating the fileset can take a long time (metadata calculation) so
 	// we do it outside of the lock.
-	fset := db.NewFileSet(cfg.ID, cfg.Filesystem(), m.db)
+	fset := db.NewFileSet(cfg.ID, m.folderFilesystem(cfg), m.db)
 
 	m.fmut.Lock()
 	defer m.fmut.Unlock()
@@ -425,8 +451,9 @@ func (m *model) addFolder(cfg config.FolderConfiguration) {
 func (m *model) addFolderLocked(cfg config.FolderConfiguration, fset *db.FileSet) {
 	m.folderCfgs[cfg.ID] = cfg
 	m.folderFiles[cfg.ID] = fset
+	fset.SetKeepHistory(cfg.KeepHistory)
 
-	ignores := ignore.New(cfg.Filesystem(), ignore.WithCache(m.cacheIgnoredFiles))
+	ignores := ignore.New(cfg.Filesystem(), ignore.WithCache(m.cacheIgnoredFiles), ignore.WithShared(cfg.SharedIgnores.Lines))
 	if err := ignores.Load(".stignore"); err != nil && !fs.IsNotExist(err) {
 		l.Warnln("Loading ignores:", err)
 	}
@@ -522,7 +549,7 @@ func (m *model) restartFolder(from, to config.FolderConfiguration) {
 	if !to.Paused {
 		// Creating the fileset can take a long time (metadata calculation)
 		// so we do it outside of the lock.
-		fset = db.NewFileSet(to.ID, to.Filesystem(), m.db)
+		fset = db.NewFileSet(to.ID, m.folderFilesystem(to), m.db)
 	}
 
 	m.stopFolder(from, fmt.Errorf("%v folder %v", errMsg, to.Description()))
@@ -541,7 +568,7 @@ func (m *model) restartFolder(from, to config.FolderConfiguration) {
 func (m *model) newFolder(cfg config.FolderConfiguration) {
 	// Creating the fileset can take a long time (metadata calculation) so
 	// we do it outside of the lock.
-	fset := db.NewFileSet(cfg.ID, cfg.Filesystem(), m.db)
+	fset := db.NewFileSet(cfg.ID, m.folderFilesystem(cfg), m.db)
 
 	// Close connections to affected devices
 	m.closeConns(cfg.DeviceIDs(), fmt.Errorf("started folder %v", cfg.Description()))
@@ -656,9 +683,19 @@ type ConnectionInfo struct {
 	ClientVersion string
 	Type          string
 	Crypto        string
+	Connections   []protocol.ConnectionStatistics
 }
 
 func (info ConnectionInfo) MarshalJSON() ([]byte, error) {
+	conns := make([]map[string]interface{}, len(info.Connections))
+	for i, c := range info.Connections {
+		conns[i] = map[string]interface{}{
+			"name":          c.Name,
+			"at":            c.At,
+			"inBytesTotal":  c.InBytesTotal,
+			"outBytesTotal": c.OutBytesTotal,
+		}
+	}
 	return json.Marshal(map[string]interface{}{
 		"at":            info.At,
 		"inBytesTotal":  info.InBytesTotal,
@@ -669,6 +706,7 @@ func (info ConnectionInfo) MarshalJSON() ([]byte, error) {
 		"clientVersion": info.ClientVersion,
 		"type":          info.Type,
 		"crypto":        info.Crypto,
+		"connections":   conns,
 	})
 }
 
@@ -695,6 +733,7 @@ func (m *model) ConnectionStats() map[string]interface{} {
 			ci.Crypto = conn.Crypto()
 			ci.Connected = ok
 			ci.Statistics = conn.Statistics()
+			ci.Connections = conn.Connections()
 			if addr := conn.RemoteAddr(); addr != nil {
 				ci.Address = addr.String()
 			}
@@ -1016,6 +1055,10 @@ func (m *model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 		}
 	}
 
+	for _, folder := range cm.Folders {
+		changed = m.handleSharedIgnores(deviceID, folder) || changed
+	}
+
 	m.fmut.RLock()
 	var paused []string
 	for _, folder := range cm.Folders {
@@ -1025,8 +1068,9 @@ func (m *model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 				l.Infof("Ignoring folder %s from device %s since we are configured to", folder.Description(), deviceID)
 				continue
 			}
-			m.cfg.AddOrUpdatePendingFolder(folder.ID, folder.Label, deviceID)
-			changed = true
+			if err := m.db.AddOrUpdatePendingFolder(folder.ID, folder.Label, deviceID); err != nil {
+				l.Warnf("Failed to persist pending folder entry to database: %v", err)
+			}
 			m.evLogger.Log(events.FolderRejected, map[string]string{
 				"folder":      folder.ID,
 				"folderLabel": folder.Label,
@@ -1042,6 +1086,10 @@ func (m *model) ClusterConfig(deviceID protocol.DeviceID, cm protocol.ClusterCon
 		if cfg.Paused {
 			continue
 		}
+		if err := m.checkFolderEncryption(cfg, deviceID, folder); err != nil {
+			l.Warnf("Not sharing folder %s with device %v: %v", folder.Description(), deviceID, err)
+			continue
+		}
 		fs, ok := m.folderFiles[folder.ID]
 		if !ok {
 			// Shouldn't happen because !cfg.Paused, but might happen
@@ -1339,6 +1387,38 @@ func (m *model) handleAutoAccepts(deviceCfg config.DeviceConfiguration, folder p
 	}
 }
 
+// handleSharedIgnores adopts the shared ignores announced by the device for
+// a folder we share with it, when they are newer than ours. The folder
+// restart that follows closes the connections to the other devices, which
+// then get the new version in the next cluster config. Untrusted devices,
+// and trusted devices for an encrypted folder, don't get to decide what is
+// ignored.
+func (m *model) handleSharedIgnores(deviceID protocol.DeviceID, folder protocol.Folder) bool {
+	cfg, ok := m.cfg.Folder(folder.ID)
+	if !ok || cfg.Type == config.FolderTypeReceiveEncrypted {
+		return false
+	}
+	if dev, ok := cfg.Device(deviceID); !ok || dev.EncryptionPassword != "" {
+		return false
+	}
+
+	switch folder.SharedIgnores.Version.Compare(cfg.SharedIgnores.Version) {
+	case protocol.Greater:
+		cfg.SharedIgnores.Version = folder.SharedIgnores.Version
+	case protocol.ConcurrentGreater:
+		// Concurrent edits: the announced lines win on all devices, and
+		// the merged version supersedes both edits.
+		cfg.SharedIgnores.Version = cfg.SharedIgnores.Version.Merge(folder.SharedIgnores.Version)
+	default:
+		return false
+	}
+	cfg.SharedIgnores.Lines = folder.SharedIgnores.Lines
+
+	l.Infof("Adopting shared ignores for folder %s from device %v", folder.Description(), deviceID)
+	m.cfg.SetFolder(cfg)
+	return true
+}
+
 func (m *model) introduceDevice(device protocol.Device, introducerCfg config.DeviceConfiguration) config.DeviceConfiguration {
 	addresses := []string{"dynamic"}
 	for _, addr := range device.Addresses {
@@ -1462,7 +1542,7 @@ func (r *requestResponse) Wait() {
 
 // Request returns the specified data segment by reading it from local disk.
 // Implements the protocol.Model interface.
-func (m *model) Request(deviceID protocol.DeviceID, folder, name string, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (out protocol.RequestResponse, err error) {
+func (m *model) Request(deviceID protocol.DeviceID, folder, name string, blockNo, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (out protocol.RequestResponse, err error) {
 	if size < 0 || offset < 0 {
 		return nil, protocol.ErrInvalid
 	}
@@ -1543,7 +1623,7 @@ func (m *model) Request(deviceID protocol.DeviceID, folder, name string, size in
 			return nil, protocol.ErrNoSuchFile
 		}
 		err := readOffsetIntoBuf(folderFs, tempFn, offset, res.data)
-		if err == nil && scanner.Validate(res.data, hash, weakHash) {
+		if err == nil && (folderCfg.Type == config.FolderTypeReceiveEncrypted || scanner.Validate(res.data, hash, weakHash)) {
 			return res, nil
 		}
 		// Fall through to reading from a non-temp file, just incase the temp
@@ -1565,7 +1645,9 @@ func (m *model) Request(deviceID protocol.DeviceID, folder, name string, size in
 		return nil, protocol.ErrGeneric
 	}
 
-	if !scanner.Validate(res.data, hash, weakHash) {
+	// The hashes of encrypted data are not hashes of what we have on disk,
+	// so we can't validate it.
+	if folderCfg.Type != config.FolderTypeReceiveEncrypted && !scanner.Validate(res.data, hash, weakHash) {
 		m.recheckFile(deviceID, folderFs, folder, name, size, offset, hash)
 		l.Debugf("%v REQ(in) failed validating data (%v): %s: %q / %q o=%d s=%d", m, err, deviceID, folder, name, offset, size)
 		return nil, protocol.ErrNoSuchFile
@@ -1698,7 +1780,7 @@ func (m *model) GetIgnores(folder string) ([]string, []string, error) {
 	}
 
 	if !ignoresOk {
-		ignores = ignore.New(fs.NewFilesystem(cfg.FilesystemType, cfg.Path))
+		ignores = ignore.New(fs.NewFilesystem(cfg.FilesystemType, cfg.Path), ignore.WithShared(cfg.SharedIgnores.Lines))
 	}
 
 	if err := ignores.Load(".stignore"); err != nil && !fs.IsNotExist(err) {
@@ -1739,6 +1821,29 @@ func (m *model) SetIgnores(folder string, content []string) error {
 	return nil
 }
 
+// SetSharedIgnores replaces the shared ignores of the folder with a new
+// version. The folder is restarted, which also sends the new version to
+// the other devices.
+func (m *model) SetSharedIgnores(folder string, lines []string) error {
+	cfg, ok := m.cfg.Folder(folder)
+	if !ok {
+		return fmt.Errorf("folder %s does not exist", folder)
+	}
+
+	if err := ignore.New(cfg.Filesystem()).Parse(strings.NewReader(strings.Join(lines, "\n")), ".stignore"); err != nil {
+		return err
+	}
+
+	cfg.SharedIgnores.Lines = lines
+	cfg.SharedIgnores.Version = cfg.SharedIgnores.Version.Update(m.shortID)
+	w, err := m.cfg.SetFolder(cfg)
+	if err != nil {
+		return err
+	}
+	w.Wait()
+	return m.cfg.Save()
+}
+
 // OnHello is called when an device connects to us.
 // This allows us to extract some information from the Hello message
 // and add it to a list of known devices ahead of any checks.
@@ -1749,8 +1854,9 @@ func (m *model) OnHello(remoteID protocol.DeviceID, addr net.Addr, hello protoco
 
 	cfg, ok := m.cfg.Device(remoteID)
 	if !ok {
-		m.cfg.AddOrUpdatePendingDevice(remoteID, hello.DeviceName, addr.String())
-		_ = m.cfg.Save() // best effort
+		if err := m.db.AddOrUpdatePendingDevice(remoteID, hello.DeviceName, addr.String()); err != nil {
+			l.Warnf("Failed to persist pending device entry to database: %v", err)
+		}
 		m.evLogger.Log(events.DeviceRejected, map[string]string{
 			"name":    hello.DeviceName,
 			"device":  remoteID.String(),
@@ -1775,13 +1881,16 @@ func (m *model) OnHello(remoteID protocol.DeviceID, addr net.Addr, hello protoco
 // GetHello is called when we are about to connect to some remote device.
 func (m *model) GetHello(id protocol.DeviceID) protocol.HelloIntf {
 	name := ""
-	if _, ok := m.cfg.Device(id); ok {
+	numConns := 0
+	if cfg, ok := m.cfg.Device(id); ok {
 		name = m.cfg.MyName()
+		numConns = cfg.NumConnections
 	}
 	return &protocol.Hello{
-		DeviceName:    name,
-		ClientName:    m.clientName,
-		ClientVersion: m.clientVersion,
+		DeviceName:     name,
+		ClientName:     m.clientName,
+		ClientVersion:  m.clientVersion,
+		NumConnections: numConns,
 	}
 }
 
@@ -1841,6 +1950,7 @@ func (m *model) AddConnection(conn connections.Connection, hello protocol.HelloR
 
 	l.Infof(`Device %s client is "%s %s" named "%s" at %s`, deviceID, hello.ClientName, hello.ClientVersion, hello.DeviceName, conn)
 
+	conn.SetFolderPasswords(m.folderPasswords(deviceID))
 	conn.Start()
 	m.pmut.Unlock()
 
@@ -2028,7 +2138,7 @@ func (s *indexSender) String() string {
 	return fmt.Sprintf("indexSender@%p for %s to %s at %s", s, s.folder, s.dev, s.conn)
 }
 
-func (m *model) requestGlobal(ctx context.Context, deviceID protocol.DeviceID, folder, name string, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
+func (m *model) requestGlobal(ctx context.Context, deviceID protocol.DeviceID, folder, name string, blockNo int, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
 	m.pmut.RLock()
 	nc, ok := m.conn[deviceID]
 	m.pmut.RUnlock()
@@ -2037,9 +2147,9 @@ func (m *model) requestGlobal(ctx context.Context, deviceID protocol.DeviceID, f
 		return nil, fmt.Errorf("requestGlobal: no such device: %s", deviceID)
 	}
 
-	l.Debugf("%v REQ(out): %s: %q / %q o=%d s=%d h=%x wh=%x ft=%t", m, deviceID, folder, name, offset, size, hash, weakHash, fromTemporary)
+	l.Debugf("%v REQ(out): %s: %q / %q b=%d o=%d s=%d h=%x wh=%x ft=%t", m, deviceID, folder, name, blockNo, offset, size, hash, weakHash, fromTemporary)
 
-	return nc.Request(ctx, folder, name, offset, size, hash, weakHash, fromTemporary)
+	return nc.Request(ctx, folder, name, blockNo, offset, size, hash, weakHash, fromTemporary)
 }
 
 func (m *model) ScanFolders() map[string]error {
@@ -2150,13 +2260,22 @@ func (m *model) generateClusterConfig(device protocol.DeviceID) protocol.Cluster
 			Paused:             folderCfg.Paused,
 		}
 
+		// The shared ignores name files in plain text, which is none of an
+		// untrusted device's business.
+		if dev, ok := folderCfg.Device(device); !ok || dev.EncryptionPassword == "" {
+			protocolFolder.SharedIgnores = protocol.SharedIgnores{
+				Lines:   folderCfg.SharedIgnores.Lines,
+				Version: folderCfg.SharedIgnores.Version,
+			}
+		}
+
 		var fs *db.FileSet
 		if !folderCfg.Paused {
 			fs = m.folderFiles[folderCfg.ID]
 		}
 
-		for _, device := range folderCfg.Devices {
-			deviceCfg, _ := m.cfg.Device(device.DeviceID)
+		for _, folderDevice := range folderCfg.Devices {
+			deviceCfg, _ := m.cfg.Device(folderDevice.DeviceID)
 
 			protocolDevice := protocol.Device{
 				ID:          deviceCfg.DeviceID,
@@ -2167,6 +2286,14 @@ func (m *model) generateClusterConfig(device protocol.DeviceID) protocol.Cluster
 				Introducer:  deviceCfg.Introducer,
 			}
 
+			if deviceCfg.DeviceID == m.id {
+				// Let an untrusted device know that what we send is
+				// encrypted, and with which password.
+				if dev, ok := folderCfg.Device(device); ok && dev.EncryptionPassword != "" {
+					protocolDevice.EncryptionPasswordToken = protocol.PasswordToken(folderCfg.ID, dev.EncryptionPassword)
+				}
+			}
+
 			if fs != nil {
 				if deviceCfg.DeviceID == m.id {
 					protocolDevice.IndexID = fs.IndexID(protocol.LocalDeviceID)
@@ -2186,6 +2313,171 @@ func (m *model) generateClusterConfig(device protocol.DeviceID) protocol.Cluster
 	return message
 }
 
+// PendingDevices lists unknown devices that tried to connect.
+func (m *model) PendingDevices() (map[protocol.DeviceID]db.ObservedDevice, error) {
+	return m.db.PendingDevices()
+}
+
+// PendingFolders lists folders that we don't yet share with the offering
+// devices. It returns the entries grouped by folder and filters for a given
+// device unless the argument is specified as EmptyDeviceID.
+func (m *model) PendingFolders(device protocol.DeviceID) (map[string]db.PendingFolder, error) {
+	return m.db.PendingFoldersForDevice(device)
+}
+
+// DismissPendingDevice removes the record of a specific pending device.
+func (m *model) DismissPendingDevice(device protocol.DeviceID) error {
+	return m.db.RemovePendingDevice(device)
+}
+
+// DismissPendingFolder removes the record of a pending folder, either
+// offered by a specific device, or by any device if EmptyDeviceID is given.
+func (m *model) DismissPendingFolder(device protocol.DeviceID, folder string) error {
+	if device == protocol.EmptyDeviceID {
+		return m.db.RemovePendingFolder(folder)
+	}
+	return m.db.RemovePendingFolderForDevice(folder, device)
+}
+
+// migratePending moves the pending devices and folders of older config
+// files into the database, removing them from the config.
+func (m *model) migratePending() {
+	cfg := m.cfg.RawCopy()
+	migrated := false
+	for _, dev := range cfg.DeprecatedPendingDevices {
+		if err := m.db.AddOrUpdatePendingDevice(dev.ID, dev.Name, dev.Address); err != nil {
+			l.Warnf("Failed to migrate pending device entry to database: %v", err)
+			return
+		}
+		migrated = true
+	}
+	cfg.DeprecatedPendingDevices = nil
+	for i, dev := range cfg.Devices {
+		for _, folder := range dev.DeprecatedPendingFolders {
+			if err := m.db.AddOrUpdatePendingFolder(folder.ID, folder.Label, dev.DeviceID); err != nil {
+				l.Warnf("Failed to migrate pending folder entry to database: %v", err)
+				return
+			}
+			migrated = true
+		}
+		cfg.Devices[i].DeprecatedPendingFolders = nil
+	}
+	if !migrated {
+		return
+	}
+
+	l.Infoln("Moved pending devices and folders from the config to the database")
+	waiter, err := m.cfg.Replace(cfg)
+	if err != nil {
+		l.Warnln("Removing migrated pending entries from config:", err)
+		return
+	}
+	waiter.Wait()
+	if err := m.cfg.Save(); err != nil {
+		l.Warnln("Saving config:", err)
+	}
+}
+
+// cleanPending removes pending device and folder entries that are now
+// configured or ignored, or that were offered by devices we no longer know
+// about.
+func (m *model) cleanPending(cfg config.Configuration) {
+	existingDevices := cfg.DeviceMap()
+	ignoredDevices := make(map[protocol.DeviceID]bool, len(cfg.IgnoredDevices))
+	for _, dev := range cfg.IgnoredDevices {
+		ignoredDevices[dev.ID] = true
+	}
+
+	pendingDevices, err := m.db.PendingDevices()
+	if err != nil {
+		l.Infof("Could not iterate through pending device entries: %v", err)
+		return
+	}
+	for deviceID := range pendingDevices {
+		if _, ok := existingDevices[deviceID]; ok || ignoredDevices[deviceID] {
+			l.Debugf("Discarding pending device %v, now configured or ignored", deviceID)
+			if err := m.db.RemovePendingDevice(deviceID); err != nil {
+				l.Infof("Could not remove pending device entry: %v", err)
+			}
+		}
+	}
+
+	existingFolders := mapFolders(cfg.Folders)
+	pendingFolders, err := m.db.PendingFolders()
+	if err != nil {
+		l.Infof("Could not iterate through pending folder entries: %v", err)
+		return
+	}
+	for folderID, pf := range pendingFolders {
+		fcfg, folderOk := existingFolders[folderID]
+		for deviceID := range pf.OfferedBy {
+			dev, ok := existingDevices[deviceID]
+			if ok && !dev.IgnoredFolder(folderID) && !(folderOk && fcfg.SharedWith(deviceID)) {
+				continue
+			}
+			l.Debugf("Discarding pending folder %v from device %v, now shared, ignored or device unknown", folderID, deviceID)
+			if err := m.db.RemovePendingFolderForDevice(folderID, deviceID); err != nil {
+				l.Infof("Could not remove pending folder entry: %v", err)
+			}
+		}
+	}
+}
+
+// folderPasswords returns the encryption passwords for the folders we share
+// with the given (untrusted) device.
+func (m *model) folderPasswords(device protocol.DeviceID) map[string]string {
+	res := make(map[string]string)
+	for _, folderCfg := range m.cfg.FolderList() {
+		if dev, ok := folderCfg.Device(device); ok && dev.EncryptionPassword != "" {
+			res[folderCfg.ID] = dev.EncryptionPassword
+		}
+	}
+	return res
+}
+
+// checkFolderEncryption verifies that what the remote device sends for the
+// folder matches our expectations: plaintext data for regular folders, and
+// encrypted data using a consistent password for receive-encrypted
+// folders.
+func (m *model) checkFolderEncryption(cfg config.FolderConfiguration, deviceID protocol.DeviceID, folder protocol.Folder) error {
+	var token []byte
+	for _, dev := range folder.Devices {
+		if dev.ID == deviceID {
+			token = dev.EncryptionPasswordToken
+			break
+		}
+	}
+
+	if cfg.Type != config.FolderTypeReceiveEncrypted {
+		if len(token) > 0 {
+			return errEncryptionNotReceiveEncrypted
+		}
+		return nil
+	}
+
+	if dev, ok := cfg.Device(deviceID); ok && dev.EncryptionPassword != "" {
+		return errEncryptionBothEncrypted
+	}
+	if len(token) == 0 {
+		return errEncryptionPlaintext
+	}
+
+	stored, err := readEncryptionToken(cfg)
+	if err == nil {
+		if !bytes.Equal(stored, token) {
+			return errEncryptionTokenMismatch
+		}
+		return nil
+	}
+	if !fs.IsNotExist(err) {
+		return errors.Wrap(err, "reading encryption token")
+	}
+	if err := writeEncryptionToken(token, cfg); err != nil {
+		return errors.Wrap(err, "storing encryption token")
+	}
+	return nil
+}
+
 func (m *model) State(folder string) (string, time.Time, error) {
 	m.fmut.RLock()
 	runner, ok := m.folderRunners[folder]
@@ -2333,6 +2625,77 @@ func (m *model) GetFolderVersions(folder string) (map[string][]versioner.FileVer
 	return ver.GetVersions()
 }
 
+// CleanFolderVersions applies the retention policy of the folder's
+// versioning configuration now.
+func (m *model) CleanFolderVersions(folder string) (versioner.CleanResult, error) {
+	m.fmut.RLock()
+	err := m.checkFolderRunningLocked(folder)
+	fcfg := m.folderCfgs[folder]
+	ver := m.folderVersioners[folder]
+	m.fmut.RUnlock()
+	if err != nil {
+		return versioner.CleanResult{}, err
+	}
+	if ver == nil {
+		return versioner.CleanResult{}, errNoVersioner
+	}
+
+	return versioner.Clean(ver, versioner.NewRetentionPolicy(fcfg.Versioning.Params))
+}
+
+// A FileHistoryEntry is a recorded change of the global version of a file,
+// along with the copy of the replaced version in the versioner archive, if
+// any.
+type FileHistoryEntry struct {
+	db.HistoryEntry
+	Archived *versioner.FileVersion
+}
+
+// History returns the recorded changes of the file, or of all files in the
+// folder when the name is empty. Replaced versions are matched up with the
+// archived copies by modification time and size.
+func (m *model) History(folder, file string) ([]FileHistoryEntry, error) {
+	m.fmut.RLock()
+	err := m.checkFolderRunningLocked(folder)
+	fset := m.folderFiles[folder]
+	ver := m.folderVersioners[folder]
+	m.fmut.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fset.History(file)
+	if err != nil {
+		return nil, err
+	}
+	var versions map[string][]versioner.FileVersion
+	if ver != nil {
+		if versions, err = ver.GetVersions(); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]FileHistoryEntry, len(entries))
+	for i, entry := range entries {
+		if entry.Device == protocol.LocalDeviceID {
+			entry.Device = m.id
+		}
+		res[i].HistoryEntry = entry
+		if entry.OldFile == nil {
+			continue
+		}
+		modTime := entry.OldFile.ModTime().Truncate(time.Second)
+		for _, version := range versions[osutil.NormalizedFilename(entry.OldFile.Name)] {
+			if version.ModTime.Equal(modTime) && version.Size == entry.OldFile.Size {
+				version := version
+				res[i].Archived = &version
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
 func (m *model) RestoreFolderVersions(folder string, versions map[string]time.Time) (map[string]string, error) {
 	m.fmut.RLock()
 	err := m.checkFolderRunningLocked(folder)
@@ -2349,6 +2712,12 @@ func (m *model) RestoreFolderVersions(folder string, versions map[string]time.Ti
 	restoreErrors := make(map[string]string)
 
 	for file, version := range versions {
+		// The versioner archives the current file, which must not be a
+		// placeholder.
+		if err := m.Hydrate(folder, file); err != nil {
+			restoreErrors[file] = err.Error()
+			continue
+		}
 		if err := ver.Restore(file, version); err != nil {
 			restoreErrors[file] = err.Error()
 		}
@@ -2415,6 +2784,63 @@ func (m *model) BringToFront(folder, file string) {
 	}
 }
 
+// Hydrate makes sure the file in an on-demand folder has its contents on
+// disk, fetching them from other devices if it is a placeholder.
+func (m *model) Hydrate(folder, file string) error {
+	m.fmut.RLock()
+	err := m.checkFolderRunningLocked(folder)
+	runner := m.folderRunners[folder]
+	m.fmut.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	return runner.Hydrate(file)
+}
+
+// folderFilesystem returns the filesystem of the folder's file set. For
+// on-demand folders it hydrates placeholders when they are opened, so that
+// readers of the folder's MtimeFS see the real contents. Hydration runs on
+// the folder's goroutine, so the folder itself and its versioner use the
+// plain filesystem instead.
+func (m *model) folderFilesystem(cfg config.FolderConfiguration) fs.Filesystem {
+	ffs := cfg.Filesystem()
+	if !cfg.OnDemand {
+		return ffs
+	}
+	return fs.NewHydratingFilesystem(ffs, func(name string) error {
+		return m.Hydrate(cfg.ID, name)
+	})
+}
+
+// Conflicts returns the conflict copies in the folder.
+func (m *model) Conflicts(folder string) ([]Conflict, error) {
+	m.fmut.RLock()
+	err := m.checkFolderRunningLocked(folder)
+	runner := m.folderRunners[folder]
+	m.fmut.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.Conflicts(), nil
+}
+
+// ResolveConflict keeps either the conflict copy or the file it's a copy
+// of, versioning the other one.
+func (m *model) ResolveConflict(folder, copyName string, keepCopy bool) error {
+	m.fmut.RLock()
+	err := m.checkFolderRunningLocked(folder)
+	runner := m.folderRunners[folder]
+	m.fmut.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return runner.ResolveConflict(copyName, keepCopy)
+}
+
 func (m *model) ResetFolder(folder string) {
 	l.Infof("Cleaning data for folder %q", folder)
 	db.DropFolder(m.db, folder)
@@ -2518,6 +2944,8 @@ func (m *model) CommitConfiguration(from, to config.Configuration) bool {
 	}
 	m.fmut.Unlock()
 
+	m.cleanPending(to)
+
 	m.globalRequestLimiter.setCapacity(1024 * to.Options.MaxConcurrentIncomingRequestKiB())
 	m.folderIOLimiter.setCapacity(to.Options.MaxFolderConcurrency())
 
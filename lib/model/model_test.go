@@ -134,7 +134,7 @@ func TestRequest(t *testing.T) {
 	defer cleanupModel(m)
 
 	// Existing, shared file
-	res, err := m.Request(device1, "default", "foo", 6, 0, nil, 0, false)
+	res, err := m.Request(device1, "default", "foo", 0, 6, 0, nil, 0, false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -144,31 +144,31 @@ func TestRequest(t *testing.T) {
 	}
 
 	// Existing, nonshared file
-	_, err = m.Request(device2, "default", "foo", 6, 0, nil, 0, false)
+	_, err = m.Request(device2, "default", "foo", 0, 6, 0, nil, 0, false)
 	if err == nil {
 		t.Error("Unexpected nil error on insecure file read")
 	}
 
 	// Nonexistent file
-	_, err = m.Request(device1, "default", "nonexistent", 6, 0, nil, 0, false)
+	_, err = m.Request(device1, "default", "nonexistent", 0, 6, 0, nil, 0, false)
 	if err == nil {
 		t.Error("Unexpected nil error on insecure file read")
 	}
 
 	// Shared folder, but disallowed file name
-	_, err = m.Request(device1, "default", "../walk.go", 6, 0, nil, 0, false)
+	_, err = m.Request(device1, "default", "../walk.go", 0, 6, 0, nil, 0, false)
 	if err == nil {
 		t.Error("Unexpected nil error on insecure file read")
 	}
 
 	// Negative offset
-	_, err = m.Request(device1, "default", "foo", -4, 0, nil, 0, false)
+	_, err = m.Request(device1, "default", "foo", 0, -4, 0, nil, 0, false)
 	if err == nil {
 		t.Error("Unexpected nil error on insecure file read")
 	}
 
 	// Larger block than available
-	_, err = m.Request(device1, "default", "foo", 42, 0, nil, 0, false)
+	_, err = m.Request(device1, "default", "foo", 0, 42, 0, nil, 0, false)
 	if err == nil {
 		t.Error("Unexpected nil error on insecure file read")
 	}
@@ -256,7 +256,7 @@ func BenchmarkRequestOut(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		data, err := m.requestGlobal(context.Background(), device1, "default", files[i%n].Name, 0, 32, nil, 0, false)
+		data, err := m.requestGlobal(context.Background(), device1, "default", files[i%n].Name, 0, 0, 32, nil, 0, false)
 		if err != nil {
 			b.Error(err)
 		}
@@ -282,7 +282,7 @@ func BenchmarkRequestInSingleFile(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		if _, err := m.Request(device1, "default", "request/for/a/file/in/a/couple/of/dirs/128k", 128<<10, 0, nil, 0, false); err != nil {
+		if _, err := m.Request(device1, "default", "request/for/a/file/in/a/couple/of/dirs/128k", 0, 128<<10, 0, nil, 0, false); err != nil {
 			b.Error(err)
 		}
 	}
@@ -461,6 +461,84 @@ func TestClusterConfig(t *testing.T) {
 	}
 }
 
+func TestClusterConfigEncryptionToken(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	m := setupModel(w)
+	defer cleanupModelAndRemoveDir(m, fcfg.Filesystem().URI())
+
+	token := func() []byte {
+		cm := m.generateClusterConfig(device1)
+		if len(cm.Folders) != 1 {
+			t.Fatalf("Incorrect number of folders %d != 1", len(cm.Folders))
+		}
+		var res []byte
+		for _, d := range cm.Folders[0].Devices {
+			if d.ID == myID {
+				res = d.EncryptionPasswordToken
+			} else if len(d.EncryptionPasswordToken) != 0 {
+				t.Errorf("Unexpected encryption token for %v", d.ID)
+			}
+		}
+		return res
+	}
+
+	if len(token()) != 0 {
+		t.Error("Unexpected encryption token for trusted device")
+	}
+
+	fcfg.Devices = []config.FolderDeviceConfiguration{
+		{DeviceID: myID},
+		{DeviceID: device1, EncryptionPassword: "password"},
+	}
+	waiter, _ := w.SetFolder(fcfg)
+	waiter.Wait()
+
+	if !bytes.Equal(token(), protocol.PasswordToken(fcfg.ID, "password")) {
+		t.Error("Expected encryption token for untrusted device")
+	}
+}
+
+func TestCheckFolderEncryption(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	m := setupModel(w)
+	defer cleanupModelAndRemoveDir(m, fcfg.Filesystem().URI())
+
+	folder := func(token []byte) protocol.Folder {
+		return protocol.Folder{
+			ID: fcfg.ID,
+			Devices: []protocol.Device{
+				{ID: device1, EncryptionPasswordToken: token},
+			},
+		}
+	}
+	token := protocol.PasswordToken(fcfg.ID, "password")
+
+	if err := m.checkFolderEncryption(fcfg, device1, folder(nil)); err != nil {
+		t.Error("Unexpected error for plaintext folder:", err)
+	}
+	if err := m.checkFolderEncryption(fcfg, device1, folder(token)); err != errEncryptionNotReceiveEncrypted {
+		t.Error("Expected errEncryptionNotReceiveEncrypted, got", err)
+	}
+
+	fcfg.Type = config.FolderTypeReceiveEncrypted
+	if err := m.checkFolderEncryption(fcfg, device1, folder(nil)); err != errEncryptionPlaintext {
+		t.Error("Expected errEncryptionPlaintext, got", err)
+	}
+	if err := m.checkFolderEncryption(fcfg, device1, folder(token)); err != nil {
+		t.Error("Unexpected error storing token:", err)
+	}
+	if stored, err := readEncryptionToken(fcfg); err != nil || !bytes.Equal(stored, token) {
+		t.Error("Token was not stored:", err)
+	}
+	if err := m.checkFolderEncryption(fcfg, device1, folder(token)); err != nil {
+		t.Error("Unexpected error for matching token:", err)
+	}
+	other := protocol.PasswordToken(fcfg.ID, "other")
+	if err := m.checkFolderEncryption(fcfg, device1, folder(other)); err != errEncryptionTokenMismatch {
+		t.Error("Expected errEncryptionTokenMismatch, got", err)
+	}
+}
+
 func TestIntroducer(t *testing.T) {
 	var introducedByAnyone protocol.DeviceID
 
@@ -944,6 +1022,119 @@ func TestAutoAcceptRejected(t *testing.T) {
 	}
 }
 
+func TestPendingFolderCleanup(t *testing.T) {
+	tcfg := defaultAutoAcceptCfg.Copy()
+	for i := range tcfg.Devices {
+		tcfg.Devices[i].AutoAcceptFolders = false
+	}
+	m := newState(tcfg)
+	defer cleanupModel(m)
+	id := srand.String(8)
+	defer os.RemoveAll(id)
+	m.ClusterConfig(device1, protocol.ClusterConfig{
+		Folders: []protocol.Folder{
+			{
+				ID:    id,
+				Label: "label",
+			},
+		},
+	})
+
+	pending, err := m.PendingFolders(protocol.EmptyDeviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if of, ok := pending[id].OfferedBy[device1]; !ok || of.Label != "label" {
+		t.Fatalf("expected folder %v to be pending from device1, got %v", id, pending)
+	}
+
+	// Sharing the folder removes the pending entry
+	fcfg := config.NewFolderConfiguration(myID, id, "label", fs.FilesystemTypeBasic, id)
+	fcfg.Devices = append(fcfg.Devices, config.FolderDeviceConfiguration{DeviceID: device1})
+	waiter, err := m.cfg.SetFolder(fcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+
+	pending, err = m.PendingFolders(device1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Error("expected no pending folders after sharing, got", pending)
+	}
+}
+
+func TestPendingDeviceCleanup(t *testing.T) {
+	m := newState(defaultAutoAcceptCfg)
+	defer cleanupModel(m)
+
+	device3 := protocol.DeviceID{3}
+	addr := &fakeAddr{}
+	if err := m.OnHello(device3, addr, protocol.HelloResult{DeviceName: "three"}); err != errDeviceUnknown {
+		t.Fatal("expected errDeviceUnknown, got", err)
+	}
+
+	pending, err := m.PendingDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if od, ok := pending[device3]; !ok || od.Name != "three" || od.Address != addr.String() {
+		t.Fatalf("expected device3 to be pending, got %v", pending)
+	}
+
+	if err := m.DismissPendingDevice(device3); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := m.PendingDevices(); len(pending) != 0 {
+		t.Error("expected no pending devices after dismissing, got", pending)
+	}
+
+	// Adding the device to the config removes the pending entry
+	m.OnHello(device3, addr, protocol.HelloResult{DeviceName: "three"})
+	waiter, err := m.cfg.SetDevice(config.NewDeviceConfiguration(device3, "three"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if pending, _ := m.PendingDevices(); len(pending) != 0 {
+		t.Error("expected no pending devices after adding, got", pending)
+	}
+}
+
+func TestPendingMigration(t *testing.T) {
+	device3 := protocol.DeviceID{3}
+	tcfg := defaultAutoAcceptCfg.Copy()
+	tcfg.DeprecatedPendingDevices = []config.ObservedDevice{
+		{ID: device3, Name: "three"},
+		{ID: device1, Name: "configured"},
+	}
+	tcfg.Devices[0].DeprecatedPendingFolders = []config.ObservedFolder{{ID: "pending", Label: "label"}}
+	m := newState(tcfg)
+	defer cleanupModel(m)
+
+	pending, err := m.PendingDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[device3].Name != "three" {
+		t.Error("expected only device3 to be pending, got", pending)
+	}
+	folders, err := m.PendingFolders(tcfg.Devices[0].DeviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if of, ok := folders["pending"].OfferedBy[tcfg.Devices[0].DeviceID]; !ok || of.Label != "label" {
+		t.Error("expected migrated pending folder, got", folders)
+	}
+
+	cfg := m.cfg.RawCopy()
+	if len(cfg.DeprecatedPendingDevices) != 0 || len(cfg.Devices[0].DeprecatedPendingFolders) != 0 {
+		t.Error("expected pending entries to be removed from the config")
+	}
+}
+
 func TestAutoAcceptNewFolder(t *testing.T) {
 	// New folder
 	m := newState(defaultAutoAcceptCfg)
@@ -1514,6 +1705,140 @@ func TestIgnores(t *testing.T) {
 	changeIgnores(t, m, []string{})
 }
 
+func TestSharedIgnores(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	m := setupModel(w)
+	defer cleanupModelAndRemoveDir(m, fcfg.Filesystem().URI())
+
+	if err := m.SetSharedIgnores("default", []string{"shared", "!local"}); err != nil {
+		t.Fatal(err)
+	}
+	fcfg, _ = w.Folder("default")
+	if len(fcfg.SharedIgnores.Lines) != 2 || fcfg.SharedIgnores.Version.Counter(myID.Short()) != 1 {
+		t.Fatal("Unexpected shared ignores", fcfg.SharedIgnores)
+	}
+	if err := m.SetIgnores("default", []string{"local"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, patterns, err := m.GetIgnores("default"); err != nil {
+		t.Fatal(err)
+	} else if len(patterns) != 12 {
+		t.Errorf("Expected local and shared patterns, got %v", patterns)
+	}
+	m.fmut.RLock()
+	ignores := m.folderIgnores["default"]
+	m.fmut.RUnlock()
+	if !ignores.Match("shared").IsIgnored() {
+		t.Error("shared should be ignored")
+	}
+	if !ignores.Match("local").IsIgnored() {
+		t.Error("local should be ignored, as local patterns take precedence")
+	}
+
+	if cm := m.generateClusterConfig(device1); len(cm.Folders) != 1 || len(cm.Folders[0].SharedIgnores.Lines) != 2 {
+		t.Error("Expected shared ignores in cluster config, got", cm)
+	}
+
+	// Newer versions from other devices are adopted, older ones aren't.
+
+	newer := protocol.Folder{
+		ID: "default",
+		SharedIgnores: protocol.SharedIgnores{
+			Lines:   []string{"remote"},
+			Version: fcfg.SharedIgnores.Version.Copy().Update(device1.Short()),
+		},
+	}
+	if !m.handleSharedIgnores(device1, newer) {
+		t.Error("Expected newer shared ignores to be adopted")
+	}
+	if fcfg, _ := w.Folder("default"); len(fcfg.SharedIgnores.Lines) != 1 || fcfg.SharedIgnores.Lines[0] != "remote" {
+		t.Error("Unexpected shared ignores", fcfg.SharedIgnores)
+	}
+	older := newer
+	older.SharedIgnores.Version = fcfg.SharedIgnores.Version
+	if m.handleSharedIgnores(device1, older) {
+		t.Error("Expected older shared ignores to be rejected")
+	}
+	if m.handleSharedIgnores(device2, newer) {
+		t.Error("Expected shared ignores from device not sharing the folder to be rejected")
+	}
+
+	// Untrusted devices neither get nor set the shared ignores.
+
+	waiter, err := w.SetDevice(config.NewDeviceConfiguration(device2, "device2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	fcfg, _ = w.Folder("default")
+	fcfg.Devices = append(fcfg.Devices, config.FolderDeviceConfiguration{DeviceID: device2, EncryptionPassword: "pw"})
+	waiter, err = w.SetFolder(fcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if cm := m.generateClusterConfig(device2); len(cm.Folders) != 1 || len(cm.Folders[0].SharedIgnores.Lines) != 0 {
+		t.Error("Expected no shared ignores in cluster config for untrusted device, got", cm)
+	}
+	newer.SharedIgnores.Lines = []string{"*"}
+	newer.SharedIgnores.Version = fcfg.SharedIgnores.Version.Copy().Update(device2.Short())
+	if m.handleSharedIgnores(device2, newer) {
+		t.Error("Expected shared ignores from untrusted device to be rejected")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	fcfg.KeepHistory = true
+	fcfg.Versioning = config.VersioningConfiguration{Type: "simple"}
+	w.SetFolder(fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(w)
+	defer cleanupModelAndRemoveDir(m, fcfg.Filesystem().URI())
+
+	waitForVersion := func(version protocol.Vector) {
+		t.Helper()
+		for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+			if file, ok := m.CurrentFolderFile("default", "a"); ok && file.Version.Equal(version) {
+				return
+			}
+			if time.Since(start) > 10*time.Second {
+				t.Fatal("Timed out waiting for file to be pulled")
+			}
+		}
+	}
+
+	fc.addFile("a", 0644, protocol.FileInfoTypeFile, []byte("first"))
+	fc.sendIndexUpdate()
+	waitForVersion(fc.files[0].Version)
+	first := fc.files[0]
+	first.Version = first.Version.Copy()
+	fc.updateFile("a", 0644, protocol.FileInfoTypeFile, []byte("second version"))
+	fc.sendIndexUpdate()
+	waitForVersion(fc.files[0].Version)
+
+	entries, err := m.History("default", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected two entries, got %v", entries)
+	}
+	for _, entry := range entries {
+		if entry.Device != device1 {
+			t.Errorf("Expected change by %v, got %v", device1, entry.Device)
+		}
+	}
+	if entries[0].OldFile != nil || entries[0].Archived != nil {
+		t.Error("Expected no previous version for the first entry, got", entries[0])
+	}
+	if entries[1].OldFile == nil || !entries[1].OldFile.Version.Equal(first.Version) {
+		t.Fatal("Expected the first version to be replaced, got", entries[1].OldFile)
+	}
+	if entries[1].Archived == nil || entries[1].Archived.Size != first.Size {
+		t.Error("Expected the first version to be archived, got", entries[1].Archived)
+	}
+}
+
 func TestEmptyIgnores(t *testing.T) {
 	testOs := &fatalOs{t}
 
@@ -3269,14 +3594,14 @@ func TestRequestLimit(t *testing.T) {
 
 	file := "tmpfile"
 	befReq := time.Now()
-	first, err := m.Request(device1, "default", file, 2000, 0, nil, 0, false)
+	first, err := m.Request(device1, "default", file, 0, 2000, 0, nil, 0, false)
 	if err != nil {
 		t.Fatalf("First request failed: %v", err)
 	}
 	reqDur := time.Since(befReq)
 	returned := make(chan struct{})
 	go func() {
-		second, err := m.Request(device1, "default", file, 2000, 0, nil, 0, false)
+		second, err := m.Request(device1, "default", file, 0, 2000, 0, nil, 0, false)
 		if err != nil {
 			t.Errorf("Second request failed: %v", err)
 		}
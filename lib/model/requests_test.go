@@ -65,6 +65,61 @@ func TestRequestSimple(t *testing.T) {
 	}
 }
 
+func TestPullConditionalIgnore(t *testing.T) {
+	// Verify that files ignored by a condition on their attributes are not
+	// pulled.
+
+	m, fc, fcfg := setupModelWithConnection()
+	tfs := fcfg.Filesystem()
+	defer cleanupModelAndRemoveDir(m, tfs.URI())
+
+	if err := m.SetIgnores("default", []string{"(?size>10)*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	seen := make(map[string]protocol.FileInfo)
+	fc.mut.Lock()
+	fc.indexFn = func(_ context.Context, folder string, fs []protocol.FileInfo) {
+		for _, f := range fs {
+			seen[f.Name] = f
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		_, small := seen["small"]
+		_, large := seen["large"]
+		if small && large {
+			close(done)
+		}
+	}
+	fc.mut.Unlock()
+
+	fc.addFile("small", 0644, protocol.FileInfoTypeFile, []byte("small\n"))
+	fc.addFile("large", 0644, protocol.FileInfoTypeFile, []byte("larger file contents\n"))
+	fc.sendIndexUpdate()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out before index was received")
+	}
+
+	fc.mut.Lock()
+	large := seen["large"]
+	fc.mut.Unlock()
+	if !large.IsInvalid() {
+		t.Error("large file should have been announced as invalid")
+	}
+	if _, err := tfs.Lstat("large"); !fs.IsNotExist(err) {
+		t.Error("large file should not have been pulled, got", err)
+	}
+	if err := equalContents(filepath.Join(tfs.URI(), "small"), []byte("small\n")); err != nil {
+		t.Error("small file did not sync correctly:", err)
+	}
+}
+
 func TestSymlinkTraversalRead(t *testing.T) {
 	// Verify that a symlink can not be traversed for reading.
 
@@ -102,7 +157,7 @@ func TestSymlinkTraversalRead(t *testing.T) {
 	<-done
 
 	// Request a file by traversing the symlink
-	res, err := m.Request(device1, "default", "symlink/requests_test.go", 10, 0, nil, 0, false)
+	res, err := m.Request(device1, "default", "symlink/requests_test.go", 0, 10, 0, nil, 0, false)
 	if err == nil || res != nil {
 		t.Error("Managed to traverse symlink")
 	}
@@ -508,7 +563,7 @@ func TestRescanIfHaveInvalidContent(t *testing.T) {
 		t.Fatalf("unexpected weak hash: %d != 103547413", f.Blocks[0].WeakHash)
 	}
 
-	res, err := m.Request(device1, "default", "foo", int32(len(payload)), 0, f.Blocks[0].Hash, f.Blocks[0].WeakHash, false)
+	res, err := m.Request(device1, "default", "foo", 0, int32(len(payload)), 0, f.Blocks[0].Hash, f.Blocks[0].WeakHash, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -522,7 +577,7 @@ func TestRescanIfHaveInvalidContent(t *testing.T) {
 
 	must(t, ioutil.WriteFile(filepath.Join(tmpDir, "foo"), payload, 0777))
 
-	_, err = m.Request(device1, "default", "foo", int32(len(payload)), 0, f.Blocks[0].Hash, f.Blocks[0].WeakHash, false)
+	_, err = m.Request(device1, "default", "foo", 0, int32(len(payload)), 0, f.Blocks[0].Hash, f.Blocks[0].WeakHash, false)
 	if err == nil {
 		t.Fatalf("expected failure")
 	}
@@ -1171,3 +1226,87 @@ func TestRequestLastFileProgress(t *testing.T) {
 		t.Fatal("Timed out before file was requested")
 	}
 }
+
+func TestSelectiveSync(t *testing.T) {
+	w, fcfg := tmpDefaultWrapper()
+	fcfg.SelectiveSync = []string{"sel"}
+	w.SetFolder(fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(w)
+	tfs := fcfg.Filesystem()
+	defer cleanupModelAndRemoveDir(m, tfs.URI())
+
+	selFile := filepath.Join("sel", "file")
+	other := "other"
+	contents := []byte("test file contents\n")
+
+	done := make(chan struct{})
+	expected := map[string]bool{"sel": false, selFile: false, other: true}
+	fc.mut.Lock()
+	fc.indexFn = func(_ context.Context, folder string, fs []protocol.FileInfo) {
+		for _, f := range fs {
+			invalid, ok := expected[f.Name]
+			if !ok {
+				t.Errorf("Unexpected file %v was added to index", f.Name)
+				continue
+			}
+			if f.IsInvalid() != invalid {
+				t.Errorf("File %v: invalid is %v, expected %v", f.Name, f.IsInvalid(), invalid)
+			}
+			delete(expected, f.Name)
+		}
+		if len(expected) == 0 {
+			close(done)
+		}
+	}
+	fc.mut.Unlock()
+
+	fc.addFile("sel", 0755, protocol.FileInfoTypeDirectory, nil)
+	fc.addFile(selFile, 0644, protocol.FileInfoTypeFile, contents)
+	fc.addFile(other, 0644, protocol.FileInfoTypeFile, contents)
+
+	fc.sendIndexUpdate()
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out before index was received")
+	case <-done:
+	}
+
+	if _, err := tfs.Lstat(selFile); err != nil {
+		t.Error("Selected file wasn't pulled:", err)
+	}
+	if _, err := tfs.Lstat(other); !fs.IsNotExist(err) {
+		t.Error("Deselected file exists or unexpected error:", err)
+	}
+	if size := needSize(t, m, "default"); size.TotalItems() != 0 {
+		t.Error("Expected nothing to be needed, got", size)
+	}
+
+	// Swapping the selection pulls the newly selected file and removes the
+	// deselected ones.
+
+	fcfg.SelectiveSync = []string{other}
+	waiter, _ := w.SetFolder(fcfg)
+	waiter.Wait()
+
+	// The restart closed the connection, reconnect.
+	fc.mut.Lock()
+	files, fileData := fc.files, fc.fileData
+	fc.mut.Unlock()
+	fc = addFakeConn(m, device1)
+	fc.folder = "default"
+	fc.files, fc.fileData = files, fileData
+	fc.sendIndexUpdate()
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		_, otherErr := tfs.Lstat(other)
+		_, selErr := tfs.Lstat("sel")
+		if otherErr == nil && fs.IsNotExist(selErr) {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("Timed out waiting for selection change, other: %v, sel: %v", otherErr, selErr)
+		}
+		m.ScanFolders()
+	}
+}
@@ -128,7 +128,8 @@ func copyFileContents(srcFs, dstFs fs.Filesystem, src, dst string) (err error) {
 }
 
 func IsDeleted(ffs fs.Filesystem, name string) bool {
-	if _, err := ffs.Lstat(name); fs.IsNotExist(err) {
+	if _, err := ffs.Lstat(name); fs.IsNotExist(err) || fs.IsErrCaseConflict(err) {
+		// A case conflict means the item exists with a different name.
 		return true
 	}
 	switch TraversesSymlink(ffs, filepath.Dir(name)).(type) {
@@ -0,0 +1,118 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package osutil
+
+import (
+	"os/user"
+	"strconv"
+
+	"github.com/syncthing/syncthing/lib/sync"
+)
+
+// Lookups of users and groups are comparatively expensive (potentially
+// going to the network for LDAP, NIS etc.) and the answers rarely change,
+// so they are cached for the lifetime of the process. Failed lookups are
+// cached as well.
+var (
+	userNames  = newNameCache(lookupUserName)
+	groupNames = newNameCache(lookupGroupName)
+	userIDs    = newNameCache(lookupUserID)
+	groupIDs   = newNameCache(lookupGroupID)
+)
+
+// UserName returns the name of the user with the given uid, or the empty
+// string if it is unknown.
+func UserName(uid int) string {
+	return userNames.get(strconv.Itoa(uid))
+}
+
+// GroupName returns the name of the group with the given gid, or the empty
+// string if it is unknown.
+func GroupName(gid int) string {
+	return groupNames.get(strconv.Itoa(gid))
+}
+
+// LookupUID returns the uid of the named user, and whether it exists.
+func LookupUID(name string) (int, bool) {
+	return atoi(userIDs.get(name))
+}
+
+// LookupGID returns the gid of the named group, and whether it exists.
+func LookupGID(name string) (int, bool) {
+	return atoi(groupIDs.get(name))
+}
+
+// nameCache caches the results of a lookup function, which returns the
+// empty string when the key is unknown.
+type nameCache struct {
+	lookup func(string) string
+	cache  map[string]string
+	mut    sync.Mutex
+}
+
+func newNameCache(lookup func(string) string) *nameCache {
+	return &nameCache{
+		lookup: lookup,
+		cache:  make(map[string]string),
+		mut:    sync.NewMutex(),
+	}
+}
+
+func (c *nameCache) get(key string) string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	val, ok := c.cache[key]
+	if !ok {
+		val = c.lookup(key)
+		c.cache[key] = val
+	}
+	return val
+}
+
+func lookupUserName(uid string) string {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+func lookupGroupName(gid string) string {
+	g, err := user.LookupGroupId(gid)
+	if err != nil {
+		return ""
+	}
+	return g.Name
+}
+
+func lookupUserID(name string) string {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return ""
+	}
+	return u.Uid
+}
+
+func lookupGroupID(name string) string {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return ""
+	}
+	return g.Gid
+}
+
+func atoi(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		// On Windows the ids are SIDs, which we can't use.
+		return 0, false
+	}
+	return i, true
+}
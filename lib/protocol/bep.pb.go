@@ -210,9 +210,10 @@ func (FileDownloadProgressUpdateType) EnumDescriptor() ([]byte, []int) {
 }
 
 type Hello struct {
-	DeviceName    string `protobuf:"bytes,1,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
-	ClientName    string `protobuf:"bytes,2,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
-	ClientVersion string `protobuf:"bytes,3,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	DeviceName     string `protobuf:"bytes,1,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	ClientName     string `protobuf:"bytes,2,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
+	ClientVersion  string `protobuf:"bytes,3,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	NumConnections int    `protobuf:"varint,4,opt,name=num_connections,json=numConnections,proto3,casttype=int" json:"num_connections,omitempty"`
 }
 
 func (m *Hello) Reset()         { *m = Hello{} }
@@ -324,14 +325,15 @@ func (m *ClusterConfig) XXX_DiscardUnknown() {
 var xxx_messageInfo_ClusterConfig proto.InternalMessageInfo
 
 type Folder struct {
-	ID                 string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Label              string   `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
-	ReadOnly           bool     `protobuf:"varint,3,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
-	IgnorePermissions  bool     `protobuf:"varint,4,opt,name=ignore_permissions,json=ignorePermissions,proto3" json:"ignore_permissions,omitempty"`
-	IgnoreDelete       bool     `protobuf:"varint,5,opt,name=ignore_delete,json=ignoreDelete,proto3" json:"ignore_delete,omitempty"`
-	DisableTempIndexes bool     `protobuf:"varint,6,opt,name=disable_temp_indexes,json=disableTempIndexes,proto3" json:"disable_temp_indexes,omitempty"`
-	Paused             bool     `protobuf:"varint,7,opt,name=paused,proto3" json:"paused,omitempty"`
-	Devices            []Device `protobuf:"bytes,16,rep,name=devices,proto3" json:"devices"`
+	ID                 string        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label              string        `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	ReadOnly           bool          `protobuf:"varint,3,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	IgnorePermissions  bool          `protobuf:"varint,4,opt,name=ignore_permissions,json=ignorePermissions,proto3" json:"ignore_permissions,omitempty"`
+	IgnoreDelete       bool          `protobuf:"varint,5,opt,name=ignore_delete,json=ignoreDelete,proto3" json:"ignore_delete,omitempty"`
+	DisableTempIndexes bool          `protobuf:"varint,6,opt,name=disable_temp_indexes,json=disableTempIndexes,proto3" json:"disable_temp_indexes,omitempty"`
+	Paused             bool          `protobuf:"varint,7,opt,name=paused,proto3" json:"paused,omitempty"`
+	SharedIgnores      SharedIgnores `protobuf:"bytes,8,opt,name=shared_ignores,json=sharedIgnores,proto3" json:"shared_ignores"`
+	Devices            []Device      `protobuf:"bytes,16,rep,name=devices,proto3" json:"devices"`
 }
 
 func (m *Folder) Reset()         { *m = Folder{} }
@@ -367,6 +369,47 @@ func (m *Folder) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Folder proto.InternalMessageInfo
 
+// Ignore patterns distributed to all devices sharing the folder, in
+// addition to each device's own .stignore. The version is updated on every
+// edit, so that the latest one wins.
+type SharedIgnores struct {
+	Lines   []string `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	Version Vector   `protobuf:"bytes,2,opt,name=version,proto3" json:"version"`
+}
+
+func (m *SharedIgnores) Reset()         { *m = SharedIgnores{} }
+func (m *SharedIgnores) String() string { return proto.CompactTextString(m) }
+func (*SharedIgnores) ProtoMessage()    {}
+func (*SharedIgnores) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e3f59eb60afbbc6e, []int{4}
+}
+func (m *SharedIgnores) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SharedIgnores) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SharedIgnores.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SharedIgnores) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SharedIgnores.Merge(m, src)
+}
+func (m *SharedIgnores) XXX_Size() int {
+	return m.ProtoSize()
+}
+func (m *SharedIgnores) XXX_DiscardUnknown() {
+	xxx_messageInfo_SharedIgnores.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SharedIgnores proto.InternalMessageInfo
+
 type Device struct {
 	ID                       DeviceID    `protobuf:"bytes,1,opt,name=id,proto3,customtype=DeviceID" json:"id"`
 	Name                     string      `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
@@ -377,13 +420,14 @@ type Device struct {
 	Introducer               bool        `protobuf:"varint,7,opt,name=introducer,proto3" json:"introducer,omitempty"`
 	IndexID                  IndexID     `protobuf:"varint,8,opt,name=index_id,json=indexId,proto3,customtype=IndexID" json:"index_id"`
 	SkipIntroductionRemovals bool        `protobuf:"varint,9,opt,name=skip_introduction_removals,json=skipIntroductionRemovals,proto3" json:"skip_introduction_removals,omitempty"`
+	EncryptionPasswordToken  []byte      `protobuf:"bytes,10,opt,name=encryption_password_token,json=encryptionPasswordToken,proto3" json:"encryption_password_token,omitempty"`
 }
 
 func (m *Device) Reset()         { *m = Device{} }
 func (m *Device) String() string { return proto.CompactTextString(m) }
 func (*Device) ProtoMessage()    {}
 func (*Device) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{4}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{5}
 }
 func (m *Device) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -421,7 +465,7 @@ func (m *Index) Reset()         { *m = Index{} }
 func (m *Index) String() string { return proto.CompactTextString(m) }
 func (*Index) ProtoMessage()    {}
 func (*Index) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{5}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{6}
 }
 func (m *Index) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -459,7 +503,7 @@ func (m *IndexUpdate) Reset()         { *m = IndexUpdate{} }
 func (m *IndexUpdate) String() string { return proto.CompactTextString(m) }
 func (*IndexUpdate) ProtoMessage()    {}
 func (*IndexUpdate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{6}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{7}
 }
 func (m *IndexUpdate) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -498,6 +542,8 @@ type FileInfo struct {
 	Blocks        []BlockInfo  `protobuf:"bytes,16,rep,name=blocks,proto3" json:"blocks"`
 	SymlinkTarget string       `protobuf:"bytes,17,opt,name=symlink_target,json=symlinkTarget,proto3" json:"symlink_target,omitempty"`
 	BlocksHash    []byte       `protobuf:"bytes,18,opt,name=blocks_hash,json=blocksHash,proto3" json:"blocks_hash,omitempty"`
+	Encrypted     []byte       `protobuf:"bytes,19,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	Platform      PlatformData `protobuf:"bytes,14,opt,name=platform,proto3" json:"platform"`
 	Type          FileInfoType `protobuf:"varint,2,opt,name=type,proto3,enum=protocol.FileInfoType" json:"type,omitempty"`
 	Permissions   uint32       `protobuf:"varint,4,opt,name=permissions,proto3" json:"permissions,omitempty"`
 	ModifiedNs    int32        `protobuf:"varint,11,opt,name=modified_ns,json=modifiedNs,proto3" json:"modified_ns,omitempty"`
@@ -515,7 +561,7 @@ type FileInfo struct {
 func (m *FileInfo) Reset()      { *m = FileInfo{} }
 func (*FileInfo) ProtoMessage() {}
 func (*FileInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{7}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{8}
 }
 func (m *FileInfo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -544,6 +590,166 @@ func (m *FileInfo) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_FileInfo proto.InternalMessageInfo
 
+// PlatformData holds file metadata that only makes sense on some
+// platforms. It is applied only by devices running on the respective
+// platform, and passed along unchanged by everyone else.
+type PlatformData struct {
+	Unix   *UnixData  `protobuf:"bytes,1,opt,name=unix,proto3" json:"unix,omitempty"`
+	Linux  *XattrData `protobuf:"bytes,3,opt,name=linux,proto3" json:"linux,omitempty"`
+	Darwin *XattrData `protobuf:"bytes,4,opt,name=darwin,proto3" json:"darwin,omitempty"`
+}
+
+func (m *PlatformData) Reset()         { *m = PlatformData{} }
+func (m *PlatformData) String() string { return proto.CompactTextString(m) }
+func (*PlatformData) ProtoMessage()    {}
+func (*PlatformData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e3f59eb60afbbc6e, []int{9}
+}
+func (m *PlatformData) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PlatformData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PlatformData.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PlatformData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlatformData.Merge(m, src)
+}
+func (m *PlatformData) XXX_Size() int {
+	return m.ProtoSize()
+}
+func (m *PlatformData) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlatformData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlatformData proto.InternalMessageInfo
+
+type UnixData struct {
+	// The owner name and group name are set when known (i.e., could be
+	// resolved on the source device), while the UID and GID are always set
+	// as they come directly from the stat() call.
+	OwnerName string `protobuf:"bytes,1,opt,name=owner_name,json=ownerName,proto3" json:"owner_name,omitempty"`
+	GroupName string `protobuf:"bytes,2,opt,name=group_name,json=groupName,proto3" json:"group_name,omitempty"`
+	UID       int    `protobuf:"varint,3,opt,name=uid,proto3,casttype=int" json:"uid,omitempty"`
+	GID       int    `protobuf:"varint,4,opt,name=gid,proto3,casttype=int" json:"gid,omitempty"`
+}
+
+func (m *UnixData) Reset()         { *m = UnixData{} }
+func (m *UnixData) String() string { return proto.CompactTextString(m) }
+func (*UnixData) ProtoMessage()    {}
+func (*UnixData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e3f59eb60afbbc6e, []int{10}
+}
+func (m *UnixData) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UnixData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UnixData.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UnixData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnixData.Merge(m, src)
+}
+func (m *UnixData) XXX_Size() int {
+	return m.ProtoSize()
+}
+func (m *UnixData) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnixData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnixData proto.InternalMessageInfo
+
+type XattrData struct {
+	Xattrs []Xattr `protobuf:"bytes,1,rep,name=xattrs,proto3" json:"xattrs"`
+}
+
+func (m *XattrData) Reset()         { *m = XattrData{} }
+func (m *XattrData) String() string { return proto.CompactTextString(m) }
+func (*XattrData) ProtoMessage()    {}
+func (*XattrData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e3f59eb60afbbc6e, []int{11}
+}
+func (m *XattrData) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *XattrData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_XattrData.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *XattrData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_XattrData.Merge(m, src)
+}
+func (m *XattrData) XXX_Size() int {
+	return m.ProtoSize()
+}
+func (m *XattrData) XXX_DiscardUnknown() {
+	xxx_messageInfo_XattrData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_XattrData proto.InternalMessageInfo
+
+type Xattr struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Xattr) Reset()         { *m = Xattr{} }
+func (m *Xattr) String() string { return proto.CompactTextString(m) }
+func (*Xattr) ProtoMessage()    {}
+func (*Xattr) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e3f59eb60afbbc6e, []int{12}
+}
+func (m *Xattr) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Xattr) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Xattr.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Xattr) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Xattr.Merge(m, src)
+}
+func (m *Xattr) XXX_Size() int {
+	return m.ProtoSize()
+}
+func (m *Xattr) XXX_DiscardUnknown() {
+	xxx_messageInfo_Xattr.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Xattr proto.InternalMessageInfo
+
 type BlockInfo struct {
 	Hash     []byte `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
 	Offset   int64  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
@@ -554,7 +760,7 @@ type BlockInfo struct {
 func (m *BlockInfo) Reset()      { *m = BlockInfo{} }
 func (*BlockInfo) ProtoMessage() {}
 func (*BlockInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{8}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{13}
 }
 func (m *BlockInfo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -591,7 +797,7 @@ func (m *Vector) Reset()         { *m = Vector{} }
 func (m *Vector) String() string { return proto.CompactTextString(m) }
 func (*Vector) ProtoMessage()    {}
 func (*Vector) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{9}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{14}
 }
 func (m *Vector) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -629,7 +835,7 @@ func (m *Counter) Reset()         { *m = Counter{} }
 func (m *Counter) String() string { return proto.CompactTextString(m) }
 func (*Counter) ProtoMessage()    {}
 func (*Counter) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{10}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{15}
 }
 func (m *Counter) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -667,13 +873,14 @@ type Request struct {
 	Hash          []byte `protobuf:"bytes,6,opt,name=hash,proto3" json:"hash,omitempty"`
 	FromTemporary bool   `protobuf:"varint,7,opt,name=from_temporary,json=fromTemporary,proto3" json:"from_temporary,omitempty"`
 	WeakHash      uint32 `protobuf:"varint,8,opt,name=weak_hash,json=weakHash,proto3" json:"weak_hash,omitempty"`
+	BlockNo       int32  `protobuf:"varint,9,opt,name=block_no,json=blockNo,proto3" json:"block_no,omitempty"`
 }
 
 func (m *Request) Reset()         { *m = Request{} }
 func (m *Request) String() string { return proto.CompactTextString(m) }
 func (*Request) ProtoMessage()    {}
 func (*Request) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{11}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{16}
 }
 func (m *Request) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -712,7 +919,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{12}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{17}
 }
 func (m *Response) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -750,7 +957,7 @@ func (m *DownloadProgress) Reset()         { *m = DownloadProgress{} }
 func (m *DownloadProgress) String() string { return proto.CompactTextString(m) }
 func (*DownloadProgress) ProtoMessage()    {}
 func (*DownloadProgress) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{13}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{18}
 }
 func (m *DownloadProgress) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -790,7 +997,7 @@ func (m *FileDownloadProgressUpdate) Reset()         { *m = FileDownloadProgress
 func (m *FileDownloadProgressUpdate) String() string { return proto.CompactTextString(m) }
 func (*FileDownloadProgressUpdate) ProtoMessage()    {}
 func (*FileDownloadProgressUpdate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{14}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{19}
 }
 func (m *FileDownloadProgressUpdate) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -826,7 +1033,7 @@ func (m *Ping) Reset()         { *m = Ping{} }
 func (m *Ping) String() string { return proto.CompactTextString(m) }
 func (*Ping) ProtoMessage()    {}
 func (*Ping) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{15}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{20}
 }
 func (m *Ping) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -863,7 +1070,7 @@ func (m *Close) Reset()         { *m = Close{} }
 func (m *Close) String() string { return proto.CompactTextString(m) }
 func (*Close) ProtoMessage()    {}
 func (*Close) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e3f59eb60afbbc6e, []int{16}
+	return fileDescriptor_e3f59eb60afbbc6e, []int{21}
 }
 func (m *Close) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -903,10 +1110,15 @@ func init() {
 	proto.RegisterType((*Header)(nil), "protocol.Header")
 	proto.RegisterType((*ClusterConfig)(nil), "protocol.ClusterConfig")
 	proto.RegisterType((*Folder)(nil), "protocol.Folder")
+	proto.RegisterType((*SharedIgnores)(nil), "protocol.SharedIgnores")
 	proto.RegisterType((*Device)(nil), "protocol.Device")
 	proto.RegisterType((*Index)(nil), "protocol.Index")
 	proto.RegisterType((*IndexUpdate)(nil), "protocol.IndexUpdate")
 	proto.RegisterType((*FileInfo)(nil), "protocol.FileInfo")
+	proto.RegisterType((*PlatformData)(nil), "protocol.PlatformData")
+	proto.RegisterType((*UnixData)(nil), "protocol.UnixData")
+	proto.RegisterType((*XattrData)(nil), "protocol.XattrData")
+	proto.RegisterType((*Xattr)(nil), "protocol.Xattr")
 	proto.RegisterType((*BlockInfo)(nil), "protocol.BlockInfo")
 	proto.RegisterType((*Vector)(nil), "protocol.Vector")
 	proto.RegisterType((*Counter)(nil), "protocol.Counter")
@@ -921,121 +1133,141 @@ func init() {
 func init() { proto.RegisterFile("bep.proto", fileDescriptor_e3f59eb60afbbc6e) }
 
 var fileDescriptor_e3f59eb60afbbc6e = []byte{
-	// 1816 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4f, 0x6f, 0xdb, 0xc8,
-	0x15, 0x17, 0x25, 0xea, 0xdf, 0x93, 0xec, 0xa5, 0x27, 0x89, 0xcb, 0x32, 0x59, 0x89, 0x51, 0x92,
-	0x8d, 0xd6, 0xd8, 0x26, 0xe9, 0xee, 0xb6, 0x45, 0x8b, 0xb6, 0x80, 0xfe, 0xd0, 0x8e, 0x50, 0x47,
-	0x72, 0x47, 0x72, 0xb6, 0xd9, 0x43, 0x09, 0x5a, 0x1c, 0xc9, 0x44, 0x28, 0x8e, 0x4a, 0x52, 0x76,
-	0xb4, 0x1f, 0x41, 0xa7, 0x1e, 0x7b, 0x11, 0xb0, 0x40, 0x4f, 0xfd, 0x26, 0x39, 0xa6, 0x3d, 0x14,
-	0x45, 0x0f, 0x46, 0xd7, 0xb9, 0xec, 0xb1, 0x9f, 0xa0, 0x2d, 0x66, 0x86, 0x94, 0x28, 0x7b, 0xb3,
-	0xc8, 0xa1, 0x27, 0xce, 0xbc, 0xf7, 0x9b, 0x37, 0x33, 0xbf, 0xf7, 0xde, 0x6f, 0x08, 0xc5, 0x13,
-	0x32, 0x7d, 0x34, 0xf5, 0x69, 0x48, 0x51, 0x81, 0x7f, 0x86, 0xd4, 0xd5, 0xee, 0xf9, 0x64, 0x4a,
-	0x83, 0xc7, 0x7c, 0x7e, 0x32, 0x1b, 0x3d, 0x1e, 0xd3, 0x31, 0xe5, 0x13, 0x3e, 0x12, 0xf0, 0xda,
-	0x14, 0xb2, 0x4f, 0x89, 0xeb, 0x52, 0x54, 0x85, 0x92, 0x4d, 0xce, 0x9c, 0x21, 0x31, 0x3d, 0x6b,
-	0x42, 0x54, 0x49, 0x97, 0xea, 0x45, 0x0c, 0xc2, 0xd4, 0xb5, 0x26, 0x84, 0x01, 0x86, 0xae, 0x43,
-	0xbc, 0x50, 0x00, 0xd2, 0x02, 0x20, 0x4c, 0x1c, 0xf0, 0x00, 0xb6, 0x23, 0xc0, 0x19, 0xf1, 0x03,
-	0x87, 0x7a, 0x6a, 0x86, 0x63, 0xb6, 0x84, 0xf5, 0xb9, 0x30, 0xd6, 0x02, 0xc8, 0x3d, 0x25, 0x96,
-	0x4d, 0x7c, 0xf4, 0x31, 0xc8, 0xe1, 0x7c, 0x2a, 0xf6, 0xda, 0xfe, 0xf4, 0xd6, 0xa3, 0xf8, 0xe4,
-	0x8f, 0x9e, 0x91, 0x20, 0xb0, 0xc6, 0x64, 0x30, 0x9f, 0x12, 0xcc, 0x21, 0xe8, 0xd7, 0x50, 0x1a,
-	0xd2, 0xc9, 0xd4, 0x27, 0x01, 0x0f, 0x9c, 0xe6, 0x2b, 0xee, 0x5c, 0x5b, 0xd1, 0x5a, 0x63, 0x70,
-	0x72, 0x41, 0xad, 0x01, 0x5b, 0x2d, 0x77, 0x16, 0x84, 0xc4, 0x6f, 0x51, 0x6f, 0xe4, 0x8c, 0xd1,
-	0x13, 0xc8, 0x8f, 0xa8, 0x6b, 0x13, 0x3f, 0x50, 0x25, 0x3d, 0x53, 0x2f, 0x7d, 0xaa, 0xac, 0x83,
-	0xed, 0x73, 0x47, 0x53, 0x7e, 0x7d, 0x51, 0x4d, 0xe1, 0x18, 0x56, 0xfb, 0x73, 0x1a, 0x72, 0xc2,
-	0x83, 0x76, 0x21, 0xed, 0xd8, 0x82, 0xa2, 0x66, 0xee, 0xf2, 0xa2, 0x9a, 0xee, 0xb4, 0x71, 0xda,
-	0xb1, 0xd1, 0x4d, 0xc8, 0xba, 0xd6, 0x09, 0x71, 0x23, 0x72, 0xc4, 0x04, 0xdd, 0x86, 0xa2, 0x4f,
-	0x2c, 0xdb, 0xa4, 0x9e, 0x3b, 0xe7, 0x94, 0x14, 0x70, 0x81, 0x19, 0x7a, 0x9e, 0x3b, 0x47, 0x3f,
-	0x02, 0xe4, 0x8c, 0x3d, 0xea, 0x13, 0x73, 0x4a, 0xfc, 0x89, 0xc3, 0x4f, 0x1b, 0xa8, 0x32, 0x47,
-	0xed, 0x08, 0xcf, 0xd1, 0xda, 0x81, 0xee, 0xc1, 0x56, 0x04, 0xb7, 0x89, 0x4b, 0x42, 0xa2, 0x66,
-	0x39, 0xb2, 0x2c, 0x8c, 0x6d, 0x6e, 0x43, 0x4f, 0xe0, 0xa6, 0xed, 0x04, 0xd6, 0x89, 0x4b, 0xcc,
-	0x90, 0x4c, 0xa6, 0xa6, 0xe3, 0xd9, 0xe4, 0x15, 0x09, 0xd4, 0x1c, 0xc7, 0xa2, 0xc8, 0x37, 0x20,
-	0x93, 0x69, 0x47, 0x78, 0xd0, 0x2e, 0xe4, 0xa6, 0xd6, 0x2c, 0x20, 0xb6, 0x9a, 0xe7, 0x98, 0x68,
-	0xc6, 0x58, 0x12, 0x15, 0x10, 0xa8, 0xca, 0x55, 0x96, 0xda, 0xdc, 0x11, 0xb3, 0x14, 0xc1, 0x6a,
-	0xff, 0x4e, 0x43, 0x4e, 0x78, 0xd0, 0x47, 0x2b, 0x96, 0xca, 0xcd, 0x5d, 0x86, 0xfa, 0xe7, 0x45,
-	0xb5, 0x20, 0x7c, 0x9d, 0x76, 0x82, 0x35, 0x04, 0x72, 0xa2, 0xa2, 0xf8, 0x18, 0xdd, 0x81, 0xa2,
-	0x65, 0xdb, 0x2c, 0x7b, 0x24, 0x50, 0x33, 0x7a, 0xa6, 0x5e, 0xc4, 0x6b, 0x03, 0xfa, 0xd9, 0x66,
-	0x35, 0xc8, 0x57, 0xeb, 0xe7, 0x5d, 0x65, 0xc0, 0x52, 0x31, 0x24, 0x7e, 0x54, 0xc1, 0x59, 0xbe,
-	0x5f, 0x81, 0x19, 0x78, 0xfd, 0xde, 0x85, 0xf2, 0xc4, 0x7a, 0x65, 0x06, 0xe4, 0x0f, 0x33, 0xe2,
-	0x0d, 0x09, 0xa7, 0x2b, 0x83, 0x4b, 0x13, 0xeb, 0x55, 0x3f, 0x32, 0xa1, 0x0a, 0x80, 0xe3, 0x85,
-	0x3e, 0xb5, 0x67, 0x43, 0xe2, 0x47, 0x5c, 0x25, 0x2c, 0xe8, 0x27, 0x50, 0xe0, 0x64, 0x9b, 0x8e,
-	0xad, 0x16, 0x74, 0xa9, 0x2e, 0x37, 0xb5, 0xe8, 0xe2, 0x79, 0x4e, 0x35, 0xbf, 0x77, 0x3c, 0xc4,
-	0x79, 0x8e, 0xed, 0xd8, 0xe8, 0x97, 0xa0, 0x05, 0x2f, 0x1d, 0x96, 0x28, 0x11, 0x29, 0x74, 0xa8,
-	0x67, 0xfa, 0x64, 0x42, 0xcf, 0x2c, 0x37, 0x50, 0x8b, 0x7c, 0x1b, 0x95, 0x21, 0x3a, 0x09, 0x00,
-	0x8e, 0xfc, 0xb5, 0x1e, 0x64, 0x79, 0x44, 0x96, 0x45, 0x51, 0xac, 0x51, 0xf7, 0x46, 0x33, 0xf4,
-	0x08, 0xb2, 0x23, 0xc7, 0x25, 0x81, 0x9a, 0xe6, 0x39, 0x44, 0x89, 0x4a, 0x77, 0x5c, 0xd2, 0xf1,
-	0x46, 0x34, 0xca, 0xa2, 0x80, 0xd5, 0x8e, 0xa1, 0xc4, 0x03, 0x1e, 0x4f, 0x6d, 0x2b, 0x24, 0xff,
-	0xb7, 0xb0, 0xff, 0x95, 0xa1, 0x10, 0x7b, 0x56, 0x49, 0x97, 0x12, 0x49, 0x47, 0x20, 0x07, 0xce,
-	0x57, 0x84, 0xf7, 0x48, 0x06, 0xf3, 0x31, 0xfa, 0x10, 0x60, 0x42, 0x6d, 0x67, 0xe4, 0x10, 0xdb,
-	0x0c, 0x78, 0xca, 0x32, 0xb8, 0x18, 0x5b, 0xfa, 0xe8, 0x09, 0x94, 0x56, 0xee, 0x93, 0xb9, 0x5a,
-	0xe6, 0x9c, 0x7f, 0x10, 0x73, 0xde, 0x3f, 0xa5, 0x7e, 0xd8, 0x69, 0xe3, 0x55, 0x88, 0xe6, 0x9c,
-	0x95, 0x74, 0x2c, 0x4f, 0x8c, 0xd8, 0x8d, 0x92, 0x7e, 0x4e, 0x86, 0x21, 0x5d, 0x35, 0x7e, 0x04,
-	0x43, 0x1a, 0x14, 0x56, 0x35, 0x01, 0xfc, 0x00, 0xab, 0x39, 0xfa, 0x31, 0xe4, 0x4e, 0x5c, 0x3a,
-	0x7c, 0x19, 0xf7, 0xc7, 0x8d, 0x75, 0xb0, 0x26, 0xb3, 0x27, 0x58, 0x88, 0x80, 0x4c, 0x26, 0x83,
-	0xf9, 0xc4, 0x75, 0xbc, 0x97, 0x66, 0x68, 0xf9, 0x63, 0x12, 0xaa, 0x3b, 0x42, 0x26, 0x23, 0xeb,
-	0x80, 0x1b, 0x99, 0xdc, 0x8a, 0x05, 0xe6, 0xa9, 0x15, 0x9c, 0xaa, 0x88, 0xb5, 0x11, 0x06, 0x61,
-	0x7a, 0x6a, 0x05, 0xa7, 0x68, 0x2f, 0x52, 0x4f, 0xa1, 0x85, 0xbb, 0xd7, 0xd9, 0x4f, 0xc8, 0xa7,
-	0x0e, 0xa5, 0xab, 0xf2, 0xb2, 0x85, 0x93, 0x26, 0xb6, 0xdd, 0x8a, 0x48, 0x2f, 0x50, 0x4b, 0xba,
-	0x54, 0xcf, 0xae, 0x79, 0xeb, 0x06, 0xe8, 0x31, 0x88, 0xcd, 0x4d, 0x9e, 0xa2, 0x2d, 0xe6, 0x6f,
-	0x2a, 0x97, 0x17, 0xd5, 0x32, 0xb6, 0xce, 0xf9, 0x55, 0xfb, 0xce, 0x57, 0x04, 0x17, 0x4f, 0xe2,
-	0x21, 0xdb, 0xd3, 0xa5, 0x43, 0xcb, 0x35, 0x47, 0xae, 0x35, 0x0e, 0xd4, 0x6f, 0xf3, 0x7c, 0x53,
-	0xe0, 0xb6, 0x7d, 0x66, 0x42, 0x2a, 0x53, 0x17, 0xa6, 0x58, 0x76, 0x24, 0x4d, 0xf1, 0x14, 0xd5,
-	0x21, 0xef, 0x78, 0x67, 0x96, 0xeb, 0x44, 0x82, 0xd4, 0xdc, 0xbe, 0xbc, 0xa8, 0x02, 0xb6, 0xce,
-	0x3b, 0xc2, 0x8a, 0x63, 0x37, 0x63, 0xd3, 0xa3, 0x1b, 0xda, 0x59, 0xe0, 0xa1, 0xb6, 0x3c, 0x9a,
-	0xd0, 0xcd, 0x5f, 0xc8, 0x7f, 0xfa, 0xba, 0x9a, 0xaa, 0x79, 0x50, 0x5c, 0x65, 0x85, 0x55, 0x1b,
-	0x67, 0x36, 0xc3, 0x99, 0xe5, 0x63, 0x56, 0xea, 0x74, 0x34, 0x0a, 0x48, 0xc8, 0xeb, 0x32, 0x83,
-	0xa3, 0xd9, 0xaa, 0x32, 0xd3, 0x9c, 0x16, 0x51, 0x99, 0xb7, 0xa1, 0x78, 0x4e, 0xac, 0x97, 0x22,
-	0x3d, 0x82, 0xd1, 0x02, 0x33, 0xb0, 0xe4, 0x44, 0xfb, 0xfd, 0x0a, 0x72, 0xa2, 0xa4, 0xd0, 0x67,
-	0x50, 0x18, 0xd2, 0x99, 0x17, 0xae, 0xdf, 0x9b, 0x9d, 0xa4, 0x5c, 0x71, 0x4f, 0x54, 0x27, 0x2b,
-	0x60, 0x6d, 0x1f, 0xf2, 0x91, 0x0b, 0x3d, 0x58, 0x69, 0xa9, 0xdc, 0xbc, 0x75, 0xa5, 0xbc, 0x37,
-	0x1f, 0xa0, 0x33, 0xcb, 0x9d, 0x89, 0x83, 0xca, 0x58, 0x4c, 0x6a, 0x7f, 0x95, 0x20, 0x8f, 0x59,
-	0xc5, 0x06, 0x61, 0xe2, 0xe9, 0xca, 0x6e, 0x3c, 0x5d, 0xeb, 0x26, 0x4f, 0x6f, 0x34, 0x79, 0xdc,
-	0xa7, 0x99, 0x44, 0x9f, 0xae, 0x59, 0x92, 0xbf, 0x93, 0xa5, 0x6c, 0x82, 0xa5, 0x98, 0xe5, 0x5c,
-	0x82, 0xe5, 0x07, 0xb0, 0x3d, 0xf2, 0xe9, 0x84, 0x3f, 0x4e, 0xd4, 0xb7, 0xfc, 0x79, 0xa4, 0xa4,
-	0x5b, 0xcc, 0x3a, 0x88, 0x8d, 0x9b, 0x04, 0x17, 0x36, 0x09, 0xae, 0x99, 0x50, 0xc0, 0x24, 0x98,
-	0x52, 0x2f, 0x20, 0xef, 0xbc, 0x13, 0x02, 0xd9, 0xb6, 0x42, 0x8b, 0xdf, 0xa8, 0x8c, 0xf9, 0x18,
-	0x3d, 0x04, 0x79, 0x48, 0x6d, 0x71, 0x9f, 0xed, 0x64, 0xbb, 0x1a, 0xbe, 0x4f, 0xfd, 0x16, 0xb5,
-	0x09, 0xe6, 0x80, 0xda, 0x14, 0x94, 0x36, 0x3d, 0xf7, 0x5c, 0x6a, 0xd9, 0x47, 0x3e, 0x1d, 0xb3,
-	0x17, 0xe4, 0x9d, 0x4a, 0xd8, 0x86, 0xfc, 0x8c, 0x6b, 0x65, 0xac, 0x85, 0xf7, 0x37, 0xbb, 0xf1,
-	0x6a, 0x20, 0x21, 0xac, 0xb1, 0xce, 0x44, 0x4b, 0x6b, 0x7f, 0x97, 0x40, 0x7b, 0x37, 0x1a, 0x75,
-	0xa0, 0x24, 0x90, 0x66, 0xe2, 0xa7, 0xa9, 0xfe, 0x3e, 0x1b, 0x71, 0x21, 0x80, 0xd9, 0x6a, 0xfc,
-	0x9d, 0x2f, 0x6e, 0x42, 0x17, 0x33, 0xef, 0xa7, 0x8b, 0x0f, 0x61, 0x4b, 0x28, 0x42, 0xfc, 0x7f,
-	0x21, 0xeb, 0x99, 0x7a, 0xb6, 0x99, 0x56, 0x52, 0xb8, 0x7c, 0x22, 0xda, 0x8c, 0xdb, 0x6b, 0x39,
-	0x90, 0x8f, 0x1c, 0x6f, 0x5c, 0xab, 0x42, 0xb6, 0xe5, 0x52, 0x9e, 0xb0, 0x9c, 0x4f, 0xac, 0x80,
-	0x7a, 0x31, 0x8f, 0x62, 0xb6, 0xf7, 0xb7, 0x34, 0x94, 0x12, 0xff, 0x7e, 0xe8, 0x09, 0x6c, 0xb7,
-	0x0e, 0x8f, 0xfb, 0x03, 0x03, 0x9b, 0xad, 0x5e, 0x77, 0xbf, 0x73, 0xa0, 0xa4, 0xb4, 0x3b, 0x8b,
-	0xa5, 0xae, 0x4e, 0xd6, 0xa0, 0xcd, 0xdf, 0xba, 0x2a, 0x64, 0x3b, 0xdd, 0xb6, 0xf1, 0x3b, 0x45,
-	0xd2, 0x6e, 0x2e, 0x96, 0xba, 0x92, 0x00, 0x8a, 0x37, 0xf2, 0x13, 0x28, 0x73, 0x80, 0x79, 0x7c,
-	0xd4, 0x6e, 0x0c, 0x0c, 0x25, 0xad, 0x69, 0x8b, 0xa5, 0xbe, 0x7b, 0x15, 0x17, 0x71, 0x7e, 0x0f,
-	0xf2, 0xd8, 0xf8, 0xed, 0xb1, 0xd1, 0x1f, 0x28, 0x19, 0x6d, 0x77, 0xb1, 0xd4, 0x51, 0x02, 0x18,
-	0xb7, 0xd4, 0x03, 0x28, 0x60, 0xa3, 0x7f, 0xd4, 0xeb, 0xf6, 0x0d, 0x45, 0xd6, 0x7e, 0xb0, 0x58,
-	0xea, 0x37, 0x36, 0x50, 0x51, 0x95, 0xfe, 0x14, 0x76, 0xda, 0xbd, 0x2f, 0xba, 0x87, 0xbd, 0x46,
-	0xdb, 0x3c, 0xc2, 0xbd, 0x03, 0x6c, 0xf4, 0xfb, 0x4a, 0x56, 0xab, 0x2e, 0x96, 0xfa, 0xed, 0x04,
-	0xfe, 0x5a, 0xd1, 0x7d, 0x08, 0xf2, 0x51, 0xa7, 0x7b, 0xa0, 0xe4, 0xb4, 0x1b, 0x8b, 0xa5, 0xfe,
-	0x41, 0x02, 0xca, 0x48, 0x65, 0x37, 0x6e, 0x1d, 0xf6, 0xfa, 0x86, 0x92, 0xbf, 0x76, 0x63, 0x4e,
-	0xf6, 0xde, 0xef, 0x01, 0x5d, 0xff, 0x3b, 0x46, 0xf7, 0x41, 0xee, 0xf6, 0xba, 0x86, 0x92, 0x12,
-	0xf7, 0xbf, 0x8e, 0xe8, 0x52, 0x8f, 0xa0, 0x1a, 0x64, 0x0e, 0xbf, 0xfc, 0x5c, 0x91, 0xb4, 0x1f,
-	0x2e, 0x96, 0xfa, 0xad, 0xeb, 0xa0, 0xc3, 0x2f, 0x3f, 0xdf, 0xa3, 0x50, 0x4a, 0x06, 0xae, 0x41,
-	0xe1, 0x99, 0x31, 0x68, 0xb4, 0x1b, 0x83, 0x86, 0x92, 0x12, 0x47, 0x8a, 0xdd, 0xcf, 0x48, 0x68,
-	0xf1, 0x26, 0xbc, 0x03, 0xd9, 0xae, 0xf1, 0xdc, 0xc0, 0x8a, 0xa4, 0xed, 0x2c, 0x96, 0xfa, 0x56,
-	0x0c, 0xe8, 0x92, 0x33, 0xe2, 0xa3, 0x0a, 0xe4, 0x1a, 0x87, 0x5f, 0x34, 0x5e, 0xf4, 0x95, 0xb4,
-	0x86, 0x16, 0x4b, 0x7d, 0x3b, 0x76, 0x37, 0xdc, 0x73, 0x6b, 0x1e, 0xec, 0xfd, 0x47, 0x82, 0x72,
-	0xf2, 0x8d, 0x43, 0x15, 0x90, 0xf7, 0x3b, 0x87, 0x46, 0xbc, 0x5d, 0xd2, 0xc7, 0xc6, 0xa8, 0x0e,
-	0xc5, 0x76, 0x07, 0x1b, 0xad, 0x41, 0x0f, 0xbf, 0x88, 0xef, 0x92, 0x04, 0xb5, 0x1d, 0x9f, 0x17,
-	0xf8, 0x1c, 0xfd, 0x1c, 0xca, 0xfd, 0x17, 0xcf, 0x0e, 0x3b, 0xdd, 0xdf, 0x98, 0x3c, 0x62, 0x5a,
-	0x7b, 0xb8, 0x58, 0xea, 0x77, 0x37, 0xc0, 0x64, 0xea, 0x93, 0xa1, 0x15, 0x12, 0xbb, 0x2f, 0xde,
-	0x6b, 0xe6, 0x2c, 0x48, 0xa8, 0x05, 0x3b, 0xf1, 0xd2, 0xf5, 0x66, 0x19, 0xed, 0x93, 0xc5, 0x52,
-	0xff, 0xe8, 0x7b, 0xd7, 0xaf, 0x76, 0x2f, 0x48, 0xe8, 0x3e, 0xe4, 0xa3, 0x20, 0x71, 0x25, 0x25,
-	0x97, 0x46, 0x0b, 0xf6, 0xfe, 0x22, 0x41, 0x71, 0x25, 0x57, 0x8c, 0xf0, 0x6e, 0xcf, 0x34, 0x30,
-	0xee, 0xe1, 0x98, 0x81, 0x95, 0xb3, 0x4b, 0xf9, 0x10, 0xdd, 0x85, 0xfc, 0x81, 0xd1, 0x35, 0x70,
-	0xa7, 0x15, 0x37, 0xc6, 0x0a, 0x72, 0x40, 0x3c, 0xe2, 0x3b, 0x43, 0xf4, 0x31, 0x94, 0xbb, 0x3d,
-	0xb3, 0x7f, 0xdc, 0x7a, 0x1a, 0x5f, 0x9d, 0xef, 0x9f, 0x08, 0xd5, 0x9f, 0x0d, 0x4f, 0x39, 0x9f,
-	0x7b, 0xac, 0x87, 0x9e, 0x37, 0x0e, 0x3b, 0x6d, 0x01, 0xcd, 0x68, 0xea, 0x62, 0xa9, 0xdf, 0x5c,
-	0x41, 0xa3, 0x47, 0x9a, 0x61, 0xf7, 0x6c, 0xa8, 0x7c, 0xbf, 0x30, 0x21, 0x1d, 0x72, 0x8d, 0xa3,
-	0x23, 0xa3, 0xdb, 0x8e, 0x4f, 0xbf, 0xf6, 0x35, 0xa6, 0x53, 0xe2, 0xd9, 0x0c, 0xb1, 0xdf, 0xc3,
-	0x07, 0xc6, 0x20, 0x3e, 0xfc, 0x1a, 0xb1, 0x4f, 0xd9, 0xcf, 0x52, 0xb3, 0xfe, 0xfa, 0x9b, 0x4a,
-	0xea, 0xcd, 0x37, 0x95, 0xd4, 0xeb, 0xcb, 0x8a, 0xf4, 0xe6, 0xb2, 0x22, 0xfd, 0xeb, 0xb2, 0x92,
-	0xfa, 0xf6, 0xb2, 0x22, 0xfd, 0xf1, 0x6d, 0x25, 0xf5, 0xf5, 0xdb, 0x8a, 0xf4, 0xe6, 0x6d, 0x25,
-	0xf5, 0x8f, 0xb7, 0x95, 0xd4, 0x49, 0x8e, 0x8b, 0xda, 0x67, 0xff, 0x0b, 0x00, 0x00, 0xff, 0xff,
-	0x39, 0xd2, 0xc3, 0x6e, 0x32, 0x0f, 0x00, 0x00,
+	// 2138 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xcd, 0x6f, 0xdb, 0xc8,
+	0x15, 0x17, 0xf5, 0x49, 0x3d, 0xc9, 0x8e, 0x3c, 0xc9, 0x3a, 0x8c, 0x36, 0x91, 0x19, 0xe5, 0xcb,
+	0x71, 0x77, 0x93, 0x6c, 0x76, 0xfb, 0x15, 0xb4, 0x05, 0x64, 0x49, 0x76, 0x84, 0x3a, 0x92, 0x4a,
+	0xc9, 0xc9, 0x66, 0x0f, 0x25, 0x68, 0x71, 0x24, 0x13, 0xa1, 0x38, 0x2a, 0x49, 0xd9, 0xd6, 0xfe,
+	0x09, 0x42, 0x51, 0xf4, 0xd8, 0x8b, 0x80, 0x45, 0x6f, 0x05, 0xfa, 0x87, 0xe4, 0x18, 0xf4, 0xd0,
+	0x16, 0x3d, 0x18, 0x5d, 0xe7, 0xb2, 0x05, 0xfa, 0x17, 0xf4, 0x50, 0x14, 0xf3, 0x86, 0x94, 0x28,
+	0x3b, 0x59, 0xe4, 0xd0, 0x13, 0x67, 0xde, 0xfb, 0xcd, 0x9b, 0x79, 0xdf, 0x8f, 0x90, 0x3d, 0xa0,
+	0xa3, 0x07, 0x23, 0x97, 0xf9, 0x8c, 0xc8, 0xf8, 0xe9, 0x31, 0xbb, 0x78, 0xcb, 0xa5, 0x23, 0xe6,
+	0x3d, 0xc4, 0xfd, 0xc1, 0xb8, 0xff, 0x70, 0xc0, 0x06, 0x0c, 0x37, 0xb8, 0x12, 0xf0, 0xf2, 0x1f,
+	0x25, 0x48, 0x3d, 0xa5, 0xb6, 0xcd, 0xc8, 0x06, 0xe4, 0x4c, 0x7a, 0x64, 0xf5, 0xa8, 0xee, 0x18,
+	0x43, 0xaa, 0x48, 0xaa, 0xb4, 0x99, 0xd5, 0x40, 0x90, 0x9a, 0xc6, 0x90, 0x72, 0x40, 0xcf, 0xb6,
+	0xa8, 0xe3, 0x0b, 0x40, 0x5c, 0x00, 0x04, 0x09, 0x01, 0x77, 0x60, 0x35, 0x00, 0x1c, 0x51, 0xd7,
+	0xb3, 0x98, 0xa3, 0x24, 0x10, 0xb3, 0x22, 0xa8, 0xcf, 0x05, 0x91, 0x3c, 0x82, 0x4b, 0xce, 0x78,
+	0xa8, 0xf7, 0x98, 0xe3, 0xd0, 0x9e, 0x6f, 0x31, 0xc7, 0x53, 0x92, 0xaa, 0xb4, 0x99, 0xda, 0xce,
+	0xfc, 0xe7, 0x74, 0x23, 0x61, 0x39, 0xbe, 0xb6, 0xea, 0x8c, 0x87, 0xd5, 0x05, 0xbb, 0xec, 0x41,
+	0xfa, 0x29, 0x35, 0x4c, 0xea, 0x92, 0xfb, 0x90, 0xf4, 0x27, 0x23, 0xf1, 0xba, 0xd5, 0xc7, 0x1f,
+	0x3d, 0x08, 0x95, 0x7d, 0xf0, 0x8c, 0x7a, 0x9e, 0x31, 0xa0, 0xdd, 0xc9, 0x88, 0x6a, 0x08, 0x21,
+	0xbf, 0x80, 0x5c, 0x8f, 0x0d, 0x47, 0x2e, 0xf5, 0xf0, 0x29, 0x71, 0x3c, 0x71, 0xfd, 0xc2, 0x89,
+	0xea, 0x02, 0xa3, 0x45, 0x0f, 0x94, 0x2b, 0xb0, 0x52, 0xb5, 0xc7, 0x9e, 0x4f, 0xdd, 0x2a, 0x73,
+	0xfa, 0xd6, 0x80, 0x3c, 0x82, 0x4c, 0x9f, 0xd9, 0x26, 0x75, 0x3d, 0x45, 0x52, 0x13, 0x9b, 0xb9,
+	0xc7, 0x85, 0x85, 0xb0, 0x1d, 0x64, 0x6c, 0x27, 0x5f, 0x9f, 0x6e, 0xc4, 0xb4, 0x10, 0x56, 0xfe,
+	0x57, 0x1c, 0xd2, 0x82, 0x43, 0xd6, 0x21, 0x6e, 0x99, 0xc2, 0xa8, 0xdb, 0xe9, 0xb3, 0xd3, 0x8d,
+	0x78, 0xa3, 0xa6, 0xc5, 0x2d, 0x93, 0x5c, 0x81, 0x94, 0x6d, 0x1c, 0x50, 0x3b, 0x30, 0xa7, 0xd8,
+	0x90, 0x8f, 0x21, 0xeb, 0x52, 0xc3, 0xd4, 0x99, 0x63, 0x4f, 0xd0, 0x88, 0xb2, 0x26, 0x73, 0x42,
+	0xcb, 0xb1, 0x27, 0xe4, 0x53, 0x20, 0xd6, 0xc0, 0x61, 0x2e, 0xd5, 0x47, 0xd4, 0x1d, 0x5a, 0x9e,
+	0x37, 0x37, 0xa1, 0xac, 0xad, 0x09, 0x4e, 0x7b, 0xc1, 0x20, 0xb7, 0x60, 0x25, 0x80, 0x9b, 0xd4,
+	0xa6, 0x3e, 0x55, 0x52, 0x88, 0xcc, 0x0b, 0x62, 0x0d, 0x69, 0xe4, 0x11, 0x5c, 0x31, 0x2d, 0xcf,
+	0x38, 0xb0, 0xa9, 0xee, 0xd3, 0xe1, 0x48, 0xb7, 0x1c, 0x93, 0x9e, 0x50, 0x4f, 0x49, 0x23, 0x96,
+	0x04, 0xbc, 0x2e, 0x1d, 0x8e, 0x1a, 0x82, 0x43, 0xd6, 0x21, 0x3d, 0x32, 0xc6, 0x1e, 0x35, 0x95,
+	0x0c, 0x62, 0x82, 0x1d, 0xa9, 0xc1, 0xaa, 0x77, 0x68, 0xb8, 0xd4, 0xd4, 0xc5, 0x05, 0x9e, 0x22,
+	0xab, 0xd2, 0x66, 0xee, 0xf1, 0xd5, 0x85, 0xb1, 0x3a, 0xc8, 0x6f, 0x08, 0x76, 0x60, 0xb3, 0x15,
+	0x2f, 0x4a, 0xe4, 0xb6, 0x16, 0x91, 0xe7, 0x29, 0x85, 0xf3, 0xb6, 0xae, 0x21, 0x23, 0xb4, 0x75,
+	0x00, 0x2b, 0xbf, 0x80, 0x95, 0x25, 0xb9, 0x68, 0x59, 0xcb, 0xa1, 0xc2, 0x59, 0x59, 0x4d, 0x6c,
+	0xb8, 0xe0, 0x30, 0x38, 0xe3, 0xaa, 0xb4, 0x2c, 0xf8, 0x39, 0xed, 0xf9, 0x6c, 0xee, 0xc4, 0x00,
+	0x56, 0xfe, 0x73, 0x02, 0xd2, 0xe2, 0x4a, 0x72, 0x77, 0xee, 0xc4, 0xfc, 0xf6, 0x3a, 0x47, 0xfd,
+	0xe3, 0x74, 0x43, 0x16, 0xbc, 0x46, 0x2d, 0xe2, 0x54, 0x02, 0xc9, 0x48, 0x8a, 0xe0, 0x9a, 0x5c,
+	0x87, 0xac, 0x61, 0x9a, 0x3c, 0xb8, 0xa8, 0xa7, 0x24, 0xf0, 0x49, 0x0b, 0x02, 0xf9, 0xf1, 0x72,
+	0xb0, 0x26, 0xcf, 0x87, 0xf7, 0xfb, 0xa2, 0x94, 0x47, 0x4a, 0x8f, 0xba, 0x41, 0x4a, 0xa6, 0xf0,
+	0x3e, 0x99, 0x13, 0x30, 0x21, 0x6f, 0x42, 0x7e, 0x68, 0x9c, 0xe8, 0x1e, 0xfd, 0xcd, 0x98, 0x3a,
+	0x3d, 0x8a, 0xde, 0x4c, 0x68, 0xb9, 0xa1, 0x71, 0xd2, 0x09, 0x48, 0xa4, 0x04, 0x60, 0x39, 0xbe,
+	0xcb, 0xcc, 0x71, 0x8f, 0xba, 0x81, 0x2b, 0x23, 0x14, 0xf2, 0x43, 0x90, 0x31, 0x16, 0x74, 0xcb,
+	0x44, 0x47, 0x26, 0xb7, 0x8b, 0x81, 0xe2, 0x19, 0x8c, 0x04, 0xd4, 0x3b, 0x5c, 0x6a, 0x19, 0xc4,
+	0x36, 0x4c, 0xf2, 0x33, 0x28, 0x7a, 0xaf, 0xac, 0x91, 0x1e, 0x4a, 0xe2, 0x79, 0xac, 0xbb, 0x74,
+	0xc8, 0x8e, 0x0c, 0xdb, 0x53, 0xb2, 0x78, 0x8d, 0xc2, 0x11, 0x8d, 0x08, 0x40, 0x0b, 0xf8, 0xe4,
+	0x09, 0x5c, 0xa3, 0x4e, 0xcf, 0x9d, 0x8c, 0xf0, 0xd8, 0xc8, 0xf0, 0xbc, 0x63, 0xe6, 0x9a, 0xba,
+	0xcf, 0x5e, 0x51, 0x47, 0x01, 0x6e, 0x7e, 0xed, 0xea, 0x02, 0xd0, 0x0e, 0xf8, 0x5d, 0xce, 0x2e,
+	0xb7, 0x20, 0x85, 0xaf, 0xe1, 0x01, 0x2a, 0xf2, 0x30, 0x28, 0x65, 0xc1, 0x8e, 0x3c, 0x80, 0x54,
+	0xdf, 0xb2, 0xa9, 0xa7, 0xc4, 0x31, 0xb0, 0x48, 0x24, 0x89, 0x2d, 0x9b, 0x36, 0x9c, 0x3e, 0x0b,
+	0x22, 0x40, 0xc0, 0xca, 0xfb, 0x90, 0x43, 0x81, 0xfb, 0x23, 0xd3, 0xf0, 0xe9, 0xff, 0x4d, 0xec,
+	0xdf, 0x52, 0x20, 0x87, 0x9c, 0x79, 0xc0, 0x48, 0x91, 0x80, 0x21, 0x90, 0xf4, 0xac, 0xaf, 0x29,
+	0xa6, 0x7f, 0x42, 0xc3, 0x35, 0xb9, 0x01, 0x30, 0x64, 0xa6, 0xd5, 0xb7, 0xa8, 0xa9, 0x7b, 0xe8,
+	0xee, 0x84, 0x96, 0x0d, 0x29, 0x1d, 0xf2, 0x08, 0x72, 0x73, 0xf6, 0xc1, 0x44, 0xc9, 0xa3, 0xbf,
+	0x2e, 0x85, 0xfe, 0xea, 0x1c, 0x32, 0xd7, 0x6f, 0xd4, 0xb4, 0xb9, 0x88, 0xed, 0x49, 0x34, 0x1d,
+	0xb2, 0x1f, 0x94, 0x0e, 0xa4, 0x08, 0xf2, 0x3c, 0x9e, 0x00, 0x1f, 0x30, 0xdf, 0x93, 0xcf, 0x20,
+	0x7d, 0x60, 0xb3, 0xde, 0xab, 0x30, 0x69, 0x2f, 0x2f, 0x84, 0x6d, 0x73, 0x7a, 0xc4, 0x0a, 0x01,
+	0x90, 0xf7, 0x0c, 0x6f, 0x32, 0xb4, 0x2d, 0xe7, 0x95, 0xee, 0x1b, 0xee, 0x80, 0xfa, 0xca, 0x9a,
+	0xe8, 0x19, 0x01, 0xb5, 0x8b, 0x44, 0xde, 0x7b, 0xc4, 0x01, 0xfd, 0xd0, 0xf0, 0x0e, 0x15, 0x82,
+	0x31, 0x00, 0x82, 0xf4, 0xd4, 0xf0, 0x0e, 0x79, 0x7a, 0x05, 0x11, 0x41, 0x4d, 0xe5, 0x32, 0xb2,
+	0x17, 0x04, 0xf2, 0x13, 0x90, 0x47, 0xb6, 0xe1, 0xf7, 0x99, 0x3b, 0x54, 0x56, 0x51, 0xcf, 0xf5,
+	0xc5, 0xd3, 0xda, 0x01, 0xa7, 0x66, 0xf8, 0x46, 0xf0, 0xba, 0x39, 0x9a, 0x6c, 0x05, 0x0d, 0x47,
+	0xb4, 0x8f, 0xf5, 0x8b, 0x5e, 0x8d, 0x74, 0x1c, 0x15, 0x72, 0xe7, 0x2b, 0xf2, 0x8a, 0x16, 0x25,
+	0x71, 0x35, 0xe6, 0x0e, 0x72, 0x3c, 0x25, 0xc7, 0xdb, 0xde, 0xc2, 0x1f, 0x4d, 0x8f, 0x3c, 0x04,
+	0xa1, 0x94, 0x8e, 0xae, 0x5f, 0xc1, 0xb6, 0x58, 0x38, 0x3b, 0xdd, 0xc8, 0x6b, 0xc6, 0x31, 0x9a,
+	0xb0, 0x63, 0x7d, 0x4d, 0xb5, 0xec, 0x41, 0xb8, 0xe4, 0x77, 0xda, 0xac, 0x67, 0xd8, 0x7a, 0xdf,
+	0x36, 0x06, 0x9e, 0xf2, 0x5d, 0x06, 0x2f, 0x05, 0xa4, 0xed, 0x70, 0x12, 0x51, 0x78, 0x29, 0xe5,
+	0x45, 0xde, 0x0c, 0xaa, 0x79, 0xb8, 0x25, 0x9b, 0x90, 0xb1, 0x9c, 0x23, 0xc3, 0xb6, 0x82, 0x1a,
+	0xbe, 0xbd, 0x7a, 0x76, 0xba, 0x01, 0x9a, 0x71, 0xdc, 0x10, 0x54, 0x2d, 0x64, 0x73, 0x2f, 0x39,
+	0x6c, 0xa9, 0xdd, 0xc8, 0x28, 0x6a, 0xc5, 0x61, 0x91, 0x56, 0xf3, 0x24, 0xf9, 0x87, 0x6f, 0x36,
+	0x62, 0xe5, 0xdf, 0x49, 0x90, 0x8f, 0xda, 0x94, 0xdc, 0x85, 0xe4, 0xd8, 0xb1, 0x4e, 0x30, 0xba,
+	0x97, 0x32, 0x63, 0xdf, 0xb1, 0x4e, 0x38, 0x42, 0x43, 0x3e, 0xb9, 0x8f, 0x15, 0x7b, 0x7c, 0x82,
+	0x21, 0xbf, 0x14, 0x3d, 0x5f, 0x1a, 0xbe, 0xef, 0x22, 0x52, 0x20, 0xc8, 0x0f, 0x20, 0x6d, 0x1a,
+	0xee, 0xb1, 0x25, 0x4a, 0xe5, 0x7b, 0xb0, 0x01, 0xa4, 0xfc, 0x5b, 0x09, 0xe4, 0xf0, 0x2a, 0x9e,
+	0x42, 0xec, 0xd8, 0xa1, 0x6e, 0x74, 0xca, 0xc9, 0x22, 0x05, 0x4b, 0xe6, 0x0d, 0x80, 0x81, 0xcb,
+	0xc6, 0xa3, 0xe8, 0x8c, 0x93, 0x45, 0x0a, 0xb2, 0x55, 0x48, 0x8c, 0x2d, 0x13, 0x1f, 0x98, 0x42,
+	0x73, 0x25, 0xf6, 0x1b, 0xb5, 0x70, 0x6c, 0xe1, 0x2c, 0x8e, 0x18, 0x58, 0xa6, 0x92, 0x5c, 0x20,
+	0x76, 0x23, 0x88, 0x81, 0x65, 0x96, 0x9f, 0x40, 0x76, 0xfe, 0x46, 0xf2, 0x29, 0xa4, 0x4f, 0xf8,
+	0x26, 0x9c, 0x29, 0x2e, 0x9d, 0x53, 0x24, 0x4c, 0x17, 0x01, 0x2a, 0x7f, 0x06, 0x29, 0x24, 0xbf,
+	0xb3, 0x62, 0x5c, 0x81, 0xd4, 0x91, 0x61, 0x8f, 0xc5, 0xb3, 0xf3, 0x9a, 0xd8, 0x94, 0x1d, 0xc8,
+	0xce, 0x93, 0x8f, 0x1f, 0xc3, 0x04, 0x4a, 0x20, 0x02, 0xd7, 0xbc, 0xa2, 0xb1, 0x7e, 0xdf, 0xa3,
+	0x3e, 0x0a, 0x4b, 0x68, 0xc1, 0x6e, 0x5e, 0x80, 0xe2, 0x18, 0xa5, 0xb8, 0xe6, 0xed, 0xe6, 0x98,
+	0x1a, 0xaf, 0x44, 0x16, 0x8a, 0x00, 0x97, 0x39, 0x81, 0xe7, 0x60, 0xe0, 0xfe, 0x9f, 0x43, 0x5a,
+	0x54, 0x0e, 0xf2, 0x39, 0xc8, 0x3d, 0x36, 0x76, 0xfc, 0xc5, 0xc4, 0xb4, 0x16, 0xed, 0x68, 0xc8,
+	0x09, 0x13, 0x2e, 0x04, 0x96, 0x77, 0x20, 0x13, 0xb0, 0xc8, 0x9d, 0x79, 0xbb, 0x4d, 0x6e, 0x7f,
+	0x74, 0xae, 0x8a, 0x2d, 0x8f, 0x50, 0x0b, 0xb5, 0x93, 0xa1, 0xda, 0xff, 0x96, 0x20, 0xa3, 0xf1,
+	0xc2, 0xe4, 0xf9, 0x91, 0xe1, 0x2b, 0xb5, 0x34, 0x7c, 0x2d, 0x6a, 0x79, 0x7c, 0xa9, 0x96, 0x87,
+	0xc6, 0x4d, 0x44, 0x8c, 0xbb, 0xb0, 0x52, 0xf2, 0x9d, 0x56, 0x4a, 0x45, 0xac, 0x14, 0x5a, 0x39,
+	0x1d, 0xb1, 0xf2, 0x1d, 0x58, 0xed, 0xbb, 0x6c, 0x88, 0xe3, 0x15, 0x73, 0x0d, 0x77, 0x12, 0x34,
+	0xdb, 0x15, 0x4e, 0xed, 0x86, 0xc4, 0x65, 0x03, 0xcb, 0xcb, 0x06, 0x26, 0xd7, 0x40, 0x16, 0xd5,
+	0xc1, 0x61, 0x58, 0xae, 0x53, 0x5a, 0x06, 0xf7, 0x4d, 0x56, 0xd6, 0x41, 0xd6, 0xa8, 0x37, 0x62,
+	0x8e, 0x47, 0xdf, 0xab, 0x2e, 0x81, 0xa4, 0x69, 0xf8, 0x46, 0x10, 0x1e, 0xb8, 0x26, 0xf7, 0x20,
+	0xd9, 0x63, 0xa6, 0x50, 0x75, 0x35, 0x9a, 0x46, 0x75, 0xd7, 0x65, 0x6e, 0x95, 0x99, 0x54, 0x43,
+	0x40, 0x79, 0x04, 0x85, 0x1a, 0x3b, 0x76, 0x6c, 0x66, 0x98, 0x6d, 0x97, 0x0d, 0xf8, 0xfc, 0xf1,
+	0xde, 0x5e, 0x58, 0x83, 0xcc, 0x18, 0xbb, 0x65, 0xd8, 0x0d, 0x6f, 0x2f, 0xd7, 0xcd, 0xf3, 0x82,
+	0x44, 0x6b, 0x0d, 0x3b, 0x4d, 0x70, 0xb4, 0xfc, 0x57, 0x09, 0x8a, 0xef, 0x47, 0x93, 0x06, 0xe4,
+	0x04, 0x52, 0x8f, 0xfc, 0x11, 0x6c, 0x7e, 0xc8, 0x45, 0x58, 0xb2, 0x61, 0x3c, 0x5f, 0xbf, 0x73,
+	0x5e, 0x8b, 0x74, 0xc6, 0xc4, 0x87, 0x75, 0xc6, 0x7b, 0xb0, 0x22, 0xbc, 0x13, 0x0e, 0xcf, 0x49,
+	0x35, 0xb1, 0x99, 0xda, 0x8e, 0x17, 0x62, 0x5a, 0xfe, 0x40, 0x64, 0x20, 0xd2, 0xcb, 0x69, 0x48,
+	0xb6, 0x2d, 0x67, 0x50, 0xde, 0x80, 0x54, 0xd5, 0x66, 0xe8, 0xb0, 0xb4, 0x4b, 0x0d, 0x8f, 0x39,
+	0xa1, 0x1d, 0xc5, 0x6e, 0xeb, 0x2f, 0x71, 0xc8, 0x45, 0x7e, 0x6c, 0xc8, 0x23, 0x58, 0xad, 0xee,
+	0xed, 0x77, 0xba, 0x75, 0x4d, 0xaf, 0xb6, 0x9a, 0x3b, 0x8d, 0xdd, 0x42, 0xac, 0x78, 0x7d, 0x3a,
+	0x53, 0x95, 0xe1, 0x02, 0xb4, 0xfc, 0xcf, 0xb2, 0x01, 0xa9, 0x46, 0xb3, 0x56, 0xff, 0xb2, 0x20,
+	0x15, 0xaf, 0x4c, 0x67, 0x6a, 0x21, 0x02, 0x14, 0x53, 0xd2, 0x27, 0x90, 0x47, 0x80, 0xbe, 0xdf,
+	0xae, 0x55, 0xba, 0xf5, 0x42, 0xbc, 0x58, 0x9c, 0xce, 0xd4, 0xf5, 0xf3, 0xb8, 0xc0, 0xe6, 0xb7,
+	0x20, 0xa3, 0xd5, 0x7f, 0xb5, 0x5f, 0xef, 0x74, 0x0b, 0x89, 0xe2, 0xfa, 0x74, 0xa6, 0x92, 0x08,
+	0x30, 0xcc, 0xb6, 0x3b, 0x20, 0x6b, 0xf5, 0x4e, 0xbb, 0xd5, 0xec, 0xd4, 0x0b, 0xc9, 0xe2, 0xd5,
+	0xe9, 0x4c, 0xbd, 0xbc, 0x84, 0x0a, 0xa2, 0xf4, 0x47, 0xb0, 0x56, 0x6b, 0xbd, 0x68, 0xee, 0xb5,
+	0x2a, 0x35, 0xbd, 0xad, 0xb5, 0x76, 0xb5, 0x7a, 0xa7, 0x53, 0x48, 0x15, 0x37, 0xa6, 0x33, 0xf5,
+	0xe3, 0x08, 0xfe, 0x42, 0xd0, 0xdd, 0x80, 0x64, 0xbb, 0xd1, 0xdc, 0x2d, 0xa4, 0x8b, 0x97, 0xa7,
+	0x33, 0xf5, 0x52, 0x04, 0xca, 0x8d, 0xca, 0x35, 0xae, 0xee, 0xb5, 0x3a, 0xf5, 0x42, 0xe6, 0x82,
+	0xc6, 0x68, 0xec, 0xad, 0x5f, 0x03, 0xb9, 0xf8, 0xeb, 0x47, 0x6e, 0x43, 0xb2, 0xd9, 0x6a, 0xd6,
+	0x0b, 0x31, 0xa1, 0xff, 0x45, 0x44, 0x93, 0x39, 0x94, 0x94, 0x21, 0xb1, 0xf7, 0xd5, 0x17, 0x05,
+	0xa9, 0x78, 0x6d, 0x3a, 0x53, 0x3f, 0xba, 0x08, 0xda, 0xfb, 0xea, 0x8b, 0x2d, 0x06, 0xb9, 0xa8,
+	0xe0, 0x32, 0xc8, 0xcf, 0xea, 0xdd, 0x4a, 0xad, 0xd2, 0xad, 0x14, 0x62, 0xe2, 0x49, 0x21, 0xfb,
+	0x19, 0xf5, 0x0d, 0x4c, 0xc2, 0xeb, 0x90, 0x6a, 0xd6, 0x9f, 0xd7, 0xb5, 0x82, 0x54, 0x5c, 0x9b,
+	0xce, 0xd4, 0x95, 0x10, 0xd0, 0xa4, 0x47, 0xd4, 0x25, 0x25, 0x48, 0x57, 0xf6, 0x5e, 0x54, 0x5e,
+	0x76, 0x0a, 0xf1, 0x22, 0x99, 0xce, 0xd4, 0xd5, 0x90, 0x5d, 0xb1, 0x8f, 0x8d, 0x89, 0xb7, 0xf5,
+	0x5f, 0x09, 0xf2, 0xd1, 0x69, 0x84, 0x94, 0x20, 0xb9, 0xd3, 0xd8, 0xab, 0x87, 0xd7, 0x45, 0x79,
+	0x7c, 0x4d, 0x36, 0x21, 0x5b, 0x6b, 0x68, 0xf5, 0x6a, 0xb7, 0xa5, 0xbd, 0x0c, 0x75, 0x89, 0x82,
+	0x6a, 0x96, 0x8b, 0x01, 0x3e, 0x21, 0x3f, 0x85, 0x7c, 0xe7, 0xe5, 0xb3, 0xbd, 0x46, 0xf3, 0x97,
+	0x3a, 0x4a, 0x8c, 0x17, 0xef, 0x4d, 0x67, 0xea, 0xcd, 0x25, 0x30, 0x1d, 0xb9, 0xb4, 0x67, 0xf8,
+	0xd4, 0xec, 0x88, 0x89, 0x8d, 0x33, 0x65, 0x89, 0x54, 0x61, 0x2d, 0x3c, 0xba, 0xb8, 0x2c, 0x51,
+	0xfc, 0x64, 0x3a, 0x53, 0xef, 0x7e, 0xef, 0xf9, 0xf9, 0xed, 0xb2, 0x44, 0x6e, 0x43, 0x26, 0x10,
+	0x12, 0x46, 0x52, 0xf4, 0x68, 0x70, 0x60, 0xeb, 0x4f, 0x12, 0x64, 0xe7, 0xe5, 0x8a, 0x1b, 0xbc,
+	0xd9, 0xd2, 0xeb, 0x9a, 0xd6, 0xd2, 0x42, 0x0b, 0xcc, 0x99, 0x4d, 0x86, 0x4b, 0x72, 0x13, 0x32,
+	0xbb, 0xf5, 0x66, 0x5d, 0x6b, 0x54, 0xc3, 0xc4, 0x98, 0x43, 0x76, 0xa9, 0x43, 0x5d, 0xab, 0x47,
+	0xee, 0x43, 0xbe, 0xd9, 0xd2, 0x3b, 0xfb, 0xd5, 0xa7, 0xa1, 0xea, 0x78, 0x7f, 0x44, 0x54, 0x67,
+	0xdc, 0x3b, 0x44, 0x7b, 0x6e, 0xf1, 0x1c, 0x7a, 0x5e, 0xd9, 0x6b, 0xd4, 0x04, 0x34, 0x51, 0x54,
+	0xa6, 0x33, 0xf5, 0xca, 0x1c, 0x1a, 0x8c, 0x53, 0x1c, 0xbb, 0x65, 0x42, 0xe9, 0xfb, 0x0b, 0x13,
+	0x51, 0x21, 0x5d, 0x69, 0xb7, 0xeb, 0xcd, 0x5a, 0xf8, 0xfa, 0x05, 0xaf, 0x32, 0x1a, 0x51, 0x87,
+	0x8f, 0x18, 0xe9, 0x9d, 0x96, 0xb6, 0x5b, 0xef, 0x16, 0xa4, 0xf3, 0x88, 0x1d, 0xc6, 0xc7, 0xe5,
+	0xed, 0xcd, 0xd7, 0xdf, 0x96, 0x62, 0x6f, 0xbe, 0x2d, 0xc5, 0x5e, 0x9f, 0x95, 0xa4, 0x37, 0x67,
+	0x25, 0xe9, 0x9f, 0x67, 0xa5, 0xd8, 0x77, 0x67, 0x25, 0xe9, 0xf7, 0x6f, 0x4b, 0xb1, 0x6f, 0xde,
+	0x96, 0xa4, 0x37, 0x6f, 0x4b, 0xb1, 0xbf, 0xbf, 0x2d, 0xc5, 0x0e, 0xd2, 0x58, 0xd4, 0x3e, 0xff,
+	0xdf, 0x00, 0x3c, 0x15, 0x83, 0x5f, 0x42, 0x12, 0x00, 0x00,
 }
 
 func (m *Hello) Marshal() (dAtA []byte, err error) {
@@ -1058,6 +1290,11 @@ func (m *Hello) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.NumConnections != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.NumConnections))
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.ClientVersion) > 0 {
 		i -= len(m.ClientVersion)
 		copy(dAtA[i:], m.ClientVersion)
@@ -1188,6 +1425,16 @@ func (m *Folder) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x82
 		}
 	}
+	{
+		size, err := m.SharedIgnores.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintBep(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x42
 	if m.Paused {
 		i--
 		if m.Paused {
@@ -1255,6 +1502,48 @@ func (m *Folder) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *SharedIgnores) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SharedIgnores) MarshalTo(dAtA []byte) (int, error) {
+	size := m.ProtoSize()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SharedIgnores) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Version.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintBep(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	if len(m.Lines) > 0 {
+		for iNdEx := len(m.Lines) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Lines[iNdEx])
+			copy(dAtA[i:], m.Lines[iNdEx])
+			i = encodeVarintBep(dAtA, i, uint64(len(m.Lines[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Device) Marshal() (dAtA []byte, err error) {
 	size := m.ProtoSize()
 	dAtA = make([]byte, size)
@@ -1275,6 +1564,13 @@ func (m *Device) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.EncryptionPasswordToken) > 0 {
+		i -= len(m.EncryptionPasswordToken)
+		copy(dAtA[i:], m.EncryptionPasswordToken)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.EncryptionPasswordToken)))
+		i--
+		dAtA[i] = 0x52
+	}
 	if m.SkipIntroductionRemovals {
 		i--
 		if m.SkipIntroductionRemovals {
@@ -1461,6 +1757,15 @@ func (m *FileInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xc0
 	}
+	if len(m.Encrypted) > 0 {
+		i -= len(m.Encrypted)
+		copy(dAtA[i:], m.Encrypted)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.Encrypted)))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x9a
+	}
 	if len(m.BlocksHash) > 0 {
 		i -= len(m.BlocksHash)
 		copy(dAtA[i:], m.BlocksHash)
@@ -1495,6 +1800,16 @@ func (m *FileInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x82
 		}
 	}
+	{
+		size, err := m.Platform.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintBep(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x72
 	if m.RawBlockSize != 0 {
 		i = encodeVarintBep(dAtA, i, uint64(m.RawBlockSize))
 		i--
@@ -1585,7 +1900,7 @@ func (m *FileInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *BlockInfo) Marshal() (dAtA []byte, err error) {
+func (m *PlatformData) Marshal() (dAtA []byte, err error) {
 	size := m.ProtoSize()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1595,42 +1910,103 @@ func (m *BlockInfo) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *BlockInfo) MarshalTo(dAtA []byte) (int, error) {
+func (m *PlatformData) MarshalTo(dAtA []byte) (int, error) {
 	size := m.ProtoSize()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *BlockInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *PlatformData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.WeakHash != 0 {
-		i = encodeVarintBep(dAtA, i, uint64(m.WeakHash))
+	if m.Darwin != nil {
+		{
+			size, err := m.Darwin.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintBep(dAtA, i, uint64(size))
+		}
 		i--
-		dAtA[i] = 0x20
+		dAtA[i] = 0x22
 	}
-	if len(m.Hash) > 0 {
-		i -= len(m.Hash)
-		copy(dAtA[i:], m.Hash)
-		i = encodeVarintBep(dAtA, i, uint64(len(m.Hash)))
+	if m.Linux != nil {
+		{
+			size, err := m.Linux.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintBep(dAtA, i, uint64(size))
+		}
 		i--
 		dAtA[i] = 0x1a
 	}
-	if m.Size != 0 {
-		i = encodeVarintBep(dAtA, i, uint64(m.Size))
+	if m.Unix != nil {
+		{
+			size, err := m.Unix.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintBep(dAtA, i, uint64(size))
+		}
 		i--
-		dAtA[i] = 0x10
+		dAtA[i] = 0xa
 	}
-	if m.Offset != 0 {
-		i = encodeVarintBep(dAtA, i, uint64(m.Offset))
+	return len(dAtA) - i, nil
+}
+
+func (m *UnixData) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UnixData) MarshalTo(dAtA []byte) (int, error) {
+	size := m.ProtoSize()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UnixData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.GID != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.GID))
 		i--
-		dAtA[i] = 0x8
+		dAtA[i] = 0x20
+	}
+	if m.UID != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.UID))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.GroupName) > 0 {
+		i -= len(m.GroupName)
+		copy(dAtA[i:], m.GroupName)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.GroupName)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.OwnerName) > 0 {
+		i -= len(m.OwnerName)
+		copy(dAtA[i:], m.OwnerName)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.OwnerName)))
+		i--
+		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *Vector) Marshal() (dAtA []byte, err error) {
+func (m *XattrData) Marshal() (dAtA []byte, err error) {
 	size := m.ProtoSize()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1640,20 +2016,20 @@ func (m *Vector) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *Vector) MarshalTo(dAtA []byte) (int, error) {
+func (m *XattrData) MarshalTo(dAtA []byte) (int, error) {
 	size := m.ProtoSize()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *Vector) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *XattrData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Counters) > 0 {
-		for iNdEx := len(m.Counters) - 1; iNdEx >= 0; iNdEx-- {
+	if len(m.Xattrs) > 0 {
+		for iNdEx := len(m.Xattrs) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size, err := m.Counters[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				size, err := m.Xattrs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -1667,7 +2043,7 @@ func (m *Vector) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *Counter) Marshal() (dAtA []byte, err error) {
+func (m *Xattr) Marshal() (dAtA []byte, err error) {
 	size := m.ProtoSize()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1677,18 +2053,137 @@ func (m *Counter) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *Counter) MarshalTo(dAtA []byte) (int, error) {
+func (m *Xattr) MarshalTo(dAtA []byte) (int, error) {
 	size := m.ProtoSize()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *Counter) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *Xattr) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Value != 0 {
-		i = encodeVarintBep(dAtA, i, uint64(m.Value))
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BlockInfo) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlockInfo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.ProtoSize()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BlockInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.WeakHash != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.WeakHash))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Hash) > 0 {
+		i -= len(m.Hash)
+		copy(dAtA[i:], m.Hash)
+		i = encodeVarintBep(dAtA, i, uint64(len(m.Hash)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Size != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.Size))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Offset != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Vector) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Vector) MarshalTo(dAtA []byte) (int, error) {
+	size := m.ProtoSize()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Vector) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Counters) > 0 {
+		for iNdEx := len(m.Counters) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Counters[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintBep(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Counter) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Counter) MarshalTo(dAtA []byte) (int, error) {
+	size := m.ProtoSize()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Counter) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.Value))
 		i--
 		dAtA[i] = 0x10
 	}
@@ -1720,6 +2215,11 @@ func (m *Request) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.BlockNo != 0 {
+		i = encodeVarintBep(dAtA, i, uint64(m.BlockNo))
+		i--
+		dAtA[i] = 0x48
+	}
 	if m.WeakHash != 0 {
 		i = encodeVarintBep(dAtA, i, uint64(m.WeakHash))
 		i--
@@ -1992,6 +2492,9 @@ func (m *Hello) ProtoSize() (n int) {
 	if l > 0 {
 		n += 1 + l + sovBep(uint64(l))
 	}
+	if m.NumConnections != 0 {
+		n += 1 + sovBep(uint64(m.NumConnections))
+	}
 	return n
 }
 
@@ -2054,6 +2557,8 @@ func (m *Folder) ProtoSize() (n int) {
 	if m.Paused {
 		n += 2
 	}
+	l = m.SharedIgnores.ProtoSize()
+	n += 1 + l + sovBep(uint64(l))
 	if len(m.Devices) > 0 {
 		for _, e := range m.Devices {
 			l = e.ProtoSize()
@@ -2063,6 +2568,23 @@ func (m *Folder) ProtoSize() (n int) {
 	return n
 }
 
+func (m *SharedIgnores) ProtoSize() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Lines) > 0 {
+		for _, s := range m.Lines {
+			l = len(s)
+			n += 1 + l + sovBep(uint64(l))
+		}
+	}
+	l = m.Version.ProtoSize()
+	n += 1 + l + sovBep(uint64(l))
+	return n
+}
+
 func (m *Device) ProtoSize() (n int) {
 	if m == nil {
 		return 0
@@ -2100,6 +2622,10 @@ func (m *Device) ProtoSize() (n int) {
 	if m.SkipIntroductionRemovals {
 		n += 2
 	}
+	l = len(m.EncryptionPasswordToken)
+	if l > 0 {
+		n += 1 + l + sovBep(uint64(l))
+	}
 	return n
 }
 
@@ -2186,6 +2712,8 @@ func (m *FileInfo) ProtoSize() (n int) {
 	if m.RawBlockSize != 0 {
 		n += 1 + sovBep(uint64(m.RawBlockSize))
 	}
+	l = m.Platform.ProtoSize()
+	n += 1 + l + sovBep(uint64(l))
 	if len(m.Blocks) > 0 {
 		for _, e := range m.Blocks {
 			l = e.ProtoSize()
@@ -2200,12 +2728,92 @@ func (m *FileInfo) ProtoSize() (n int) {
 	if l > 0 {
 		n += 2 + l + sovBep(uint64(l))
 	}
+	l = len(m.Encrypted)
+	if l > 0 {
+		n += 2 + l + sovBep(uint64(l))
+	}
 	if m.LocalFlags != 0 {
 		n += 2 + sovBep(uint64(m.LocalFlags))
 	}
 	return n
 }
 
+func (m *PlatformData) ProtoSize() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Unix != nil {
+		l = m.Unix.ProtoSize()
+		n += 1 + l + sovBep(uint64(l))
+	}
+	if m.Linux != nil {
+		l = m.Linux.ProtoSize()
+		n += 1 + l + sovBep(uint64(l))
+	}
+	if m.Darwin != nil {
+		l = m.Darwin.ProtoSize()
+		n += 1 + l + sovBep(uint64(l))
+	}
+	return n
+}
+
+func (m *UnixData) ProtoSize() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.OwnerName)
+	if l > 0 {
+		n += 1 + l + sovBep(uint64(l))
+	}
+	l = len(m.GroupName)
+	if l > 0 {
+		n += 1 + l + sovBep(uint64(l))
+	}
+	if m.UID != 0 {
+		n += 1 + sovBep(uint64(m.UID))
+	}
+	if m.GID != 0 {
+		n += 1 + sovBep(uint64(m.GID))
+	}
+	return n
+}
+
+func (m *XattrData) ProtoSize() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Xattrs) > 0 {
+		for _, e := range m.Xattrs {
+			l = e.ProtoSize()
+			n += 1 + l + sovBep(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Xattr) ProtoSize() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovBep(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovBep(uint64(l))
+	}
+	return n
+}
+
 func (m *BlockInfo) ProtoSize() (n int) {
 	if m == nil {
 		return 0
@@ -2291,6 +2899,9 @@ func (m *Request) ProtoSize() (n int) {
 	if m.WeakHash != 0 {
 		n += 1 + sovBep(uint64(m.WeakHash))
 	}
+	if m.BlockNo != 0 {
+		n += 1 + sovBep(uint64(m.BlockNo))
+	}
 	return n
 }
 
@@ -2508,6 +3119,25 @@ func (m *Hello) Unmarshal(dAtA []byte) error {
 			}
 			m.ClientVersion = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumConnections", wireType)
+			}
+			m.NumConnections = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumConnections |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBep(dAtA[iNdEx:])
@@ -2903,6 +3533,39 @@ func (m *Folder) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Paused = bool(v != 0)
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SharedIgnores", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.SharedIgnores.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 16:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Devices", wireType)
@@ -2961,7 +3624,7 @@ func (m *Folder) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Device) Unmarshal(dAtA []byte) error {
+func (m *SharedIgnores) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2984,17 +3647,17 @@ func (m *Device) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Device: wiretype end group for non-group")
+			return fmt.Errorf("proto: SharedIgnores: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Device: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SharedIgnores: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Lines", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowBep
@@ -3004,7 +3667,125 @@ func (m *Device) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Lines = append(m.Lines, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Version.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBep(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Device) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBep
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Device: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Device: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -3216,6 +3997,40 @@ func (m *Device) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.SkipIntroductionRemovals = bool(v != 0)
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EncryptionPasswordToken", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EncryptionPasswordToken = append(m.EncryptionPasswordToken[:0], dAtA[iNdEx:postIndex]...)
+			if m.EncryptionPasswordToken == nil {
+				m.EncryptionPasswordToken = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBep(dAtA[iNdEx:])
@@ -3784,6 +4599,39 @@ func (m *FileInfo) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Platform", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Platform.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 16:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Blocks", wireType)
@@ -3884,6 +4732,40 @@ func (m *FileInfo) Unmarshal(dAtA []byte) error {
 				m.BlocksHash = []byte{}
 			}
 			iNdEx = postIndex
+		case 19:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encrypted", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Encrypted = append(m.Encrypted[:0], dAtA[iNdEx:postIndex]...)
+			if m.Encrypted == nil {
+				m.Encrypted = []byte{}
+			}
+			iNdEx = postIndex
 		case 1000:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field LocalFlags", wireType)
@@ -3927,7 +4809,7 @@ func (m *FileInfo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *BlockInfo) Unmarshal(dAtA []byte) error {
+func (m *PlatformData) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3950,17 +4832,17 @@ func (m *BlockInfo) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: BlockInfo: wiretype end group for non-group")
+			return fmt.Errorf("proto: PlatformData: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: BlockInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PlatformData: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unix", wireType)
 			}
-			m.Offset = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowBep
@@ -3970,35 +4852,33 @@ func (m *BlockInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Offset |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Size", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthBep
 			}
-			m.Size = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowBep
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Size |= int32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Unix == nil {
+				m.Unix = &UnixData{}
+			}
+			if err := m.Unix.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
+			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Linux", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowBep
@@ -4008,23 +4888,547 @@ func (m *BlockInfo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthBep
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthBep
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Hash = append(m.Hash[:0], dAtA[iNdEx:postIndex]...)
-			if m.Hash == nil {
+			if m.Linux == nil {
+				m.Linux = &XattrData{}
+			}
+			if err := m.Linux.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Darwin", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Darwin == nil {
+				m.Darwin = &XattrData{}
+			}
+			if err := m.Darwin.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBep(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UnixData) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBep
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UnixData: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UnixData: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OwnerName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OwnerName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GroupName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GroupName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UID", wireType)
+			}
+			m.UID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UID |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GID", wireType)
+			}
+			m.GID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.GID |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBep(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *XattrData) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBep
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: XattrData: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: XattrData: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Xattrs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Xattrs = append(m.Xattrs, Xattr{})
+			if err := m.Xattrs[len(m.Xattrs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBep(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Xattr) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBep
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Xattr: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Xattr: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = append(m.Value[:0], dAtA[iNdEx:postIndex]...)
+			if m.Value == nil {
+				m.Value = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBep(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBep
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlockInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBep
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlockInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlockInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Size", wireType)
+			}
+			m.Size = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Size |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthBep
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBep
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Hash = append(m.Hash[:0], dAtA[iNdEx:postIndex]...)
+			if m.Hash == nil {
 				m.Hash = []byte{}
 			}
 			iNdEx = postIndex
@@ -4472,6 +5876,25 @@ func (m *Request) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockNo", wireType)
+			}
+			m.BlockNo = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBep
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlockNo |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBep(dAtA[iNdEx:])
@@ -67,6 +67,14 @@ func (f FileInfo) IsReceiveOnlyChanged() bool {
 	return f.LocalFlags&FlagLocalReceiveOnly != 0
 }
 
+func (f FileInfo) IsDeselected() bool {
+	return f.LocalFlags&FlagLocalDeselected != 0
+}
+
+func (f FileInfo) IsPlaceholder() bool {
+	return f.LocalFlags&FlagLocalPlaceholder != 0
+}
+
 func (f FileInfo) IsDirectory() bool {
 	return f.Type == FileInfoTypeDirectory
 }
@@ -171,22 +179,35 @@ func (f FileInfo) IsEmpty() bool {
 	return f.Version.Counters == nil
 }
 
+// FileInfoComparison selects what FileInfo.IsEquivalentOptional considers.
+type FileInfoComparison struct {
+	ModTimeWindow   time.Duration
+	IgnorePerms     bool
+	IgnoreBlocks    bool
+	IgnoreFlags     uint32
+	IgnoreXattrs    bool
+	IgnoreOwnership bool
+}
+
 func (f FileInfo) IsEquivalent(other FileInfo, modTimeWindow time.Duration) bool {
-	return f.isEquivalent(other, modTimeWindow, false, false, 0)
+	return f.isEquivalent(other, FileInfoComparison{ModTimeWindow: modTimeWindow})
 }
 
-func (f FileInfo) IsEquivalentOptional(other FileInfo, modTimeWindow time.Duration, ignorePerms bool, ignoreBlocks bool, ignoreFlags uint32) bool {
-	return f.isEquivalent(other, modTimeWindow, ignorePerms, ignoreBlocks, ignoreFlags)
+func (f FileInfo) IsEquivalentOptional(other FileInfo, comp FileInfoComparison) bool {
+	return f.isEquivalent(other, comp)
 }
 
 // isEquivalent checks that the two file infos represent the same actual file content,
 // i.e. it does purposely not check only selected (see below) struct members.
-// Permissions (config) and blocks (scanning) can be excluded from the comparison.
+// Permissions (config), blocks (scanning), extended attributes and
+// ownership can be excluded from the comparison.
 // Any file info is not "equivalent", if it has different
 //  - type
 //  - deleted flag
 //  - invalid flag
 //  - permissions, unless they are ignored
+//  - extended attributes, unless they are ignored
+//  - ownership, unless it is ignored
 // A file is not "equivalent", if it has different
 //  - modification time (difference bigger than modTimeWindow)
 //  - size
@@ -194,7 +215,7 @@ func (f FileInfo) IsEquivalentOptional(other FileInfo, modTimeWindow time.Durati
 // A symlink is not "equivalent", if it has different
 //  - target
 // A directory does not have anything specific to check.
-func (f FileInfo) isEquivalent(other FileInfo, modTimeWindow time.Duration, ignorePerms bool, ignoreBlocks bool, ignoreFlags uint32) bool {
+func (f FileInfo) isEquivalent(other FileInfo, comp FileInfoComparison) bool {
 	if f.MustRescan() || other.MustRescan() {
 		// These are per definition not equivalent because they don't
 		// represent a valid state, even if both happen to have the
@@ -203,20 +224,28 @@ func (f FileInfo) isEquivalent(other FileInfo, modTimeWindow time.Duration, igno
 	}
 
 	// Mask out the ignored local flags before checking IsInvalid() below
-	f.LocalFlags &^= ignoreFlags
-	other.LocalFlags &^= ignoreFlags
+	f.LocalFlags &^= comp.IgnoreFlags
+	other.LocalFlags &^= comp.IgnoreFlags
 
 	if f.Name != other.Name || f.Type != other.Type || f.Deleted != other.Deleted || f.IsInvalid() != other.IsInvalid() {
 		return false
 	}
 
-	if !ignorePerms && !f.NoPermissions && !other.NoPermissions && !PermsEqual(f.Permissions, other.Permissions) {
+	if !comp.IgnorePerms && !f.NoPermissions && !other.NoPermissions && !PermsEqual(f.Permissions, other.Permissions) {
+		return false
+	}
+
+	if !comp.IgnoreXattrs && !f.Platform.xattrsEqual(other.Platform) {
+		return false
+	}
+
+	if !comp.IgnoreOwnership && !unixOwnershipEqual(f.Platform.Unix, other.Platform.Unix) {
 		return false
 	}
 
 	switch f.Type {
 	case FileInfoTypeFile:
-		return f.Size == other.Size && ModTimeEqual(f.ModTime(), other.ModTime(), modTimeWindow) && (ignoreBlocks || f.BlocksEqual(other))
+		return f.Size == other.Size && ModTimeEqual(f.ModTime(), other.ModTime(), comp.ModTimeWindow) && (comp.IgnoreBlocks || f.BlocksEqual(other))
 	case FileInfoTypeSymlink:
 		return f.SymlinkTarget == other.SymlinkTarget
 	case FileInfoTypeDirectory:
@@ -249,6 +278,90 @@ func PermsEqual(a, b uint32) bool {
 	}
 }
 
+// XattrData returns the extended attributes for the platform we are
+// running on, or nil if there are none or they are not supported here.
+func (p *PlatformData) XattrData() *XattrData {
+	switch runtime.GOOS {
+	case "linux":
+		return p.Linux
+	case "darwin":
+		return p.Darwin
+	default:
+		return nil
+	}
+}
+
+// SetXattrData sets the extended attributes for the platform we are running
+// on. It does nothing on platforms where they are not supported.
+func (p *PlatformData) SetXattrData(xd *XattrData) {
+	switch runtime.GOOS {
+	case "linux":
+		p.Linux = xd
+	case "darwin":
+		p.Darwin = xd
+	}
+}
+
+// MergeWith copies the data for any platform that p doesn't have from
+// other. This retains what was received from other platforms when
+// rescanning a file locally.
+func (p *PlatformData) MergeWith(other *PlatformData) {
+	if p.Unix == nil {
+		p.Unix = other.Unix
+	}
+	if p.Linux == nil {
+		p.Linux = other.Linux
+	}
+	if p.Darwin == nil {
+		p.Darwin = other.Darwin
+	}
+}
+
+// unixOwnershipEqual compares ownership. Owners and groups are compared by
+// name when both sides have one, as they are mapped by name to the local
+// IDs, which may differ between devices. Otherwise the IDs are compared.
+func unixOwnershipEqual(a, b *UnixData) bool {
+	if a == nil && b == nil {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	return idOrNameEqual(a.UID, b.UID, a.OwnerName, b.OwnerName) && idOrNameEqual(a.GID, b.GID, a.GroupName, b.GroupName)
+}
+
+func idOrNameEqual(aID, bID int, aName, bName string) bool {
+	if aName != "" && bName != "" {
+		return aName == bName
+	}
+	return aID == bID
+}
+
+func (p PlatformData) xattrsEqual(other PlatformData) bool {
+	return xattrDataEqual(p.Linux, other.Linux) && xattrDataEqual(p.Darwin, other.Darwin)
+}
+
+// xattrDataEqual compares two sets of extended attributes, which are
+// expected to be sorted by name.
+func xattrDataEqual(a, b *XattrData) bool {
+	var aa, bb []Xattr
+	if a != nil {
+		aa = a.Xattrs
+	}
+	if b != nil {
+		bb = b.Xattrs
+	}
+	if len(aa) != len(bb) {
+		return false
+	}
+	for i := range aa {
+		if aa[i].Name != bb[i].Name || !bytes.Equal(aa[i].Value, bb[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
 // BlocksEqual returns true when the two files have identical block lists.
 func (f FileInfo) BlocksEqual(other FileInfo) bool {
 	// If both sides have blocks hashes then we can just compare those.
@@ -288,6 +401,18 @@ func (f *FileInfo) SetUnsupported(by ShortID) {
 	f.setLocalFlags(by, FlagLocalUnsupported)
 }
 
+func (f *FileInfo) SetDeselected(by ShortID) {
+	f.setLocalFlags(by, FlagLocalDeselected)
+}
+
+// SetPlaceholder marks the file as present on disk without its contents.
+// Unlike the other local flags it retains size and blocks, as they describe
+// the contents to fetch.
+func (f *FileInfo) SetPlaceholder() {
+	f.RawInvalid = false
+	f.LocalFlags |= FlagLocalPlaceholder
+}
+
 func (f *FileInfo) SetDeleted(by ShortID) {
 	f.ModifiedBy = by
 	f.Deleted = true
@@ -0,0 +1,572 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package protocol
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	nonceSize             = chacha20poly1305.NonceSizeX // 24
+	tagSize               = 16                          // poly1305 tag
+	keySize               = chacha20poly1305.KeySize    // 32
+	blockOverhead         = tagSize + nonceSize
+	maxPathComponent      = 200
+	encryptedDirExtension = ".syncthing-enc"
+)
+
+// The encrypted modification time given to all files on the untrusted
+// side. The real one is contained in the encrypted FileInfo.
+const encryptedModifiedS = 1234567890 // Sat Feb 14 00:31:30 CET 2009
+
+var (
+	errNoEncryptedFileInfo = errors.New("file info is missing the encrypted payload")
+	errShortEncrypted      = errors.New("encrypted data is too short")
+	errNonceMismatch       = errors.New("deterministic nonce mismatch")
+)
+
+// The encryptedModel sits between the encrypted device and the model. It
+// receives encrypted metadata and requests from the untrusted device, so it
+// must decrypt those and answer requests by encrypting the data.
+type encryptedModel struct {
+	Model
+	folderKeys *folderKeyRegistry
+}
+
+func (e encryptedModel) Index(deviceID DeviceID, folder string, files []FileInfo) error {
+	if folderKey, ok := e.folderKeys.get(folder); ok {
+		var err error
+		if files, err = decryptFileInfos(files, folderKey); err != nil {
+			return err
+		}
+	}
+	return e.Model.Index(deviceID, folder, files)
+}
+
+func (e encryptedModel) IndexUpdate(deviceID DeviceID, folder string, files []FileInfo) error {
+	if folderKey, ok := e.folderKeys.get(folder); ok {
+		var err error
+		if files, err = decryptFileInfos(files, folderKey); err != nil {
+			return err
+		}
+	}
+	return e.Model.IndexUpdate(deviceID, folder, files)
+}
+
+func (e encryptedModel) Request(deviceID DeviceID, folder, name string, blockNo, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (RequestResponse, error) {
+	folderKey, ok := e.folderKeys.get(folder)
+	if !ok {
+		return e.Model.Request(deviceID, folder, name, blockNo, size, offset, hash, weakHash, fromTemporary)
+	}
+
+	// Figure out the real file name, offset and size from the encrypted /
+	// tweaked values.
+
+	realName, err := decryptName(name, folderKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting name: %w", err)
+	}
+	realSize := size - blockOverhead
+	realOffset := offset - int64(blockNo)*blockOverhead
+	if realSize < 0 || realOffset < 0 {
+		return nil, ErrInvalid
+	}
+
+	// Decrypt the block hash.
+
+	fileKey := FileKey(realName, folderKey)
+	var additional [8]byte
+	binary.BigEndian.PutUint64(additional[:], uint64(realOffset))
+	realHash, err := decryptDeterministic(hash, fileKey, additional[:])
+	if err != nil {
+		return nil, fmt.Errorf("decrypting block hash: %w", err)
+	}
+
+	// Perform that request and grab the data. Explicitly zero out the
+	// weak hash, because it's not correct and we don't want to trigger a
+	// rescan on mismatch.
+
+	resp, err := e.Model.Request(deviceID, folder, realName, blockNo, realSize, realOffset, realHash, 0, fromTemporary)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt the response. Blocks smaller than the requested size are
+	// returned as is; the other side will fail to decrypt them and try
+	// elsewhere.
+
+	enc := encryptBytes(resp.Data(), fileKey)
+	resp.Close()
+	return rawResponse{enc}, nil
+}
+
+func (e encryptedModel) DownloadProgress(deviceID DeviceID, folder string, updates []FileDownloadProgressUpdate) error {
+	if _, ok := e.folderKeys.get(folder); !ok {
+		return e.Model.DownloadProgress(deviceID, folder, updates)
+	}
+
+	// Encrypted devices shouldn't send these - ignore them.
+	return nil
+}
+
+// The encryptedConnection sits between the model and the encrypted device.
+// It encrypts outgoing metadata and decrypts incoming responses.
+type encryptedConnection struct {
+	*rawConnection
+	folderKeys *folderKeyRegistry
+}
+
+func (e encryptedConnection) Index(ctx context.Context, folder string, files []FileInfo) error {
+	if folderKey, ok := e.folderKeys.get(folder); ok {
+		files = encryptFileInfos(files, folderKey)
+	}
+	return e.rawConnection.Index(ctx, folder, files)
+}
+
+func (e encryptedConnection) IndexUpdate(ctx context.Context, folder string, files []FileInfo) error {
+	if folderKey, ok := e.folderKeys.get(folder); ok {
+		files = encryptFileInfos(files, folderKey)
+	}
+	return e.rawConnection.IndexUpdate(ctx, folder, files)
+}
+
+func (e encryptedConnection) Request(ctx context.Context, folder string, name string, blockNo int, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
+	folderKey, ok := e.folderKeys.get(folder)
+	if !ok {
+		return e.rawConnection.Request(ctx, folder, name, blockNo, offset, size, hash, weakHash, fromTemporary)
+	}
+
+	// Encrypt / adjust the request parameters.
+
+	origSize := size
+	encSize := size + blockOverhead
+	encOffset := offset + int64(blockNo)*blockOverhead
+	encName := encryptName(name, folderKey)
+	fileKey := FileKey(name, folderKey)
+	var additional [8]byte
+	binary.BigEndian.PutUint64(additional[:], uint64(offset))
+	encHash := encryptDeterministic(hash, fileKey, additional[:])
+
+	// Perform that request, getting back an encrypted block.
+
+	bs, err := e.rawConnection.Request(ctx, folder, encName, blockNo, encOffset, encSize, encHash, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the decrypted block (or an error if it fails decryption)
+
+	bs, err = DecryptBytes(bs, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) != origSize {
+		return nil, fmt.Errorf("decrypted block has unexpected size %d != %d", len(bs), origSize)
+	}
+	return bs, nil
+}
+
+func (e encryptedConnection) DownloadProgress(ctx context.Context, folder string, updates []FileDownloadProgressUpdate) {
+	if _, ok := e.folderKeys.get(folder); !ok {
+		e.rawConnection.DownloadProgress(ctx, folder, updates)
+	}
+
+	// No need to send these
+}
+
+func encryptFileInfos(files []FileInfo, folderKey *[keySize]byte) []FileInfo {
+	newFiles := make([]FileInfo, len(files))
+	for i, fi := range files {
+		newFiles[i] = encryptFileInfo(fi, folderKey)
+	}
+	return newFiles
+}
+
+// encryptFileInfo encrypts a FileInfo and wraps it into a new fake FileInfo
+// with an encrypted name.
+func encryptFileInfo(fi FileInfo, folderKey *[keySize]byte) FileInfo {
+	fileKey := FileKey(fi.Name, folderKey)
+
+	// The entire FileInfo is encrypted with a random nonce, and concatenated
+	// with that nonce.
+
+	bs, err := fi.Marshal()
+	if err != nil {
+		panic("impossible serialization mishap: " + err.Error())
+	}
+	encryptedFI := encryptBytes(bs, fileKey)
+
+	// The vector is kept as is, so that the untrusted device can order
+	// versions and detect conflicts without knowing the contents.
+
+	// The block list is encrypted, with the hashes deterministically
+	// encrypted using the block offset as additional data, and offsets and
+	// sizes adjusted for the encryption overhead.
+
+	var blocks []BlockInfo
+	if len(fi.Blocks) > 0 {
+		blocks = make([]BlockInfo, len(fi.Blocks))
+		for i, b := range fi.Blocks {
+			var additional [8]byte
+			binary.BigEndian.PutUint64(additional[:], uint64(b.Offset))
+			blocks[i] = BlockInfo{
+				Offset: b.Offset + int64(i)*blockOverhead,
+				Size:   b.Size + blockOverhead,
+				Hash:   encryptDeterministic(b.Hash, fileKey, additional[:]),
+			}
+		}
+	}
+
+	// Construct the fake block list. Directories remain directories,
+	// everything else (including symlinks) becomes a regular file.
+
+	typ := FileInfoTypeFile
+	if fi.Type == FileInfoTypeDirectory {
+		typ = FileInfoTypeDirectory
+	}
+	var size int64
+	var blockSize int32
+	if len(blocks) > 0 {
+		last := blocks[len(blocks)-1]
+		size = last.Offset + int64(last.Size)
+		blockSize = int32(fi.BlockSize() + blockOverhead)
+	}
+	enc := FileInfo{
+		Name:         encryptName(fi.Name, folderKey),
+		Type:         typ,
+		Size:         size,
+		Permissions:  0644,
+		ModifiedS:    encryptedModifiedS,
+		Deleted:      fi.Deleted,
+		RawInvalid:   fi.IsInvalid(),
+		Version:      fi.Version,
+		Sequence:     fi.Sequence,
+		RawBlockSize: blockSize,
+		Blocks:       blocks,
+		Encrypted:    encryptedFI,
+	}
+
+	return enc
+}
+
+func decryptFileInfos(files []FileInfo, folderKey *[keySize]byte) ([]FileInfo, error) {
+	newFiles := make([]FileInfo, 0, len(files))
+	for _, fi := range files {
+		if len(fi.Encrypted) == 0 {
+			// Locally changed item on the untrusted side, which is never
+			// going to be useful to us.
+			l.Debugf("skipping file without encrypted payload: %v", fi.Name)
+			continue
+		}
+		decFI, err := DecryptFileInfo(fi, folderKey)
+		if err != nil {
+			return nil, err
+		}
+		newFiles = append(newFiles, decFI)
+	}
+	return newFiles, nil
+}
+
+// DecryptFileInfo extracts the encrypted portion of a FileInfo, decrypts it
+// and returns that.
+func DecryptFileInfo(fi FileInfo, folderKey *[keySize]byte) (FileInfo, error) {
+	realName, err := decryptName(fi.Name, folderKey)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	fileKey := FileKey(realName, folderKey)
+
+	if len(fi.Encrypted) == 0 {
+		return FileInfo{}, errNoEncryptedFileInfo
+	}
+	dec, err := DecryptBytes(fi.Encrypted, fileKey)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	var decFI FileInfo
+	if err := decFI.Unmarshal(dec); err != nil {
+		return FileInfo{}, err
+	}
+	if decFI.Name != realName {
+		return FileInfo{}, fmt.Errorf("encrypted name %q does not match file info", realName)
+	}
+
+	// The version and sequence are those of the untrusted device; the
+	// invalid bit is set if the untrusted device has the file flagged.
+	decFI.Version = fi.Version
+	decFI.Sequence = fi.Sequence
+	decFI.RawInvalid = decFI.RawInvalid || fi.RawInvalid
+	decFI.LocalFlags = 0
+	return decFI, nil
+}
+
+var base32Hex = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// encryptName encrypts the given string in a deterministic manner (the
+// result is always the same for any given string) and encodes it in a
+// filesystem-friendly manner.
+func encryptName(name string, key *[keySize]byte) string {
+	enc := encryptDeterministic([]byte(name), key, nil)
+	return slashify(base32Hex.EncodeToString(enc))
+}
+
+// decryptName decrypts a string from encryptName
+func decryptName(name string, key *[keySize]byte) (string, error) {
+	name, err := deslashify(name)
+	if err != nil {
+		return "", err
+	}
+	bs, err := base32Hex.DecodeString(name)
+	if err != nil {
+		return "", err
+	}
+	dec, err := decryptDeterministic(bs, key, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(dec), nil
+}
+
+// encryptBytes encrypts bytes with a random nonce
+func encryptBytes(data []byte, key *[keySize]byte) []byte {
+	nonce := randomNonce()
+	return encrypt(data, nonce, key, nil)
+}
+
+// encryptDeterministic encrypts bytes using a nonce derived from the
+// plaintext and the additional data, so that the same input always results
+// in the same output.
+func encryptDeterministic(data []byte, key *[keySize]byte, additionalData []byte) []byte {
+	nonce := syntheticNonce(data, key, additionalData)
+	return encrypt(data, nonce, key, additionalData)
+}
+
+func encrypt(data []byte, nonce *[nonceSize]byte, key *[keySize]byte, additionalData []byte) []byte {
+	aead, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		// Can only fail if the key is the wrong length
+		panic("cipher failure: " + err.Error())
+	}
+
+	// Output is nonce + ciphertext + tag
+	out := make([]byte, nonceSize, nonceSize+len(data)+tagSize)
+	copy(out, nonce[:])
+	return aead.Seal(out, nonce[:], data, additionalData)
+}
+
+// DecryptBytes returns the decrypted bytes, or an error if decryption
+// failed.
+func DecryptBytes(data []byte, key *[keySize]byte) ([]byte, error) {
+	return decrypt(data, key, nil)
+}
+
+// decryptDeterministic decrypts bytes from encryptDeterministic, verifying
+// that the nonce is the one that would have been derived from the
+// plaintext.
+func decryptDeterministic(data []byte, key *[keySize]byte, additionalData []byte) ([]byte, error) {
+	dec, err := decrypt(data, key, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	nonce := syntheticNonce(dec, key, additionalData)
+	if !hmac.Equal(nonce[:], data[:nonceSize]) {
+		return nil, errNonceMismatch
+	}
+	return dec, nil
+}
+
+func decrypt(data []byte, key *[keySize]byte, additionalData []byte) ([]byte, error) {
+	if len(data) < blockOverhead {
+		return nil, errShortEncrypted
+	}
+	aead, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, data[:nonceSize], data[nonceSize:], additionalData)
+}
+
+func randomNonce() *[nonceSize]byte {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		panic("catastrophic randomness failure: " + err.Error())
+	}
+	return &nonce
+}
+
+func syntheticNonce(data []byte, key *[keySize]byte, additionalData []byte) *[nonceSize]byte {
+	h := hmac.New(sha256.New, key[:])
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(additionalData)))
+	h.Write(length[:])
+	h.Write(additionalData)
+	h.Write(data)
+	var nonce [nonceSize]byte
+	copy(nonce[:], h.Sum(nil))
+	return &nonce
+}
+
+var (
+	keysMut sync.Mutex
+	keys    = make(map[string]*[keySize]byte)
+)
+
+// KeyFromPassword uses key derivation to generate a stronger key from a
+// probably weak password.
+func KeyFromPassword(folderID, password string) *[keySize]byte {
+	cacheKey := folderID + "\x00" + password
+	keysMut.Lock()
+	defer keysMut.Unlock()
+	if key, ok := keys[cacheKey]; ok {
+		return key
+	}
+
+	bs, err := scrypt.Key([]byte(password), knownBytes(folderID), 32768, 8, 1, keySize)
+	if err != nil {
+		panic("key derivation failure: " + err.Error())
+	}
+	if len(bs) != keySize {
+		panic("key derivation failure: wrong number of bytes")
+	}
+	var key [keySize]byte
+	copy(key[:], bs)
+	keys[cacheKey] = &key
+	return &key
+}
+
+func knownBytes(folderID string) []byte {
+	return []byte("syncthing" + folderID)
+}
+
+// FileKey is a per-file encryption key, derived from the folder key and
+// the (plaintext) file name.
+func FileKey(filename string, folderKey *[keySize]byte) *[keySize]byte {
+	kdf := hkdf.New(sha256.New, append(folderKey[:], filename...), []byte("syncthing"), nil)
+	var fileKey [keySize]byte
+	if _, err := io.ReadFull(kdf, fileKey[:]); err != nil {
+		panic("hkdf failure: " + err.Error())
+	}
+	return &fileKey
+}
+
+// PasswordToken returns a token that an untrusted device can use to verify
+// that all trusted devices share a folder using the same password, without
+// being able to derive the password or folder key from it.
+func PasswordToken(folderID, password string) []byte {
+	return encryptDeterministic(knownBytes(folderID), KeyFromPassword(folderID, password), nil)
+}
+
+// slashify inserts slashes (and file extension) in the string to create an
+// appropriate tree. ABCDEFGH... => A.syncthing-enc/BC/DEFGH... We can use
+// forward slashes here because we're on the wire format, so they get
+// converted to native separators as appropriate.
+func slashify(s string) string {
+	// We somewhat sloppily assume bytes == characters here, but the only
+	// file names we should deal with are those that come from our base32
+	// encoding.
+
+	comps := make([]string, 0, len(s)/maxPathComponent+3)
+	comps = append(comps, s[:1]+encryptedDirExtension)
+	s = s[1:]
+	comps = append(comps, s[:2])
+	s = s[2:]
+
+	for len(s) > maxPathComponent {
+		comps = append(comps, s[:maxPathComponent])
+		s = s[maxPathComponent:]
+	}
+	if len(s) > 0 {
+		comps = append(comps, s)
+	}
+	return strings.Join(comps, "/")
+}
+
+// deslashify removes slashes and encrypted file extensions from the string.
+// This is the inverse of slashify().
+func deslashify(s string) (string, error) {
+	if len(s) < 1+len(encryptedDirExtension) || !strings.HasPrefix(s[1:], encryptedDirExtension) {
+		return "", fmt.Errorf("invalid encrypted path: %q", s)
+	}
+	s = s[:1] + s[1+len(encryptedDirExtension):]
+	return strings.Replace(s, "/", "", -1), nil
+}
+
+// IsEncryptedParent returns true if the path points at a parent directory
+// of encrypted data, i.e. is not a "real" directory. This is determined by
+// checking for a sentinel string in the path.
+func IsEncryptedParent(path string) bool {
+	if l := len(path); l == 2 && path[1] == '.' || l > 2 && path[1] == '.' && path[2] == '/' {
+		// Avoid false positives on "a.", "a./..."
+		return false
+	}
+	comps := strings.Split(path, "/")
+	if len(comps) < 1 || len(comps) > 2 {
+		return false
+	}
+	if len(comps[0]) != 1+len(encryptedDirExtension) || !strings.HasSuffix(comps[0], encryptedDirExtension) {
+		return false
+	}
+	if len(comps) == 2 && len(comps[1]) != 2 {
+		return false
+	}
+	return true
+}
+
+type folderKeyRegistry struct {
+	keys map[string]*[keySize]byte // folder ID -> key
+	mut  sync.RWMutex
+}
+
+func newFolderKeyRegistry(passwords map[string]string) *folderKeyRegistry {
+	r := &folderKeyRegistry{}
+	r.setPasswords(passwords)
+	return r
+}
+
+func (r *folderKeyRegistry) get(folder string) (*[keySize]byte, bool) {
+	r.mut.RLock()
+	key, ok := r.keys[folder]
+	r.mut.RUnlock()
+	return key, ok
+}
+
+func (r *folderKeyRegistry) setPasswords(passwords map[string]string) {
+	keys := make(map[string]*[keySize]byte, len(passwords))
+	for folder, password := range passwords {
+		keys[folder] = KeyFromPassword(folder, password)
+	}
+	r.mut.Lock()
+	r.keys = keys
+	r.mut.Unlock()
+}
+
+type rawResponse struct {
+	data []byte
+}
+
+func (r rawResponse) Data() []byte {
+	return r.data
+}
+
+func (r rawResponse) Close() {}
+func (r rawResponse) Wait()  {}
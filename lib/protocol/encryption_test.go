@@ -0,0 +1,285 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+func TestEnDecryptName(t *testing.T) {
+	var key [32]byte
+	cases := []string{
+		"",
+		"foo",
+		"a longer name/with/slashes and spaces",
+		strings.Repeat("long name", 100),
+	}
+	for _, tc := range cases {
+		var prev string
+		for i := 0; i < 5; i++ {
+			enc := encryptName(tc, &key)
+			if prev != "" && prev != enc {
+				t.Error("name should always encrypt the same")
+			}
+			prev = enc
+			if tc != "" && strings.Contains(enc, tc) {
+				t.Error("shouldn't contain plaintext")
+			}
+			dec, err := decryptName(enc, &key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dec != tc {
+				t.Error("mismatch after decryption")
+			}
+		}
+	}
+}
+
+func TestDecryptNameInvalid(t *testing.T) {
+	key := new([32]byte)
+	for _, c := range []string{
+		"T.syncthing-enc/OD",
+		"T.syncthing-enc/OD/",
+		"T.wrong-extension/OD/PHVDC6V1AN9GSPMTFC4C1FNO6LV4HVFRHC5LHJM3VF6VJAOAVP5I",
+		"/T.syncthing-enc/OD/PHVDC6V1AN9GSPMTFC4C1FNO6LV4HVFRHC5LHJM3VF6VJAOAVP5I",
+		"T.syncthing-enc/OD/PHVDC6V1AN9GSPMTFC4C1FNO6LV4HVFRHC5LHJM3VF6VJAOAVP5I",
+	} {
+		if _, err := decryptName(c, key); err == nil {
+			t.Errorf("no error for %q", c)
+		}
+	}
+}
+
+func TestEnDecryptBytes(t *testing.T) {
+	var key [32]byte
+	cases := [][]byte{
+		{},
+		{1, 2, 3, 4, 5},
+	}
+	for _, tc := range cases {
+		var prev []byte
+		for i := 0; i < 5; i++ {
+			enc := encryptBytes(tc, &key)
+			if bytes.Equal(enc, prev) {
+				t.Error("encryption should not repeat")
+			}
+			prev = enc
+			if len(tc) > 0 && bytes.Contains(enc, tc) {
+				t.Error("shouldn't contain plaintext")
+			}
+			dec, err := DecryptBytes(enc, &key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(dec, tc) {
+				t.Error("mismatch after decryption")
+			}
+		}
+	}
+}
+
+func TestDecryptDeterministicTampered(t *testing.T) {
+	var key [32]byte
+	enc := encryptDeterministic([]byte("hello"), &key, []byte("additional"))
+	if _, err := decryptDeterministic(enc, &key, []byte("additional")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptDeterministic(enc, &key, []byte("other")); err == nil {
+		t.Error("expected error with wrong additional data")
+	}
+	enc[len(enc)-1] ^= 1
+	if _, err := decryptDeterministic(enc, &key, []byte("additional")); err == nil {
+		t.Error("expected error with tampered data")
+	}
+}
+
+func TestEnDecryptFileInfo(t *testing.T) {
+	var key [32]byte
+	fi := FileInfo{
+		Name:         "hello",
+		Size:         45,
+		ModifiedS:    8080,
+		RawBlockSize: 128 << KiB,
+		Version:      Vector{}.Update(1),
+		Sequence:     42,
+		Blocks: []BlockInfo{
+			{
+				Size: 45,
+				Hash: []byte{1, 2, 3},
+			},
+		},
+	}
+
+	enc := encryptFileInfo(fi, &key)
+	if bytes.Equal(enc.Blocks[0].Hash, fi.Blocks[0].Hash) {
+		t.Error("block hashes should be encrypted")
+	}
+	if enc.Name == fi.Name {
+		t.Error("name should be encrypted")
+	}
+	if enc.Size != fi.Size+blockOverhead || enc.Blocks[0].Size != fi.Blocks[0].Size+blockOverhead {
+		t.Error("sizes should include the encryption overhead")
+	}
+	if enc.ModifiedS == fi.ModifiedS {
+		t.Error("modification time should not be revealed")
+	}
+	if !enc.Version.Equal(fi.Version) {
+		t.Error("version should be retained")
+	}
+
+	dec, err := DecryptFileInfo(enc, &key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fi, dec) {
+		t.Error("mismatch after decryption")
+	}
+}
+
+func TestEncryptedBlockOffsets(t *testing.T) {
+	var key [32]byte
+	fi := FileInfo{
+		Name:         "three blocks",
+		Size:         2*128<<KiB + 10,
+		RawBlockSize: 128 << KiB,
+		Blocks: []BlockInfo{
+			{Offset: 0, Size: 128 << KiB, Hash: []byte{1}},
+			{Offset: 128 << KiB, Size: 128 << KiB, Hash: []byte{2}},
+			{Offset: 2 * 128 << KiB, Size: 10, Hash: []byte{3}},
+		},
+	}
+
+	enc := encryptFileInfo(fi, &key)
+	if enc.BlockSize() != 128<<KiB+blockOverhead {
+		t.Error("unexpected encrypted block size", enc.BlockSize())
+	}
+	for i, b := range enc.Blocks {
+		if b.Offset != int64(i)*int64(enc.BlockSize()) {
+			t.Errorf("block %d at unexpected offset %d", i, b.Offset)
+		}
+	}
+	if enc.Size != fi.Size+3*blockOverhead {
+		t.Error("unexpected encrypted size", enc.Size)
+	}
+}
+
+func TestDecryptFileInfosSkipsPlaintext(t *testing.T) {
+	key := KeyFromPassword("folder", "password")
+	files := []FileInfo{
+		encryptFileInfo(FileInfo{Name: "a", Type: FileInfoTypeDirectory}, key),
+		{Name: "local change", RawInvalid: true},
+	}
+	dec, err := decryptFileInfos(files, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec) != 1 || dec[0].Name != "a" || !dec[0].IsDirectory() {
+		t.Error("unexpected result", dec)
+	}
+}
+
+func TestKeyFromPassword(t *testing.T) {
+	a := KeyFromPassword("folder1", "password")
+	if b := KeyFromPassword("folder1", "password"); *a != *b {
+		t.Error("keys should be stable")
+	}
+	if b := KeyFromPassword("folder2", "password"); *a == *b {
+		t.Error("keys should differ between folders")
+	}
+	if b := KeyFromPassword("folder1", "other"); *a == *b {
+		t.Error("keys should differ between passwords")
+	}
+
+	if !bytes.Equal(PasswordToken("folder1", "password"), PasswordToken("folder1", "password")) {
+		t.Error("tokens should be stable")
+	}
+	if bytes.Equal(PasswordToken("folder1", "password"), PasswordToken("folder1", "other")) {
+		t.Error("tokens should differ between passwords")
+	}
+}
+
+func TestFileKey(t *testing.T) {
+	folderKey := new([32]byte)
+	a := FileKey("a", folderKey)
+	if b := FileKey("a", folderKey); *a != *b {
+		t.Error("file keys should be stable")
+	}
+	if b := FileKey("b", folderKey); *a == *b {
+		t.Error("file keys should differ between files")
+	}
+}
+
+func TestIsEncryptedParent(t *testing.T) {
+	cases := []struct {
+		path string
+		is   bool
+	}{
+		{"", false},
+		{".", false},
+		{"/", false},
+		{"12" + encryptedDirExtension, false},
+		{"1" + encryptedDirExtension, true},
+		{"1" + encryptedDirExtension + "/b", false},
+		{"1" + encryptedDirExtension + "/bc", true},
+		{"1" + encryptedDirExtension + "/bcd", false},
+		{"1" + encryptedDirExtension + "/bc/foo", false},
+		{"1.12/22", false},
+	}
+	for _, tc := range cases {
+		if res := IsEncryptedParent(tc.path); res != tc.is {
+			t.Errorf("%v: got %v, expected %v", tc.path, res, tc.is)
+		}
+	}
+}
+
+func TestEncryptedConnectionRequest(t *testing.T) {
+	const folder = "folder"
+	password := "password"
+
+	data := make([]byte, 128<<KiB)
+	io.ReadFull(rand.Reader, data)
+	plain := FileInfo{
+		Name:         "file",
+		Size:         int64(len(data)),
+		RawBlockSize: 128 << KiB,
+		Blocks:       []BlockInfo{{Offset: 0, Size: int32(len(data)), Hash: []byte("hash")}},
+	}
+
+	// The trusted side answers requests from the untrusted side with
+	// encrypted data, the untrusted side only ever sees the encrypted
+	// name, offset and hash.
+	m := newTestModel()
+	m.data = data
+	em := encryptedModel{Model: m, folderKeys: newFolderKeyRegistry(map[string]string{folder: password})}
+
+	enc := encryptFileInfo(plain, KeyFromPassword(folder, password))
+	b := enc.Blocks[0]
+	resp, err := em.Request(LocalDeviceID, folder, enc.Name, 0, b.Size, b.Offset, b.Hash, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.name != plain.Name || m.size != int32(len(data)) || !bytes.Equal(m.hash, plain.Blocks[0].Hash) {
+		t.Errorf("model got unexpected request: %q %d %x", m.name, m.size, m.hash)
+	}
+	if len(resp.Data()) != int(b.Size) {
+		t.Errorf("unexpected response length %d != %d", len(resp.Data()), b.Size)
+	}
+	dec, err := DecryptBytes(resp.Data(), FileKey(plain.Name, KeyFromPassword(folder, password)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec, data) {
+		t.Error("mismatch after decryption")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package protocol
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var errSecondaryConnection = errors.New("protocol error: message not allowed on secondary connection")
+
+// A ConnectionGroup is a Connection to a device that may be made up of
+// several underlying connections. The primary connection carries the
+// cluster config, index and download progress messages, which keeps them
+// ordered. Secondary connections carry only requests and their responses,
+// and block requests are distributed over all connections in the group.
+// When the primary connection closes, the whole group closes.
+type ConnectionGroup interface {
+	Connection
+	// AddSecondary starts a secondary connection over the given reader and
+	// writer. The closer is closed when the secondary connection closes.
+	AddSecondary(reader io.Reader, writer io.Writer, closer io.Closer, name string)
+	// Connections returns the statistics of each underlying connection, the
+	// primary connection first.
+	Connections() []ConnectionStatistics
+}
+
+// ConnectionStatistics are the Statistics of one of the connections of a
+// ConnectionGroup.
+type ConnectionStatistics struct {
+	Name string
+	Statistics
+}
+
+type connectionGroup struct {
+	Connection // the primary connection
+
+	id          DeviceID
+	receiver    Model
+	compression Compression
+
+	mut         sync.Mutex
+	secondaries []*secondaryConnection
+	passwords   map[string]string
+	next        int
+}
+
+type secondaryConnection struct {
+	Connection
+	closer io.Closer
+}
+
+// NewConnectionGroup returns a ConnectionGroup with the primary connection
+// running over the given reader and writer.
+func NewConnectionGroup(deviceID DeviceID, reader io.Reader, writer io.Writer, receiver Model, name string, compress Compression) ConnectionGroup {
+	g := &connectionGroup{
+		id:          deviceID,
+		receiver:    receiver,
+		compression: compress,
+	}
+	g.Connection = NewConnection(deviceID, reader, writer, primaryModel{Model: receiver, group: g}, name, compress)
+	return g
+}
+
+func (g *connectionGroup) AddSecondary(reader io.Reader, writer io.Writer, closer io.Closer, name string) {
+	sec := &secondaryConnection{closer: closer}
+	sec.Connection = NewConnection(g.id, reader, writer, secondaryModel{Model: g.receiver, group: g, conn: sec}, name, g.compression)
+
+	g.mut.Lock()
+	if g.Connection.Closed() {
+		g.mut.Unlock()
+		_ = closer.Close()
+		return
+	}
+	sec.SetFolderPasswords(g.passwords)
+	g.secondaries = append(g.secondaries, sec)
+	g.mut.Unlock()
+
+	sec.Start()
+	// The cluster config must be the first message on any connection. It
+	// is ignored on secondary connections.
+	sec.ClusterConfig(ClusterConfig{})
+}
+
+func (g *connectionGroup) Connections() []ConnectionStatistics {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	res := make([]ConnectionStatistics, 0, len(g.secondaries)+1)
+	res = append(res, ConnectionStatistics{Name: g.Connection.Name(), Statistics: g.Connection.Statistics()})
+	for _, sec := range g.secondaries {
+		res = append(res, ConnectionStatistics{Name: sec.Name(), Statistics: sec.Statistics()})
+	}
+	return res
+}
+
+// Request sends the request over the next connection in turn, falling back
+// to the primary connection if a secondary one has closed in the meantime.
+func (g *connectionGroup) Request(ctx context.Context, folder string, name string, blockNo int, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
+	conn, primary := g.nextConnection()
+	data, err := conn.Request(ctx, folder, name, blockNo, offset, size, hash, weakHash, fromTemporary)
+	if err == ErrClosed && !primary {
+		return g.Connection.Request(ctx, folder, name, blockNo, offset, size, hash, weakHash, fromTemporary)
+	}
+	return data, err
+}
+
+func (g *connectionGroup) nextConnection() (Connection, bool) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	i := g.next % (len(g.secondaries) + 1)
+	g.next++
+	if i == 0 {
+		return g.Connection, true
+	}
+	return g.secondaries[i-1], false
+}
+
+func (g *connectionGroup) SetFolderPasswords(passwords map[string]string) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	g.passwords = passwords
+	g.Connection.SetFolderPasswords(passwords)
+	for _, sec := range g.secondaries {
+		sec.SetFolderPasswords(passwords)
+	}
+}
+
+func (g *connectionGroup) Close(err error) {
+	g.closeSecondaries(err)
+	g.Connection.Close(err)
+}
+
+func (g *connectionGroup) closeSecondaries(err error) {
+	g.mut.Lock()
+	secondaries := g.secondaries
+	g.secondaries = nil
+	g.mut.Unlock()
+	for _, sec := range secondaries {
+		sec.Close(err)
+	}
+}
+
+func (g *connectionGroup) removeSecondary(conn *secondaryConnection) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	for i, sec := range g.secondaries {
+		if sec == conn {
+			g.secondaries = append(g.secondaries[:i], g.secondaries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Statistics returns the totals over all connections in the group.
+func (g *connectionGroup) Statistics() Statistics {
+	stats := Statistics{At: time.Now()}
+	for _, cs := range g.Connections() {
+		stats.InBytesTotal += cs.InBytesTotal
+		stats.OutBytesTotal += cs.OutBytesTotal
+	}
+	return stats
+}
+
+// primaryModel closes the secondary connections of the group once the
+// primary one has closed.
+type primaryModel struct {
+	Model
+	group *connectionGroup
+}
+
+func (m primaryModel) Closed(conn Connection, err error) {
+	m.group.closeSecondaries(err)
+	m.Model.Closed(m.group, err)
+}
+
+// secondaryModel passes on requests only, and removes the connection from
+// the group once it has closed.
+type secondaryModel struct {
+	Model
+	group *connectionGroup
+	conn  *secondaryConnection
+}
+
+func (m secondaryModel) ClusterConfig(DeviceID, ClusterConfig) error {
+	return nil
+}
+
+func (m secondaryModel) Index(DeviceID, string, []FileInfo) error {
+	return errSecondaryConnection
+}
+
+func (m secondaryModel) IndexUpdate(DeviceID, string, []FileInfo) error {
+	return errSecondaryConnection
+}
+
+func (m secondaryModel) DownloadProgress(DeviceID, string, []FileDownloadProgressUpdate) error {
+	return errSecondaryConnection
+}
+
+func (m secondaryModel) Closed(_ Connection, err error) {
+	l.Debugf("secondary connection %s to %s closed: %v", m.conn.Name(), m.group.id, err)
+	m.group.removeSecondary(m.conn)
+	_ = m.conn.closer.Close()
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package protocol
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// testCloser closes the pipes making up a connection, like closing the
+// underlying network connection would.
+type testCloser struct {
+	closed chan struct{}
+	pipes  []io.Closer
+}
+
+func newTestCloser(pipes ...io.Closer) *testCloser {
+	return &testCloser{closed: make(chan struct{}), pipes: pipes}
+}
+
+func (c *testCloser) Close() error {
+	for _, p := range c.pipes {
+		p.Close()
+	}
+	close(c.closed)
+	return nil
+}
+
+func TestConnectionGroup(t *testing.T) {
+	m0 := newTestModel()
+	m1 := newTestModel()
+	m1.data = []byte("some data")
+
+	ar, aw := io.Pipe()
+	br, bw := io.Pipe()
+	g0 := NewConnectionGroup(c0ID, ar, bw, m0, "primary", CompressNever)
+	g1 := NewConnectionGroup(c1ID, br, aw, m1, "primary", CompressNever)
+	g0.Start()
+	g1.Start()
+	g0.ClusterConfig(ClusterConfig{})
+	g1.ClusterConfig(ClusterConfig{})
+
+	cr, cw := io.Pipe()
+	dr, dw := io.Pipe()
+	closed0 := newTestCloser(cr, dw)
+	closed1 := newTestCloser(dr, cw)
+	g0.AddSecondary(cr, dw, closed0, "secondary")
+	g1.AddSecondary(dr, cw, closed1, "secondary")
+
+	conns := g0.Connections()
+	if len(conns) != 2 || conns[0].Name != "primary" || conns[1].Name != "secondary" {
+		t.Fatalf("unexpected connections: %v", conns)
+	}
+
+	// Requests go over both connections.
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		data, err := g0.Request(ctx, "default", "foo", 0, 0, 0, nil, 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "some data" {
+			t.Errorf("unexpected response %q", data)
+		}
+	}
+	for _, cs := range g0.Connections() {
+		if cs.OutBytesTotal == 0 || cs.InBytesTotal == 0 {
+			t.Errorf("connection %s was not used", cs.Name)
+		}
+	}
+
+	// Index messages on a secondary connection are a protocol error,
+	// closing just that connection.
+
+	g0.(*connectionGroup).secondaries[0].Index(ctx, "default", nil)
+	for _, c := range []*testCloser{closed0, closed1} {
+		select {
+		case <-c.closed:
+		case <-time.After(time.Second):
+			t.Fatal("secondary connection should have been closed")
+		}
+	}
+	if conns := g1.Connections(); len(conns) != 1 {
+		t.Errorf("expected only the primary connection to remain, got %v", conns)
+	}
+	if _, err := g0.Request(ctx, "default", "foo", 0, 0, 0, nil, 0, false); err != nil {
+		t.Error("request on the remaining primary connection failed:", err)
+	}
+
+	// Closing the group closes new secondary connections as well.
+
+	er, ew := io.Pipe()
+	fr, fw := io.Pipe()
+	closed0 = newTestCloser(er, fw)
+	closed1 = newTestCloser(fr, ew)
+	g0.AddSecondary(er, fw, closed0, "secondary")
+	g1.AddSecondary(fr, ew, closed1, "secondary")
+
+	g0.Close(errManual)
+	if err := m0.closedError(); err != errManual {
+		t.Fatal("Connection should be closed")
+	}
+	for _, c := range []*testCloser{closed0, closed1} {
+		select {
+		case <-c.closed:
+		case <-time.After(time.Second):
+			t.Fatal("secondary connection should have been closed")
+		}
+	}
+}
@@ -22,6 +22,9 @@ type HelloResult struct {
 	DeviceName    string
 	ClientName    string
 	ClientVersion string
+	// NumConnections is the number of connections the other side would
+	// like to have; zero for versions that don't support more than one.
+	NumConnections int
 }
 
 var (
@@ -86,16 +86,18 @@ const (
 	FlagLocalIgnored     = 1 << 1 // Matches local ignore patterns
 	FlagLocalMustRescan  = 1 << 2 // Doesn't match content on disk, must be rechecked fully
 	FlagLocalReceiveOnly = 1 << 3 // Change detected on receive only folder
+	FlagLocalDeselected  = 1 << 4 // Outside the paths selected for selective sync
+	FlagLocalPlaceholder = 1 << 5 // Contents not present, fetched on demand
 
 	// Flags that should result in the Invalid bit on outgoing updates
-	LocalInvalidFlags = FlagLocalUnsupported | FlagLocalIgnored | FlagLocalMustRescan | FlagLocalReceiveOnly
+	LocalInvalidFlags = FlagLocalUnsupported | FlagLocalIgnored | FlagLocalMustRescan | FlagLocalReceiveOnly | FlagLocalDeselected | FlagLocalPlaceholder
 
 	// Flags that should result in a file being in conflict with its
 	// successor, due to us not having an up to date picture of its state on
 	// disk.
-	LocalConflictFlags = FlagLocalUnsupported | FlagLocalIgnored | FlagLocalReceiveOnly
+	LocalConflictFlags = FlagLocalUnsupported | FlagLocalIgnored | FlagLocalReceiveOnly | FlagLocalDeselected
 
-	LocalAllFlags = FlagLocalUnsupported | FlagLocalIgnored | FlagLocalMustRescan | FlagLocalReceiveOnly
+	LocalAllFlags = FlagLocalUnsupported | FlagLocalIgnored | FlagLocalMustRescan | FlagLocalReceiveOnly | FlagLocalDeselected | FlagLocalPlaceholder
 )
 
 var (
@@ -115,7 +117,7 @@ type Model interface {
 	// An index update was received from the peer device
 	IndexUpdate(deviceID DeviceID, folder string, files []FileInfo) error
 	// A request was made by the peer device
-	Request(deviceID DeviceID, folder, name string, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (RequestResponse, error)
+	Request(deviceID DeviceID, folder, name string, blockNo, size int32, offset int64, hash []byte, weakHash uint32, fromTemporary bool) (RequestResponse, error)
 	// A cluster configuration message was received
 	ClusterConfig(deviceID DeviceID, config ClusterConfig) error
 	// The peer device closed the connection
@@ -137,11 +139,15 @@ type Connection interface {
 	Name() string
 	Index(ctx context.Context, folder string, files []FileInfo) error
 	IndexUpdate(ctx context.Context, folder string, files []FileInfo) error
-	Request(ctx context.Context, folder string, name string, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error)
+	Request(ctx context.Context, folder string, name string, blockNo int, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error)
 	ClusterConfig(config ClusterConfig)
 	DownloadProgress(ctx context.Context, folder string, updates []FileDownloadProgressUpdate)
 	Statistics() Statistics
 	Closed() bool
+	// SetFolderPasswords sets the encryption passwords for folders shared
+	// with an untrusted device. Data for these folders is encrypted before
+	// being sent, and decrypted when received.
+	SetFolderPasswords(passwords map[string]string)
 }
 
 type rawConnection struct {
@@ -170,6 +176,7 @@ type rawConnection struct {
 	closeOnce             sync.Once
 	sendCloseOnce         sync.Once
 	compression           Compression
+	folderKeys            *folderKeyRegistry // shared with the encryption wrappers
 }
 
 type asyncResult struct {
@@ -207,10 +214,14 @@ func NewConnection(deviceID DeviceID, reader io.Reader, writer io.Writer, receiv
 	cr := &countingReader{Reader: reader}
 	cw := &countingWriter{Writer: writer}
 
+	// Encryption / decryption is first (outermost) before conversion to
+	// native path formats.
+	folderKeys := newFolderKeyRegistry(nil)
+
 	c := rawConnection{
 		id:                    deviceID,
 		name:                  name,
-		receiver:              nativeModel{receiver},
+		receiver:              encryptedModel{Model: nativeModel{receiver}, folderKeys: folderKeys},
 		cr:                    cr,
 		cw:                    cw,
 		awaiting:              make(map[int32]chan asyncResult),
@@ -222,9 +233,10 @@ func NewConnection(deviceID DeviceID, reader io.Reader, writer io.Writer, receiv
 		preventSends:          make(chan struct{}),
 		closed:                make(chan struct{}),
 		compression:           compress,
+		folderKeys:            folderKeys,
 	}
 
-	return wireFormatConnection{&c}
+	return wireFormatConnection{encryptedConnection{rawConnection: &c, folderKeys: folderKeys}}
 }
 
 // Start creates the goroutines for sending and receiving of messages. It must
@@ -248,6 +260,12 @@ func (c *rawConnection) Name() string {
 	return c.name
 }
 
+// SetFolderPasswords sets the passwords for folders that are shared with the
+// remote device in encrypted form.
+func (c *rawConnection) SetFolderPasswords(passwords map[string]string) {
+	c.folderKeys.setPasswords(passwords)
+}
+
 // Index writes the list of file information to the connected peer device
 func (c *rawConnection) Index(ctx context.Context, folder string, idx []FileInfo) error {
 	select {
@@ -281,7 +299,7 @@ func (c *rawConnection) IndexUpdate(ctx context.Context, folder string, idx []Fi
 }
 
 // Request returns the bytes for the specified block after fetching them from the connected peer.
-func (c *rawConnection) Request(ctx context.Context, folder string, name string, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
+func (c *rawConnection) Request(ctx context.Context, folder string, name string, blockNo int, offset int64, size int, hash []byte, weakHash uint32, fromTemporary bool) ([]byte, error) {
 	c.nextIDMut.Lock()
 	id := c.nextID
 	c.nextID++
@@ -304,6 +322,7 @@ func (c *rawConnection) Request(ctx context.Context, folder string, name string,
 		Hash:          hash,
 		WeakHash:      weakHash,
 		FromTemporary: fromTemporary,
+		BlockNo:       int32(blockNo),
 	}, nil)
 	if !ok {
 		return nil, ErrClosed
@@ -624,7 +643,7 @@ func checkFilename(name string) error {
 }
 
 func (c *rawConnection) handleRequest(req Request) {
-	res, err := c.receiver.Request(c.id, req.Folder, req.Name, req.Size, req.Offset, req.Hash, req.WeakHash, req.FromTemporary)
+	res, err := c.receiver.Request(c.id, req.Folder, req.Name, req.BlockNo, req.Size, req.Offset, req.Hash, req.WeakHash, req.FromTemporary)
 	if err != nil {
 		c.send(context.Background(), &Response{
 			ID:   req.ID,
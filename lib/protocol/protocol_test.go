@@ -31,9 +31,9 @@ func TestPing(t *testing.T) {
 	ar, aw := io.Pipe()
 	br, bw := io.Pipe()
 
-	c0 := NewConnection(c0ID, ar, bw, newTestModel(), "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c0 := NewConnection(c0ID, ar, bw, newTestModel(), "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c0.Start()
-	c1 := NewConnection(c1ID, br, aw, newTestModel(), "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c1 := NewConnection(c1ID, br, aw, newTestModel(), "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c1.Start()
 	c0.ClusterConfig(ClusterConfig{})
 	c1.ClusterConfig(ClusterConfig{})
@@ -55,7 +55,7 @@ func TestClose(t *testing.T) {
 	ar, aw := io.Pipe()
 	br, bw := io.Pipe()
 
-	c0 := NewConnection(c0ID, ar, bw, m0, "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c0 := NewConnection(c0ID, ar, bw, m0, "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c0.Start()
 	c1 := NewConnection(c1ID, br, aw, m1, "name", CompressAlways)
 	c1.Start()
@@ -80,7 +80,7 @@ func TestClose(t *testing.T) {
 	c0.Index(ctx, "default", nil)
 	c0.Index(ctx, "default", nil)
 
-	if _, err := c0.Request(ctx, "default", "foo", 0, 0, nil, 0, false); err == nil {
+	if _, err := c0.Request(ctx, "default", "foo", 0, 0, 0, nil, 0, false); err == nil {
 		t.Error("Request should return an error")
 	}
 }
@@ -97,7 +97,7 @@ func TestCloseOnBlockingSend(t *testing.T) {
 
 	m := newTestModel()
 
-	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.BlockingRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.BlockingRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c.Start()
 
 	wg := sync.WaitGroup{}
@@ -147,7 +147,7 @@ func TestCloseRace(t *testing.T) {
 	ar, aw := io.Pipe()
 	br, bw := io.Pipe()
 
-	c0 := NewConnection(c0ID, ar, bw, m0, "c0", CompressNever).(wireFormatConnection).Connection.(*rawConnection)
+	c0 := NewConnection(c0ID, ar, bw, m0, "c0", CompressNever).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c0.Start()
 	c1 := NewConnection(c1ID, br, aw, m1, "c1", CompressNever)
 	c1.Start()
@@ -184,7 +184,7 @@ func TestCloseRace(t *testing.T) {
 func TestClusterConfigFirst(t *testing.T) {
 	m := newTestModel()
 
-	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.NoopRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.NoopRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c.Start()
 
 	select {
@@ -234,7 +234,7 @@ func TestCloseTimeout(t *testing.T) {
 
 	m := newTestModel()
 
-	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.BlockingRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.BlockingRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c.Start()
 
 	done := make(chan struct{})
@@ -330,6 +330,9 @@ func TestMarshalClusterConfigMessage(t *testing.T) {
 			if len(m1.Folders[i].Devices) == 0 {
 				m1.Folders[i].Devices = nil
 			}
+			if len(m1.Folders[i].SharedIgnores.Version.Counters) == 0 {
+				m1.Folders[i].SharedIgnores.Version.Counters = nil
+			}
 		}
 		return testMarshal(t, "clusterconfig", &m1, &ClusterConfig{})
 	}
@@ -616,6 +619,15 @@ func TestLocalFlagBits(t *testing.T) {
 	if f.IsIgnored() || f.MustRescan() || !f.IsInvalid() {
 		t.Error("file should be invalid")
 	}
+
+	f = FileInfo{Size: 1234, Blocks: []BlockInfo{{Size: 1234}}}
+	f.SetPlaceholder()
+	if !f.IsPlaceholder() || !f.IsInvalid() || f.ShouldConflict() {
+		t.Error("file should be a placeholder, invalid and not conflicting")
+	}
+	if f.Size != 1234 || len(f.Blocks) != 1 {
+		t.Error("placeholder should retain size and blocks")
+	}
 }
 
 func TestIsEquivalent(t *testing.T) {
@@ -628,6 +640,8 @@ func TestIsEquivalent(t *testing.T) {
 		b         FileInfo
 		ignPerms  *bool // nil means should not matter, we'll test both variants
 		ignBlocks *bool
+		ignXattrs *bool
+		ignOwner  *bool
 		ignFlags  uint32
 		eq        bool
 	}
@@ -779,6 +793,76 @@ func TestIsEquivalent(t *testing.T) {
 			b:  FileInfo{Type: FileInfoTypeFile, SymlinkTarget: "b"},
 			eq: true,
 		},
+
+		// Extended attributes are checked, unless ignored
+		{
+			a:         FileInfo{Platform: PlatformData{Linux: &XattrData{Xattrs: []Xattr{{Name: "user.foo", Value: []byte("bar")}}}}},
+			b:         FileInfo{Platform: PlatformData{Linux: &XattrData{Xattrs: []Xattr{{Name: "user.foo", Value: []byte("baz")}}}}},
+			ignXattrs: b(false),
+			eq:        false,
+		},
+		{
+			a:         FileInfo{Platform: PlatformData{Darwin: &XattrData{Xattrs: []Xattr{{Name: "com.apple.foo", Value: []byte("bar")}}}}},
+			b:         FileInfo{},
+			ignXattrs: b(false),
+			eq:        false,
+		},
+		{
+			a:         FileInfo{Platform: PlatformData{Linux: &XattrData{Xattrs: []Xattr{{Name: "user.foo", Value: []byte("bar")}}}}},
+			b:         FileInfo{},
+			ignXattrs: b(true),
+			eq:        true,
+		},
+
+		// No extended attributes at all is the same as an empty set
+		{
+			a:  FileInfo{Platform: PlatformData{Linux: &XattrData{}}},
+			b:  FileInfo{},
+			eq: true,
+		},
+
+		// Ownership, by name when known on both sides and by ID otherwise
+		{
+			a:        FileInfo{Platform: PlatformData{Unix: &UnixData{UID: 1000, GID: 1000}}},
+			b:        FileInfo{Platform: PlatformData{Unix: &UnixData{UID: 1001, GID: 1000}}},
+			ignOwner: b(false),
+			eq:       false,
+		},
+		{
+			a:        FileInfo{Platform: PlatformData{Unix: &UnixData{UID: 1000, GID: 1000}}},
+			b:        FileInfo{Platform: PlatformData{Unix: &UnixData{UID: 1001, GID: 1000}}},
+			ignOwner: b(true),
+			eq:       true,
+		},
+		{
+			a:        FileInfo{Platform: PlatformData{Unix: &UnixData{UID: 1000, GID: 1000}}},
+			b:        FileInfo{},
+			ignOwner: b(false),
+			eq:       false,
+		},
+		{
+			a:        FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "alice", UID: 1000, GID: 1000}}},
+			b:        FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "bob", UID: 1000, GID: 1000}}},
+			ignOwner: b(false),
+			eq:       false,
+		},
+		{
+			a:  FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "alice", GroupName: "staff", UID: 1000, GID: 1000}}},
+			b:  FileInfo{Platform: PlatformData{Unix: &UnixData{UID: 1000, GID: 1000}}},
+			eq: true,
+		},
+		{
+			a:        FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "alice", GroupName: "staff", UID: 1000, GID: 20}}},
+			b:        FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "alice", GroupName: "staff", UID: 501, GID: 50}}},
+			ignOwner: b(false),
+			eq:       true,
+		},
+		{
+			a:        FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "alice", UID: 1000, GID: 20}}},
+			b:        FileInfo{Platform: PlatformData{Unix: &UnixData{OwnerName: "alice", UID: 1000, GID: 50}}},
+			ignOwner: b(false),
+			eq:       false,
+		},
 	}
 
 	if runtime.GOOS == "windows" {
@@ -798,18 +882,35 @@ func TestIsEquivalent(t *testing.T) {
 		// in the tests.
 		for _, ignPerms := range []bool{true, false} {
 			for _, ignBlocks := range []bool{true, false} {
-				if tc.ignPerms != nil && *tc.ignPerms != ignPerms {
-					continue
-				}
-				if tc.ignBlocks != nil && *tc.ignBlocks != ignBlocks {
-					continue
-				}
-
-				if res := tc.a.isEquivalent(tc.b, 0, ignPerms, ignBlocks, tc.ignFlags); res != tc.eq {
-					t.Errorf("Case %d:\na: %v\nb: %v\na.IsEquivalent(b, %v, %v) => %v, expected %v", i, tc.a, tc.b, ignPerms, ignBlocks, res, tc.eq)
-				}
-				if res := tc.b.isEquivalent(tc.a, 0, ignPerms, ignBlocks, tc.ignFlags); res != tc.eq {
-					t.Errorf("Case %d:\na: %v\nb: %v\nb.IsEquivalent(a, %v, %v) => %v, expected %v", i, tc.a, tc.b, ignPerms, ignBlocks, res, tc.eq)
+				for _, ignXattrs := range []bool{true, false} {
+					for _, ignOwner := range []bool{true, false} {
+						if tc.ignPerms != nil && *tc.ignPerms != ignPerms {
+							continue
+						}
+						if tc.ignBlocks != nil && *tc.ignBlocks != ignBlocks {
+							continue
+						}
+						if tc.ignXattrs != nil && *tc.ignXattrs != ignXattrs {
+							continue
+						}
+						if tc.ignOwner != nil && *tc.ignOwner != ignOwner {
+							continue
+						}
+
+						comp := FileInfoComparison{
+							IgnorePerms:     ignPerms,
+							IgnoreBlocks:    ignBlocks,
+							IgnoreXattrs:    ignXattrs,
+							IgnoreOwnership: ignOwner,
+							IgnoreFlags:     tc.ignFlags,
+						}
+						if res := tc.a.isEquivalent(tc.b, comp); res != tc.eq {
+							t.Errorf("Case %d:\na: %v\nb: %v\na.IsEquivalent(b, %+v) => %v, expected %v", i, tc.a, tc.b, comp, res, tc.eq)
+						}
+						if res := tc.b.isEquivalent(tc.a, comp); res != tc.eq {
+							t.Errorf("Case %d:\na: %v\nb: %v\nb.IsEquivalent(a, %+v) => %v, expected %v", i, tc.a, tc.b, comp, res, tc.eq)
+						}
+					}
 				}
 			}
 		}
@@ -831,7 +932,7 @@ func TestSha256OfEmptyBlock(t *testing.T) {
 func TestClusterConfigAfterClose(t *testing.T) {
 	m := newTestModel()
 
-	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.BlockingRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.BlockingRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	c.Start()
 
 	c.internalClose(errManual)
@@ -853,7 +954,7 @@ func TestDispatcherToCloseDeadlock(t *testing.T) {
 	// Verify that we don't deadlock when calling Close() from within one of
 	// the model callbacks (ClusterConfig).
 	m := newTestModel()
-	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.NoopRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(*rawConnection)
+	c := NewConnection(c0ID, &testutils.BlockingRW{}, &testutils.NoopRW{}, m, "name", CompressAlways).(wireFormatConnection).Connection.(encryptedConnection).rawConnection
 	m.ccFn = func(devID DeviceID, cc ClusterConfig) {
 		c.Close(errManual)
 	}
@@ -16,6 +16,8 @@ var (
 	ResponseSuccess           = Response{0, "success"}
 	ResponseNotFound          = Response{1, "not found"}
 	ResponseAlreadyConnected  = Response{2, "already connected"}
+	ResponseNotAllowed        = Response{3, "not allowed"}
+	ResponseQuotaExceeded     = Response{4, "quota exceeded"}
 	ResponseUnexpectedMessage = Response{100, "unexpected message"}
 )
 
@@ -59,6 +59,14 @@ type Config struct {
 	ModTimeWindow time.Duration
 	// Event logger to which the scan progress events are sent
 	EventLogger events.Logger
+	// If ScanXattrs is true, extended attributes are read and changes to
+	// them are detected.
+	ScanXattrs bool
+	// Limits for the extended attributes to read, if ScanXattrs is set.
+	XattrFilter fs.XattrFilter
+	// If ScanOwnership is true, the owner and group of items are recorded
+	// and changes to them are detected.
+	ScanOwnership bool
 }
 
 type CurrentFiler interface {
@@ -89,9 +97,10 @@ func Walk(ctx context.Context, cfg Config) chan ScanResult {
 }
 
 var (
-	errUTF8Invalid       = errors.New("item is not in UTF8 encoding")
-	errUTF8Normalization = errors.New("item is not in the correct UTF8 normalization form")
-	errUTF8Conflict      = errors.New("item has UTF8 encoding conflict with another item")
+	errUTF8Invalid         = errors.New("item is not in UTF8 encoding")
+	errUTF8Normalization   = errors.New("item is not in the correct UTF8 normalization form")
+	errUTF8Conflict        = errors.New("item has UTF8 encoding conflict with another item")
+	errPlaceholderModified = errors.New("placeholder modified without being hydrated first")
 )
 
 type walker struct {
@@ -118,7 +127,13 @@ func (w *walker) walk(ctx context.Context) chan ScanResult {
 					l.Debugf("Skip walking %v as it is below a symlink", sub)
 					continue
 				}
-				w.Filesystem.Walk(sub, hashFiles)
+				if err := w.Filesystem.Walk(sub, hashFiles); fs.IsErrCaseConflict(err) {
+					// A known item that now exists with a different case
+					// has been renamed, which the deletion check handles.
+					if _, ok := w.CurrentFiler.CurrentFile(sub); !ok {
+						w.handleError(ctx, "scan", sub, err, finishedChan)
+					}
+				}
 			}
 		}
 		close(toHashChan)
@@ -241,7 +256,7 @@ func (w *walker) walkAndHashFiles(ctx context.Context, toHashChan chan<- protoco
 			return skip
 		}
 
-		if w.Matcher.Match(path).IsIgnored() {
+		if w.Matcher.MatchInfo(path, info).IsIgnored() {
 			l.Debugln("ignored (patterns):", path)
 			// Only descend if matcher says so and the current file is not a symlink.
 			if err != nil || w.Matcher.SkipIgnoredDirs() || info.IsSymlink() {
@@ -324,13 +339,13 @@ func (w *walker) handleItem(ctx context.Context, path string, toHashChan chan<-
 		err = w.walkDir(ctx, path, info, finishedChan)
 
 	case info.IsRegular():
-		err = w.walkRegular(ctx, path, info, toHashChan)
+		err = w.walkRegular(ctx, path, info, toHashChan, finishedChan)
 	}
 
 	return err
 }
 
-func (w *walker) walkRegular(ctx context.Context, relPath string, info fs.FileInfo, toHashChan chan<- protocol.FileInfo) error {
+func (w *walker) walkRegular(ctx context.Context, relPath string, info fs.FileInfo, toHashChan chan<- protocol.FileInfo, finishedChan chan<- ScanResult) error {
 	curFile, hasCurFile := w.CurrentFiler.CurrentFile(relPath)
 
 	blockSize := protocol.BlockSize(info.Size())
@@ -350,12 +365,23 @@ func (w *walker) walkRegular(ctx context.Context, relPath string, info fs.FileIn
 	}
 
 	f, _ := CreateFileInfo(info, relPath, nil)
+	if err := w.scanPlatformData(&f, info); err != nil {
+		w.handleError(ctx, "reading platform data", relPath, err, finishedChan)
+		return nil
+	}
 	f = w.updateFileInfo(f, curFile)
 	f.NoPermissions = w.IgnorePerms
 	f.RawBlockSize = int32(blockSize)
 
 	if hasCurFile {
-		if curFile.IsEquivalentOptional(f, w.ModTimeWindow, w.IgnorePerms, true, w.LocalFlags) {
+		if curFile.IsEquivalentOptional(f, w.comparison()) {
+			return nil
+		}
+		if curFile.IsPlaceholder() && curFile.Type == protocol.FileInfoTypeFile {
+			// The contents are zeroes, plus whatever was written without
+			// hydrating the file first. Announcing that would replace the
+			// real file everywhere.
+			w.handleError(ctx, "scanning placeholder", relPath, errPlaceholderModified, finishedChan)
 			return nil
 		}
 		if curFile.ShouldConflict() {
@@ -384,11 +410,15 @@ func (w *walker) walkDir(ctx context.Context, relPath string, info fs.FileInfo,
 	curFile, hasCurFile := w.CurrentFiler.CurrentFile(relPath)
 
 	f, _ := CreateFileInfo(info, relPath, nil)
+	if err := w.scanPlatformData(&f, info); err != nil {
+		w.handleError(ctx, "reading platform data", relPath, err, finishedChan)
+		return nil
+	}
 	f = w.updateFileInfo(f, curFile)
 	f.NoPermissions = w.IgnorePerms
 
 	if hasCurFile {
-		if curFile.IsEquivalentOptional(f, w.ModTimeWindow, w.IgnorePerms, true, w.LocalFlags) {
+		if curFile.IsEquivalentOptional(f, w.comparison()) {
 			return nil
 		}
 		if curFile.ShouldConflict() {
@@ -427,12 +457,17 @@ func (w *walker) walkSymlink(ctx context.Context, relPath string, info fs.FileIn
 		return nil
 	}
 
+	if err := w.scanPlatformData(&f, info); err != nil {
+		w.handleError(ctx, "reading platform data", relPath, err, finishedChan)
+		return nil
+	}
+
 	curFile, hasCurFile := w.CurrentFiler.CurrentFile(relPath)
 
 	f = w.updateFileInfo(f, curFile)
 
 	if hasCurFile {
-		if curFile.IsEquivalentOptional(f, w.ModTimeWindow, w.IgnorePerms, true, w.LocalFlags) {
+		if curFile.IsEquivalentOptional(f, w.comparison()) {
 			return nil
 		}
 		if curFile.ShouldConflict() {
@@ -525,9 +560,47 @@ func (w *walker) updateFileInfo(file, curFile protocol.FileInfo) protocol.FileIn
 	file.Version = curFile.Version.Update(w.ShortID)
 	file.ModifiedBy = w.ShortID
 	file.LocalFlags = w.LocalFlags
+	file.Platform.MergeWith(&curFile.Platform)
 	return file
 }
 
+// scanPlatformData records ownership and extended attributes of the item
+// in its platform data, as far as enabled and supported. Extended
+// attributes of symlinks are not handled.
+func (w *walker) scanPlatformData(file *protocol.FileInfo, info fs.FileInfo) error {
+	if w.ScanOwnership && runtime.GOOS != "windows" {
+		file.Platform.Unix = &protocol.UnixData{
+			OwnerName: osutil.UserName(info.Owner()),
+			GroupName: osutil.GroupName(info.Group()),
+			UID:       info.Owner(),
+			GID:       info.Group(),
+		}
+	}
+
+	if !w.ScanXattrs || file.IsSymlink() {
+		return nil
+	}
+	xattrs, err := w.Filesystem.GetXattr(file.Name, w.XattrFilter)
+	if errors.Is(err, fs.ErrXattrsNotSupported) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	file.Platform.SetXattrData(&protocol.XattrData{Xattrs: xattrs})
+	return nil
+}
+
+func (w *walker) comparison() protocol.FileInfoComparison {
+	return protocol.FileInfoComparison{
+		ModTimeWindow:   w.ModTimeWindow,
+		IgnorePerms:     w.IgnorePerms,
+		IgnoreBlocks:    true,
+		IgnoreFlags:     w.LocalFlags | protocol.FlagLocalPlaceholder, // placeholders are unchanged while they match
+		IgnoreXattrs:    !w.ScanXattrs,
+		IgnoreOwnership: !w.ScanOwnership,
+	}
+}
+
 func (w *walker) handleError(ctx context.Context, context, path string, err error, finishedChan chan<- ScanResult) {
 	// Ignore missing items, as deletions are not handled by the scanner.
 	if fs.IsNotExist(err) {
@@ -483,6 +483,125 @@ func TestWalkReceiveOnly(t *testing.T) {
 	}
 }
 
+type testXattrFilter struct{}
+
+func (testXattrFilter) GetMaxSingleEntrySize() int { return 1024 }
+func (testXattrFilter) GetMaxTotalSize() int       { return 4096 }
+
+func TestWalkXattrs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	xfs := fs.NewFilesystem(fs.FilesystemTypeBasic, dir)
+
+	fd, err := xfs.Create("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Close()
+
+	xattrs := []protocol.Xattr{{Name: "user.test", Value: []byte("value")}}
+	if err := xfs.SetXattr("file", xattrs, testXattrFilter{}); err != nil {
+		t.Skip("extended attributes not supported:", err)
+	}
+
+	walk := func(current fakeCurrentFiler, scanXattrs bool) []protocol.FileInfo {
+		cfg := testConfig()
+		cfg.Filesystem = xfs
+		cfg.CurrentFiler = current
+		cfg.ScanXattrs = scanXattrs
+		cfg.XattrFilter = testXattrFilter{}
+		var files []protocol.FileInfo
+		for res := range Walk(context.TODO(), cfg) {
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			files = append(files, res.File)
+		}
+		return files
+	}
+
+	current := make(fakeCurrentFiler)
+	files := walk(current, true)
+	if len(files) != 1 {
+		t.Fatal("Should have scanned one file")
+	}
+	xd := files[0].Platform.XattrData()
+	if xd == nil || len(xd.Xattrs) != 1 || xd.Xattrs[0].Name != "user.test" || string(xd.Xattrs[0].Value) != "value" {
+		t.Fatalf("unexpected xattrs: %v", files[0].Platform)
+	}
+	current["file"] = files[0]
+
+	// Nothing changed.
+
+	if files := walk(current, true); len(files) != 0 {
+		t.Fatal("Should not have scanned anything")
+	}
+
+	// Changing only the extended attributes is detected, unless disabled.
+
+	xattrs[0].Value = []byte("changed")
+	if err := xfs.SetXattr("file", xattrs, testXattrFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	if files := walk(current, false); len(files) != 0 {
+		t.Fatal("Should not have scanned anything with xattrs disabled")
+	}
+	files = walk(current, true)
+	if len(files) != 1 {
+		t.Fatal("Should have rescanned the file")
+	}
+	if xd := files[0].Platform.XattrData(); xd == nil || string(xd.Xattrs[0].Value) != "changed" {
+		t.Fatalf("unexpected xattrs: %v", files[0].Platform)
+	}
+}
+
+func TestWalkOwnership(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not recorded on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ofs := fs.NewFilesystem(fs.FilesystemTypeBasic, dir)
+
+	fd, err := ofs.Create("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.Close()
+
+	cfg := testConfig()
+	cfg.Filesystem = ofs
+	cfg.ScanOwnership = true
+	var files []protocol.FileInfo
+	for res := range Walk(context.TODO(), cfg) {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		files = append(files, res.File)
+	}
+	if len(files) != 1 {
+		t.Fatal("Should have scanned one file")
+	}
+
+	unix := files[0].Platform.Unix
+	if unix == nil {
+		t.Fatal("Ownership should have been recorded")
+	}
+	if unix.UID != os.Getuid() || unix.GID != os.Getgid() {
+		t.Errorf("Got uid %d gid %d, expected %d and %d", unix.UID, unix.GID, os.Getuid(), os.Getgid())
+	}
+	if unix.OwnerName != osutil.UserName(os.Getuid()) {
+		t.Errorf("Got owner name %q, expected %q", unix.OwnerName, osutil.UserName(os.Getuid()))
+	}
+}
+
 func walkDir(fs fs.Filesystem, dir string, cfiler CurrentFiler, matcher *ignore.Matcher, localFlags uint32) []protocol.FileInfo {
 	cfg := testConfig()
 	cfg.Filesystem = fs
@@ -811,6 +930,28 @@ func TestSkipIgnoredDirs(t *testing.T) {
 	}
 }
 
+func TestWalkConditionalIgnores(t *testing.T) {
+	fss := fs.NewFilesystem(fs.FilesystemTypeFake, "/TestWalkConditionalIgnores?content=true")
+	for name, size := range map[string]int{"small": 10, "large": 1000} {
+		fd, err := fss.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fd.Write(make([]byte, size))
+		fd.Close()
+	}
+
+	pats := ignore.New(fss)
+	if err := pats.Parse(bytes.NewBufferString("(?size>100)"), ".stignore"); err != nil {
+		t.Fatal(err)
+	}
+
+	files := walkDir(fss, ".", nil, pats, 0)
+	if len(files) != 1 || files[0].Name != "small" {
+		t.Errorf("Expected only small to be scanned, got %v", files)
+	}
+}
+
 // Verify returns nil or an error describing the mismatch between the block
 // list and actual reader contents
 func verify(r io.Reader, blocksize int, blocks []protocol.BlockInfo) error {
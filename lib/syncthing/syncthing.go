@@ -36,6 +36,7 @@ import (
 	"github.com/syncthing/syncthing/lib/sha256"
 	"github.com/syncthing/syncthing/lib/tlsutil"
 	"github.com/syncthing/syncthing/lib/ur"
+	"github.com/syncthing/syncthing/lib/webhook"
 )
 
 const (
@@ -269,7 +270,7 @@ func (a *App) startup() error {
 
 	if a.cfg.Options().GlobalAnnEnabled {
 		for _, srv := range a.cfg.Options().GlobalDiscoveryServers() {
-			l.Infoln("Using discovery server", srv)
+			l.Infoln("Using discovery server", discover.RedactToken(srv))
 			gd, err := discover.NewGlobal(srv, a.cert, connectionsService, a.evLogger)
 			if err != nil {
 				l.Warnln("Global discovery:", err)
@@ -285,14 +286,14 @@ func (a *App) startup() error {
 
 	if a.cfg.Options().LocalAnnEnabled {
 		// v4 broadcasts
-		bcd, err := discover.NewLocal(a.myID, fmt.Sprintf(":%d", a.cfg.Options().LocalAnnPort), connectionsService, a.evLogger)
+		bcd, err := discover.NewLocal(a.myID, fmt.Sprintf(":%d", a.cfg.Options().LocalAnnPort), a.cert, a.cfg.Options().LocalAnnAcceptUnsigned, connectionsService, a.evLogger)
 		if err != nil {
 			l.Warnln("IPv4 local discovery:", err)
 		} else {
 			cachedDiscovery.Add(bcd, 0, 0)
 		}
 		// v6 multicasts
-		mcd, err := discover.NewLocal(a.myID, a.cfg.Options().LocalAnnMCAddr, connectionsService, a.evLogger)
+		mcd, err := discover.NewLocal(a.myID, a.cfg.Options().LocalAnnMCAddr, a.cert, a.cfg.Options().LocalAnnAcceptUnsigned, connectionsService, a.evLogger)
 		if err != nil {
 			l.Warnln("IPv6 local discovery:", err)
 		} else {
@@ -300,6 +301,12 @@ func (a *App) startup() error {
 		}
 	}
 
+	// mDNS / DNS-SD, which also works where the broadcast and multicast
+	// port is blocked.
+	if a.cfg.Options().LocalAnnMDNSEnabled {
+		cachedDiscovery.Add(discover.NewMDNS(a.myID, a.cert, a.cfg.Options().LocalAnnAcceptUnsigned, connectionsService, a.evLogger), 0, 0)
+	}
+
 	// Candidate builds always run with usage reporting.
 
 	if opts := a.cfg.Options(); build.IsCandidate {
@@ -322,6 +329,10 @@ func (a *App) startup() error {
 	usageReportingSvc := ur.New(a.cfg, m, connectionsService, a.opts.NoUpgrade)
 	a.mainService.Add(usageReportingSvc)
 
+	// Webhooks
+
+	a.mainService.Add(webhook.New(a.cfg, a.evLogger))
+
 	// GUI
 
 	if err := a.setupGUI(m, defaultSub, diskSub, cachedDiscovery, connectionsService, usageReportingSvc, errors, systemLog); err != nil {
@@ -0,0 +1,544 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package versioner
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thejerf/suture"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/sha256"
+	"github.com/syncthing/syncthing/lib/sync"
+	"github.com/syncthing/syncthing/lib/util"
+)
+
+func init() {
+	// Register the constructor for this type of versioner with the name "dedup"
+	factories["dedup"] = newDedup
+}
+
+// blocksDir is the directory in the version archive holding the blocks,
+// each stored once under the hex encoding of its hash. The archive mirrors
+// the folder, and .stversions at the top of a folder is internal and never
+// versioned, so no version can end up in there.
+const blocksDir = ".stversions"
+
+var errBlockCorrupt = errors.New("archived block is corrupt")
+
+// The dedup versioner archives a file by storing its blocks, and a manifest
+// in place of the file listing them. The manifest is a marshalled FileInfo,
+// named like the versions of the simple versioner. Versions that share
+// most of their contents thus only take up the space of the changed
+// blocks. Blocks that are no longer referenced by any manifest are removed
+// every cleanInterval seconds, and when the retention policy removes
+// versions.
+type dedup struct {
+	suture.Service
+	fileArchive
+	keep          int
+	cleanInterval int64
+	folderFs      fs.Filesystem
+	mut           sync.Mutex // serializes archiving and garbage collection
+}
+
+func newDedup(folderFs fs.Filesystem, params map[string]string) Versioner {
+	keep, err := strconv.Atoi(params["keep"])
+	if err != nil {
+		keep = 5 // A reasonable default
+	}
+	cleanInterval, err := strconv.ParseInt(params["cleanInterval"], 10, 0)
+	if err != nil || cleanInterval <= 0 {
+		cleanInterval = 3600 // Default: clean once per hour
+	}
+
+	s := &dedup{
+		keep:          keep,
+		cleanInterval: cleanInterval,
+		folderFs:      folderFs,
+		fileArchive:   fileArchive{fsFromParams(folderFs, params)},
+		mut:           sync.NewMutex(),
+	}
+	s.Service = util.AsService(s.serve, s.String())
+
+	l.Debugf("instantiated %#v", s)
+	return s
+}
+
+func (v *dedup) serve(ctx context.Context) {
+	tck := time.NewTicker(time.Duration(v.cleanInterval) * time.Second)
+	defer tck.Stop()
+	for {
+		select {
+		case <-tck.C:
+			v.clean()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// clean removes the blocks no longer used by any version. This reads all
+// manifests, so it runs periodically rather than after each change.
+func (v *dedup) clean() {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	if _, err := v.versionsFs.Stat(blocksDir); fs.IsNotExist(err) {
+		return
+	}
+	reclaimed, err := v.collectGarbage()
+	if err != nil {
+		l.Warnln("Versioner: removing unused blocks:", err)
+		return
+	}
+	l.Debugf("Versioner: removed unused blocks in %v, reclaiming %d bytes", v.versionsFs, reclaimed)
+}
+
+func (v *dedup) String() string {
+	return fmt.Sprintf("Dedup/@%p", v)
+}
+
+// Archive stores the blocks and manifest of the named file and removes it.
+// If this function returns nil, the named file does not exist any more
+// (has been archived).
+func (v *dedup) Archive(filePath string) error {
+	return v.ArchiveFrom(filePath, filePath)
+}
+
+// ArchiveFrom stores the file at srcPath as a version of filePath and
+// removes it.
+func (v *dedup) ArchiveFrom(srcPath, filePath string) error {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	if err := v.archive(srcPath, filePath); err != nil {
+		return err
+	}
+
+	// Versions are sorted by timestamp in the file name, oldest first.
+	versions := findAllVersions(v.versionsFs, osutil.NativeFilename(filePath))
+	if len(versions) <= v.keep {
+		return nil
+	}
+	for _, toRemove := range versions[:len(versions)-v.keep] {
+		l.Debugln("cleaning out", toRemove)
+		if err := v.versionsFs.Remove(toRemove); err != nil {
+			l.Warnln("removing old version:", err)
+		}
+	}
+	return nil
+}
+
+func (v *dedup) archive(srcPath, filePath string) error {
+	srcPath = osutil.NativeFilename(srcPath)
+	filePath = osutil.NativeFilename(filePath)
+	info, err := v.folderFs.Lstat(srcPath)
+	if fs.IsNotExist(err) {
+		l.Debugln("not archiving nonexistent file", srcPath)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.IsSymlink() {
+		panic("bug: attempting to version a symlink")
+	}
+
+	if err := createVersionsDir(v.versionsFs); err != nil {
+		return err
+	}
+	blockSize := protocol.BlockSize(info.Size())
+	blocks, err := v.storeBlocks(srcPath, blockSize)
+	if err != nil {
+		return errors.Wrap(err, "storing blocks")
+	}
+
+	manifest := protocol.FileInfo{
+		Name:         filePath,
+		Type:         protocol.FileInfoTypeFile,
+		Size:         info.Size(),
+		ModifiedS:    info.ModTime().Unix(),
+		ModifiedNs:   int32(info.ModTime().Nanosecond()),
+		Permissions:  uint32(info.Mode() & fs.ModePerm),
+		RawBlockSize: int32(blockSize),
+		Blocks:       blocks,
+	}
+	dst := TagFilename(filePath, time.Now().Format(TimeFormat))
+	l.Debugln("archiving", srcPath, "as manifest", dst)
+	if err := v.versionsFs.MkdirAll(filepath.Dir(dst), 0755); err != nil && !fs.IsExist(err) {
+		return err
+	}
+	if err := writeManifest(v.versionsFs, dst, manifest); err != nil {
+		return errors.Wrap(err, "writing manifest")
+	}
+
+	return v.folderFs.Remove(srcPath)
+}
+
+// storeBlocks splits the file into blocks, stores the ones not yet in the
+// archive and returns the list of them.
+func (v *dedup) storeBlocks(name string, blockSize int) ([]protocol.BlockInfo, error) {
+	fd, err := v.folderFs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var blocks []protocol.BlockInfo
+	var offset int64
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(fd, buf)
+		if n > 0 {
+			hash := sha256.Sum256(buf[:n])
+			if err := v.storeBlock(hash[:], buf[:n]); err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, protocol.BlockInfo{
+				Offset: offset,
+				Size:   int32(n),
+				Hash:   hash[:],
+			})
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return blocks, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (v *dedup) storeBlock(hash, data []byte) error {
+	name := blockPath(hash)
+	if _, err := v.versionsFs.Lstat(name); err == nil {
+		return nil
+	}
+	if err := v.versionsFs.MkdirAll(filepath.Dir(name), 0755); err != nil && !fs.IsExist(err) {
+		return err
+	}
+
+	// Written to a temporary name first, so that a block that exists is
+	// always complete.
+	tempName := fs.TempName(name)
+	fd, err := v.versionsFs.Create(tempName)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		v.versionsFs.Remove(tempName)
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		v.versionsFs.Remove(tempName)
+		return err
+	}
+	return v.versionsFs.Rename(tempName, name)
+}
+
+func (v *dedup) GetVersions() (map[string][]FileVersion, error) {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	files := make(map[string][]FileVersion)
+	err := v.walkManifests(func(_, name string, versionTime time.Time, manifest protocol.FileInfo) {
+		files[name] = append(files[name], FileVersion{
+			VersionTime: versionTime,
+			ModTime:     manifest.ModTime().Truncate(time.Second),
+			Size:        manifest.Size,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Restore puts the version back together from its blocks, archiving what
+// is in its place first. Like in the other versioners, the restored
+// version is removed from the archive.
+func (v *dedup) Restore(filePath string, versionTime time.Time) error {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	filePath = osutil.NativeFilename(filePath)
+	tag := versionTime.In(time.Local).Truncate(time.Second).Format(TimeFormat)
+	manifestPath := TagFilename(filePath, tag)
+	manifest, err := readManifest(v.versionsFs, manifestPath)
+	if fs.IsNotExist(err) {
+		return errNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "reading manifest")
+	}
+
+	if info, err := v.folderFs.Lstat(filePath); err == nil {
+		switch {
+		case info.IsDir():
+			return errDirectory
+		case info.IsSymlink():
+			// Remove existing symlinks (as we don't want to archive them)
+			if err := v.folderFs.Remove(filePath); err != nil {
+				return errors.Wrap(err, "removing existing symlink")
+			}
+		case info.IsRegular():
+			if err := v.archive(filePath, filePath); err != nil {
+				return errors.Wrap(err, "archiving existing file")
+			}
+		default:
+			panic("bug: unknown item type")
+		}
+	} else if !fs.IsNotExist(err) {
+		return err
+	}
+
+	_ = v.folderFs.MkdirAll(filepath.Dir(filePath), 0755)
+	tempName := fs.TempName(filePath)
+	if err := v.restoreBlocks(tempName, manifest); err != nil {
+		v.folderFs.Remove(tempName)
+		return err
+	}
+	if err := v.folderFs.Rename(tempName, filePath); err != nil {
+		v.folderFs.Remove(tempName)
+		return err
+	}
+	_ = v.folderFs.Chtimes(filePath, manifest.ModTime(), manifest.ModTime())
+
+	return v.versionsFs.Remove(manifestPath)
+}
+
+// ReadVersion puts the contents of the version together from its blocks.
+func (v *dedup) ReadVersion(filePath string, versionTime time.Time) ([]byte, error) {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	tag := versionTime.In(time.Local).Truncate(time.Second).Format(TimeFormat)
+	manifest, err := readManifest(v.versionsFs, TagFilename(osutil.NativeFilename(filePath), tag))
+	if fs.IsNotExist(err) {
+		return nil, errNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading manifest")
+	}
+
+	buf := make([]byte, 0, manifest.Size)
+	for _, block := range manifest.Blocks {
+		data, err := v.readBlock(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, data...)
+	}
+	return buf, nil
+}
+
+// restoreBlocks writes the blocks listed in the manifest to the named file,
+// verifying their hashes.
+func (v *dedup) restoreBlocks(name string, manifest protocol.FileInfo) error {
+	fd, err := v.folderFs.Create(name)
+	if err != nil {
+		return err
+	}
+	for _, block := range manifest.Blocks {
+		data, err := v.readBlock(block.Hash)
+		if err == nil {
+			_, err = fd.Write(data)
+		}
+		if err != nil {
+			fd.Close()
+			return err
+		}
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	return v.folderFs.Chmod(name, fs.FileMode(manifest.Permissions&0777))
+}
+
+func (v *dedup) readBlock(hash []byte) ([]byte, error) {
+	fd, err := v.versionsFs.Open(blockPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	data, err := ioutil.ReadAll(fd)
+	if err != nil {
+		return nil, err
+	}
+	if actual := sha256.Sum256(data); !bytes.Equal(actual[:], hash) {
+		return nil, errBlockCorrupt
+	}
+	return data, nil
+}
+
+// collectGarbage removes the blocks that aren't referenced by any manifest
+// and returns the number of bytes reclaimed.
+func (v *dedup) collectGarbage() (int64, error) {
+	used := make(map[string]struct{})
+	err := v.walkManifests(func(_, _ string, _ time.Time, manifest protocol.FileInfo) {
+		for _, block := range manifest.Blocks {
+			used[blockPath(block.Hash)] = struct{}{}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var reclaimed int64
+	err = v.versionsFs.Walk(blocksDir, func(path string, info fs.FileInfo, err error) error {
+		if fs.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !info.IsRegular() {
+			return nil
+		}
+		if _, ok := used[path]; !ok {
+			l.Debugln("removing unused block", path)
+			if err := v.versionsFs.Remove(path); err != nil {
+				return err
+			}
+			reclaimed += info.Size()
+		}
+		return nil
+	})
+	return reclaimed, err
+}
+
+// archivedVersions returns the versions with the size of the files they
+// restore to, even though blocks are shared between them.
+func (v *dedup) archivedVersions() ([]archivedVersion, error) {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	var versions []archivedVersion
+	err := v.walkManifests(func(path, name string, versionTime time.Time, manifest protocol.FileInfo) {
+		versions = append(versions, archivedVersion{
+			FileVersion: FileVersion{
+				VersionTime: versionTime,
+				ModTime:     manifest.ModTime().Truncate(time.Second),
+				Size:        manifest.Size,
+			},
+			name: name,
+			path: path,
+		})
+	})
+	return versions, err
+}
+
+// removeVersions removes the manifests and then the blocks no longer used,
+// which are what is reclaimed.
+func (v *dedup) removeVersions(versions []archivedVersion) (int64, error) {
+	if len(versions) == 0 {
+		return 0, nil
+	}
+
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	var reclaimed int64
+	for _, version := range versions {
+		l.Debugln("retention: removing", version.path)
+		info, err := v.versionsFs.Lstat(version.path)
+		if fs.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return reclaimed, err
+		}
+		if err := v.versionsFs.Remove(version.path); err != nil {
+			return reclaimed, err
+		}
+		reclaimed += info.Size()
+	}
+	blocks, err := v.collectGarbage()
+	return reclaimed + blocks, err
+}
+
+// walkManifests calls the function for every manifest in the archive, with
+// its path, the name of the file and the time it was archived.
+func (v *dedup) walkManifests(fn func(path, name string, versionTime time.Time, manifest protocol.FileInfo)) error {
+	return v.versionsFs.Walk(".", func(path string, info fs.FileInfo, err error) error {
+		// Skip root (which is ok to be a symlink)
+		if path == "." {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if path == blocksDir {
+			return fs.SkipDir
+		}
+		if !info.IsRegular() {
+			return nil
+		}
+
+		name, tag := UntagFilename(osutil.NormalizedFilename(path))
+		if name == "" || tag == "" {
+			return nil
+		}
+		versionTime, err := time.ParseInLocation(TimeFormat, tag, time.Local)
+		if err != nil {
+			return nil
+		}
+		// An unreadable manifest is an error rather than skipped, as its
+		// blocks would be garbage collected otherwise.
+		manifest, err := readManifest(v.versionsFs, path)
+		if err != nil {
+			return errors.Wrapf(err, "reading manifest %v", path)
+		}
+
+		fn(path, name, versionTime, manifest)
+		return nil
+	})
+}
+
+func blockPath(hash []byte) string {
+	name := hex.EncodeToString(hash)
+	return filepath.Join(blocksDir, name[:2], name[2:])
+}
+
+func readManifest(filesystem fs.Filesystem, name string) (protocol.FileInfo, error) {
+	var manifest protocol.FileInfo
+	fd, err := filesystem.Open(name)
+	if err != nil {
+		return manifest, err
+	}
+	defer fd.Close()
+	bs, err := ioutil.ReadAll(fd)
+	if err != nil {
+		return manifest, err
+	}
+	err = manifest.Unmarshal(bs)
+	return manifest, err
+}
+
+func writeManifest(filesystem fs.Filesystem, name string, manifest protocol.FileInfo) error {
+	bs, err := manifest.Marshal()
+	if err != nil {
+		return err
+	}
+	fd, err := filesystem.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(bs); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package versioner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestDedupVersioning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Test takes some time, skipping.")
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	folderFs := fs.NewFilesystem(fs.FilesystemTypeBasic, dir)
+	v := newDedup(folderFs, map[string]string{"keep": "1"}).(*dedup)
+
+	writeFile := func(data []byte) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(dir, "file"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	countBlocks := func() int {
+		t.Helper()
+		n := 0
+		v.versionsFs.Walk(blocksDir, func(_ string, info fs.FileInfo, err error) error {
+			if err == nil && info.IsRegular() {
+				n++
+			}
+			return nil
+		})
+		return n
+	}
+
+	// Two versions differing in their second block share the first one.
+
+	first := bytes.Repeat([]byte("a"), 2*protocol.MinBlockSize)
+	second := append(bytes.Repeat([]byte("a"), protocol.MinBlockSize), bytes.Repeat([]byte("b"), protocol.MinBlockSize)...)
+	writeFile(first)
+	if err := v.Archive("file"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := folderFs.Lstat("file"); !fs.IsNotExist(err) {
+		t.Fatal("Expected archived file to be removed, got", err)
+	}
+	if n := countBlocks(); n != 1 {
+		t.Errorf("Expected one unique block, got %d", n)
+	}
+
+	time.Sleep(time.Second)
+	writeFile(second)
+	v.keep = 2
+	if err := v.Archive("file"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlocks(); n != 2 {
+		t.Errorf("Expected two unique blocks, got %d", n)
+	}
+
+	versions, err := v.GetVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions["file"]) != 2 {
+		t.Fatalf("Expected two versions, got %v", versions)
+	}
+	for _, version := range versions["file"] {
+		if version.Size != int64(len(first)) {
+			t.Errorf("Expected size %d, got %d", len(first), version.Size)
+		}
+	}
+
+	// Restoring the older version archives nothing, as the file doesn't
+	// exist, and leaves the blocks of the newer one.
+
+	oldest := versions["file"][0].VersionTime
+	if other := versions["file"][1].VersionTime; other.Before(oldest) {
+		oldest = other
+	}
+	if bs, err := v.ReadVersion("file", oldest); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(bs, first) {
+		t.Error("Read version has unexpected contents")
+	}
+	if err := v.Restore("file", oldest); err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := ioutil.ReadFile(filepath.Join(dir, "file")); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(bs, first) {
+		t.Error("Restored file has unexpected contents")
+	}
+	if versions, err := v.GetVersions(); err != nil {
+		t.Fatal(err)
+	} else if len(versions["file"]) != 1 {
+		t.Errorf("Expected one version after restoring, got %v", versions)
+	}
+	if n := countBlocks(); n != 2 {
+		t.Errorf("Expected two unique blocks, got %d", n)
+	}
+
+	// Cleaning out the remaining version leaves its blocks until the next
+	// clean.
+
+	v.keep = 0
+	if err := v.Archive("file"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlocks(); n != 2 {
+		t.Errorf("Expected two unique blocks before cleaning, got %d", n)
+	}
+	v.clean()
+	if n := countBlocks(); n != 0 {
+		t.Errorf("Expected all blocks to be removed, got %d", n)
+	}
+
+	// Versions of files in a directory named like the old block directory
+	// are neither hidden nor removed as unused blocks.
+
+	if err := folderFs.MkdirAll(".blocks", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".blocks", "file"), first, 0644); err != nil {
+		t.Fatal(err)
+	}
+	v.keep = 1
+	if err := v.Archive(filepath.Join(".blocks", "file")); err != nil {
+		t.Fatal(err)
+	}
+	v.clean()
+	if versions, err := v.GetVersions(); err != nil {
+		t.Fatal(err)
+	} else if len(versions[".blocks/file"]) != 1 {
+		t.Errorf("Expected a version of .blocks/file, got %v", versions)
+	}
+}
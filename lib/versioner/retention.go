@@ -0,0 +1,244 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package versioner
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/thejerf/suture"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/util"
+)
+
+var ErrRetentionNotSupported = errors.New("retention policy not supported with the current versioner")
+
+// A RetentionPolicy limits the versions kept, on top of the cleaning done
+// by the versioner itself. It applies to all versioners that keep their
+// versions in a filesystem, i.e. all but the external one. Zero values mean
+// no limit.
+type RetentionPolicy struct {
+	MaxTotalSize  int64 // bytes, for all versions together
+	MaxVersions   int   // per file
+	MaxAge        time.Duration
+	MinFree       config.Size // on the filesystem holding the versions
+	CleanInterval time.Duration
+}
+
+// NewRetentionPolicy returns the policy given by the retainMaxTotalSize,
+// retainMaxVersions, retainMaxAge (seconds), retainMinFree and
+// cleanInterval (seconds) versioning parameters.
+func NewRetentionPolicy(params map[string]string) RetentionPolicy {
+	p := RetentionPolicy{
+		CleanInterval: time.Hour,
+	}
+	if size, err := config.ParseSize(params["retainMaxTotalSize"]); err == nil {
+		p.MaxTotalSize = int64(size.BaseValue())
+	}
+	p.MaxVersions, _ = strconv.Atoi(params["retainMaxVersions"])
+	if maxAge, err := strconv.ParseInt(params["retainMaxAge"], 10, 0); err == nil {
+		p.MaxAge = time.Duration(maxAge) * time.Second
+	}
+	p.MinFree, _ = config.ParseSize(params["retainMinFree"])
+	if interval, err := strconv.ParseInt(params["cleanInterval"], 10, 0); err == nil && interval > 0 {
+		p.CleanInterval = time.Duration(interval) * time.Second
+	}
+	return p
+}
+
+// IsZero returns true when the policy doesn't limit anything.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxTotalSize <= 0 && p.MaxVersions <= 0 && p.MaxAge <= 0 && p.MinFree.BaseValue() <= 0
+}
+
+// A CleanResult summarizes the versions removed by applying a retention
+// policy.
+type CleanResult struct {
+	Removed   int   `json:"removed"`
+	Reclaimed int64 `json:"reclaimed"` // bytes
+}
+
+// An archivedVersion is a version as seen by the retention policy.
+type archivedVersion struct {
+	FileVersion
+	name string // of the versioned file
+	path string // of the version in the archive
+}
+
+// A versionArchive is implemented by versioners keeping their versions in
+// a filesystem.
+type versionArchive interface {
+	archivedVersions() ([]archivedVersion, error)
+	// removeVersions returns the number of bytes reclaimed.
+	removeVersions(versions []archivedVersion) (int64, error)
+	usage() (fs.Usage, error)
+}
+
+// fileArchive is the versionArchive of versioners that store each version
+// as a file.
+type fileArchive struct {
+	versionsFs fs.Filesystem
+}
+
+func (a fileArchive) archivedVersions() ([]archivedVersion, error) {
+	return listVersions(a.versionsFs)
+}
+
+func (a fileArchive) removeVersions(versions []archivedVersion) (int64, error) {
+	var reclaimed int64
+	for _, version := range versions {
+		l.Debugln("retention: removing", version.path)
+		if err := a.versionsFs.Remove(version.path); err != nil && !fs.IsNotExist(err) {
+			return reclaimed, err
+		}
+		reclaimed += version.Size
+	}
+	return reclaimed, nil
+}
+
+func (a fileArchive) usage() (fs.Usage, error) {
+	return a.versionsFs.Usage(".")
+}
+
+// ReadVersion returns the contents of the given version of the file.
+func (a fileArchive) ReadVersion(filePath string, versionTime time.Time) ([]byte, error) {
+	versions, err := a.archivedVersions()
+	if err != nil {
+		return nil, err
+	}
+	name := osutil.NormalizedFilename(filePath)
+	versionTime = versionTime.Truncate(time.Second)
+	for _, version := range versions {
+		if version.name != name || !version.VersionTime.Equal(versionTime) {
+			continue
+		}
+		fd, err := a.versionsFs.Open(version.path)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		return ioutil.ReadAll(fd)
+	}
+	return nil, errNotFound
+}
+
+// Clean applies the retention policy to the versions kept by the
+// versioner. Where the limits leave a choice, older versions are removed
+// before newer ones.
+func Clean(v Versioner, policy RetentionPolicy) (CleanResult, error) {
+	archive, ok := v.(versionArchive)
+	if !ok {
+		return CleanResult{}, ErrRetentionNotSupported
+	}
+	if policy.IsZero() {
+		return CleanResult{}, nil
+	}
+
+	versions, err := archive.archivedVersions()
+	if err != nil {
+		return CleanResult{}, err
+	}
+	// Newest first
+	sort.Slice(versions, func(a, b int) bool {
+		return versions[a].VersionTime.After(versions[b].VersionTime)
+	})
+
+	var keep, remove []archivedVersion
+	perFile := make(map[string]int)
+	cutoff := time.Now().Add(-policy.MaxAge)
+	var total int64
+	full := false
+	for _, version := range versions {
+		perFile[version.name]++
+		if policy.MaxTotalSize > 0 && total+version.Size > policy.MaxTotalSize {
+			full = true
+		}
+		switch {
+		case full,
+			policy.MaxAge > 0 && version.VersionTime.Before(cutoff),
+			policy.MaxVersions > 0 && perFile[version.name] > policy.MaxVersions:
+			remove = append(remove, version)
+		default:
+			keep = append(keep, version)
+			total += version.Size
+		}
+	}
+
+	var res CleanResult
+	reclaimed, err := archive.removeVersions(remove)
+	res.Reclaimed += reclaimed
+	if err != nil {
+		return res, err
+	}
+	res.Removed += len(remove)
+
+	if policy.MinFree.BaseValue() <= 0 {
+		return res, nil
+	}
+	// Removing a version may free less than its size, as when its blocks
+	// are shared with other versions, so measure again after each one.
+	for i := len(keep) - 1; i >= 0; i-- {
+		usage, err := archive.usage()
+		if err != nil {
+			return res, err
+		}
+		if neededSpace(policy.MinFree, usage) <= 0 {
+			break
+		}
+		reclaimed, err := archive.removeVersions(keep[i : i+1])
+		res.Reclaimed += reclaimed
+		if err != nil {
+			return res, err
+		}
+		res.Removed++
+	}
+	return res, nil
+}
+
+// neededSpace returns the number of bytes to free to satisfy the minimum
+// free space, which is negative when there is enough.
+func neededSpace(minFree config.Size, usage fs.Usage) int64 {
+	val := minFree.BaseValue()
+	if minFree.Percentage() {
+		val = val / 100 * float64(usage.Total)
+	}
+	return int64(val) - usage.Free
+}
+
+// NewRetentionCleaner returns a service applying the retention policy to
+// the versions kept by the versioner at the policy's interval, or nil if
+// there is nothing to apply.
+func NewRetentionCleaner(v Versioner, policy RetentionPolicy) suture.Service {
+	if _, ok := v.(versionArchive); !ok || policy.IsZero() {
+		return nil
+	}
+	return util.AsService(func(ctx context.Context) {
+		tck := time.NewTicker(policy.CleanInterval)
+		defer tck.Stop()
+		for {
+			res, err := Clean(v, policy)
+			if err != nil {
+				l.Warnln("Versioner: applying retention policy:", err)
+			} else if res.Removed > 0 {
+				l.Infof("Versioner: removed %d versions per retention policy, reclaiming %d bytes", res.Removed, res.Reclaimed)
+			}
+
+			select {
+			case <-tck.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, "versioner.retentionCleaner")
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package versioner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	versions := []struct {
+		name string
+		age  time.Duration
+		size int
+	}{
+		{"a", 0, 10},
+		{"a", time.Hour, 10},
+		{"a", 2 * time.Hour, 10},
+		{"b", 30 * time.Minute, 100},
+		{"b", 48 * time.Hour, 100},
+		{"c", 3 * time.Hour, 1000},
+	}
+
+	cases := []struct {
+		policy    RetentionPolicy
+		remaining int
+		reclaimed int64
+	}{
+		{RetentionPolicy{}, 6, 0},
+		{RetentionPolicy{MaxVersions: 1}, 3, 120},
+		{RetentionPolicy{MaxAge: 24 * time.Hour}, 5, 100},
+		// Once the total size is exceeded, all older versions go.
+		{RetentionPolicy{MaxTotalSize: 150}, 4, 1100},
+		{RetentionPolicy{MaxVersions: 2, MaxAge: 150 * time.Minute}, 3, 1110},
+		// There is never enough free space.
+		{RetentionPolicy{MinFree: config.Size{Value: 100, Unit: "%"}}, 0, 1230},
+	}
+
+	for i, tc := range cases {
+		dir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		for _, version := range versions {
+			name := filepath.Join(dir, ".stversions", TagFilename(version.name, now.Add(-version.age).Format(TimeFormat)))
+			os.MkdirAll(filepath.Dir(name), 0755)
+			if err := ioutil.WriteFile(name, make([]byte, version.size), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		v := newSimple(fs.NewFilesystem(fs.FilesystemTypeBasic, dir), nil)
+		res, err := Clean(v, tc.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Reclaimed != tc.reclaimed {
+			t.Errorf("%d: expected %d bytes reclaimed, got %d", i, tc.reclaimed, res.Reclaimed)
+		}
+		if res.Removed != len(versions)-tc.remaining {
+			t.Errorf("%d: expected %d versions removed, got %d", i, len(versions)-tc.remaining, res.Removed)
+		}
+		remaining, err := listVersions(fs.NewFilesystem(fs.FilesystemTypeBasic, filepath.Join(dir, ".stversions")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(remaining) != tc.remaining {
+			t.Errorf("%d: expected %d versions remaining, got %d", i, tc.remaining, len(remaining))
+		}
+	}
+}
+
+// sharedArchive is a versionArchive where all versions share the same
+// contents, stored once, as with the dedup versioner.
+type sharedArchive struct {
+	Versioner
+	versions []archivedVersion
+	shared   int64 // bytes, freed with the last version
+	total    int64
+}
+
+func (a *sharedArchive) archivedVersions() ([]archivedVersion, error) {
+	return a.versions, nil
+}
+
+func (a *sharedArchive) removeVersions(versions []archivedVersion) (int64, error) {
+	for _, version := range versions {
+		for i := range a.versions {
+			if a.versions[i].path == version.path {
+				a.versions = append(a.versions[:i], a.versions[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(a.versions) == 0 {
+		return a.shared, nil
+	}
+	return 0, nil
+}
+
+func (a *sharedArchive) usage() (fs.Usage, error) {
+	used := int64(0)
+	if len(a.versions) > 0 {
+		used = a.shared
+	}
+	return fs.Usage{Free: a.total - used, Total: a.total}, nil
+}
+
+func TestRetentionMinFreeShared(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	a := &sharedArchive{shared: 100, total: 200}
+	for i := 0; i < 3; i++ {
+		a.versions = append(a.versions, archivedVersion{
+			FileVersion: FileVersion{VersionTime: now.Add(-time.Duration(i) * time.Hour), Size: 100},
+			name:        "a",
+			path:        strconv.Itoa(i),
+		})
+	}
+
+	// Removing one version frees nothing, so all need to go to get to
+	// 150 bytes free.
+	res, err := Clean(a, RetentionPolicy{MinFree: config.Size{Value: 150, Unit: "B"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 3 || res.Reclaimed != 100 {
+		t.Errorf("expected 3 versions removed reclaiming 100 bytes, got %+v", res)
+	}
+}
+
+func TestRetentionNotSupported(t *testing.T) {
+	v := newExternal(fs.NewFilesystem(fs.FilesystemTypeBasic, "testdata"), map[string]string{"command": "true"})
+	if _, err := Clean(v, RetentionPolicy{MaxVersions: 1}); err != ErrRetentionNotSupported {
+		t.Error("Expected retention to be unsupported by the external versioner, got", err)
+	}
+}
@@ -19,9 +19,9 @@ func init() {
 }
 
 type simple struct {
-	keep       int
-	folderFs   fs.Filesystem
-	versionsFs fs.Filesystem
+	fileArchive
+	keep     int
+	folderFs fs.Filesystem
 }
 
 func newSimple(folderFs fs.Filesystem, params map[string]string) Versioner {
@@ -31,9 +31,9 @@ func newSimple(folderFs fs.Filesystem, params map[string]string) Versioner {
 	}
 
 	s := simple{
-		keep:       keep,
-		folderFs:   folderFs,
-		versionsFs: fsFromParams(folderFs, params),
+		keep:        keep,
+		folderFs:    folderFs,
+		fileArchive: fileArchive{fsFromParams(folderFs, params)},
 	}
 
 	l.Debugf("instantiated %#v", s)
@@ -43,7 +43,13 @@ func newSimple(folderFs fs.Filesystem, params map[string]string) Versioner {
 // Archive moves the named file away to a version archive. If this function
 // returns nil, the named file does not exist any more (has been archived).
 func (v simple) Archive(filePath string) error {
-	err := archiveFile(v.folderFs, v.versionsFs, filePath, TagFilename)
+	return v.ArchiveFrom(filePath, filePath)
+}
+
+// ArchiveFrom moves the file at srcPath away to the version archive as a
+// version of filePath.
+func (v simple) ArchiveFrom(srcPath, filePath string) error {
+	err := archiveFileFrom(v.folderFs, v.versionsFs, srcPath, filePath, TagFilename)
 	if err != nil {
 		return err
 	}
@@ -32,9 +32,9 @@ type interval struct {
 
 type staggered struct {
 	suture.Service
+	fileArchive
 	cleanInterval int64
 	folderFs      fs.Filesystem
-	versionsFs    fs.Filesystem
 	interval      [4]interval
 	mutex         sync.Mutex
 
@@ -58,7 +58,7 @@ func newStaggered(folderFs fs.Filesystem, params map[string]string) Versioner {
 	s := &staggered{
 		cleanInterval: cleanInterval,
 		folderFs:      folderFs,
-		versionsFs:    versionsFs,
+		fileArchive:   fileArchive{versionsFs},
 		interval: [4]interval{
 			{30, 3600},       // first hour -> 30 sec between versions
 			{3600, 86400},    // next day -> 1 h between versions
@@ -212,11 +212,17 @@ func (v *staggered) toRemove(versions []string, now time.Time) []string {
 // Archive moves the named file away to a version archive. If this function
 // returns nil, the named file does not exist any more (has been archived).
 func (v *staggered) Archive(filePath string) error {
+	return v.ArchiveFrom(filePath, filePath)
+}
+
+// ArchiveFrom moves the file at srcPath away to the version archive as a
+// version of filePath.
+func (v *staggered) ArchiveFrom(srcPath, filePath string) error {
 	l.Debugln("Waiting for lock on ", v.versionsFs)
 	v.mutex.Lock()
 	defer v.mutex.Unlock()
 
-	if err := archiveFile(v.folderFs, v.versionsFs, filePath, TagFilename); err != nil {
+	if err := archiveFileFrom(v.folderFs, v.versionsFs, srcPath, filePath, TagFilename); err != nil {
 		return err
 	}
 
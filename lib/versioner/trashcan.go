@@ -25,8 +25,8 @@ func init() {
 
 type trashcan struct {
 	suture.Service
+	fileArchive
 	folderFs     fs.Filesystem
-	versionsFs   fs.Filesystem
 	cleanoutDays int
 }
 
@@ -36,7 +36,7 @@ func newTrashcan(folderFs fs.Filesystem, params map[string]string) Versioner {
 
 	s := &trashcan{
 		folderFs:     folderFs,
-		versionsFs:   fsFromParams(folderFs, params),
+		fileArchive:  fileArchive{fsFromParams(folderFs, params)},
 		cleanoutDays: cleanoutDays,
 	}
 	s.Service = util.AsService(s.serve, s.String())
@@ -48,7 +48,13 @@ func newTrashcan(folderFs fs.Filesystem, params map[string]string) Versioner {
 // Archive moves the named file away to a version archive. If this function
 // returns nil, the named file does not exist any more (has been archived).
 func (t *trashcan) Archive(filePath string) error {
-	return archiveFile(t.folderFs, t.versionsFs, filePath, func(name, tag string) string {
+	return t.ArchiveFrom(filePath, filePath)
+}
+
+// ArchiveFrom moves the file at srcPath away to the trash can in place of
+// filePath.
+func (t *trashcan) ArchiveFrom(srcPath, filePath string) error {
+	return archiveFileFrom(t.folderFs, t.versionsFs, srcPath, filePath, func(name, tag string) string {
 		return name
 	})
 }
@@ -67,7 +67,22 @@ func UntagFilename(path string) (string, string) {
 }
 
 func retrieveVersions(fileSystem fs.Filesystem) (map[string][]FileVersion, error) {
+	versions, err := listVersions(fileSystem)
+	if err != nil {
+		return nil, err
+	}
+
 	files := make(map[string][]FileVersion)
+	for _, version := range versions {
+		files[version.name] = append(files[version.name], version.FileVersion)
+	}
+	return files, nil
+}
+
+// listVersions returns the versions stored as files in the filesystem,
+// either tagged with the version time or, as in the trashcan, untagged.
+func listVersions(fileSystem fs.Filesystem) ([]archivedVersion, error) {
+	var versions []archivedVersion
 
 	err := fileSystem.Walk(".", func(path string, f fs.FileInfo, err error) error {
 		// Skip root (which is ok to be a symlink)
@@ -92,15 +107,19 @@ func retrieveVersions(fileSystem fs.Filesystem) (map[string][]FileVersion, error
 
 		modTime := f.ModTime().Truncate(time.Second)
 
-		path = osutil.NormalizedFilename(path)
+		normalized := osutil.NormalizedFilename(path)
 
-		name, tag := UntagFilename(path)
+		name, tag := UntagFilename(normalized)
 		// Something invalid, assume it's an untagged file (trashcan versioner stuff)
 		if name == "" || tag == "" {
-			files[path] = append(files[path], FileVersion{
-				VersionTime: modTime,
-				ModTime:     modTime,
-				Size:        f.Size(),
+			versions = append(versions, archivedVersion{
+				FileVersion: FileVersion{
+					VersionTime: modTime,
+					ModTime:     modTime,
+					Size:        f.Size(),
+				},
+				name: normalized,
+				path: path,
 			})
 			return nil
 		}
@@ -111,10 +130,14 @@ func retrieveVersions(fileSystem fs.Filesystem) (map[string][]FileVersion, error
 			return nil
 		}
 
-		files[name] = append(files[name], FileVersion{
-			VersionTime: versionTime,
-			ModTime:     modTime,
-			Size:        f.Size(),
+		versions = append(versions, archivedVersion{
+			FileVersion: FileVersion{
+				VersionTime: versionTime,
+				ModTime:     modTime,
+				Size:        f.Size(),
+			},
+			name: name,
+			path: path,
 		})
 
 		return nil
@@ -124,16 +147,23 @@ func retrieveVersions(fileSystem fs.Filesystem) (map[string][]FileVersion, error
 		return nil, err
 	}
 
-	return files, nil
+	return versions, nil
 }
 
 type fileTagger func(string, string) string
 
 func archiveFile(srcFs, dstFs fs.Filesystem, filePath string, tagger fileTagger) error {
+	return archiveFileFrom(srcFs, dstFs, filePath, filePath, tagger)
+}
+
+// archiveFileFrom moves the file at srcPath to the archive as a version of
+// filePath.
+func archiveFileFrom(srcFs, dstFs fs.Filesystem, srcPath, filePath string, tagger fileTagger) error {
+	srcPath = osutil.NativeFilename(srcPath)
 	filePath = osutil.NativeFilename(filePath)
-	info, err := srcFs.Lstat(filePath)
+	info, err := srcFs.Lstat(srcPath)
 	if fs.IsNotExist(err) {
-		l.Debugln("not archiving nonexistent file", filePath)
+		l.Debugln("not archiving nonexistent file", srcPath)
 		return nil
 	} else if err != nil {
 		return err
@@ -142,18 +172,8 @@ func archiveFile(srcFs, dstFs fs.Filesystem, filePath string, tagger fileTagger)
 		panic("bug: attempting to version a symlink")
 	}
 
-	_, err = dstFs.Stat(".")
-	if err != nil {
-		if fs.IsNotExist(err) {
-			l.Debugln("creating versions dir")
-			err := dstFs.Mkdir(".", 0755)
-			if err != nil {
-				return err
-			}
-			_ = dstFs.Hide(".")
-		} else {
-			return err
-		}
+	if err := createVersionsDir(dstFs); err != nil {
+		return err
 	}
 
 	file := filepath.Base(filePath)
@@ -169,8 +189,8 @@ func archiveFile(srcFs, dstFs fs.Filesystem, filePath string, tagger fileTagger)
 
 	ver := tagger(file, now.Format(TimeFormat))
 	dst := filepath.Join(inFolderPath, ver)
-	l.Debugln("archiving", filePath, "moving to", dst)
-	err = osutil.RenameOrCopy(srcFs, dstFs, filePath, dst)
+	l.Debugln("archiving", srcPath, "moving to", dst)
+	err = osutil.RenameOrCopy(srcFs, dstFs, srcPath, dst)
 
 	mtime := info.ModTime()
 	// If it's a trashcan versioner type thing, then it does not have version time in the name
@@ -184,6 +204,25 @@ func archiveFile(srcFs, dstFs fs.Filesystem, filePath string, tagger fileTagger)
 	return err
 }
 
+// createVersionsDir creates the hidden root directory of the version
+// archive, unless it exists.
+func createVersionsDir(versionsFs fs.Filesystem) error {
+	_, err := versionsFs.Stat(".")
+	if err != nil {
+		if fs.IsNotExist(err) {
+			l.Debugln("creating versions dir")
+			err := versionsFs.Mkdir(".", 0755)
+			if err != nil {
+				return err
+			}
+			_ = versionsFs.Hide(".")
+		} else {
+			return err
+		}
+	}
+	return nil
+}
+
 func restoreFile(src, dst fs.Filesystem, filePath string, versionTime time.Time, tagger fileTagger) error {
 	tag := versionTime.In(time.Local).Truncate(time.Second).Format(TimeFormat)
 	taggedFilePath := tagger(filePath, tag)
@@ -23,6 +23,20 @@ type Versioner interface {
 	Restore(filePath string, versionTime time.Time) error
 }
 
+// A ContentArchiver can archive contents kept in another file in the
+// folder, such as the reconstructed previous contents of a file, as a
+// version of the file. The source file does not exist after a successful
+// call. All versioners but the external one are ContentArchivers.
+type ContentArchiver interface {
+	ArchiveFrom(srcPath, filePath string) error
+}
+
+// A VersionReader reads the contents of an archived version without
+// restoring it. All versioners but the external one are VersionReaders.
+type VersionReader interface {
+	ReadVersion(filePath string, versionTime time.Time) ([]byte, error)
+}
+
 type FileVersion struct {
 	VersionTime time.Time `json:"versionTime"`
 	ModTime     time.Time `json:"modTime"`
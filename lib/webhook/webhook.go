@@ -0,0 +1,322 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package webhook implements posting events to configured HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/thejerf/suture"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/util"
+)
+
+const (
+	maxBatchSize    = 100              // events per request
+	maxQueuedEvents = 1000             // per webhook, the oldest are dropped beyond this
+	maxAttempts     = 5                // per batch, before it is dropped
+	requestTimeout  = 30 * time.Second // per request
+	minBackoff      = time.Second
+	maxBackoff      = 5 * time.Minute
+)
+
+// These are variables so that the tests can shorten them.
+var (
+	batchDelay   = time.Second // to collect events into a batch
+	backoffUnit  = minBackoff
+	backoffLimit = maxBackoff
+)
+
+// The Service posts events to the webhooks in the options, as JSON arrays
+// of events. Each webhook gets its own queue, so a slow or unavailable
+// endpoint does not hold up the others.
+type Service struct {
+	suture.Service
+	cfg      config.Wrapper
+	evLogger events.Logger
+	changed  chan []config.WebhookConfiguration
+}
+
+func New(cfg config.Wrapper, evLogger events.Logger) *Service {
+	s := &Service{
+		cfg:      cfg,
+		evLogger: evLogger,
+		changed:  make(chan []config.WebhookConfiguration, 1),
+	}
+	s.Service = util.AsService(s.serve, s.String())
+	return s
+}
+
+func (s *Service) serve(ctx context.Context) {
+	sub := s.evLogger.Subscribe(events.AllEvents)
+	defer sub.Unsubscribe()
+
+	s.cfg.Subscribe(s)
+	defer s.cfg.Unsubscribe(s)
+
+	sinksCtx, cancel := context.WithCancel(ctx)
+	sinks := startSinks(sinksCtx, s.cfg.Options().Webhooks)
+
+	for {
+		select {
+		case ev := <-sub.C():
+			for _, sink := range sinks {
+				sink.offer(ev)
+			}
+		case hooks := <-s.changed:
+			cancel()
+			sinksCtx, cancel = context.WithCancel(ctx)
+			sinks = startSinks(sinksCtx, hooks)
+		case <-ctx.Done():
+			cancel()
+			return
+		}
+	}
+}
+
+func (s *Service) VerifyConfiguration(from, to config.Configuration) error {
+	for _, hook := range to.Options.Webhooks {
+		if _, err := parseURL(hook.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) CommitConfiguration(from, to config.Configuration) bool {
+	if reflect.DeepEqual(from.Options.Webhooks, to.Options.Webhooks) {
+		return true
+	}
+	// Replace a pending change that hasn't been picked up yet.
+	select {
+	case <-s.changed:
+	default:
+	}
+	s.changed <- to.Options.Webhooks
+	return true
+}
+
+func (*Service) String() string {
+	return "webhook.Service"
+}
+
+func parseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("webhook URL %q: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook URL %q: unsupported scheme %q", rawURL, u.Scheme)
+	}
+	return u, nil
+}
+
+func startSinks(ctx context.Context, hooks []config.WebhookConfiguration) []*sink {
+	sinks := make([]*sink, 0, len(hooks))
+	for _, hook := range hooks {
+		if _, err := parseURL(hook.URL); err != nil {
+			l.Warnln("Skipping webhook:", err)
+			continue
+		}
+		s := newSink(hook)
+		go s.run(ctx)
+		sinks = append(sinks, s)
+	}
+	return sinks
+}
+
+// A sink queues the events matching a webhook's filters and posts them.
+type sink struct {
+	url     string
+	mask    events.EventType
+	folders map[string]struct{}
+	client  *http.Client
+
+	mut    sync.Mutex
+	queue  []events.Event
+	notify chan struct{}
+}
+
+func newSink(hook config.WebhookConfiguration) *sink {
+	s := &sink{
+		url:    hook.URL,
+		mask:   events.AllEvents,
+		client: &http.Client{Timeout: requestTimeout},
+		notify: make(chan struct{}, 1),
+	}
+	if len(hook.Events) > 0 {
+		s.mask = 0
+		for _, name := range hook.Events {
+			t := events.UnmarshalEventType(name)
+			if t == 0 {
+				l.Warnf("Webhook %s: unknown event type %q", hook.URL, name)
+			}
+			s.mask |= t
+		}
+	}
+	if len(hook.Folders) > 0 {
+		s.folders = make(map[string]struct{}, len(hook.Folders))
+		for _, folder := range hook.Folders {
+			s.folders[folder] = struct{}{}
+		}
+	}
+	return s
+}
+
+// matches returns whether the event passes the event type and folder
+// filters. Events that do not relate to a folder pass the folder filter.
+func (s *sink) matches(ev events.Event) bool {
+	if ev.Type&s.mask == 0 {
+		return false
+	}
+	if s.folders == nil {
+		return true
+	}
+	folder, ok := eventFolder(ev)
+	if !ok {
+		return true
+	}
+	_, ok = s.folders[folder]
+	return ok
+}
+
+// eventFolder returns the ID of the folder the event relates to, if any.
+func eventFolder(ev events.Event) (string, bool) {
+	// Folder pause and resume events carry the folder ID as "id", which
+	// for other events is the device ID.
+	key := "folder"
+	if ev.Type == events.FolderPaused || ev.Type == events.FolderResumed {
+		key = "id"
+	}
+
+	switch data := ev.Data.(type) {
+	case map[string]interface{}:
+		folder, ok := data[key].(string)
+		return folder, ok
+	case map[string]string:
+		folder, ok := data[key]
+		return folder, ok
+	}
+	return "", false
+}
+
+func (s *sink) offer(ev events.Event) {
+	if !s.matches(ev) {
+		return
+	}
+
+	s.mut.Lock()
+	if len(s.queue) >= maxQueuedEvents {
+		l.Debugf("Webhook %s: queue full, dropping event %d", s.url, s.queue[0].GlobalID)
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, ev)
+	s.mut.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *sink) run(ctx context.Context) {
+	for {
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return
+		}
+
+		// Give more events the chance to join the batch.
+		select {
+		case <-time.After(batchDelay):
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			batch := s.nextBatch()
+			if len(batch) == 0 {
+				break
+			}
+			if err := s.deliver(ctx, batch); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				l.Infof("Webhook %s: dropping %d events: %v", s.url, len(batch), err)
+			}
+		}
+	}
+}
+
+func (s *sink) nextBatch() []events.Event {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	n := len(s.queue)
+	if n > maxBatchSize {
+		n = maxBatchSize
+	}
+	batch := s.queue[:n:n]
+	s.queue = s.queue[n:]
+	return batch
+}
+
+// deliver posts the batch, retrying with exponential backoff.
+func (s *sink) deliver(ctx context.Context, batch []events.Event) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := backoffUnit
+	for attempt := 1; ; attempt++ {
+		err = s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			return err
+		}
+		l.Debugf("Webhook %s: attempt %d failed, retrying in %v: %v", s.url, attempt, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > backoffLimit {
+			backoff = backoffLimit
+		}
+	}
+}
+
+func (s *sink) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
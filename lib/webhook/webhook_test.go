@@ -0,0 +1,99 @@
+// Copyright (C) 2020 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+func init() {
+	batchDelay = 10 * time.Millisecond
+	backoffUnit = 10 * time.Millisecond
+	backoffLimit = 10 * time.Millisecond
+}
+
+func TestSinkMatches(t *testing.T) {
+	s := newSink(config.WebhookConfiguration{
+		URL:     "http://127.0.0.1/hook",
+		Events:  []string{"FolderErrors", "DeviceDisconnected", "FolderRejected", "FolderPaused"},
+		Folders: []string{"default"},
+	})
+
+	cases := []struct {
+		ev      events.Event
+		matches bool
+	}{
+		{events.Event{Type: events.FolderErrors, Data: map[string]interface{}{"folder": "default"}}, true},
+		{events.Event{Type: events.FolderErrors, Data: map[string]interface{}{"folder": "other"}}, false},
+		{events.Event{Type: events.DeviceDisconnected, Data: map[string]string{"id": "device"}}, true},
+		{events.Event{Type: events.DeviceConnected, Data: map[string]string{"id": "device"}}, false},
+		{events.Event{Type: events.StateChanged, Data: map[string]interface{}{"folder": "default"}}, false},
+		{events.Event{Type: events.FolderRejected, Data: map[string]string{"folder": "default"}}, true},
+		{events.Event{Type: events.FolderRejected, Data: map[string]string{"folder": "other"}}, false},
+		{events.Event{Type: events.FolderPaused, Data: map[string]string{"id": "default"}}, true},
+		{events.Event{Type: events.FolderPaused, Data: map[string]string{"id": "other"}}, false},
+	}
+	for i, tc := range cases {
+		if res := s.matches(tc.ev); res != tc.matches {
+			t.Errorf("%d: matches(%v) = %v, expected %v", i, tc.ev.Type, res, tc.matches)
+		}
+	}
+
+	s = newSink(config.WebhookConfiguration{URL: "http://127.0.0.1/hook"})
+	for i, tc := range cases {
+		if !s.matches(tc.ev) {
+			t.Errorf("%d: %v should match without filters", i, tc.ev.Type)
+		}
+	}
+}
+
+func TestSinkDelivery(t *testing.T) {
+	var failures int32 = 2
+	received := make(chan []events.Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		var evs []events.Event
+		if err := json.NewDecoder(r.Body).Decode(&evs); err != nil {
+			t.Error(err)
+		}
+		received <- evs
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := newSink(config.WebhookConfiguration{URL: srv.URL, Events: []string{"FolderErrors"}})
+	go s.run(ctx)
+
+	s.offer(events.Event{GlobalID: 1, Type: events.FolderErrors, Data: map[string]interface{}{"folder": "default"}})
+	s.offer(events.Event{GlobalID: 2, Type: events.DeviceConnected})
+	s.offer(events.Event{GlobalID: 3, Type: events.FolderErrors, Data: map[string]interface{}{"folder": "other"}})
+
+	select {
+	case evs := <-received:
+		if len(evs) != 2 || evs[0].GlobalID != 1 || evs[1].GlobalID != 3 {
+			t.Fatalf("Unexpected batch %v", evs)
+		}
+		if evs[0].Type != events.FolderErrors {
+			t.Errorf("Unexpected event type %v", evs[0].Type)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for delivery")
+	}
+}